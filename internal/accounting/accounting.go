@@ -0,0 +1,181 @@
+// Package accounting tracks token usage and estimated cost per agent.
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// Cost returns the estimated USD cost of the given usage.
+func (p Price) Cost(usage agent.TokenUsage) float64 {
+	return float64(usage.InputTokens)/1e6*p.InputPerMTok +
+		float64(usage.OutputTokens)/1e6*p.OutputPerMTok
+}
+
+// PriceTable maps model names to their price. A key also prices every
+// model it is a prefix of, so "claude-opus-4" covers each dated release.
+// Agent names are keys too, pricing agents whose model isn't known.
+type PriceTable map[string]Price
+
+// DefaultPrices returns list-price estimates for common models and for each
+// known agent's default model.
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"claude":       {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+		"codex":        {InputPerMTok: 1.25, OutputPerMTok: 10.00},
+		"cursor-agent": {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+		"auggie":       {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+		"gemini":       {InputPerMTok: 1.25, OutputPerMTok: 10.00},
+		"amp":          {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+
+		"claude-opus-4":    {InputPerMTok: 15.00, OutputPerMTok: 75.00},
+		"claude-opus-4-5":  {InputPerMTok: 5.00, OutputPerMTok: 25.00},
+		"claude-sonnet-4":  {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+		"claude-haiku-4":   {InputPerMTok: 1.00, OutputPerMTok: 5.00},
+		"gpt-5":            {InputPerMTok: 1.25, OutputPerMTok: 10.00},
+		"gpt-5-mini":       {InputPerMTok: 0.25, OutputPerMTok: 2.00},
+		"gemini-2.5-pro":   {InputPerMTok: 1.25, OutputPerMTok: 10.00},
+		"gemini-2.5-flash": {InputPerMTok: 0.30, OutputPerMTok: 2.50},
+	}
+}
+
+// lookup returns the price for model, or for agentName if model is empty
+// or unpriced. An exact key wins over the longest key model starts with.
+func (pt PriceTable) lookup(model, agentName string) (Price, bool) {
+	if model != "" {
+		if p, ok := pt[model]; ok {
+			return p, true
+		}
+		best := ""
+		for key := range pt {
+			if len(key) > len(best) && strings.HasPrefix(model, key) {
+				best = key
+			}
+		}
+		if best != "" {
+			return pt[best], true
+		}
+	}
+	p, ok := pt[agentName]
+	return p, ok
+}
+
+// AgentCost is the accumulated usage and estimated cost for one agent.
+type AgentCost struct {
+	Agent   string
+	Usage   agent.TokenUsage
+	CostUSD float64
+	Priced  bool // False if no price was known for any of the usage
+	Partial bool // True if some usage had no price, so CostUSD undercounts
+}
+
+// CostTracker accumulates token usage per agent across rounds.
+type CostTracker struct {
+	prices PriceTable
+	costs  map[string]*AgentCost
+	order  []string
+}
+
+// NewCostTracker creates a tracker using the default prices, with any
+// entries in overrides replacing the defaults.
+func NewCostTracker(overrides PriceTable) *CostTracker {
+	prices := DefaultPrices()
+	for name, p := range overrides {
+		prices[name] = p
+	}
+	return &CostTracker{
+		prices: prices,
+		costs:  make(map[string]*AgentCost),
+	}
+}
+
+// Add records usage for an agent, priced by usage.Model when the table
+// has it and by the agent's name otherwise. Usage with no tokens, as from an
+// agent that didn't report any, is not priced: its cost is unknown, not 0.
+func (t *CostTracker) Add(agentName string, usage agent.TokenUsage) {
+	ac, seen := t.costs[agentName]
+	if !seen {
+		ac = &AgentCost{Agent: agentName}
+		t.costs[agentName] = ac
+		t.order = append(t.order, agentName)
+	}
+	ac.Usage = ac.Usage.Add(usage)
+	if usage.Total() == 0 {
+		return
+	}
+
+	if price, ok := t.prices.lookup(usage.Model, agentName); ok {
+		ac.CostUSD += price.Cost(usage)
+		ac.Priced = true
+	} else {
+		ac.Partial = true
+	}
+}
+
+// Totals returns per-agent usage and cost in the order agents were first seen.
+func (t *CostTracker) Totals() []AgentCost {
+	totals := make([]AgentCost, 0, len(t.order))
+	for _, name := range t.order {
+		totals = append(totals, *t.costs[name])
+	}
+	return totals
+}
+
+// Total returns the usage and cost summed across all agents. It is
+// Priced if any agent's usage was, and Partial if any agent's usage went
+// unpriced.
+func (t *CostTracker) Total() AgentCost {
+	total := AgentCost{Agent: "total"}
+	for _, ac := range t.Totals() {
+		total.Usage = total.Usage.Add(ac.Usage)
+		total.CostUSD += ac.CostUSD
+		total.Priced = total.Priced || ac.Priced
+		total.Partial = total.Partial || ac.Partial
+	}
+	total.Usage.Model = ""
+	return total
+}
+
+// FormatSummary renders a "Token & cost summary" table.
+func (t *CostTracker) FormatSummary() string {
+	var sb strings.Builder
+
+	sb.WriteString("Token & cost summary:\n")
+	sb.WriteString(fmt.Sprintf("  %-14s %12s %12s %10s\n", "Agent", "Input", "Output", "Est. USD"))
+
+	totals := t.Totals()
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].CostUSD > totals[j].CostUSD
+	})
+	for _, ac := range totals {
+		sb.WriteString(formatRow(ac))
+	}
+	total := t.Total()
+	sb.WriteString(formatRow(total))
+	if total.Partial {
+		sb.WriteString("  + leaves out usage with no known price; add it under \"prices\" in --config\n")
+	}
+
+	return sb.String()
+}
+
+// formatRow formats a single summary row. A partial cost is marked with "+".
+func formatRow(ac AgentCost) string {
+	cost := fmt.Sprintf("$%.4f", ac.CostUSD)
+	switch {
+	case !ac.Priced:
+		cost = "n/a"
+	case ac.Partial:
+		cost += "+"
+	}
+	return fmt.Sprintf("  %-14s %12d %12d %10s\n", ac.Agent, ac.Usage.InputTokens, ac.Usage.OutputTokens, cost)
+}
@@ -0,0 +1,190 @@
+package accounting
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestCostTracker_AccumulatesPerAgent tests that usage sums across rounds
+func TestCostTracker_AccumulatesPerAgent(t *testing.T) {
+	tracker := NewCostTracker(nil)
+
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1000, OutputTokens: 200})
+	tracker.Add("codex", agent.TokenUsage{InputTokens: 500, OutputTokens: 100})
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 2000, OutputTokens: 300})
+
+	totals := tracker.Totals()
+	if len(totals) != 2 {
+		t.Fatalf("Totals() returned %d agents, want 2", len(totals))
+	}
+
+	if totals[0].Agent != "claude" {
+		t.Errorf("Totals()[0].Agent = %q, want claude (first seen)", totals[0].Agent)
+	}
+	if totals[0].Usage.InputTokens != 3000 || totals[0].Usage.OutputTokens != 500 {
+		t.Errorf("claude usage = %+v, want 3000 in / 500 out", totals[0].Usage)
+	}
+
+	total := tracker.Total()
+	if total.Usage.InputTokens != 3500 || total.Usage.OutputTokens != 600 {
+		t.Errorf("Total() usage = %+v, want 3500 in / 600 out", total.Usage)
+	}
+}
+
+// TestCostTracker_CostMath tests the per-million-token cost calculation
+func TestCostTracker_CostMath(t *testing.T) {
+	tracker := NewCostTracker(PriceTable{
+		"claude": {InputPerMTok: 3, OutputPerMTok: 15},
+	})
+
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1_000_000, OutputTokens: 100_000})
+
+	got := tracker.Totals()[0].CostUSD
+	want := 3.0 + 1.5
+	if !almostEqual(got, want) {
+		t.Errorf("CostUSD = %f, want %f", got, want)
+	}
+}
+
+// TestCostTracker_OverridesReplaceDefaults tests that config prices win
+func TestCostTracker_OverridesReplaceDefaults(t *testing.T) {
+	tracker := NewCostTracker(PriceTable{
+		"codex":  {InputPerMTok: 10, OutputPerMTok: 0},
+		"custom": {InputPerMTok: 1, OutputPerMTok: 1},
+	})
+
+	tracker.Add("codex", agent.TokenUsage{InputTokens: 100_000})
+	tracker.Add("custom", agent.TokenUsage{InputTokens: 500_000, OutputTokens: 500_000})
+	tracker.Add("claude", agent.TokenUsage{OutputTokens: 1_000_000})
+
+	totals := tracker.Totals()
+	if !almostEqual(totals[0].CostUSD, 1.0) {
+		t.Errorf("codex CostUSD = %f, want 1.0 (override)", totals[0].CostUSD)
+	}
+	if !almostEqual(totals[1].CostUSD, 1.0) {
+		t.Errorf("custom CostUSD = %f, want 1.0", totals[1].CostUSD)
+	}
+	if !almostEqual(totals[2].CostUSD, DefaultPrices()["claude"].OutputPerMTok) {
+		t.Errorf("claude CostUSD = %f, want default output price", totals[2].CostUSD)
+	}
+	if !almostEqual(tracker.Total().CostUSD, 2.0+DefaultPrices()["claude"].OutputPerMTok) {
+		t.Errorf("Total().CostUSD = %f, want sum of agents", tracker.Total().CostUSD)
+	}
+}
+
+// TestCostTracker_UnpricedAgent tests agents missing from the price table
+func TestCostTracker_UnpricedAgent(t *testing.T) {
+	tracker := NewCostTracker(nil)
+	tracker.Add("mystery", agent.TokenUsage{InputTokens: 10, OutputTokens: 10})
+
+	ac := tracker.Totals()[0]
+	if ac.Priced {
+		t.Error("Priced = true, want false for unknown agent")
+	}
+	if ac.CostUSD != 0 {
+		t.Errorf("CostUSD = %f, want 0 for unknown agent", ac.CostUSD)
+	}
+	if !strings.Contains(tracker.FormatSummary(), "n/a") {
+		t.Error("FormatSummary() should show n/a for unpriced agents")
+	}
+}
+
+// TestCostTracker_FormatSummary tests the summary table layout
+func TestCostTracker_FormatSummary(t *testing.T) {
+	tracker := NewCostTracker(nil)
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1234, OutputTokens: 567})
+
+	summary := tracker.FormatSummary()
+
+	for _, want := range []string{"Token & cost summary", "Agent", "Input", "Output", "Est. USD", "claude", "1234", "567", "total"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("FormatSummary() missing %q, got:\n%s", want, summary)
+		}
+	}
+}
+
+// TestCostTracker_PricesByModel tests that the reported model picks the
+// price, by exact key or longest prefix, before the agent name
+func TestCostTracker_PricesByModel(t *testing.T) {
+	tracker := NewCostTracker(PriceTable{"my-model": {InputPerMTok: 2}})
+	million := agent.TokenUsage{InputTokens: 1_000_000}
+
+	tests := []struct {
+		agent, model string
+		want         float64
+	}{
+		{"claude", "claude-opus-4-1-20250805", DefaultPrices()["claude-opus-4"].InputPerMTok},
+		{"claude", "claude-opus-4-5-20251101", DefaultPrices()["claude-opus-4-5"].InputPerMTok},
+		{"local", "my-model", 2},
+		{"codex", "unheard-of", DefaultPrices()["codex"].InputPerMTok},
+		{"gemini", "", DefaultPrices()["gemini"].InputPerMTok},
+	}
+	for _, tt := range tests {
+		usage := million
+		usage.Model = tt.model
+		price, ok := tracker.prices.lookup(tt.model, tt.agent)
+		if !ok || !almostEqual(price.Cost(usage), tt.want) {
+			t.Errorf("lookup(%q, %q) = %+v, %v; want input price %f", tt.model, tt.agent, price, ok, tt.want)
+		}
+	}
+
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1_000_000, Model: "claude-haiku-4-5"})
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1_000_000})
+	want := DefaultPrices()["claude-haiku-4"].InputPerMTok + DefaultPrices()["claude"].InputPerMTok
+	if got := tracker.Totals()[0].CostUSD; !almostEqual(got, want) {
+		t.Errorf("CostUSD = %f, want each turn priced by its own model (%f)", got, want)
+	}
+}
+
+// TestCostTracker_PartialTotal tests that unpriced usage marks the total
+// as partial rather than complete
+func TestCostTracker_PartialTotal(t *testing.T) {
+	tracker := NewCostTracker(nil)
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1_000_000})
+	if tracker.Total().Partial {
+		t.Error("Total().Partial = true with every agent priced")
+	}
+
+	tracker.Add("mystery", agent.TokenUsage{InputTokens: 10})
+	total := tracker.Total()
+	if !total.Priced || !total.Partial {
+		t.Errorf("Total() = %+v, want a priced but partial total", total)
+	}
+	summary := tracker.FormatSummary()
+	if !strings.Contains(summary, "$3.0000+") || !strings.Contains(summary, "no known price") {
+		t.Errorf("FormatSummary() should mark the partial total, got:\n%s", summary)
+	}
+}
+
+// TestCostTracker_ZeroUsage tests that usage with no tokens is listed but
+// neither priced nor counted as unpriced
+func TestCostTracker_ZeroUsage(t *testing.T) {
+	tracker := NewCostTracker(nil)
+	tracker.Add("claude", agent.TokenUsage{})
+	tracker.Add("mystery", agent.TokenUsage{Model: "unheard-of"})
+
+	for _, ac := range tracker.Totals() {
+		if ac.Priced || ac.Partial {
+			t.Errorf("%s: Priced = %v, Partial = %v; want neither for zero usage", ac.Agent, ac.Priced, ac.Partial)
+		}
+	}
+	if total := tracker.Total(); total.Priced || total.Partial {
+		t.Errorf("Total() = %+v, want an unpriced, non-partial total", total)
+	}
+	summary := tracker.FormatSummary()
+	if strings.Contains(summary, "$0.0000") || strings.Contains(summary, "no known price") {
+		t.Errorf("FormatSummary() should show n/a for unreported usage, got:\n%s", summary)
+	}
+
+	tracker.Add("claude", agent.TokenUsage{InputTokens: 1_000_000})
+	if total := tracker.Total(); !total.Priced || total.Partial {
+		t.Errorf("Total() = %+v, want priced and complete once real usage is priced", total)
+	}
+}
@@ -1,24 +1,44 @@
 // Package agent provides detection and management of AI coding agents.
 package agent
 
+import "context"
+
 // Agent represents a detected AI coding agent CLI tool.
 type Agent struct {
 	Name          string       // e.g., "claude", "codex", "cursor-agent"
 	Path          string       // Full path to the binary
 	Authenticated bool         // Whether the agent is authenticated
 	Version       string       // Agent version if available
+	CheckTimedOut bool         // A version or auth check hung, so Authenticated is unknown
 	Pattern       CLIPattern   // CLI invocation pattern for this agent
 	Parser        OutputParser // Parser for transforming agent output
 }
 
 // Detector finds and validates available AI agents.
 type Detector interface {
-	// DetectAll returns all available agents on the system.
-	DetectAll() ([]Agent, error)
+	// DetectAll returns all available agents on the system. Version and
+	// auth checks run recently in this process are reused unless force is set.
+	DetectAll(ctx context.Context, force bool) ([]Agent, error)
 
 	// IsInstalled checks if a specific agent is installed.
 	IsInstalled(name string) bool
 
 	// IsAuthenticated checks if an agent is authenticated.
-	IsAuthenticated(agent Agent) bool
+	IsAuthenticated(ctx context.Context, agent Agent) bool
+
+	// DetectConflicts returns agents installed in more than one search
+	// path directory, where the first copy may shadow a newer one.
+	DetectConflicts() []Conflict
+}
+
+// Conflict is an agent whose binary was found in several places.
+type Conflict struct {
+	Name       string
+	Candidates []Candidate // In search order; the first is the one that runs
+}
+
+// Candidate is one copy of an agent binary.
+type Candidate struct {
+	Path    string
+	Version string // Empty if the version check failed
 }
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckCacheTTL is how long a version or auth check result is reused before
+// the agent binary is run again.
+var CheckCacheTTL = 5 * time.Minute
+
+// CheckTimeout bounds each version or auth check, so an agent whose check
+// hangs (e.g. on a network auth probe) cannot block detection.
+var CheckTimeout = 5 * time.Second
+
+// ErrCheckTimedOut means a version or auth check did not finish within
+// CheckTimeout.
+var ErrCheckTimedOut = errors.New("agent check timed out")
+
+// errNoCheck means an agent has nothing to check, e.g. no version args.
+var errNoCheck = errors.New("no check for agent")
+
+// checkWaitDelay is how long a killed check's output pipes may stay open,
+// e.g. held by a child process, before they are closed.
+const checkWaitDelay = 100 * time.Millisecond
+
+// runCheck runs an agent binary for a version or auth check and returns its
+// output. It is a variable so tests can count the processes spawned.
+var runCheck = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.WaitDelay = checkWaitDelay
+	return cmd.Output()
+}
+
+// checkResult is a cached outcome of one check.
+type checkResult struct {
+	output []byte
+	err    error
+	at     time.Time
+}
+
+// checkCache holds check results for the whole process, keyed by binary
+// path and then by args, so the detectors made by each command share them.
+var checkCache = struct {
+	sync.Mutex
+	entries map[string]map[string]checkResult
+}{entries: make(map[string]map[string]checkResult)}
+
+// cachedCheck runs path with args, or returns the result of the same check
+// run within CheckCacheTTL. A check that failed to start for a transient
+// reason is tried once more. A check that runs past CheckTimeout fails with
+// ErrCheckTimedOut and is not cached.
+func cachedCheck(ctx context.Context, path string, args []string) ([]byte, error) {
+	key := strings.Join(args, "\x00")
+
+	checkCache.Lock()
+	entry, ok := checkCache.entries[path][key]
+	checkCache.Unlock()
+	if ok && time.Since(entry.at) < CheckCacheTTL {
+		return entry.output, entry.err
+	}
+
+	output, err := runTimedCheck(ctx, path, args)
+	if isTransientCheckError(err) {
+		output, err = runTimedCheck(ctx, path, args)
+	}
+	if errors.Is(err, ErrCheckTimedOut) || ctx.Err() != nil {
+		return output, err
+	}
+
+	checkCache.Lock()
+	if checkCache.entries[path] == nil {
+		checkCache.entries[path] = make(map[string]checkResult)
+	}
+	checkCache.entries[path][key] = checkResult{output: output, err: err, at: time.Now()}
+	checkCache.Unlock()
+	return output, err
+}
+
+// runTimedCheck runs one check, failing with ErrCheckTimedOut if it does not
+// finish within CheckTimeout.
+func runTimedCheck(ctx context.Context, path string, args []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	output, err := runCheck(ctx, path, args...)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("%w after %s: %s %s", ErrCheckTimedOut, CheckTimeout, path, strings.Join(args, " "))
+	}
+	return output, err
+}
+
+// forgetChecks drops the cached check results for the binary at path.
+func forgetChecks(path string) {
+	checkCache.Lock()
+	defer checkCache.Unlock()
+	delete(checkCache.entries, path)
+}
+
+// isTransientCheckError reports whether a check failed for a reason other
+// than the binary's own answer or its absence, e.g. a binary still being
+// written ("text file busy"). Such failures are worth one retry.
+func isTransientCheckError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) || errors.Is(err, ErrCheckTimedOut) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return !errors.Is(err, exec.ErrNotFound) && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission)
+}
+
+// ClearCheckCache forgets every cached check result.
+func ClearCheckCache() {
+	checkCache.Lock()
+	defer checkCache.Unlock()
+	checkCache.entries = make(map[string]map[string]checkResult)
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// countChecks replaces runCheck with fn for the test, clears the check cache
+// and returns a pointer to the number of checks run.
+func countChecks(t *testing.T, fn func(ctx context.Context, path string, args ...string) ([]byte, error)) *int {
+	t.Helper()
+	ClearCheckCache()
+	calls := 0
+	orig := runCheck
+	runCheck = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+		calls++
+		return fn(ctx, path, args...)
+	}
+	t.Cleanup(func() {
+		runCheck = orig
+		ClearCheckCache()
+	})
+	return &calls
+}
+
+// fakeClaudeDir returns a search path holding an executable named claude.
+func fakeClaudeDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "claude"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+	return dir
+}
+
+// TestDetectAllCachesChecks tests that a second DetectAll within the TTL
+// spawns no processes, and that force reruns the checks
+func TestDetectAllCachesChecks(t *testing.T) {
+	calls := countChecks(t, func(context.Context, string, ...string) ([]byte, error) {
+		return []byte("claude 1.2.0\n"), nil
+	})
+	d := NewDetectorWithPath(fakeClaudeDir(t))
+
+	agents, err := d.DetectAll(context.Background(), false)
+	if err != nil || len(agents) != 1 {
+		t.Fatalf("DetectAll() = %+v, %v; want one agent", agents, err)
+	}
+	if !agents[0].Authenticated || agents[0].Version != "claude 1.2.0" {
+		t.Errorf("agent = %+v, want authenticated claude 1.2.0", agents[0])
+	}
+	first := *calls
+	if first == 0 {
+		t.Fatal("DetectAll() ran no checks")
+	}
+
+	again, err := NewDetectorWithPath(d.searchPath).DetectAll(context.Background(), false)
+	if err != nil {
+		t.Fatalf("DetectAll() error = %v", err)
+	}
+	if *calls != first {
+		t.Errorf("second DetectAll() ran %d checks, want 0", *calls-first)
+	}
+	if again[0].Version != agents[0].Version || again[0].Authenticated != agents[0].Authenticated {
+		t.Errorf("cached agent = %+v, want %+v", again[0], agents[0])
+	}
+
+	if _, err := d.DetectAll(context.Background(), true); err != nil {
+		t.Fatalf("DetectAll(force) error = %v", err)
+	}
+	if *calls != 2*first {
+		t.Errorf("DetectAll(force) ran %d checks, want %d", *calls-first, first)
+	}
+}
+
+// TestDetectAllCacheExpires tests that checks run again after the TTL
+func TestDetectAllCacheExpires(t *testing.T) {
+	calls := countChecks(t, func(context.Context, string, ...string) ([]byte, error) {
+		return []byte("claude 1.2.0\n"), nil
+	})
+	orig := CheckCacheTTL
+	CheckCacheTTL = time.Nanosecond
+	t.Cleanup(func() { CheckCacheTTL = orig })
+
+	d := NewDetectorWithPath(fakeClaudeDir(t))
+	_, _ = d.DetectAll(context.Background(), false)
+	first := *calls
+	time.Sleep(time.Millisecond)
+	_, _ = d.DetectAll(context.Background(), false)
+	if *calls != 2*first {
+		t.Errorf("DetectAll() after the TTL ran %d checks, want %d", *calls-first, first)
+	}
+}
+
+// TestCachedCheckRetriesTransientFailures tests that a check that failed to
+// start is tried once more, and that a check the binary rejected is not
+func TestCachedCheckRetriesTransientFailures(t *testing.T) {
+	busy := &os.PathError{Op: "fork/exec", Path: "/bin/claude", Err: syscall.ETXTBSY}
+	calls := countChecks(t, func(context.Context, string, ...string) ([]byte, error) {
+		return nil, busy
+	})
+	if _, err := cachedCheck(context.Background(), "/bin/claude", []string{"--version"}); !errors.Is(err, syscall.ETXTBSY) {
+		t.Errorf("cachedCheck() error = %v, want ETXTBSY", err)
+	}
+	if *calls != 2 {
+		t.Errorf("transient failure ran %d checks, want 2", *calls)
+	}
+
+	failed := false
+	countChecks(t, func(context.Context, string, ...string) ([]byte, error) {
+		if !failed {
+			failed = true
+			return nil, busy
+		}
+		return []byte("ok"), nil
+	})
+	if out, err := cachedCheck(context.Background(), "/bin/claude", []string{"--version"}); err != nil || string(out) != "ok" {
+		t.Errorf("cachedCheck() = %q, %v; want ok after a retry", out, err)
+	}
+
+	missing := countChecks(t, func(context.Context, string, ...string) ([]byte, error) {
+		return nil, &os.PathError{Op: "fork/exec", Path: "/bin/claude", Err: os.ErrNotExist}
+	})
+	_, _ = cachedCheck(context.Background(), "/bin/claude", []string{"--version"})
+	if *missing != 1 {
+		t.Errorf("missing binary ran %d checks, want 1", *missing)
+	}
+}
+
+// TestDetectAllCheckTimeout tests that an agent whose version check hangs is
+// reported with CheckTimedOut instead of blocking detection or being dropped
+func TestDetectAllCheckTimeout(t *testing.T) {
+	ClearCheckCache()
+	t.Cleanup(ClearCheckCache)
+	orig := CheckTimeout
+	CheckTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { CheckTimeout = orig })
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "claude"), []byte("#!/bin/sh\nsleep 10\n"), 0755); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	start := time.Now()
+	agents, err := NewDetectorWithPath(dir).DetectAll(context.Background(), false)
+	if err != nil {
+		t.Fatalf("DetectAll() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("DetectAll() took %s, want it bounded by CheckTimeout", elapsed)
+	}
+	if len(agents) != 1 {
+		t.Fatalf("DetectAll() = %+v, want the hung agent kept", agents)
+	}
+	if !agents[0].CheckTimedOut || agents[0].Authenticated {
+		t.Errorf("agent = %+v, want CheckTimedOut and not Authenticated", agents[0])
+	}
+}
+
+// TestCachedCheckDoesNotCacheTimeouts tests that a timed-out check is neither
+// retried nor remembered
+func TestCachedCheckDoesNotCacheTimeouts(t *testing.T) {
+	orig := CheckTimeout
+	CheckTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { CheckTimeout = orig })
+	calls := countChecks(t, func(ctx context.Context, _ string, _ ...string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	_, err := cachedCheck(context.Background(), "/bin/claude", []string{"--version"})
+	if !errors.Is(err, ErrCheckTimedOut) || !strings.Contains(err.Error(), "/bin/claude --version") {
+		t.Errorf("cachedCheck() error = %v, want ErrCheckTimedOut naming the command", err)
+	}
+	if *calls != 1 {
+		t.Errorf("timed-out check ran %d times, want 1", *calls)
+	}
+	_, _ = cachedCheck(context.Background(), "/bin/claude", []string{"--version"})
+	if *calls != 2 {
+		t.Errorf("timed-out check was cached; ran %d times, want 2", *calls)
+	}
+}
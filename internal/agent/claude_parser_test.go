@@ -117,3 +117,85 @@ func TestClaudeParserPreservesOrder(t *testing.T) {
 		t.Errorf("Parse() did not preserve order: First@%d, Second@%d, Third@%d", firstIdx, secondIdx, thirdIdx)
 	}
 }
+
+// TestClaudeParserParsesUsage tests token usage extraction from result events
+func TestClaudeParserParsesUsage(t *testing.T) {
+	parser := &ClaudeParser{}
+
+	input := `{"type":"system","subtype":"init","session_id":"s1","model":"claude-sonnet-4-5-20250929"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":999,"output_tokens":999}}}
+{"type":"result","subtype":"success","result":"Hi","usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":42}}`
+
+	usage := ParseUsage(parser, input)
+
+	if usage.Model != "claude-sonnet-4-5-20250929" {
+		t.Errorf("Model = %q, want the init event's model", usage.Model)
+	}
+
+	if usage.InputTokens != 1110 {
+		t.Errorf("InputTokens = %d, want 1110 (input + cache tokens)", usage.InputTokens)
+	}
+	if usage.OutputTokens != 42 {
+		t.Errorf("OutputTokens = %d, want 42", usage.OutputTokens)
+	}
+}
+
+// TestClaudeParserParsesSessionID tests session ID extraction from the init event
+func TestClaudeParserParsesSessionID(t *testing.T) {
+	input := `{"type":"system","subtype":"init","session_id":"8f1c2a4e-session","tools":[]}
+{"type":"result","subtype":"success","result":"Hi","session_id":"8f1c2a4e-session"}`
+
+	if got := ParseSessionID(&ClaudeParser{}, input); got != "8f1c2a4e-session" {
+		t.Errorf("ParseSessionID() = %q, want %q", got, "8f1c2a4e-session")
+	}
+}
+
+// TestClaudeParserDedupsRepeatedResult tests that a result echoing the assistant text is dropped
+func TestClaudeParserDedupsRepeatedResult(t *testing.T) {
+	parser := &ClaudeParser{}
+
+	input := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me check."}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Created 2 beads for the API work."}]}}
+{"type":"result","subtype":"success","result":"Created 2 beads for the API work."}`
+
+	result := parser.Parse(input)
+
+	if got := strings.Count(result, "Created 2 beads"); got != 1 {
+		t.Errorf("Parse() included the final text %d times, want 1:\n%s", got, result)
+	}
+	if !strings.Contains(result, "Let me check.") {
+		t.Errorf("Parse() dropped earlier assistant text:\n%s", result)
+	}
+}
+
+// TestClaudeParserKeepsDistinctResult tests that a result differing from the assistant text is kept
+func TestClaudeParserKeepsDistinctResult(t *testing.T) {
+	parser := &ClaudeParser{}
+
+	input := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Working on it."}]}}
+{"type":"result","subtype":"success","result":"Summary: plan complete, no changes."}`
+
+	result := parser.Parse(input)
+
+	if result != "Working on it.\nSummary: plan complete, no changes." {
+		t.Errorf("Parse() = %q, want both assistant and result text", result)
+	}
+}
+
+// TestRawParserPassesThrough tests that the raw wrapper keeps output but still reports usage
+func TestRawParserPassesThrough(t *testing.T) {
+	input := `{"type":"system","subtype":"init","session_id":"s-1"}
+{"type":"result","result":"Hi","usage":{"input_tokens":5,"output_tokens":7}}`
+
+	raw := NewRawParser(&ClaudeParser{})
+
+	if got := raw.Parse(input); got != input {
+		t.Errorf("Parse() = %q, want the input unchanged", got)
+	}
+	if usage := ParseUsage(raw, input); usage.InputTokens != 5 || usage.OutputTokens != 7 {
+		t.Errorf("ParseUsage() = %+v, want 5 in / 7 out", usage)
+	}
+	if id := ParseSessionID(raw, input); id != "s-1" {
+		t.Errorf("ParseSessionID() = %q, want s-1", id)
+	}
+}
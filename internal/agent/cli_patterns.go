@@ -56,7 +56,7 @@
 // Version check: auggie --version
 // Non-interactive mode: -p, --print (one-shot mode)
 // JSON output: --output-format json
-// Skip approvals: --permission "tool-name:allow" (per-tool)
+// Skip approvals: --permission "tool-name:allow" (per-tool, repeatable)
 // Working directory: -w, --workspace-root <path>
 // Resume session: -r, --resume [sessionId]
 //
@@ -73,6 +73,7 @@
 // Non-interactive mode: positional prompt (one-shot by default)
 // JSON output: -o, --output-format stream-json
 // Skip approvals: -y, --yolo or --approval-mode yolo
+// Gentler approvals: --approval-mode auto_edit | default
 // Resume session: -r, --resume [sessionId | "latest"]
 //
 // Example:
@@ -112,6 +113,12 @@
 // - Cursor: Has `cursor-agent status` or `cursor-agent whoami` command
 package agent
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // CLIPattern defines the invocation pattern for an AI agent CLI.
 type CLIPattern struct {
 	// Binary is the executable name
@@ -132,18 +139,57 @@ type CLIPattern struct {
 	// SkipApprovalsArgs are args to skip permission prompts
 	SkipApprovalsArgs []string
 
+	// ApprovalModeArgs are the args for approval modes gentler than
+	// skipping every prompt, for agents that offer them
+	ApprovalModeArgs map[ApprovalMode][]string
+
+	// PerToolPermissionArg is the flag repeated once per "tool:allow" or
+	// "tool:deny" permission, for agents that approve tools one at a time
+	// rather than with SkipApprovalsArgs
+	PerToolPermissionArg string
+
 	// SystemPromptArg is the flag for setting system prompt (if supported)
 	SystemPromptArg string
 
+	// SystemPromptIsFile means SystemPromptArg takes a file path rather than
+	// the prompt text, so the prompt is written to a temp file first
+	SystemPromptIsFile bool
+
 	// WorkspaceDirArg is the flag for setting working directory
 	WorkspaceDirArg string
 
 	// ResumeSessionArg is the flag for resuming a session
 	ResumeSessionArg string
+
+	// ResumeSubcommand replaces subcommands in NonInteractiveArgs when the
+	// agent resumes via a subcommand rather than a flag (session ID follows)
+	ResumeSubcommand []string
+
+	// ExtraArgs are user-supplied args appended after everything else
+	ExtraArgs []string
+
+	// OutputLastMessageArg is the flag, followed by a file path, that makes
+	// a one-shot run write its final message to that file. The file is read
+	// instead of scraping the message from stdout.
+	OutputLastMessageArg string
+
+	// PromptFraming is how prompts are written to the agent's stdin in an
+	// interactive session, so a multi-line prompt arrives as one message
+	PromptFraming PromptFraming
+
+	// PromptFramingArgs are the args that make an interactive session read
+	// prompts framed with PromptFraming from stdin
+	PromptFramingArgs []string
 }
 
-// KnownAgents returns CLI patterns for all supported agents.
+// KnownAgents returns CLI patterns for the built-in agents. Registry's
+// KnownAgents adds custom agents.
 func KnownAgents() map[string]CLIPattern {
+	return builtinAgents()
+}
+
+// builtinAgents returns CLI patterns for the agents buckshot ships with.
+func builtinAgents() map[string]CLIPattern {
 	return map[string]CLIPattern{
 		"claude": {
 			Binary:             "claude",
@@ -155,17 +201,22 @@ func KnownAgents() map[string]CLIPattern {
 			SystemPromptArg:    "--append-system-prompt",
 			WorkspaceDirArg:    "", // Uses current directory
 			ResumeSessionArg:   "--resume",
+			PromptFraming:      FramingStreamJSON,
+			PromptFramingArgs:  []string{"--input-format", "stream-json"},
 		},
 		"codex": {
-			Binary:             "codex",
-			VersionArgs:        []string{"--version"},
-			AuthCheckCmd:       []string{"--version"},
-			NonInteractiveArgs: []string{"exec"},
-			JSONOutputArgs:     []string{"--json"},
-			SkipApprovalsArgs:  []string{"--dangerously-bypass-approvals-and-sandbox"},
-			SystemPromptArg:    "", // Not directly supported
-			WorkspaceDirArg:    "--cd",
-			ResumeSessionArg:   "", // exec resume subcommand
+			Binary:               "codex",
+			VersionArgs:          []string{"--version"},
+			AuthCheckCmd:         []string{"--version"},
+			NonInteractiveArgs:   []string{"exec"},
+			JSONOutputArgs:       []string{"--json"},
+			SkipApprovalsArgs:    []string{"--dangerously-bypass-approvals-and-sandbox"},
+			SystemPromptArg:      "", // Not directly supported
+			WorkspaceDirArg:      "--cd",
+			ResumeSessionArg:     "", // exec resume subcommand
+			ResumeSubcommand:     []string{"exec", "resume"},
+			OutputLastMessageArg: "--output-last-message",
+			// No PromptFraming: exec reads one prompt, all of stdin, per run
 		},
 		"cursor-agent": {
 			Binary:             "cursor-agent",
@@ -177,17 +228,21 @@ func KnownAgents() map[string]CLIPattern {
 			SystemPromptArg:    "", // Not directly supported
 			WorkspaceDirArg:    "--workspace",
 			ResumeSessionArg:   "--resume",
+			// No PromptFraming: -p has no stdin input format to frame with
 		},
 		"auggie": {
-			Binary:             "auggie",
-			VersionArgs:        []string{"--version"},
-			AuthCheckCmd:       []string{"--version"}, // Auth checked on first real command
-			NonInteractiveArgs: []string{"--print"},
-			JSONOutputArgs:     []string{"--output-format", "json"},
-			SkipApprovalsArgs:  []string{}, // Per-tool permissions only
-			SystemPromptArg:    "--rules",
-			WorkspaceDirArg:    "--workspace-root",
-			ResumeSessionArg:   "--resume",
+			Binary:               "auggie",
+			VersionArgs:          []string{"--version"},
+			AuthCheckCmd:         []string{"--version"}, // Auth checked on first real command
+			NonInteractiveArgs:   []string{"--print"},
+			JSONOutputArgs:       []string{"--output-format", "json"},
+			SkipApprovalsArgs:    []string{}, // Per-tool permissions only
+			SystemPromptArg:      "--rules",
+			SystemPromptIsFile:   true, // --rules reads the rules from a file
+			WorkspaceDirArg:      "--workspace-root",
+			ResumeSessionArg:     "--resume",
+			PerToolPermissionArg: "--permission",
+			// No PromptFraming: --print reads one instruction per run
 		},
 		"gemini": {
 			Binary:             "gemini",
@@ -199,6 +254,11 @@ func KnownAgents() map[string]CLIPattern {
 			SystemPromptArg:    "", // Not directly supported
 			WorkspaceDirArg:    "", // Uses current directory
 			ResumeSessionArg:   "--resume",
+			ApprovalModeArgs: map[ApprovalMode][]string{
+				ApprovalAutoEdit: {"--approval-mode", "auto_edit"},
+				ApprovalDefault:  {"--approval-mode", "default"},
+			},
+			// No PromptFraming: a piped stdin is read whole as one prompt
 		},
 		"amp": {
 			Binary:             "amp",
@@ -210,6 +270,181 @@ func KnownAgents() map[string]CLIPattern {
 			SystemPromptArg:    "", // Not directly supported
 			WorkspaceDirArg:    "", // Uses current directory
 			ResumeSessionArg:   "", // Uses `amp threads continue`
+			ResumeSubcommand:   []string{"threads", "continue"},
+			PromptFraming:      FramingStreamJSON,
+			PromptFramingArgs:  []string{"--stream-json-input"},
 		},
 	}
 }
+
+// ApprovalMode is how much an agent may do without asking permission.
+type ApprovalMode string
+
+const (
+	// ApprovalYolo approves everything via SkipApprovalsArgs. The default.
+	ApprovalYolo ApprovalMode = "yolo"
+	// ApprovalAutoEdit approves file edits but not other tools.
+	ApprovalAutoEdit ApprovalMode = "auto_edit"
+	// ApprovalDefault leaves the agent's own permission prompts in place.
+	ApprovalDefault ApprovalMode = "default"
+)
+
+// ParseApprovalMode validates an approval mode name. Empty means ApprovalYolo.
+func ParseApprovalMode(name string) (ApprovalMode, error) {
+	switch mode := ApprovalMode(name); mode {
+	case "":
+		return ApprovalYolo, nil
+	case ApprovalYolo, ApprovalAutoEdit, ApprovalDefault:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown approval mode %q (want %s, %s, or %s)", name, ApprovalYolo, ApprovalAutoEdit, ApprovalDefault)
+}
+
+// ApprovalArgs returns the args that put the agent in mode. Agents without
+// args for a gentler mode get none, so they keep their own prompts.
+func (p CLIPattern) ApprovalArgs(mode ApprovalMode) []string {
+	if mode == "" || mode == ApprovalYolo {
+		return p.SkipApprovalsArgs
+	}
+	return p.ApprovalModeArgs[mode]
+}
+
+// ConfiguredModel returns the model picked with --model in ExtraArgs, or ""
+// if the agent runs its default model.
+func (p CLIPattern) ConfiguredModel() string {
+	var model string
+	for i, arg := range p.ExtraArgs {
+		if v, ok := strings.CutPrefix(arg, "--model="); ok {
+			model = v
+		} else if arg == "--model" && i+1 < len(p.ExtraArgs) {
+			model = p.ExtraArgs[i+1]
+		}
+	}
+	return model
+}
+
+// ValidateToolPermission checks that s is a per-tool permission of the form
+// "tool:allow" or "tool:deny".
+func ValidateToolPermission(s string) error {
+	tool, action, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(tool) == "" || (action != "allow" && action != "deny") {
+		return fmt.Errorf("invalid tool permission %q (want tool:allow or tool:deny)", s)
+	}
+	return nil
+}
+
+// PermissionArgs returns PerToolPermissionArg and its value for each
+// permission, or nil for agents without per-tool permissions.
+func (p CLIPattern) PermissionArgs(permissions []string) []string {
+	if p.PerToolPermissionArg == "" {
+		return nil
+	}
+	var args []string
+	for _, perm := range permissions {
+		args = append(args, p.PerToolPermissionArg, perm)
+	}
+	return args
+}
+
+// Capability names an optional feature an agent CLI may support.
+type Capability string
+
+const (
+	// CapJSONOutput means the agent can emit structured JSON output.
+	CapJSONOutput Capability = "json-output"
+	// CapSkipApprovals means the agent can run without permission prompts.
+	CapSkipApprovals Capability = "skip-approvals"
+	// CapSystemPrompt means the agent accepts a system prompt flag.
+	CapSystemPrompt Capability = "system-prompt"
+	// CapWorkspaceDir means the agent accepts a workspace directory flag.
+	CapWorkspaceDir Capability = "workspace-dir"
+	// CapResume means the agent can resume a prior session.
+	CapResume Capability = "resume"
+)
+
+// AllCapabilities lists every capability in display order.
+var AllCapabilities = []Capability{CapJSONOutput, CapSkipApprovals, CapSystemPrompt, CapWorkspaceDir, CapResume}
+
+// CapabilitySet is a set of supported capabilities.
+type CapabilitySet map[Capability]bool
+
+// Has reports whether the set includes c.
+func (s CapabilitySet) Has(c Capability) bool {
+	return s[c]
+}
+
+// List returns the supported capabilities in display order.
+func (s CapabilitySet) List() []Capability {
+	var caps []Capability
+	for _, c := range AllCapabilities {
+		if s[c] {
+			caps = append(caps, c)
+		}
+	}
+	return caps
+}
+
+// Capabilities returns the optional features this pattern supports.
+func (p CLIPattern) Capabilities() CapabilitySet {
+	return CapabilitySet{
+		CapJSONOutput:    len(p.JSONOutputArgs) > 0,
+		CapSkipApprovals: len(p.SkipApprovalsArgs) > 0,
+		CapSystemPrompt:  p.SystemPromptArg != "",
+		CapWorkspaceDir:  p.WorkspaceDirArg != "",
+		CapResume:        p.ResumeSessionArg != "" || len(p.ResumeSubcommand) > 0,
+	}
+}
+
+// ValidateCLIPattern checks that a pattern has the fields buckshot needs to
+// detect and run the agent, and that related fields agree. All problems are
+// reported together, each naming the offending field.
+func ValidateCLIPattern(p CLIPattern) error {
+	var errs []error
+	switch {
+	case strings.TrimSpace(p.Binary) == "":
+		errs = append(errs, errors.New("binary is required"))
+	case strings.ContainsAny(p.Binary, " \t\n"):
+		errs = append(errs, fmt.Errorf("binary %q must not contain whitespace", p.Binary))
+	}
+	if len(p.VersionArgs) == 0 {
+		errs = append(errs, errors.New("version_args is required to detect the agent"))
+	}
+
+	for _, list := range []struct {
+		field string
+		args  []string
+	}{
+		{"version_args", p.VersionArgs},
+		{"auth_check_cmd", p.AuthCheckCmd},
+		{"non_interactive_args", p.NonInteractiveArgs},
+		{"json_output_args", p.JSONOutputArgs},
+		{"skip_approvals_args", p.SkipApprovalsArgs},
+		{"resume_subcommand", p.ResumeSubcommand},
+	} {
+		for i, arg := range list.args {
+			if strings.TrimSpace(arg) == "" {
+				errs = append(errs, fmt.Errorf("%s[%d] is blank", list.field, i))
+			}
+		}
+	}
+
+	for _, flag := range []struct {
+		field, value string
+	}{
+		{"system_prompt_arg", p.SystemPromptArg},
+		{"workspace_dir_arg", p.WorkspaceDirArg},
+		{"resume_session_arg", p.ResumeSessionArg},
+	} {
+		if flag.value != "" && !strings.HasPrefix(flag.value, "-") {
+			errs = append(errs, fmt.Errorf("%s %q must be a flag starting with -", flag.field, flag.value))
+		}
+	}
+	if p.ResumeSessionArg != "" && len(p.ResumeSubcommand) > 0 {
+		errs = append(errs, errors.New("resume_session_arg and resume_subcommand are mutually exclusive"))
+	}
+	if _, err := ParsePromptFraming(string(p.PromptFraming)); err != nil {
+		errs = append(errs, fmt.Errorf("prompt_framing: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCapabilities_KnownAgents tests the capability sets of the built-in agents
+func TestCapabilities_KnownAgents(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"claude", "json-output skip-approvals system-prompt resume"},
+		{"codex", "json-output skip-approvals workspace-dir resume"},
+		{"cursor-agent", "json-output skip-approvals workspace-dir resume"},
+		{"auggie", "json-output system-prompt workspace-dir resume"},
+		{"gemini", "json-output skip-approvals resume"},
+		{"amp", "json-output skip-approvals resume"},
+	}
+
+	patterns := KnownAgents()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range patterns[tt.name].Capabilities().List() {
+				got = append(got, string(c))
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("Capabilities() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCapabilities_Empty tests that a bare pattern supports nothing optional
+func TestCapabilities_Empty(t *testing.T) {
+	caps := CLIPattern{Binary: "bare"}.Capabilities()
+	for _, c := range AllCapabilities {
+		if caps.Has(c) {
+			t.Errorf("bare pattern should not support %s", c)
+		}
+	}
+}
+
+// TestValidateCLIPattern tests that each malformed pattern is rejected with an
+// error naming the offending field
+func TestValidateCLIPattern(t *testing.T) {
+	valid := CLIPattern{
+		Binary:             "llm-cli",
+		VersionArgs:        []string{"--version"},
+		NonInteractiveArgs: []string{"run"},
+		SystemPromptArg:    "--system",
+		ResumeSessionArg:   "--resume",
+	}
+	if err := ValidateCLIPattern(valid); err != nil {
+		t.Fatalf("ValidateCLIPattern(valid) error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(p *CLIPattern)
+		wantErr string
+	}{
+		{"missing binary", func(p *CLIPattern) { p.Binary = "" }, "binary is required"},
+		{"binary with spaces", func(p *CLIPattern) { p.Binary = "llm cli" }, "must not contain whitespace"},
+		{"missing version args", func(p *CLIPattern) { p.VersionArgs = nil }, "version_args is required"},
+		{"blank arg", func(p *CLIPattern) { p.NonInteractiveArgs = []string{"run", " "} }, "non_interactive_args[1] is blank"},
+		{"flag without dash", func(p *CLIPattern) { p.SystemPromptArg = "system" }, "system_prompt_arg \"system\" must be a flag"},
+		{"two resume styles", func(p *CLIPattern) { p.ResumeSubcommand = []string{"resume"} }, "mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			err := ValidateCLIPattern(p)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateCLIPattern() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateCLIPattern_BuiltinAgents tests that every built-in pattern is valid
+func TestValidateCLIPattern_BuiltinAgents(t *testing.T) {
+	for name, pattern := range builtinAgents() {
+		if err := ValidateCLIPattern(pattern); err != nil {
+			t.Errorf("%s: ValidateCLIPattern() error = %v", name, err)
+		}
+	}
+}
+
+// TestParseApprovalMode tests accepted and rejected approval mode names
+func TestParseApprovalMode(t *testing.T) {
+	for name, want := range map[string]ApprovalMode{"": ApprovalYolo, "yolo": ApprovalYolo, "auto_edit": ApprovalAutoEdit, "default": ApprovalDefault} {
+		if got, err := ParseApprovalMode(name); err != nil || got != want {
+			t.Errorf("ParseApprovalMode(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseApprovalMode("sometimes"); err == nil || !strings.Contains(err.Error(), "unknown approval mode") {
+		t.Errorf("ParseApprovalMode(sometimes) error = %v, want unknown approval mode", err)
+	}
+}
+
+// TestValidateToolPermission tests the tool:allow and tool:deny forms
+func TestValidateToolPermission(t *testing.T) {
+	for _, ok := range []string{"launch-process:allow", "web-fetch:deny"} {
+		if err := ValidateToolPermission(ok); err != nil {
+			t.Errorf("ValidateToolPermission(%q) error = %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"launch-process", ":allow", "web-fetch:maybe", ""} {
+		if err := ValidateToolPermission(bad); err == nil || !strings.Contains(err.Error(), "invalid tool permission") {
+			t.Errorf("ValidateToolPermission(%q) error = %v, want invalid tool permission", bad, err)
+		}
+	}
+}
+
+// TestPermissionArgs tests that each permission repeats the agent's flag
+func TestPermissionArgs(t *testing.T) {
+	patterns := KnownAgents()
+	perms := []string{"launch-process:allow", "web-fetch:deny"}
+
+	if got, want := strings.Join(patterns["auggie"].PermissionArgs(perms), " "), "--permission launch-process:allow --permission web-fetch:deny"; got != want {
+		t.Errorf("auggie PermissionArgs() = %q, want %q", got, want)
+	}
+	if got := patterns["claude"].PermissionArgs(perms); got != nil {
+		t.Errorf("claude PermissionArgs() = %v, want none", got)
+	}
+}
+
+// TestConfiguredModel tests reading --model from an agent's extra args
+func TestConfiguredModel(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"--model", "claude-opus-4-1"}, "claude-opus-4-1"},
+		{[]string{"--verbose", "--model=gpt-5-mini"}, "gpt-5-mini"},
+		{[]string{"--model"}, ""},
+	}
+	for _, tt := range tests {
+		if got := (CLIPattern{ExtraArgs: tt.args}).ConfiguredModel(); got != tt.want {
+			t.Errorf("ConfiguredModel() with %v = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestApprovalArgs tests that gentler modes fall back to no args for agents
+// that lack them
+func TestApprovalArgs(t *testing.T) {
+	patterns := KnownAgents()
+	tests := []struct {
+		agent string
+		mode  ApprovalMode
+		want  string
+	}{
+		{"gemini", ApprovalYolo, "--yolo"},
+		{"gemini", ApprovalAutoEdit, "--approval-mode auto_edit"},
+		{"claude", "", "--dangerously-skip-permissions"},
+		{"claude", ApprovalAutoEdit, ""},
+		{"codex", ApprovalDefault, ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(patterns[tt.agent].ApprovalArgs(tt.mode), " "); got != tt.want {
+			t.Errorf("%s ApprovalArgs(%q) = %q, want %q", tt.agent, tt.mode, got, tt.want)
+		}
+	}
+}
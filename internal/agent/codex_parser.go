@@ -2,6 +2,7 @@ package agent
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -137,3 +138,85 @@ func (p *CodexParser) extractFromMessage(item map[string]interface{}) string {
 
 	return strings.Join(parts, "\n")
 }
+
+// ParseUsage sums the usage reported on turn.completed events. Older codex
+// builds report token_count events instead; those are used when no turn
+// reported usage. Codex already includes cached input in input_tokens.
+func (p *CodexParser) ParseUsage(output string) TokenUsage {
+	var turns, counted TokenUsage
+	forEachJSONEvent(output, func(event map[string]interface{}) {
+		switch eventType, _ := event["type"].(string); eventType {
+		case "turn.completed":
+			if usage, ok := event["usage"].(map[string]interface{}); ok {
+				turns = turns.Add(codexUsage(usage))
+			}
+		case "token_count":
+			// Cumulative totals replace earlier ones; bare counts add up
+			if info, ok := event["info"].(map[string]interface{}); ok {
+				if total, ok := info["total_token_usage"].(map[string]interface{}); ok {
+					counted = codexUsage(total)
+				}
+				return
+			}
+			counted = counted.Add(codexUsage(event))
+		}
+	})
+	if turns.Total() > 0 {
+		return turns
+	}
+	return counted
+}
+
+// codexUsage reads input_tokens and output_tokens from a usage object.
+func codexUsage(usage map[string]interface{}) TokenUsage {
+	return TokenUsage{
+		InputTokens:  intField(usage, "input_tokens"),
+		OutputTokens: intField(usage, "output_tokens"),
+	}
+}
+
+// ParseSessionID returns the thread ID from the thread.started event.
+func (p *CodexParser) ParseSessionID(output string) string {
+	return firstStringField(output, "thread.started", "thread_id")
+}
+
+// ParseError returns the error codex reported for the turn. A turn.failed
+// event is always an error; error events (such as stream retries) only count
+// if no turn.completed follows them.
+func (p *CodexParser) ParseError(output string) error {
+	var failed error
+	forEachJSONEvent(output, func(event map[string]interface{}) {
+		switch eventType, _ := event["type"].(string); eventType {
+		case "error":
+			msg, _ := event["message"].(string)
+			failed = fmt.Errorf("codex error: %s", msg)
+		case "turn.failed":
+			msg := "turn failed"
+			if e, ok := event["error"].(map[string]interface{}); ok {
+				if m, _ := e["message"].(string); m != "" {
+					msg = m
+				}
+			}
+			failed = fmt.Errorf("codex turn failed: %s", msg)
+		case "turn.completed":
+			failed = nil
+		}
+	})
+	return failed
+}
+
+// IsTurnComplete reports whether line is the turn.completed or turn.failed
+// event that ends a codex turn.
+func (p *CodexParser) IsTurnComplete(line string) bool {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return false
+	}
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return false
+	}
+	return event.Type == "turn.completed" || event.Type == "turn.failed"
+}
@@ -191,3 +191,133 @@ func TestCodexParserExtractsReasoningFromItemCompleted(t *testing.T) {
 		t.Errorf("Parse() did not extract reasoning text, got: %s", result)
 	}
 }
+
+// TestCodexParserParsesUsage tests token usage extraction from turn.completed events
+func TestCodexParserParsesUsage(t *testing.T) {
+	parser := &CodexParser{}
+
+	input := `{"type":"item.completed","item":{"type":"agent_message","text":"Done"}}
+{"type":"turn.completed","usage":{"input_tokens":2000,"cached_input_tokens":1500,"output_tokens":300}}
+{"type":"turn.completed","usage":{"input_tokens":100,"output_tokens":20}}`
+
+	usage := ParseUsage(parser, input)
+
+	if usage.InputTokens != 2100 || usage.OutputTokens != 320 {
+		t.Errorf("ParseUsage() = %+v, want 2100 in / 320 out", usage)
+	}
+}
+
+// TestCodexParserParsesSessionID tests thread ID extraction from thread.started events
+func TestCodexParserParsesSessionID(t *testing.T) {
+	input := `{"type":"thread.started","thread_id":"0199a213-81c0-7800-8aa1-bbab2a035a53"}
+{"type":"turn.started"}`
+
+	if got := ParseSessionID(&CodexParser{}, input); got != "0199a213-81c0-7800-8aa1-bbab2a035a53" {
+		t.Errorf("ParseSessionID() = %q, want the thread ID", got)
+	}
+}
+
+// codexTranscript is a full codex exec --json run, including a transient
+// stream error that codex recovered from.
+const codexTranscript = `{"type":"thread.started","thread_id":"0199a213-81c0-7800-8aa1-bbab2a035a53"}
+{"type":"turn.started"}
+{"type":"item.completed","item":{"id":"item_0","type":"reasoning","text":"**Reviewing the beads**"}}
+{"type":"error","message":"Reconnecting... 1/5"}
+{"type":"item.started","item":{"id":"item_1","type":"command_execution","command":"bd list","aggregated_output":"","status":"in_progress"}}
+{"type":"item.completed","item":{"id":"item_1","type":"command_execution","command":"bd list","aggregated_output":"buckshot-1 [P1] [task] open - Add auth\n","exit_code":0,"status":"completed"}}
+{"type":"item.completed","item":{"id":"item_2","type":"agent_message","text":"Created buckshot-2 for token refresh."}}
+{"type":"turn.completed","usage":{"input_tokens":24763,"cached_input_tokens":24448,"output_tokens":122}}`
+
+// TestCodexParserFullTranscript tests a realistic codex JSONL run end to end
+func TestCodexParserFullTranscript(t *testing.T) {
+	parser := &CodexParser{}
+
+	text := parser.Parse(codexTranscript)
+	if !strings.Contains(text, "Reviewing the beads") || !strings.Contains(text, "Created buckshot-2 for token refresh.") {
+		t.Errorf("Parse() = %q, want reasoning and the agent message", text)
+	}
+	if strings.Contains(text, "thread_id") || strings.Contains(text, "Reconnecting") {
+		t.Errorf("Parse() should drop thread.started and error events, got %q", text)
+	}
+
+	if usage := ParseUsage(parser, codexTranscript); usage.InputTokens != 24763 || usage.OutputTokens != 122 {
+		t.Errorf("ParseUsage() = %+v, want 24763 in / 122 out", usage)
+	}
+	if id := ParseSessionID(parser, codexTranscript); id != "0199a213-81c0-7800-8aa1-bbab2a035a53" {
+		t.Errorf("ParseSessionID() = %q", id)
+	}
+	if err := ParseError(parser, codexTranscript); err != nil {
+		t.Errorf("ParseError() = %v; a retry followed by turn.completed is not an error", err)
+	}
+}
+
+// TestCodexParserParseError tests error and turn.failed events
+func TestCodexParserParseError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "error without recovery",
+			input: `{"type":"thread.started","thread_id":"t1"}
+{"type":"turn.started"}
+{"type":"error","message":"stream disconnected before completion"}`,
+			want: "codex error: stream disconnected before completion",
+		},
+		{
+			name: "turn failed",
+			input: `{"type":"turn.started"}
+{"type":"error","message":"Reconnecting... 5/5"}
+{"type":"turn.failed","error":{"message":"exceeded retry limit, last status: 429 Too Many Requests"}}`,
+			want: "codex turn failed: exceeded retry limit, last status: 429 Too Many Requests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseError(&CodexParser{}, tt.input)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseError() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestCodexParserTokenCountEvents tests usage from token_count events when
+// no turn.completed usage is reported
+func TestCodexParserTokenCountEvents(t *testing.T) {
+	input := `{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"output_tokens":50}}}
+{"type":"item.completed","item":{"type":"agent_message","text":"Done"}}
+{"type":"token_count","info":{"total_token_usage":{"input_tokens":1800,"output_tokens":90}}}
+{"type":"turn.completed"}`
+
+	if usage := ParseUsage(&CodexParser{}, input); usage.InputTokens != 1800 || usage.OutputTokens != 90 {
+		t.Errorf("ParseUsage() = %+v, want the latest cumulative 1800 in / 90 out", usage)
+	}
+
+	flat := `{"type":"token_count","input_tokens":10,"output_tokens":2}
+{"type":"token_count","input_tokens":5,"output_tokens":1}`
+	if usage := ParseUsage(&CodexParser{}, flat); usage.InputTokens != 15 || usage.OutputTokens != 3 {
+		t.Errorf("ParseUsage() = %+v, want 15 in / 3 out", usage)
+	}
+}
+
+// TestCodexParserIsTurnComplete tests the end-of-turn marker
+func TestCodexParserIsTurnComplete(t *testing.T) {
+	parser := &CodexParser{}
+	for line, want := range map[string]bool{
+		`{"type":"turn.completed","usage":{"input_tokens":1}}`:                              true,
+		`{"type":"turn.failed","error":{"message":"x"}}`:                                    true,
+		`{"type":"turn.started"}`:                                                           false,
+		`{"type":"item.completed","item":{"type":"agent_message","text":"turn.completed"}}`: false,
+		"turn.completed": false,
+	} {
+		if got := IsTurnComplete(parser, line); got != want {
+			t.Errorf("IsTurnComplete(%q) = %v, want %v", line, got, want)
+		}
+	}
+	if IsTurnComplete(&NoopParser{}, `{"type":"turn.completed"}`) {
+		t.Error("parsers without a turn marker should never report completion")
+	}
+}
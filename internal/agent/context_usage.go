@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Context window sizes assumed when an agent's output does not report one.
+const (
+	claudeContextWindow = 200000
+	codexContextWindow  = 272000
+	geminiContextWindow = 1048576
+)
+
+// ContextWindow returns the context window, in tokens, assumed for the named
+// agent, or 0 if it is not known.
+func ContextWindow(name string) int {
+	switch name {
+	case "claude", "cursor-agent":
+		return claudeContextWindow
+	case "codex":
+		return codexContextWindow
+	case "gemini":
+		return geminiContextWindow
+	default:
+		return 0
+	}
+}
+
+// ContextUsageExtractor is implemented by parsers that can read how full the
+// agent's context window is from its structured output.
+type ContextUsageExtractor interface {
+	// ContextUsage returns the fraction (0.0-1.0) of the context window
+	// reported on a single output line, or -1 if the line reports none.
+	ContextUsage(line string) float64
+}
+
+// contextUsageRegex matches status lines like "Context: 15% used" or
+// "15% used (29368/200000 tokens)".
+var contextUsageRegex = regexp.MustCompile(`(?i)(\d+)%\s+used`)
+
+// ParseContextUsage returns the fraction of the context window reported on
+// line, or -1 if it reports none. Parsers with a ContextUsageExtractor read
+// their own JSON events; plain lines, and every line of other agents, fall
+// back to the "N% used" status line.
+func ParseContextUsage(parser OutputParser, line string) float64 {
+	if ce, ok := parser.(ContextUsageExtractor); ok {
+		if usage := ce.ContextUsage(line); usage >= 0 {
+			return usage
+		}
+		// A reply that happens to say "N% used" is not a usage report
+		if isJSONLine(line) {
+			return -1
+		}
+	}
+	matches := contextUsageRegex.FindStringSubmatch(line)
+	if len(matches) >= 2 {
+		if pct, err := strconv.Atoi(matches[1]); err == nil {
+			return float64(pct) / 100.0
+		}
+	}
+	return -1
+}
+
+// isJSONLine reports whether line looks like a JSON event.
+func isJSONLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "{")
+}
+
+// parseJSONEvent decodes line as a JSON object, returning nil if it is not one.
+func parseJSONEvent(line string) map[string]interface{} {
+	if !isJSONLine(line) {
+		return nil
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &event); err != nil {
+		return nil
+	}
+	return event
+}
+
+// contextFraction returns tokens as a fraction of window, capped at 1.
+// It returns -1 if nothing was counted.
+func contextFraction(tokens, window int) float64 {
+	if tokens <= 0 || window <= 0 {
+		return -1
+	}
+	if tokens >= window {
+		return 1
+	}
+	return float64(tokens) / float64(window)
+}
+
+// ContextUsage reads the usage on assistant messages, which reflects the
+// context of the request that produced them. Result events sum the usage of
+// every request in the turn, so they would overstate it.
+func (p *StreamJSONParser) ContextUsage(line string) float64 {
+	event := parseJSONEvent(line)
+	if eventType, _ := event["type"].(string); eventType != "assistant" {
+		return -1
+	}
+	message, _ := event["message"].(map[string]interface{})
+	usage, ok := message["usage"].(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	tokens := intField(usage, "input_tokens") +
+		intField(usage, "cache_creation_input_tokens") +
+		intField(usage, "cache_read_input_tokens") +
+		intField(usage, "output_tokens")
+	return contextFraction(tokens, claudeContextWindow)
+}
+
+// ContextUsage reads token_count events, preferring the last request's
+// usage over the session total and the reported model_context_window over
+// the default.
+func (p *CodexParser) ContextUsage(line string) float64 {
+	event := parseJSONEvent(line)
+	if eventType, _ := event["type"].(string); eventType != "token_count" {
+		return -1
+	}
+	info, ok := event["info"].(map[string]interface{})
+	if !ok {
+		return contextFraction(codexUsage(event).Total(), codexContextWindow)
+	}
+	window := intField(info, "model_context_window")
+	if window == 0 {
+		window = codexContextWindow
+	}
+	for _, key := range []string{"last_token_usage", "total_token_usage"} {
+		if usage, ok := info[key].(map[string]interface{}); ok {
+			return contextFraction(codexUsage(usage).Total(), window)
+		}
+	}
+	return -1
+}
+
+// ContextUsage reads stats.total_tokens from result events.
+func (p *GeminiParser) ContextUsage(line string) float64 {
+	event := parseJSONEvent(line)
+	if eventType, _ := event["type"].(string); eventType != "result" {
+		return -1
+	}
+	stats, ok := event["stats"].(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	return contextFraction(intField(stats, "total_tokens"), geminiContextWindow)
+}
+
+// ContextUsage delegates to the wrapped parser.
+func (p *rawParser) ContextUsage(line string) float64 {
+	return ParseContextUsage(p.inner, line)
+}
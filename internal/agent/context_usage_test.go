@@ -0,0 +1,125 @@
+package agent
+
+import "testing"
+
+func TestParseContextUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser OutputParser
+		line   string
+		want   float64
+	}{
+		{
+			name:   "claude assistant usage",
+			parser: &ClaudeParser{},
+			line:   `{"type":"assistant","message":{"content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":2000,"cache_creation_input_tokens":8000,"cache_read_input_tokens":39000,"output_tokens":1000}}}`,
+			want:   0.25,
+		},
+		{
+			name:   "claude result sums the turn, not the context",
+			parser: &ClaudeParser{},
+			line:   `{"type":"result","result":"Done","usage":{"input_tokens":150000,"output_tokens":5000}}`,
+			want:   -1,
+		},
+		{
+			name:   "claude reply mentioning usage",
+			parser: &ClaudeParser{},
+			line:   `{"type":"assistant","message":{"content":[{"type":"text","text":"Disk is 90% used"}]}}`,
+			want:   -1,
+		},
+		{
+			name:   "cursor shares the stream-json extractor",
+			parser: &CursorParser{},
+			line:   `{"type":"assistant","message":{"usage":{"input_tokens":100000}}}`,
+			want:   0.5,
+		},
+		{
+			name:   "codex last request against reported window",
+			parser: &CodexParser{},
+			line:   `{"type":"token_count","info":{"total_token_usage":{"input_tokens":500000,"output_tokens":9000},"last_token_usage":{"input_tokens":60000,"output_tokens":4000},"model_context_window":256000}}`,
+			want:   0.25,
+		},
+		{
+			name:   "codex bare counts against default window",
+			parser: &CodexParser{},
+			line:   `{"type":"token_count","input_tokens":68000,"output_tokens":0}`,
+			want:   0.25,
+		},
+		{
+			name:   "codex turn.completed is not a context report",
+			parser: &CodexParser{},
+			line:   `{"type":"turn.completed","usage":{"input_tokens":68000,"output_tokens":100}}`,
+			want:   -1,
+		},
+		{
+			name:   "gemini result stats",
+			parser: &GeminiParser{},
+			line:   `{"type":"result","status":"success","stats":{"total_tokens":262144,"input_tokens":260000,"output_tokens":2144}}`,
+			want:   0.25,
+		},
+		{
+			name:   "usage over the window is capped",
+			parser: &GeminiParser{},
+			line:   `{"type":"result","stats":{"total_tokens":2000000}}`,
+			want:   1,
+		},
+		{
+			name:   "structured agent status line",
+			parser: &CodexParser{},
+			line:   "Context: 15% used",
+			want:   0.15,
+		},
+		{
+			name:   "unknown agent status line",
+			parser: &NoopParser{},
+			line:   "15% used (29368/200000 tokens)",
+			want:   0.15,
+		},
+		{
+			name:   "unknown agent JSON line",
+			parser: &NoopParser{},
+			line:   `{"status":"40% used"}`,
+			want:   0.4,
+		},
+		{
+			name:   "raw delegates to the wrapped extractor",
+			parser: NewRawParser(&GeminiParser{}),
+			line:   `{"type":"result","stats":{"total_tokens":524288}}`,
+			want:   0.5,
+		},
+		{
+			name:   "nil parser",
+			parser: nil,
+			line:   "Context: 3% used",
+			want:   0.03,
+		},
+		{
+			name:   "no usage",
+			parser: &ClaudeParser{},
+			line:   "Looks good",
+			want:   -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseContextUsage(tt.parser, tt.line); got != tt.want {
+				t.Errorf("ParseContextUsage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"claude":       claudeContextWindow,
+		"cursor-agent": claudeContextWindow,
+		"codex":        codexContextWindow,
+		"gemini":       geminiContextWindow,
+		"auggie":       0,
+	}
+	for name, want := range tests {
+		if got := ContextWindow(name); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
@@ -1,15 +1,19 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 )
 
 // DefaultDetector is the default implementation of Detector.
 type DefaultDetector struct {
 	searchPath string
+	registry   *Registry // Custom agents to detect; nil for built-ins only
 }
 
 // NewDetector creates a new detector using the system PATH.
@@ -24,25 +28,49 @@ func NewDetectorWithPath(path string) *DefaultDetector {
 	return &DefaultDetector{searchPath: path}
 }
 
-// DetectAll returns all available agents on the system.
-func (d *DefaultDetector) DetectAll() ([]Agent, error) {
+// SetRegistry makes the detector look for the custom agents in r as well as
+// the built-ins.
+func (d *DefaultDetector) SetRegistry(r *Registry) {
+	d.registry = r
+}
+
+// DetectAll returns all available agents on the system. Agents whose pattern
+// fails ValidateCLIPattern are skipped rather than run with a broken command.
+// Version and auth checks are cached for CheckCacheTTL; force reruns them.
+// Each check is given CheckTimeout, and an agent whose check times out is
+// returned with CheckTimedOut set rather than dropped.
+func (d *DefaultDetector) DetectAll(ctx context.Context, force bool) ([]Agent, error) {
 	agents := []Agent{}
-	knownAgents := KnownAgents()
+	knownAgents := d.registry.KnownAgents()
 
 	for name, pattern := range knownAgents {
+		if ValidateCLIPattern(pattern) != nil {
+			continue
+		}
 		if d.IsInstalled(name) {
 			agent := Agent{
 				Name:    name,
 				Path:    d.GetAgentPath(name),
 				Pattern: pattern,
-				Parser:  GetParserForAgent(name),
+				Parser:  d.registry.Parser(name),
 			}
 
-			// Get version
-			agent.Version = d.getVersion(agent)
+			if force {
+				forgetChecks(agent.Path)
+			}
 
-			// Check authentication
-			agent.Authenticated = d.IsAuthenticated(agent)
+			// Get version
+			version, err := d.getVersion(ctx, agent)
+			agent.Version = version
+
+			// Check authentication, unless the binary already hung
+			if errors.Is(err, ErrCheckTimedOut) {
+				agent.CheckTimedOut = true
+			} else {
+				err = d.authCheck(ctx, agent)
+				agent.Authenticated = err == nil
+				agent.CheckTimedOut = errors.Is(err, ErrCheckTimedOut)
+			}
 
 			agents = append(agents, agent)
 		}
@@ -51,7 +79,8 @@ func (d *DefaultDetector) DetectAll() ([]Agent, error) {
 	return agents, nil
 }
 
-// GetParserForAgent returns the appropriate output parser for a given agent.
+// GetParserForAgent returns the output parser for a built-in agent.
+// Registry's Parser covers custom agents.
 func GetParserForAgent(name string) OutputParser {
 	switch name {
 	case "claude":
@@ -76,67 +105,123 @@ func (d *DefaultDetector) IsInstalled(name string) bool {
 	return d.GetAgentPath(name) != ""
 }
 
-// IsAuthenticated checks if an agent is authenticated.
-func (d *DefaultDetector) IsAuthenticated(agent Agent) bool {
+// IsAuthenticated checks if an agent is authenticated, reusing a recent
+// result for the same binary.
+func (d *DefaultDetector) IsAuthenticated(ctx context.Context, agent Agent) bool {
+	return d.authCheck(ctx, agent) == nil
+}
+
+// authCheck runs an agent's auth check, returning nil if it is authenticated.
+func (d *DefaultDetector) authCheck(ctx context.Context, agent Agent) error {
 	if agent.Path == "" {
-		return false
+		return errNoCheck
 	}
 
 	// For most agents, if they're installed and version works, assume authenticated
 	// Real auth check would require running a command that hits the API
-	pattern, ok := KnownAgents()[agent.Name]
+	pattern, ok := d.registry.KnownAgents()[agent.Name]
 	if !ok {
-		return false
+		return errNoCheck
 	}
 
-	// Try running the auth check command
-	var cmd *exec.Cmd
-	if len(pattern.AuthCheckCmd) > 0 {
-		cmd = exec.Command(agent.Path, pattern.AuthCheckCmd...)
-	} else {
-		// Fall back to version check
-		cmd = exec.Command(agent.Path, pattern.VersionArgs...)
+	// Try running the auth check command, falling back to the version check
+	args := pattern.AuthCheckCmd
+	if len(args) == 0 {
+		args = pattern.VersionArgs
 	}
 
-	err := cmd.Run()
-	return err == nil
+	_, err := cachedCheck(ctx, agent.Path, args)
+	return err
 }
 
 // GetAgentPath returns the full path for an agent binary.
 func (d *DefaultDetector) GetAgentPath(name string) string {
+	if paths := d.findAll(name); len(paths) > 0 {
+		return paths[0]
+	}
+	return ""
+}
+
+// DetectConflicts returns the known agents whose binary is found in more than
+// one search path directory, sorted by name. Copies that are the same file
+// (e.g. through a symlinked directory) count once.
+func (d *DefaultDetector) DetectConflicts() []Conflict {
+	var conflicts []Conflict
+	for name, pattern := range d.registry.KnownAgents() {
+		paths := d.findAll(name)
+		if len(paths) < 2 {
+			continue
+		}
+		c := Conflict{Name: name}
+		for _, path := range paths {
+			c.Candidates = append(c.Candidates, Candidate{Path: path, Version: versionOrEmpty(path, pattern)})
+		}
+		conflicts = append(conflicts, c)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Name < conflicts[j].Name })
+	return conflicts
+}
+
+// findAll returns every executable copy of the agent's binary on the search
+// path, in search order, skipping copies that are the same file.
+func (d *DefaultDetector) findAll(name string) []string {
 	if d.searchPath == "" {
-		return ""
+		return nil
+	}
+
+	// Custom agents may use a binary name that differs from the agent name
+	binary := name
+	if pattern, ok := d.registry.KnownAgents()[name]; ok && pattern.Binary != "" {
+		binary = pattern.Binary
 	}
 
-	// Check each directory in the search path
+	var paths []string
+	var seen []os.FileInfo
 	for _, dir := range filepath.SplitList(d.searchPath) {
-		path := filepath.Join(dir, name)
-		if info, err := os.Stat(path); err == nil {
-			// Check if it's executable
-			if info.Mode()&0111 != 0 {
-				return path
-			}
+		path := filepath.Join(dir, binary)
+		info, err := os.Stat(path)
+		if err != nil || info.Mode()&0111 == 0 {
+			continue
+		}
+		if slices.ContainsFunc(seen, func(fi os.FileInfo) bool { return os.SameFile(fi, info) }) {
+			continue
 		}
+		seen = append(seen, info)
+		paths = append(paths, path)
 	}
-
-	return ""
+	return paths
 }
 
 // getVersion retrieves the version string for an agent.
-func (d *DefaultDetector) getVersion(agent Agent) string {
+func (d *DefaultDetector) getVersion(ctx context.Context, agent Agent) (string, error) {
 	if agent.Path == "" {
-		return ""
+		return "", errNoCheck
 	}
 
-	pattern, ok := KnownAgents()[agent.Name]
-	if !ok || len(pattern.VersionArgs) == 0 {
-		return ""
+	pattern, ok := d.registry.KnownAgents()[agent.Name]
+	if !ok {
+		return "", errNoCheck
+	}
+	return versionAt(ctx, agent.Path, pattern)
+}
+
+// versionOrEmpty returns the version of the binary at path, or "" if the
+// check fails.
+func versionOrEmpty(path string, pattern CLIPattern) string {
+	version, _ := versionAt(context.Background(), path, pattern)
+	return version
+}
+
+// versionAt runs the binary at path with the pattern's version args and
+// returns the first line of output.
+func versionAt(ctx context.Context, path string, pattern CLIPattern) (string, error) {
+	if len(pattern.VersionArgs) == 0 {
+		return "", errNoCheck
 	}
 
-	cmd := exec.Command(agent.Path, pattern.VersionArgs...)
-	output, err := cmd.Output()
+	output, err := cachedCheck(ctx, path, pattern.VersionArgs)
 	if err != nil {
-		return ""
+		return "", err
 	}
 
 	// Return first line of output, trimmed
@@ -145,5 +230,5 @@ func (d *DefaultDetector) getVersion(agent Agent) string {
 		version = version[:idx]
 	}
 
-	return version
+	return version, nil
 }
@@ -1,9 +1,12 @@
 package agent
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -16,7 +19,7 @@ func TestDetectorInterface(t *testing.T) {
 // TestDetectAll tests detection of all available agents
 func TestDetectAll(t *testing.T) {
 	d := NewDetector()
-	agents, err := d.DetectAll()
+	agents, err := d.DetectAll(context.Background(), false)
 	if err != nil {
 		t.Fatalf("DetectAll() error = %v", err)
 	}
@@ -108,13 +111,13 @@ func TestIsAuthenticated(t *testing.T) {
 
 	// IsAuthenticated should return a boolean without panicking
 	// Actual auth status depends on system state
-	_ = d.IsAuthenticated(agent)
+	_ = d.IsAuthenticated(context.Background(), agent)
 }
 
 // TestDetectAllReturnsKnownAgents tests that only known agents are detected
 func TestDetectAllReturnsKnownAgents(t *testing.T) {
 	d := NewDetector()
-	agents, err := d.DetectAll()
+	agents, err := d.DetectAll(context.Background(), false)
 	if err != nil {
 		t.Fatalf("DetectAll() error = %v", err)
 	}
@@ -138,7 +141,7 @@ func TestDetectAllReturnsKnownAgents(t *testing.T) {
 // TestAgentHasVersion tests that detected agents have version info
 func TestAgentHasVersion(t *testing.T) {
 	d := NewDetector()
-	agents, err := d.DetectAll()
+	agents, err := d.DetectAll(context.Background(), false)
 	if err != nil {
 		t.Fatalf("DetectAll() error = %v", err)
 	}
@@ -154,7 +157,7 @@ func TestAgentHasVersion(t *testing.T) {
 // TestDetectorWithEmptyPath tests detector behavior with no PATH
 func TestDetectorWithEmptyPath(t *testing.T) {
 	d := NewDetectorWithPath("")
-	agents, err := d.DetectAll()
+	agents, err := d.DetectAll(context.Background(), false)
 	if err != nil {
 		t.Fatalf("DetectAll() error = %v", err)
 	}
@@ -248,7 +251,7 @@ func TestGetParserForAgent(t *testing.T) {
 // TestDetectedAgentsHaveParsers tests that detected agents are assigned parsers
 func TestDetectedAgentsHaveParsers(t *testing.T) {
 	d := NewDetector()
-	agents, err := d.DetectAll()
+	agents, err := d.DetectAll(context.Background(), false)
 	if err != nil {
 		t.Fatalf("DetectAll() error = %v", err)
 	}
@@ -259,3 +262,36 @@ func TestDetectedAgentsHaveParsers(t *testing.T) {
 		}
 	}
 }
+
+// TestDetectConflicts tests that an agent in two PATH directories is reported
+// with both copies in search order
+func TestDetectConflicts(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	for dir, version := range map[string]string{first: "claude 0.9.0", second: "claude 1.2.0"} {
+		script := "#!/bin/sh\necho '" + version + "'\n"
+		if err := os.WriteFile(filepath.Join(dir, "claude"), []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to create mock binary: %v", err)
+		}
+	}
+
+	// The first directory is listed twice; it must not count as a third copy
+	d := NewDetectorWithPath(strings.Join([]string{first, second, first}, string(os.PathListSeparator)))
+	conflicts := d.DetectConflicts()
+	if len(conflicts) != 1 || conflicts[0].Name != "claude" {
+		t.Fatalf("DetectConflicts() = %+v, want one conflict for claude", conflicts)
+	}
+	want := []Candidate{
+		{Path: filepath.Join(first, "claude"), Version: "claude 0.9.0"},
+		{Path: filepath.Join(second, "claude"), Version: "claude 1.2.0"},
+	}
+	if !reflect.DeepEqual(conflicts[0].Candidates, want) {
+		t.Errorf("Candidates = %+v, want %+v", conflicts[0].Candidates, want)
+	}
+	if got := d.GetAgentPath("claude"); got != want[0].Path {
+		t.Errorf("GetAgentPath() = %q, want the first copy %q", got, want[0].Path)
+	}
+
+	if got := NewDetectorWithPath(second).DetectConflicts(); len(got) != 0 {
+		t.Errorf("DetectConflicts() with one copy = %+v, want none", got)
+	}
+}
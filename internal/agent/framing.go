@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PromptFraming is how a prompt is written to an interactive agent's stdin.
+// Agents that read stdin a line at a time take each line of an unframed
+// prompt as a separate turn, so multi-line prompts need framing.
+type PromptFraming string
+
+const (
+	// FramingLines writes the prompt as-is, one stdin line per prompt line.
+	FramingLines PromptFraming = ""
+	// FramingJSON writes the prompt as a single-line JSON string.
+	FramingJSON PromptFraming = "json"
+	// FramingSentinel writes the prompt's lines followed by PromptSentinel
+	// alone on a line.
+	FramingSentinel PromptFraming = "sentinel"
+	// FramingStreamJSON writes the prompt as a stream-json user message on
+	// one line, the input format of claude and amp.
+	FramingStreamJSON PromptFraming = "stream-json"
+)
+
+// PromptSentinel ends a prompt sent with FramingSentinel.
+const PromptSentinel = "<<<END_OF_PROMPT>>>"
+
+// ParsePromptFraming validates a prompt framing name; "" and "lines" both
+// mean FramingLines.
+func ParsePromptFraming(s string) (PromptFraming, error) {
+	switch f := PromptFraming(s); f {
+	case FramingLines, FramingJSON, FramingSentinel, FramingStreamJSON:
+		return f, nil
+	case "lines":
+		return FramingLines, nil
+	}
+	return "", fmt.Errorf("unknown prompt framing %q (want lines, json, sentinel or stream-json)", s)
+}
+
+// FramePrompt returns prompt as it should be written to stdin, ending in a
+// newline.
+func FramePrompt(f PromptFraming, prompt string) string {
+	switch f {
+	case FramingJSON:
+		data, _ := json.Marshal(prompt) // Marshaling a string cannot fail
+		return string(data) + "\n"
+	case FramingSentinel:
+		return strings.TrimSuffix(prompt, "\n") + "\n" + PromptSentinel + "\n"
+	case FramingStreamJSON:
+		data, _ := json.Marshal(userMessage{Type: "user", Message: userMessageBody{Role: "user", Content: prompt}})
+		return string(data) + "\n"
+	}
+	return prompt + "\n"
+}
+
+// userMessage is a stream-json user message, as FramingStreamJSON writes it.
+type userMessage struct {
+	Type    string          `json:"type"`
+	Message userMessageBody `json:"message"`
+}
+
+// userMessageBody is the message inside a userMessage.
+type userMessageBody struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestFramePrompt tests that each framing turns a multi-line prompt into one
+// unit a line-reading agent can reassemble
+func TestFramePrompt(t *testing.T) {
+	prompt := "Prompt: Design API\n\nInstructions:\n- Use `bd create`"
+
+	if got := FramePrompt(FramingLines, prompt); got != prompt+"\n" {
+		t.Errorf("FramePrompt(lines) = %q, want the prompt as-is", got)
+	}
+
+	framed := FramePrompt(FramingJSON, prompt)
+	if strings.Count(framed, "\n") != 1 || !strings.HasSuffix(framed, "\n") {
+		t.Fatalf("FramePrompt(json) = %q, want a single line", framed)
+	}
+	var decoded string
+	if err := json.Unmarshal([]byte(framed), &decoded); err != nil || decoded != prompt {
+		t.Errorf("FramePrompt(json) decodes to %q, %v; want the prompt", decoded, err)
+	}
+
+	want := prompt + "\n" + PromptSentinel + "\n"
+	if got := FramePrompt(FramingSentinel, prompt); got != want {
+		t.Errorf("FramePrompt(sentinel) = %q, want %q", got, want)
+	}
+	if got := FramePrompt(FramingSentinel, prompt+"\n"); got != want {
+		t.Errorf("FramePrompt(sentinel) with a trailing newline = %q, want %q", got, want)
+	}
+	framed = FramePrompt(FramingStreamJSON, prompt)
+	if strings.Count(framed, "\n") != 1 || !strings.HasSuffix(framed, "\n") {
+		t.Fatalf("FramePrompt(stream-json) = %q, want a single line", framed)
+	}
+	var msg userMessage
+	if err := json.Unmarshal([]byte(framed), &msg); err != nil || msg.Type != "user" || msg.Message.Role != "user" || msg.Message.Content != prompt {
+		t.Errorf("FramePrompt(stream-json) decodes to %+v, %v; want a user message with the prompt", msg, err)
+	}
+}
+
+// TestParsePromptFraming tests framing names from config
+func TestParsePromptFraming(t *testing.T) {
+	tests := map[string]PromptFraming{
+		"":            FramingLines,
+		"lines":       FramingLines,
+		"json":        FramingJSON,
+		"sentinel":    FramingSentinel,
+		"stream-json": FramingStreamJSON,
+	}
+	for in, want := range tests {
+		if got, err := ParsePromptFraming(in); err != nil || got != want {
+			t.Errorf("ParsePromptFraming(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePromptFraming("heredoc"); err == nil {
+		t.Error("ParsePromptFraming(heredoc) should fail")
+	}
+}
@@ -57,3 +57,28 @@ func (p *GeminiParser) extractFromLine(line string) string {
 
 	return ""
 }
+
+// ParseUsage reads the stats reported on result events, with the model
+// named by the init event.
+func (p *GeminiParser) ParseUsage(output string) TokenUsage {
+	total := TokenUsage{Model: firstStringField(output, "init", "model")}
+	forEachJSONEvent(output, func(event map[string]interface{}) {
+		if eventType, _ := event["type"].(string); eventType != "result" {
+			return
+		}
+		stats, ok := event["stats"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		total = total.Add(TokenUsage{
+			InputTokens:  intField(stats, "input_tokens"),
+			OutputTokens: intField(stats, "output_tokens"),
+		})
+	})
+	return total
+}
+
+// ParseSessionID returns the session_id from the init event.
+func (p *GeminiParser) ParseSessionID(output string) string {
+	return firstStringField(output, "init", "session_id")
+}
@@ -120,3 +120,46 @@ func TestGeminiParserHandlesNonDeltaAssistant(t *testing.T) {
 		t.Errorf("Parse() did not extract non-delta assistant message, got: %s", result)
 	}
 }
+
+// TestGeminiParserParsesUsage tests token usage extraction from result stats
+func TestGeminiParserParsesUsage(t *testing.T) {
+	parser := &GeminiParser{}
+
+	input := `{"type":"init","session_id":"s1","model":"gemini-2.5-pro"}
+{"type":"message","role":"assistant","content":"Hi","delta":true}
+{"type":"result","status":"success","stats":{"total_tokens":150,"input_tokens":120,"output_tokens":30}}`
+
+	usage := ParseUsage(parser, input)
+
+	if usage.InputTokens != 120 || usage.OutputTokens != 30 || usage.Model != "gemini-2.5-pro" {
+		t.Errorf("ParseUsage() = %+v, want 120 in / 30 out from gemini-2.5-pro", usage)
+	}
+	if usage.Total() != 150 {
+		t.Errorf("Total() = %d, want 150", usage.Total())
+	}
+}
+
+// TestParseUsage_UnsupportedParser tests that parsers without usage support return zero
+func TestParseUsage_UnsupportedParser(t *testing.T) {
+	usage := ParseUsage(&NoopParser{}, `{"type":"result","usage":{"input_tokens":10}}`)
+	if usage != (TokenUsage{}) {
+		t.Errorf("ParseUsage() = %+v, want zero usage", usage)
+	}
+}
+
+// TestGeminiParserParsesSessionID tests session ID extraction from the init event
+func TestGeminiParserParsesSessionID(t *testing.T) {
+	input := `{"type":"init","session_id":"gem-123","model":"gemini-2.5-pro"}
+{"type":"message","role":"assistant","content":"Hi"}`
+
+	if got := ParseSessionID(&GeminiParser{}, input); got != "gem-123" {
+		t.Errorf("ParseSessionID() = %q, want %q", got, "gem-123")
+	}
+}
+
+// TestParseSessionID_UnsupportedParser tests that parsers without session support return ""
+func TestParseSessionID_UnsupportedParser(t *testing.T) {
+	if got := ParseSessionID(&NoopParser{}, `{"type":"init","session_id":"x"}`); got != "" {
+		t.Errorf("ParseSessionID() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,17 @@
+package agent
+
+// GroupAll is the agent group, selected with --agents @all, that holds
+// every detected agent.
+const GroupAll = "all"
+
+// BuiltinGroups returns the named agent groups available without config:
+// each provider family, and fast for the agents with the cheapest default
+// models. GroupAll is not listed since it depends on which agents are found.
+func BuiltinGroups() map[string][]string {
+	return map[string][]string{
+		"anthropic": {"claude", "amp"},
+		"openai":    {"codex"},
+		"google":    {"gemini"},
+		"fast":      {"codex", "gemini"},
+	}
+}
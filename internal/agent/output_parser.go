@@ -14,3 +14,48 @@ type NoopParser struct{}
 func (p *NoopParser) Parse(output string) string {
 	return output
 }
+
+// rawParser returns output unchanged but still reports usage and session
+// IDs through the parser it wraps.
+type rawParser struct {
+	inner OutputParser
+}
+
+// NewRawParser wraps a parser so agent output is passed through verbatim.
+func NewRawParser(inner OutputParser) OutputParser {
+	return &rawParser{inner: inner}
+}
+
+// Parse returns the input unchanged.
+func (p *rawParser) Parse(output string) string {
+	return output
+}
+
+// IsRaw reports whether p passes output through verbatim.
+func IsRaw(p OutputParser) bool {
+	switch p.(type) {
+	case *rawParser, *NoopParser:
+		return true
+	}
+	return false
+}
+
+// ParseUsage delegates to the wrapped parser.
+func (p *rawParser) ParseUsage(output string) TokenUsage {
+	return ParseUsage(p.inner, output)
+}
+
+// ParseSessionID delegates to the wrapped parser.
+func (p *rawParser) ParseSessionID(output string) string {
+	return ParseSessionID(p.inner, output)
+}
+
+// ParseError delegates to the wrapped parser.
+func (p *rawParser) ParseError(output string) error {
+	return ParseError(p.inner, output)
+}
+
+// IsTurnComplete delegates to the wrapped parser.
+func (p *rawParser) IsTurnComplete(line string) bool {
+	return IsTurnComplete(p.inner, line)
+}
@@ -74,3 +74,12 @@ func TestAgentHasParserField(t *testing.T) {
 		t.Errorf("Agent.Parser.Parse('test') = %q, want 'test'", result)
 	}
 }
+
+func TestIsRaw(t *testing.T) {
+	if !IsRaw(NewRawParser(&StreamJSONParser{})) || !IsRaw(&NoopParser{}) {
+		t.Error("IsRaw() should be true for raw and noop parsers")
+	}
+	if IsRaw(&StreamJSONParser{}) {
+		t.Error("IsRaw() should be false for a parser that extracts text")
+	}
+}
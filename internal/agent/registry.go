@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"sync"
+)
+
+// registeredAgent is a custom agent added at runtime.
+type registeredAgent struct {
+	pattern CLIPattern
+	parser  OutputParser
+}
+
+// Registry holds the custom agents added for one run on top of the
+// built-ins. A nil Registry has only the built-ins.
+type Registry struct {
+	mu     sync.RWMutex
+	agents map[string]registeredAgent
+}
+
+// NewRegistry creates a registry with no custom agents.
+func NewRegistry() *Registry {
+	return &Registry{agents: map[string]registeredAgent{}}
+}
+
+// Register adds a custom agent (or overrides a built-in one) so that
+// KnownAgents, Parser, and a detector using r include it.
+// A nil parser means output is passed through unchanged.
+func (r *Registry) Register(name string, pattern CLIPattern, parser OutputParser) {
+	if pattern.Binary == "" {
+		pattern.Binary = name
+	}
+	if parser == nil {
+		parser = &NoopParser{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.agents[name] = registeredAgent{pattern: pattern, parser: parser}
+}
+
+// KnownAgents returns the CLI patterns of the built-in and registered agents.
+func (r *Registry) KnownAgents() map[string]CLIPattern {
+	patterns := KnownAgents()
+	if r == nil {
+		return patterns
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, ra := range r.agents {
+		patterns[name] = ra.pattern
+	}
+	return patterns
+}
+
+// Parser returns the output parser for the named agent, registered or
+// built-in.
+func (r *Registry) Parser(name string) OutputParser {
+	if r != nil {
+		r.mu.RLock()
+		ra, ok := r.agents[name]
+		r.mu.RUnlock()
+		if ok {
+			return ra.parser
+		}
+	}
+	return GetParserForAgent(name)
+}
+
+// ParserForType returns a parser by output format name, for custom agents.
+// An empty type means plain text.
+func ParserForType(typ string) (OutputParser, error) {
+	switch typ {
+	case "", "text", "noop":
+		return &NoopParser{}, nil
+	case "stream-json", "claude":
+		return &ClaudeParser{}, nil
+	case "codex":
+		return &CodexParser{}, nil
+	case "cursor":
+		return &CursorParser{}, nil
+	case "auggie":
+		return &AuggieParser{}, nil
+	case "gemini":
+		return &GeminiParser{}, nil
+	case "amp":
+		return &AmpParser{}, nil
+	default:
+		return nil, fmt.Errorf("unknown parser type %q", typ)
+	}
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRegistry_Detected tests that a registered agent is known and detected
+func TestRegistry_Detected(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := "#!/bin/sh\necho 'local-llm 0.1.0'\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "llm-cli"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	reg := NewRegistry()
+	reg.Register("local-llm", CLIPattern{
+		Binary:             "llm-cli",
+		VersionArgs:        []string{"--version"},
+		NonInteractiveArgs: []string{"run"},
+	}, &CodexParser{})
+
+	if _, ok := reg.KnownAgents()["local-llm"]; !ok {
+		t.Fatal("KnownAgents() should include the registered agent")
+	}
+	if _, ok := reg.Parser("local-llm").(*CodexParser); !ok {
+		t.Errorf("Parser() = %T, want *CodexParser", reg.Parser("local-llm"))
+	}
+
+	detector := NewDetectorWithPath(tmpDir)
+	detector.SetRegistry(reg)
+	agents, err := detector.DetectAll(context.Background(), false)
+	if err != nil {
+		t.Fatalf("DetectAll() error = %v", err)
+	}
+	if len(agents) != 1 {
+		t.Fatalf("DetectAll() found %d agents, want 1", len(agents))
+	}
+
+	got := agents[0]
+	if got.Name != "local-llm" || got.Path != filepath.Join(tmpDir, "llm-cli") {
+		t.Errorf("detected %q at %q", got.Name, got.Path)
+	}
+	if got.Version != "local-llm 0.1.0" {
+		t.Errorf("Version = %q, want %q", got.Version, "local-llm 0.1.0")
+	}
+	if !got.Authenticated {
+		t.Error("Authenticated = false, want true (version check succeeds)")
+	}
+}
+
+// TestRegistry_InvalidPatternNotDetected tests that detection skips an
+// installed agent whose pattern fails validation
+func TestRegistry_InvalidPatternNotDetected(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "llm-cli"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	reg := NewRegistry()
+	reg.Register("local-llm", CLIPattern{Binary: "llm-cli"}, nil) // no version_args
+
+	detector := NewDetectorWithPath(tmpDir)
+	detector.SetRegistry(reg)
+	agents, err := detector.DetectAll(context.Background(), false)
+	if err != nil {
+		t.Fatalf("DetectAll() error = %v", err)
+	}
+	if len(agents) != 0 {
+		t.Errorf("DetectAll() = %v, want the invalid agent skipped", agents)
+	}
+}
+
+// TestRegistry_Isolated tests registration defaults, and that an agent
+// registered in one registry is unknown to others
+func TestRegistry_Isolated(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("temp-agent", CLIPattern{}, nil)
+	if p := reg.KnownAgents()["temp-agent"]; p.Binary != "temp-agent" {
+		t.Errorf("Binary = %q, want it to default to the agent name", p.Binary)
+	}
+	if _, ok := reg.Parser("temp-agent").(*NoopParser); !ok {
+		t.Error("a nil parser should default to NoopParser")
+	}
+
+	var none *Registry
+	for _, other := range []*Registry{NewRegistry(), none} {
+		if _, ok := other.KnownAgents()["temp-agent"]; ok {
+			t.Error("KnownAgents() should not include an agent registered elsewhere")
+		}
+		if _, ok := other.KnownAgents()["claude"]; !ok {
+			t.Error("KnownAgents() should include the built-ins")
+		}
+	}
+	if _, ok := KnownAgents()["temp-agent"]; ok {
+		t.Error("the package KnownAgents() should only list built-ins")
+	}
+}
+
+// TestParserForType tests parser lookup by format name
+func TestParserForType(t *testing.T) {
+	for _, typ := range []string{"", "text", "stream-json", "codex", "gemini", "auggie", "amp", "cursor"} {
+		if p, err := ParserForType(typ); err != nil || p == nil {
+			t.Errorf("ParserForType(%q) = %v, %v", typ, p, err)
+		}
+	}
+	if _, err := ParserForType("xml"); err == nil {
+		t.Error("ParserForType(\"xml\") should return an error")
+	}
+}
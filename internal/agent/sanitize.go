@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ansiRegex matches ANSI escape sequences: CSI (colors, cursor movement),
+// OSC (window titles, hyperlinks), and two-byte escapes.
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// SanitizeOutput strips ANSI escape sequences and other control characters
+// from agent output, and turns \r\n and lone \r line endings into \n. Tabs
+// and newlines are kept.
+func SanitizeOutput(output string) string {
+	output = ansiRegex.ReplaceAllString(output, "")
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	output = strings.ReplaceAll(output, "\r", "\n")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, output)
+}
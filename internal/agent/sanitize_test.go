@@ -0,0 +1,27 @@
+package agent
+
+import "testing"
+
+// TestSanitizeOutput tests stripping escape sequences and normalizing line endings
+func TestSanitizeOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "Plan:\n\t- cache", "Plan:\n\t- cache"},
+		{"colors", "\x1b[1;31mError\x1b[0m: none", "Error: none"},
+		{"cursor movement", "\x1b[2K\x1b[1Aline", "line"},
+		{"hyperlink", "\x1b]8;;https://example.com\x07link\x1b]8;;\x1b\\", "link"},
+		{"crlf", "one\r\ntwo\r\n", "one\ntwo\n"},
+		{"lone cr", "50%\r100%", "50%\n100%"},
+		{"other controls", "bell\x07 back\bspace", "bell backspace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeOutput(tt.in); got != tt.want {
+				t.Errorf("SanitizeOutput(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
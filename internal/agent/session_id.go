@@ -0,0 +1,33 @@
+package agent
+
+// SessionIDParser is implemented by parsers that can extract the agent's
+// session (or thread) ID from raw output, for resuming it later.
+type SessionIDParser interface {
+	// ParseSessionID returns the first session ID reported in the raw output.
+	ParseSessionID(output string) string
+}
+
+// ParseSessionID extracts the session ID from raw output using the parser if
+// it supports it. Returns "" otherwise.
+func ParseSessionID(parser OutputParser, output string) string {
+	if sp, ok := parser.(SessionIDParser); ok {
+		return sp.ParseSessionID(output)
+	}
+	return ""
+}
+
+// firstStringField returns the first non-empty string value of key on events
+// of the given type ("" matches any type).
+func firstStringField(output, eventType, key string) string {
+	var found string
+	forEachJSONEvent(output, func(event map[string]interface{}) {
+		if found != "" {
+			return
+		}
+		if t, _ := event["type"].(string); eventType != "" && t != eventType {
+			return
+		}
+		found, _ = event[key].(string)
+	})
+	return found
+}
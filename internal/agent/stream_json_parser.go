@@ -7,9 +7,22 @@ import (
 
 // StreamJSONParser parses Claude Code-compatible stream-json output format.
 // Used by: Claude, Cursor-agent, Amp (all use compatible formats).
-type StreamJSONParser struct{}
+type StreamJSONParser struct {
+	// IncludeToolResults keeps a one-line summary of each tool result, e.g.
+	// "[read file.go: 42 lines]", instead of dropping it.
+	IncludeToolResults bool
+}
+
+// WithToolResults returns a copy of p with IncludeToolResults set.
+func (p *StreamJSONParser) WithToolResults() OutputParser {
+	c := *p
+	c.IncludeToolResults = true
+	return &c
+}
 
 // Parse transforms stream-json output into readable text.
+// A result event that repeats the assistant text already collected (exactly
+// or as its ending) is not appended again.
 func (p *StreamJSONParser) Parse(output string) string {
 	if output == "" || strings.TrimSpace(output) == "" {
 		return output
@@ -17,6 +30,7 @@ func (p *StreamJSONParser) Parse(output string) string {
 
 	var result strings.Builder
 	lines := strings.Split(output, "\n")
+	tools := make(map[string]toolUse) // Tool calls seen so far, by ID
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -24,7 +38,10 @@ func (p *StreamJSONParser) Parse(output string) string {
 			continue
 		}
 
-		extracted := p.extractFromLine(line)
+		extracted, isResult := p.extractFromLine(line, tools)
+		if isResult && isDuplicateResult(result.String(), extracted) {
+			continue
+		}
 		if extracted != "" {
 			if result.Len() > 0 {
 				result.WriteString("\n")
@@ -40,53 +57,96 @@ func (p *StreamJSONParser) Parse(output string) string {
 	return result.String()
 }
 
-// extractFromLine extracts readable content from a single JSON line.
-func (p *StreamJSONParser) extractFromLine(line string) string {
+// extractFromLine extracts readable content from a single JSON line and
+// reports whether it came from a result event.
+func (p *StreamJSONParser) extractFromLine(line string, tools map[string]toolUse) (string, bool) {
 	var event map[string]interface{}
 	if err := json.Unmarshal([]byte(line), &event); err != nil {
-		return ""
+		return "", false
 	}
 
 	eventType, _ := event["type"].(string)
 
 	switch eventType {
 	case "assistant":
-		return p.extractFromAssistant(event)
+		return p.extractFromAssistant(event, tools), false
+	case "user":
+		return p.extractFromUser(event, tools), false
 	case "result":
-		return p.extractFromResult(event)
+		return p.extractFromResult(event), true
 	}
 
-	return ""
+	return "", false
+}
+
+// isDuplicateResult reports whether result text is already at the end of
+// the collected assistant text.
+func isDuplicateResult(collected, result string) bool {
+	result = strings.TrimSpace(result)
+	if result == "" {
+		return false
+	}
+	return strings.HasSuffix(strings.TrimSpace(collected), result)
 }
 
 // extractFromAssistant extracts content from an assistant message event.
-func (p *StreamJSONParser) extractFromAssistant(event map[string]interface{}) string {
-	message, ok := event["message"].(map[string]interface{})
-	if !ok {
+// Tool calls are remembered so their results can be summarized by name.
+func (p *StreamJSONParser) extractFromAssistant(event map[string]interface{}, tools map[string]toolUse) string {
+	var parts []string
+	for _, contentBlock := range messageContent(event) {
+		switch blockType, _ := contentBlock["type"].(string); blockType {
+		case "text":
+			if text, ok := contentBlock["text"].(string); ok && text != "" {
+				parts = append(parts, text)
+			}
+		case "tool_use":
+			if p.IncludeToolResults {
+				id, _ := contentBlock["id"].(string)
+				tools[id] = newToolUse(contentBlock)
+			}
+		case "tool_result":
+			if p.IncludeToolResults {
+				parts = append(parts, summarizeToolResult(contentBlock, tools))
+			}
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// extractFromUser summarizes the tool results carried by a user event; the
+// prompt text itself is never repeated.
+func (p *StreamJSONParser) extractFromUser(event map[string]interface{}, tools map[string]toolUse) string {
+	if !p.IncludeToolResults {
 		return ""
 	}
+	var parts []string
+	for _, contentBlock := range messageContent(event) {
+		if blockType, _ := contentBlock["type"].(string); blockType == "tool_result" {
+			parts = append(parts, summarizeToolResult(contentBlock, tools))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
 
+// messageContent returns the content blocks of an event's message.
+func messageContent(event map[string]interface{}) []map[string]interface{} {
+	message, ok := event["message"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
 	content, ok := message["content"].([]interface{})
 	if !ok {
-		return ""
+		return nil
 	}
 
-	var parts []string
+	blocks := make([]map[string]interface{}, 0, len(content))
 	for _, c := range content {
-		contentBlock, ok := c.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		blockType, _ := contentBlock["type"].(string)
-		if blockType == "text" {
-			if text, ok := contentBlock["text"].(string); ok && text != "" {
-				parts = append(parts, text)
-			}
+		if contentBlock, ok := c.(map[string]interface{}); ok {
+			blocks = append(blocks, contentBlock)
 		}
 	}
-
-	return strings.Join(parts, "\n")
+	return blocks
 }
 
 // extractFromResult extracts content from a result event.
@@ -120,3 +180,52 @@ type CursorParser struct {
 type AmpParser struct {
 	StreamJSONParser
 }
+
+// WithToolResults returns a copy of p with IncludeToolResults set.
+func (p *ClaudeParser) WithToolResults() OutputParser {
+	c := *p
+	c.IncludeToolResults = true
+	return &c
+}
+
+// WithToolResults returns a copy of p with IncludeToolResults set.
+func (p *CursorParser) WithToolResults() OutputParser {
+	c := *p
+	c.IncludeToolResults = true
+	return &c
+}
+
+// WithToolResults returns a copy of p with IncludeToolResults set.
+func (p *AmpParser) WithToolResults() OutputParser {
+	c := *p
+	c.IncludeToolResults = true
+	return &c
+}
+
+// ParseUsage sums the usage reported on result events, with the model
+// named by the init event. Cache creation and cache read tokens are counted
+// as input.
+func (p *StreamJSONParser) ParseUsage(output string) TokenUsage {
+	total := TokenUsage{Model: firstStringField(output, "system", "model")}
+	forEachJSONEvent(output, func(event map[string]interface{}) {
+		if eventType, _ := event["type"].(string); eventType != "result" {
+			return
+		}
+		usage, ok := event["usage"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		total = total.Add(TokenUsage{
+			InputTokens: intField(usage, "input_tokens") +
+				intField(usage, "cache_creation_input_tokens") +
+				intField(usage, "cache_read_input_tokens"),
+			OutputTokens: intField(usage, "output_tokens"),
+		})
+	})
+	return total
+}
+
+// ParseSessionID returns the session_id carried by the init event.
+func (p *StreamJSONParser) ParseSessionID(output string) string {
+	return firstStringField(output, "", "session_id")
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ToolResultsParser is implemented by parsers that can summarize the results
+// of the tools an agent ran instead of dropping them.
+type ToolResultsParser interface {
+	// WithToolResults returns a copy of the parser that summarizes tool
+	// results.
+	WithToolResults() OutputParser
+}
+
+// ShowToolResults returns a copy of parser that summarizes tool results, or
+// parser itself if it can't. Parsers may be shared, so parser is unchanged.
+func ShowToolResults(parser OutputParser) OutputParser {
+	if tp, ok := parser.(ToolResultsParser); ok {
+		return tp.WithToolResults()
+	}
+	return parser
+}
+
+// toolUse is what a summary needs to know about a tool call.
+type toolUse struct {
+	name   string // Tool name, e.g. "Read"
+	target string // What it acted on, e.g. "file.go"; "" if unknown
+}
+
+// toolTargetKeys are the tool_use input fields that name what a tool acted
+// on, most specific first.
+var toolTargetKeys = []string{"file_path", "notebook_path", "path", "pattern", "url", "command"}
+
+// maxTargetLength bounds a target taken from a command or pattern, and an
+// error message.
+const maxTargetLength = 40
+
+// newToolUse reads a tool_use content block.
+func newToolUse(block map[string]interface{}) toolUse {
+	use := toolUse{}
+	use.name, _ = block["name"].(string)
+	input, _ := block["input"].(map[string]interface{})
+	for _, key := range toolTargetKeys {
+		value, _ := input[key].(string)
+		if value == "" {
+			continue
+		}
+		if key == "file_path" || key == "notebook_path" || key == "path" {
+			use.target = filepath.Base(value)
+		} else {
+			use.target = truncateTarget(firstLine(value))
+		}
+		return use
+	}
+	return use
+}
+
+// label names the call in a summary, e.g. "read file.go".
+func (u toolUse) label() string {
+	name := strings.ToLower(u.name)
+	if name == "" {
+		name = "tool"
+	}
+	if u.target == "" {
+		return name
+	}
+	return name + " " + u.target
+}
+
+// summarizeToolResult describes a tool_result block in one line, e.g.
+// "[read file.go: 42 lines]", naming the call from its tool_use if seen.
+func summarizeToolResult(block map[string]interface{}, tools map[string]toolUse) string {
+	label := "tool result"
+	if id, _ := block["tool_use_id"].(string); id != "" {
+		if use, ok := tools[id]; ok {
+			label = use.label()
+		}
+	}
+
+	if isError, _ := block["is_error"].(bool); isError {
+		if msg := firstLine(toolResultText(block["content"])); msg != "" {
+			return fmt.Sprintf("[%s: failed: %s]", label, truncateTarget(msg))
+		}
+		return fmt.Sprintf("[%s: failed]", label)
+	}
+
+	switch n := countLines(toolResultText(block["content"])); n {
+	case 0:
+		return fmt.Sprintf("[%s: no output]", label)
+	case 1:
+		return fmt.Sprintf("[%s: 1 line]", label)
+	default:
+		return fmt.Sprintf("[%s: %d lines]", label, n)
+	}
+}
+
+// toolResultText returns a tool result's content, which is either a string
+// or a list of text blocks.
+func toolResultText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var parts []string
+		for _, item := range c {
+			if block, ok := item.(map[string]interface{}); ok {
+				if text, ok := block["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// countLines counts the lines in text, ignoring a trailing newline.
+func countLines(text string) int {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return 0
+	}
+	return strings.Count(text, "\n") + 1
+}
+
+// firstLine returns the first non-blank line of text, trimmed.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncateTarget shortens s to maxTargetLength runes.
+func truncateTarget(s string) string {
+	if r := []rune(s); len(r) > maxTargetLength {
+		return string(r[:maxTargetLength-1]) + "…"
+	}
+	return s
+}
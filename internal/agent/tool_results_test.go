@@ -0,0 +1,75 @@
+package agent
+
+import "testing"
+
+// toolSequence is a Claude stream-json turn that reads a file, runs a failing
+// command and lists beads, with results in both string and block form.
+const toolSequence = `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me look."},{"type":"tool_use","id":"toolu_1","name":"Read","input":{"file_path":"/repo/internal/file.go"}}]}}
+{"type":"user","message":{"role":"user","content":[{"tool_use_id":"toolu_1","type":"tool_result","content":"package main\n\nfunc main() {}\n"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_2","name":"Bash","input":{"command":"go test ./...","description":"Run tests"}}]}}
+{"type":"user","message":{"role":"user","content":[{"tool_use_id":"toolu_2","type":"tool_result","is_error":true,"content":"\nexit status 1\nFAIL"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_3","name":"Bash","input":{"command":"bd list"}}]}}
+{"type":"user","message":{"role":"user","content":[{"tool_use_id":"toolu_3","type":"tool_result","content":[{"type":"text","text":"bd-1 open\nbd-2 open"}]}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Two beads are open."}]}}
+{"type":"result","subtype":"success","result":"Two beads are open."}`
+
+// TestStreamJSONParser_ToolResults tests that tool results are summarized
+// only when IncludeToolResults is set
+func TestStreamJSONParser_ToolResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser OutputParser
+		want   string
+	}{
+		{"dropped by default", &ClaudeParser{}, "Let me look.\nTwo beads are open."},
+		{"summarized", &ClaudeParser{StreamJSONParser{IncludeToolResults: true}},
+			"Let me look.\n" +
+				"[read file.go: 3 lines]\n" +
+				"[bash go test ./...: failed: exit status 1]\n" +
+				"[bash bd list: 2 lines]\n" +
+				"Two beads are open."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.parser.Parse(toolSequence); got != tt.want {
+				t.Errorf("Parse() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStreamJSONParser_ToolResultWithoutUse tests a result whose tool call
+// isn't in the output, as when a streamed line is parsed on its own
+func TestStreamJSONParser_ToolResultWithoutUse(t *testing.T) {
+	parser := &AmpParser{StreamJSONParser{IncludeToolResults: true}}
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"type":"user","message":{"content":[{"tool_use_id":"toolu_9","type":"tool_result","content":"one"}]}}`, "[tool result: 1 line]"},
+		{`{"type":"user","message":{"content":[{"tool_use_id":"toolu_9","type":"tool_result","content":""}]}}`, "[tool result: no output]"},
+		{`{"type":"user","message":{"content":[{"type":"text","text":"Design API"}]}}`, `{"type":"user","message":{"content":[{"type":"text","text":"Design API"}]}}`},
+	}
+	for _, tt := range tests {
+		if got := parser.Parse(tt.line); got != tt.want {
+			t.Errorf("Parse(%s) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestShowToolResults tests that stream-json parsers are copied with tool
+// results on, leaving the original alone, and others are returned as is
+func TestShowToolResults(t *testing.T) {
+	claude := &ClaudeParser{}
+	shown, ok := ShowToolResults(claude).(*ClaudeParser)
+	if !ok || !shown.IncludeToolResults {
+		t.Errorf("ShowToolResults() = %#v, want a ClaudeParser with tool results on", shown)
+	}
+	if claude.IncludeToolResults {
+		t.Error("ShowToolResults() changed the parser it was given")
+	}
+	noop := &NoopParser{}
+	if got := ShowToolResults(noop); got != noop {
+		t.Errorf("ShowToolResults(noop) = %#v, want it unchanged", got)
+	}
+}
@@ -0,0 +1,33 @@
+package agent
+
+// ErrorParser is implemented by parsers that can tell when the agent itself
+// reported a failure in its output.
+type ErrorParser interface {
+	// ParseError returns the error the agent reported, or nil.
+	ParseError(output string) error
+}
+
+// ParseError extracts an agent-reported error from raw output using the parser
+// if it supports it. Returns nil otherwise.
+func ParseError(parser OutputParser, output string) error {
+	if ep, ok := parser.(ErrorParser); ok {
+		return ep.ParseError(output)
+	}
+	return nil
+}
+
+// TurnCompleteParser is implemented by parsers that recognize the event an
+// agent emits when it finishes a turn.
+type TurnCompleteParser interface {
+	// IsTurnComplete reports whether a single output line ends the turn.
+	IsTurnComplete(line string) bool
+}
+
+// IsTurnComplete reports whether line ends the agent's turn, using the parser
+// if it supports it. Returns false otherwise.
+func IsTurnComplete(parser OutputParser, line string) bool {
+	if tp, ok := parser.(TurnCompleteParser); ok {
+		return tp.IsTurnComplete(line)
+	}
+	return false
+}
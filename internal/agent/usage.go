@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TokenUsage reports the tokens consumed by a single agent response.
+type TokenUsage struct {
+	InputTokens  int    // Prompt tokens, including cached input where reported
+	OutputTokens int    // Completion tokens
+	Model        string // Model that produced the response, if reported
+}
+
+// Total returns the combined input and output token count.
+func (u TokenUsage) Total() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Add returns the sum of two usages, keeping other's model if it reports one.
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	model := u.Model
+	if other.Model != "" {
+		model = other.Model
+	}
+	return TokenUsage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		Model:        model,
+	}
+}
+
+// UsageParser is implemented by parsers that can extract token usage
+// from raw agent output.
+type UsageParser interface {
+	// ParseUsage returns the token usage reported in the raw output.
+	ParseUsage(output string) TokenUsage
+}
+
+// ParseUsage extracts token usage from raw output using the parser if it
+// supports usage reporting. Returns zero usage otherwise.
+func ParseUsage(parser OutputParser, output string) TokenUsage {
+	if up, ok := parser.(UsageParser); ok {
+		return up.ParseUsage(output)
+	}
+	return TokenUsage{}
+}
+
+// forEachJSONEvent calls fn for each JSON object line in output.
+func forEachJSONEvent(output string, fn func(event map[string]interface{})) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+		fn(event)
+	}
+}
+
+// intField reads a numeric JSON field as an int.
+func intField(m map[string]interface{}, key string) int {
+	if v, ok := m[key].(float64); ok {
+		return int(v)
+	}
+	return 0
+}
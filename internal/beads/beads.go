@@ -0,0 +1,439 @@
+// Package beads provides a typed client for the bd issue tracker CLI.
+package beads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Executor runs shell commands.
+type Executor interface {
+	Execute(ctx context.Context, name string, args ...string) (string, error)
+}
+
+// Client creates and modifies beads via the bd CLI.
+type Client interface {
+	// Create creates a bead and returns its ID.
+	Create(ctx context.Context, opts CreateOpts) (string, error)
+
+	// Update modifies an existing bead. Empty fields are left unchanged.
+	Update(ctx context.Context, id string, opts UpdateOpts) error
+
+	// List returns bd list output, narrowed by opts.
+	List(ctx context.Context, opts ListOpts) (string, error)
+
+	// ListIssues returns the beads matching opts, as reported by bd list --json.
+	ListIssues(ctx context.Context, opts ListOpts) ([]Issue, error)
+
+	// Show returns bd show output for a bead.
+	Show(ctx context.Context, id string) (string, error)
+
+	// Comment adds a comment to a bead, attributed to author.
+	Comment(ctx context.Context, id, author, text string) error
+
+	// Available reports whether bd is installed at all.
+	Available(ctx context.Context) bool
+}
+
+// Issue is a bead as reported by bd list --json. Description, labels and
+// dependencies are only read from beads files (see LoadFile).
+type Issue struct {
+	ID           string       `json:"id"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description,omitempty"`
+	Status       string       `json:"status"`
+	Priority     int          `json:"priority"`
+	IssueType    string       `json:"issue_type"`
+	Labels       []string     `json:"labels,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// Dependency links a bead to one it depends on.
+type Dependency struct {
+	IssueID     string `json:"issue_id"`
+	DependsOnID string `json:"depends_on_id"`
+	Type        string `json:"type"` // blocks, related, parent-child, or discovered-from
+}
+
+// CreateOpts describes a new bead.
+type CreateOpts struct {
+	Title       string   // Required, single line
+	Type        string   // bug, feature, task, epic, or chore (default: bd's default)
+	Priority    string   // 0-4 or P0-P4 (default: bd's default)
+	Description string   // May span multiple lines
+	Deps        []string // IDs this bead depends on
+}
+
+// UpdateOpts describes changes to an existing bead.
+type UpdateOpts struct {
+	Title       string
+	Status      string // open, in_progress, blocked, or closed
+	Priority    string // 0-4 or P0-P4
+	Description string
+	Notes       string
+}
+
+// ListOpts narrows bd list. Empty fields don't filter.
+type ListOpts struct {
+	Status   string   // open, in_progress, blocked, or closed
+	Priority string   // 0-4 or P0-P4
+	Labels   []string // Beads must carry every label
+}
+
+// Option configures a Client.
+type Option func(*client)
+
+// WithExecutor sets a custom executor for running bd commands.
+func WithExecutor(exec Executor) Option {
+	return func(c *client) {
+		c.executor = exec
+	}
+}
+
+// client is the default implementation.
+type client struct {
+	executor Executor
+}
+
+// NewClient creates a new Client.
+func NewClient(opts ...Option) Client {
+	c := &client{
+		executor: &defaultExecutor{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+var validTypes = map[string]bool{"bug": true, "feature": true, "task": true, "epic": true, "chore": true}
+
+var validStatuses = map[string]bool{"open": true, "in_progress": true, "blocked": true, "closed": true}
+
+// Create creates a bead and returns the ID reported by bd.
+func (c *client) Create(ctx context.Context, opts CreateOpts) (string, error) {
+	args, err := CreateArgs(opts)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := c.executor.Execute(ctx, "bd", args...)
+	if err != nil {
+		return "", fmt.Errorf("bd create failed: %w: %s", err, strings.TrimSpace(output))
+	}
+
+	return ParseCreatedID(output)
+}
+
+// Update modifies an existing bead.
+func (c *client) Update(ctx context.Context, id string, opts UpdateOpts) error {
+	args, err := UpdateArgs(id, opts)
+	if err != nil {
+		return err
+	}
+
+	output, err := c.executor.Execute(ctx, "bd", args...)
+	if err != nil {
+		return fmt.Errorf("bd update %s failed: %w: %s", id, err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// List runs bd list with the given filters.
+func (c *client) List(ctx context.Context, opts ListOpts) (string, error) {
+	args, err := ListArgs(opts)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := c.executor.Execute(ctx, "bd", args...)
+	if err != nil {
+		return "", fmt.Errorf("bd list failed: %w: %s", err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// ListIssues runs bd list --json with the given filters and decodes the result.
+func (c *client) ListIssues(ctx context.Context, opts ListOpts) ([]Issue, error) {
+	args, err := ListArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := c.executor.Execute(ctx, "bd", append(args, "--json")...)
+	if err != nil {
+		return nil, fmt.Errorf("bd list failed: %w: %s", err, strings.TrimSpace(output))
+	}
+
+	var issues []Issue
+	if err := json.Unmarshal([]byte(output), &issues); err != nil {
+		return nil, fmt.Errorf("failed to parse bd list output: %w", err)
+	}
+	return issues, nil
+}
+
+// Show runs bd show for a single bead.
+func (c *client) Show(ctx context.Context, id string) (string, error) {
+	if err := validateID(id); err != nil {
+		return "", err
+	}
+
+	output, err := c.executor.Execute(ctx, "bd", "show", id)
+	if err != nil {
+		return "", fmt.Errorf("bd show %s failed: %w: %s", id, err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// Comment runs bd comment for a single bead.
+func (c *client) Comment(ctx context.Context, id, author, text string) error {
+	args, err := CommentArgs(id, author, text)
+	if err != nil {
+		return err
+	}
+
+	output, err := c.executor.Execute(ctx, "bd", args...)
+	if err != nil {
+		return fmt.Errorf("bd comment %s failed: %w: %s", id, err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// Available runs bd version. Only a missing bd binary counts as unavailable;
+// bd failing for other reasons (e.g. no .beads directory yet) does not.
+func (c *client) Available(ctx context.Context) bool {
+	_, err := c.executor.Execute(ctx, "bd", "version")
+	return !errors.Is(err, exec.ErrNotFound)
+}
+
+// CreateArgs validates opts and builds the bd create arguments.
+// Values are passed as --flag=value so text starting with "-" is never
+// mistaken for a flag; no shell is involved, so quotes and newlines are safe.
+func CreateArgs(opts CreateOpts) ([]string, error) {
+	title := strings.TrimSpace(opts.Title)
+	if title == "" {
+		return nil, errors.New("bead title is required")
+	}
+	if strings.ContainsAny(title, "\r\n") {
+		return nil, errors.New("bead title must be a single line")
+	}
+
+	args := []string{"create", "--title=" + title}
+
+	if opts.Type != "" {
+		if !validTypes[opts.Type] {
+			return nil, fmt.Errorf("invalid bead type %q", opts.Type)
+		}
+		args = append(args, "--type="+opts.Type)
+	}
+
+	if opts.Priority != "" {
+		p, err := normalizePriority(opts.Priority)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--priority="+p)
+	}
+
+	if opts.Description != "" {
+		args = append(args, "--description="+opts.Description)
+	}
+
+	if len(opts.Deps) > 0 {
+		for _, dep := range opts.Deps {
+			if err := validateID(dep); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, "--deps="+strings.Join(opts.Deps, ","))
+	}
+
+	return args, nil
+}
+
+// UpdateArgs validates opts and builds the bd update arguments.
+func UpdateArgs(id string, opts UpdateOpts) ([]string, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	args := []string{"update", id}
+
+	if opts.Title != "" {
+		if strings.ContainsAny(opts.Title, "\r\n") {
+			return nil, errors.New("bead title must be a single line")
+		}
+		args = append(args, "--title="+opts.Title)
+	}
+
+	if opts.Status != "" {
+		if !validStatuses[opts.Status] {
+			return nil, fmt.Errorf("invalid bead status %q", opts.Status)
+		}
+		args = append(args, "--status="+opts.Status)
+	}
+
+	if opts.Priority != "" {
+		p, err := normalizePriority(opts.Priority)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--priority="+p)
+	}
+
+	if opts.Description != "" {
+		args = append(args, "--description="+opts.Description)
+	}
+
+	if opts.Notes != "" {
+		args = append(args, "--notes="+opts.Notes)
+	}
+
+	if len(args) == 2 {
+		return nil, fmt.Errorf("nothing to update for bead %s", id)
+	}
+
+	return args, nil
+}
+
+// CommentArgs validates a comment and builds the bd comment arguments. The
+// text follows "--" so a comment starting with "-" is never read as a flag.
+func CommentArgs(id, author, text string) ([]string, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+	if author == "" || strings.ContainsAny(author, " \t\r\n") {
+		return nil, fmt.Errorf("invalid comment author %q", author)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("comment on bead %s is empty", id)
+	}
+	return []string{"comment", id, "--author=" + author, "--", text}, nil
+}
+
+// ListArgs validates opts and builds the bd list arguments.
+func ListArgs(opts ListOpts) ([]string, error) {
+	args := []string{"list"}
+
+	if opts.Status != "" {
+		if !validStatuses[opts.Status] {
+			return nil, fmt.Errorf("invalid bead status %q", opts.Status)
+		}
+		args = append(args, "--status="+opts.Status)
+	}
+
+	if opts.Priority != "" {
+		p, err := normalizePriority(opts.Priority)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--priority="+p)
+	}
+
+	for _, label := range opts.Labels {
+		label = strings.TrimSpace(label)
+		if label == "" || strings.ContainsAny(label, ", \t\r\n") {
+			return nil, fmt.Errorf("invalid bead label %q", label)
+		}
+		args = append(args, "--label="+label)
+	}
+
+	return args, nil
+}
+
+var priorityRegex = regexp.MustCompile(`^[Pp]?([0-4])$`)
+
+// normalizePriority accepts "2" or "P2" and returns "2".
+func normalizePriority(p string) (string, error) {
+	m := priorityRegex.FindStringSubmatch(strings.TrimSpace(p))
+	if m == nil {
+		return "", fmt.Errorf("invalid bead priority %q (want 0-4 or P0-P4)", p)
+	}
+	return m[1], nil
+}
+
+var idRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*-[A-Za-z0-9.]+$`)
+
+// validateID rejects values that don't look like bead IDs.
+func validateID(id string) error {
+	if !idRegex.MatchString(id) {
+		return fmt.Errorf("invalid bead ID %q", id)
+	}
+	return nil
+}
+
+var prefixRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidatePrefix rejects values that can't start a bead ID.
+func ValidatePrefix(prefix string) error {
+	if !prefixRegex.MatchString(prefix) {
+		return fmt.Errorf("invalid bead prefix %q", prefix)
+	}
+	return nil
+}
+
+// IDPrefix returns the project prefix of a bead ID: everything before the
+// last hyphen, so "buckshot-a1" and "buckshot-abc.1" both give "buckshot".
+func IDPrefix(id string) string {
+	i := strings.LastIndex(id, "-")
+	if i < 0 {
+		return ""
+	}
+	return id[:i]
+}
+
+// ValidateIDPrefix checks that id is a bead ID starting with prefix.
+// An empty prefix accepts any bead ID.
+func ValidateIDPrefix(id, prefix string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+	if prefix != "" && !strings.HasPrefix(id, prefix+"-") {
+		return fmt.Errorf("bead ID %q does not start with %s-", id, prefix)
+	}
+	return nil
+}
+
+// DetectPrefix returns the prefix of the first bead in bd list output, or ""
+// if it lists none.
+func DetectPrefix(listOutput string) string {
+	for _, line := range strings.Split(listOutput, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && validateID(fields[0]) == nil {
+			return IDPrefix(fields[0])
+		}
+	}
+	return ""
+}
+
+var createdRegex = regexp.MustCompile(`(?i)created issue:\s*(\S+)`)
+
+// ParseCreatedID extracts the bead ID from bd create output such as
+// "✓ Created issue: buckshot-123".
+func ParseCreatedID(output string) (string, error) {
+	m := createdRegex.FindStringSubmatch(output)
+	if m == nil {
+		return "", fmt.Errorf("no bead ID in bd create output: %q", strings.TrimSpace(output))
+	}
+	id := strings.TrimRight(m[1], ".,:")
+	if err := validateID(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// defaultExecutor executes commands using os/exec.
+type defaultExecutor struct{}
+
+func (e *defaultExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
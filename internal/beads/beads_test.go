@@ -0,0 +1,333 @@
+package beads
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type mockExecutor struct {
+	name   string
+	args   []string
+	output string
+	err    error
+}
+
+func (m *mockExecutor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	m.name = name
+	m.args = args
+	return m.output, m.err
+}
+
+// TestCreateArgs tests argument construction for bd create
+func TestCreateArgs(t *testing.T) {
+	args, err := CreateArgs(CreateOpts{
+		Title:       "Fix \"quoted\" login",
+		Type:        "bug",
+		Priority:    "P1",
+		Description: "Line one\nLine two with 'quotes' and $HOME",
+		Deps:        []string{"buckshot-1", "buckshot-2"},
+	})
+	if err != nil {
+		t.Fatalf("CreateArgs() error = %v", err)
+	}
+
+	want := []string{
+		"create",
+		"--title=Fix \"quoted\" login",
+		"--type=bug",
+		"--priority=1",
+		"--description=Line one\nLine two with 'quotes' and $HOME",
+		"--deps=buckshot-1,buckshot-2",
+	}
+	if strings.Join(args, "|") != strings.Join(want, "|") {
+		t.Errorf("CreateArgs() = %q, want %q", args, want)
+	}
+}
+
+// TestCreateArgs_Validation tests rejected create options
+func TestCreateArgs_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CreateOpts
+	}{
+		{"empty title", CreateOpts{Title: "  "}},
+		{"multiline title", CreateOpts{Title: "a\nb"}},
+		{"bad type", CreateOpts{Title: "t", Type: "story"}},
+		{"bad priority", CreateOpts{Title: "t", Priority: "P7"}},
+		{"bad dep", CreateOpts{Title: "t", Deps: []string{"--force"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CreateArgs(tt.opts); err == nil {
+				t.Error("CreateArgs() should return an error")
+			}
+		})
+	}
+}
+
+// TestUpdateArgs tests argument construction and validation for bd update
+func TestUpdateArgs(t *testing.T) {
+	args, err := UpdateArgs("buckshot-7", UpdateOpts{Status: "in_progress", Notes: "-starts with a dash"})
+	if err != nil {
+		t.Fatalf("UpdateArgs() error = %v", err)
+	}
+	want := []string{"update", "buckshot-7", "--status=in_progress", "--notes=-starts with a dash"}
+	if strings.Join(args, "|") != strings.Join(want, "|") {
+		t.Errorf("UpdateArgs() = %q, want %q", args, want)
+	}
+
+	if _, err := UpdateArgs("buckshot-7", UpdateOpts{}); err == nil {
+		t.Error("UpdateArgs() with no changes should return an error")
+	}
+	if _, err := UpdateArgs("-rf", UpdateOpts{Notes: "x"}); err == nil {
+		t.Error("UpdateArgs() should reject an invalid ID")
+	}
+	if _, err := UpdateArgs("buckshot-7", UpdateOpts{Status: "done"}); err == nil {
+		t.Error("UpdateArgs() should reject an invalid status")
+	}
+}
+
+// TestListArgs tests argument construction for bd list
+func TestListArgs(t *testing.T) {
+	args, err := ListArgs(ListOpts{Status: "blocked", Priority: "P1", Labels: []string{"backend", "api"}})
+	if err != nil {
+		t.Fatalf("ListArgs() error = %v", err)
+	}
+	want := "list|--status=blocked|--priority=1|--label=backend|--label=api"
+	if strings.Join(args, "|") != want {
+		t.Errorf("ListArgs() = %q, want %q", args, want)
+	}
+
+	if args, _ := ListArgs(ListOpts{}); len(args) != 1 {
+		t.Errorf("ListArgs() with no filter = %q, want just list", args)
+	}
+
+	for _, opts := range []ListOpts{{Status: "done"}, {Priority: "high"}, {Labels: []string{"a,b"}}} {
+		if _, err := ListArgs(opts); err == nil {
+			t.Errorf("ListArgs(%+v) should fail", opts)
+		}
+	}
+}
+
+// TestParseCreatedID tests ID extraction from bd create output
+func TestParseCreatedID(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"✓ Created issue: buckshot-123\n", "buckshot-123"},
+		{"✓ Created issue: bd-a1b2\n  Title: Something\n  Priority: P2\n", "bd-a1b2"},
+		{"Created issue: buckshot-abc.1.", "buckshot-abc.1"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCreatedID(tt.output)
+		if err != nil {
+			t.Errorf("ParseCreatedID(%q) error = %v", tt.output, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCreatedID(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+
+	if _, err := ParseCreatedID("Error: database locked"); err == nil {
+		t.Error("ParseCreatedID() should fail without a created line")
+	}
+}
+
+// TestDetectPrefix tests that the prefix comes from the first bead listed
+func TestDetectPrefix(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"proj-a1 [P1] [task] open - First\nproj-b2 [P2] [task] open - Second\n", "proj"},
+		{"\nmy-app-9z.1 [P0] [bug] open - Child\n", "my-app"},
+		{"Found 1 issue:\nbuckshot-x1 [P2] [task] open - Only\n", "buckshot"},
+		{"No issues found.\n", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectPrefix(tt.output); got != tt.want {
+			t.Errorf("DetectPrefix(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+// TestValidateIDPrefix tests bead ID checks against a project prefix
+func TestValidateIDPrefix(t *testing.T) {
+	tests := []struct {
+		id, prefix string
+		wantErr    bool
+	}{
+		{"proj-a1", "proj", false},
+		{"proj-a1.2", "proj", false},
+		{"buckshot-a1", "", false},
+		{"buckshot-a1", "proj", true},
+		{"projx-a1", "proj", true},
+		{"not an id", "", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateIDPrefix(tt.id, tt.prefix)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateIDPrefix(%q, %q) error = %v, wantErr %v", tt.id, tt.prefix, err, tt.wantErr)
+		}
+	}
+
+	if err := ValidatePrefix("proj"); err != nil {
+		t.Errorf("ValidatePrefix(proj) error = %v", err)
+	}
+	if err := ValidatePrefix("a b"); err == nil {
+		t.Error("ValidatePrefix should reject whitespace")
+	}
+}
+
+// TestClient_Create tests that Create runs bd and returns the new ID
+func TestClient_Create(t *testing.T) {
+	mock := &mockExecutor{output: "✓ Created issue: buckshot-42\n"}
+	c := NewClient(WithExecutor(mock))
+
+	id, err := c.Create(context.Background(), CreateOpts{Title: "New bead"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if id != "buckshot-42" {
+		t.Errorf("Create() = %q, want buckshot-42", id)
+	}
+	if mock.name != "bd" || mock.args[0] != "create" {
+		t.Errorf("ran %s %v, want bd create", mock.name, mock.args)
+	}
+}
+
+// TestClient_ListAndShow tests that List and Show run bd and return its output
+func TestClient_ListAndShow(t *testing.T) {
+	mock := &mockExecutor{output: "buckshot-1 [P1] [task] open - One\n"}
+	c := NewClient(WithExecutor(mock))
+
+	out, err := c.List(context.Background(), ListOpts{Status: "open"})
+	if err != nil || out != mock.output {
+		t.Fatalf("List() = %q, %v", out, err)
+	}
+	if strings.Join(mock.args, " ") != "list --status=open" {
+		t.Errorf("ran bd %v, want bd list --status=open", mock.args)
+	}
+
+	if _, err := c.Show(context.Background(), "buckshot-1"); err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	if strings.Join(mock.args, " ") != "show buckshot-1" {
+		t.Errorf("ran bd %v, want bd show buckshot-1", mock.args)
+	}
+	if _, err := c.Show(context.Background(), "--all"); err == nil {
+		t.Error("Show() should reject an invalid ID")
+	}
+}
+
+// TestCommentArgs tests argument construction and validation for bd comment
+func TestCommentArgs(t *testing.T) {
+	args, err := CommentArgs("buckshot-1", "claude", "-1 on this split\nSee buckshot-2")
+	if err != nil {
+		t.Fatalf("CommentArgs() error = %v", err)
+	}
+	want := []string{"comment", "buckshot-1", "--author=claude", "--", "-1 on this split\nSee buckshot-2"}
+	if strings.Join(args, "|") != strings.Join(want, "|") {
+		t.Errorf("CommentArgs() = %q, want %q", args, want)
+	}
+
+	for _, tc := range []struct{ id, author, text string }{
+		{"--all", "claude", "text"},
+		{"buckshot-1", "", "text"},
+		{"buckshot-1", "claude code", "text"},
+		{"buckshot-1", "claude", " \n"},
+	} {
+		if _, err := CommentArgs(tc.id, tc.author, tc.text); err == nil {
+			t.Errorf("CommentArgs(%q, %q, %q) should fail", tc.id, tc.author, tc.text)
+		}
+	}
+}
+
+// TestClient_Comment tests that Comment runs bd comment and reports failures
+func TestClient_Comment(t *testing.T) {
+	mock := &mockExecutor{}
+	c := NewClient(WithExecutor(mock))
+	if err := c.Comment(context.Background(), "buckshot-1", "codex", "Looks good"); err != nil {
+		t.Fatalf("Comment() error = %v", err)
+	}
+	if mock.name != "bd" || strings.Join(mock.args, " ") != "comment buckshot-1 --author=codex -- Looks good" {
+		t.Errorf("ran %s %v, want bd comment", mock.name, mock.args)
+	}
+
+	mock.err = errors.New("exit status 1")
+	mock.output = "issue not found"
+	if err := c.Comment(context.Background(), "buckshot-1", "codex", "Looks good"); err == nil || !strings.Contains(err.Error(), "issue not found") {
+		t.Errorf("Comment() error = %v, want bd's output", err)
+	}
+}
+
+// TestClient_ListIssues tests decoding bd list --json output
+func TestClient_ListIssues(t *testing.T) {
+	mock := &mockExecutor{output: `[
+  {"id": "buckshot-1", "title": "One", "status": "open", "priority": 1, "issue_type": "task", "updated_at": "2025-06-01T10:00:00Z"},
+  {"id": "buckshot-2", "title": "Two", "status": "blocked", "priority": 3, "issue_type": "bug", "updated_at": "2025-06-02T10:00:00Z"}
+]`}
+	c := NewClient(WithExecutor(mock))
+
+	issues, err := c.ListIssues(context.Background(), ListOpts{Priority: "P1"})
+	if err != nil {
+		t.Fatalf("ListIssues() error = %v", err)
+	}
+	if strings.Join(mock.args, " ") != "list --priority=1 --json" {
+		t.Errorf("ran bd %v, want bd list --priority=1 --json", mock.args)
+	}
+	if len(issues) != 2 || issues[0].ID != "buckshot-1" || issues[1].Priority != 3 || issues[1].UpdatedAt.Day() != 2 {
+		t.Errorf("ListIssues() = %+v", issues)
+	}
+
+	mock.output = "Error: no database"
+	if _, err := c.ListIssues(context.Background(), ListOpts{}); err == nil {
+		t.Error("ListIssues() should fail on non-JSON output")
+	}
+}
+
+// TestClient_UpdateError tests that bd failures are reported with output
+func TestClient_UpdateError(t *testing.T) {
+	mock := &mockExecutor{output: "Error: issue not found", err: errors.New("exit status 1")}
+	c := NewClient(WithExecutor(mock))
+
+	err := c.Update(context.Background(), "buckshot-9", UpdateOpts{Status: "closed"})
+	if err == nil || !strings.Contains(err.Error(), "issue not found") {
+		t.Errorf("Update() error = %v, want bd output included", err)
+	}
+}
+
+// TestClient_Available tests that only a missing bd binary is unavailable
+func TestClient_Available(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"installed", nil, true},
+		{"failing", errors.New("exit status 1"), true},
+		{"missing", &exec.Error{Name: "bd", Err: exec.ErrNotFound}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockExecutor{err: tt.err}
+			c := NewClient(WithExecutor(mock))
+			if got := c.Available(context.Background()); got != tt.want {
+				t.Errorf("Available() = %v, want %v", got, tt.want)
+			}
+			if mock.name != "bd" || strings.Join(mock.args, " ") != "version" {
+				t.Errorf("ran %s %v, want bd version", mock.name, mock.args)
+			}
+		})
+	}
+}
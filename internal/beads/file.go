@@ -0,0 +1,185 @@
+package beads
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errReadOnly is returned by file clients for commands that modify beads.
+var errReadOnly = errors.New("beads file is read-only")
+
+// fileClient answers bd list and bd show from a snapshot of beads.
+type fileClient struct {
+	path   string
+	issues []Issue
+}
+
+// LoadFile reads beads from path instead of running bd. The file holds
+// either bd list --json output or one bead per line, as in
+// .beads/issues.jsonl. The returned client renders List and Show the way bd
+// would and rejects commands that modify beads.
+func LoadFile(path string) (Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read beads file: %w", err)
+	}
+	issues, err := parseIssues(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse beads file %s: %w", path, err)
+	}
+	return &fileClient{path: path, issues: issues}, nil
+}
+
+// parseIssues decodes a JSON array of beads, or JSONL with one per line.
+func parseIssues(data []byte) ([]Issue, error) {
+	var issues []Issue
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &issues); err != nil {
+			return nil, err
+		}
+		return issues, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var issue Issue
+		if err := json.Unmarshal(line, &issue); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		issues = append(issues, issue)
+	}
+	return issues, scanner.Err()
+}
+
+// Create is rejected: a beads file is a snapshot.
+func (c *fileClient) Create(ctx context.Context, opts CreateOpts) (string, error) {
+	return "", fmt.Errorf("cannot create bead: %w", errReadOnly)
+}
+
+// Update is rejected: a beads file is a snapshot.
+func (c *fileClient) Update(ctx context.Context, id string, opts UpdateOpts) error {
+	return fmt.Errorf("cannot update bead %s: %w", id, errReadOnly)
+}
+
+// List renders the beads matching opts one per line, as bd list does.
+func (c *fileClient) List(ctx context.Context, opts ListOpts) (string, error) {
+	issues, err := c.ListIssues(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, issue := range issues {
+		fmt.Fprintf(&sb, "%s [P%d] [%s] %s - %s\n", issue.ID, issue.Priority, issue.IssueType, issue.Status, issue.Title)
+	}
+	return sb.String(), nil
+}
+
+// ListIssues returns the beads matching opts, in file order.
+func (c *fileClient) ListIssues(ctx context.Context, opts ListOpts) ([]Issue, error) {
+	if _, err := ListArgs(opts); err != nil {
+		return nil, err
+	}
+	priority := -1
+	if opts.Priority != "" {
+		p, _ := normalizePriority(opts.Priority)
+		priority, _ = strconv.Atoi(p)
+	}
+
+	var issues []Issue
+	for _, issue := range c.issues {
+		if opts.Status != "" && issue.Status != opts.Status {
+			continue
+		}
+		if priority >= 0 && issue.Priority != priority {
+			continue
+		}
+		if !hasLabels(issue, opts.Labels) {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// hasLabels reports whether issue carries every label.
+func hasLabels(issue Issue, labels []string) bool {
+	for _, label := range labels {
+		if !slices.Contains(issue.Labels, strings.TrimSpace(label)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Show renders a bead as bd show does, listing the beads it depends on and
+// marking those already closed.
+func (c *fileClient) Show(ctx context.Context, id string) (string, error) {
+	issue, ok := c.find(id)
+	if !ok {
+		return "", fmt.Errorf("bead %s not found in %s", id, c.path)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s\n", issue.ID, issue.Title)
+	fmt.Fprintf(&sb, "Status: %s\n", issue.Status)
+	fmt.Fprintf(&sb, "Priority: P%d\n", issue.Priority)
+	fmt.Fprintf(&sb, "Type: %s\n", issue.IssueType)
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(issue.Labels, ", "))
+	}
+	if issue.Description != "" {
+		fmt.Fprintf(&sb, "Description: %s\n", issue.Description)
+	}
+
+	var deps []string
+	for _, dep := range issue.Dependencies {
+		if dep.Type != "" && dep.Type != "blocks" {
+			continue
+		}
+		line := "  → " + dep.DependsOnID
+		if target, ok := c.find(dep.DependsOnID); ok {
+			line += ": " + target.Title
+			if target.Status == "closed" {
+				line += " [closed]"
+			}
+		}
+		deps = append(deps, line)
+	}
+	if len(deps) > 0 {
+		fmt.Fprintf(&sb, "\nDepends on (%d):\n%s\n", len(deps), strings.Join(deps, "\n"))
+	}
+	return sb.String(), nil
+}
+
+// find returns the bead with the given ID.
+func (c *fileClient) find(id string) (Issue, bool) {
+	for _, issue := range c.issues {
+		if issue.ID == id {
+			return issue, true
+		}
+	}
+	return Issue{}, false
+}
+
+// Comment is rejected: a beads file is a snapshot.
+func (c *fileClient) Comment(ctx context.Context, id, author, text string) error {
+	return fmt.Errorf("cannot comment on bead %s: %w", id, errReadOnly)
+}
+
+// Available always reports true: no bd is needed.
+func (c *fileClient) Available(ctx context.Context) bool {
+	return true
+}
@@ -0,0 +1,154 @@
+package beads
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// issuesFile is the shared JSONL beads fixture.
+var issuesFile = filepath.Join("..", "..", "testdata", "beads", "issues.jsonl")
+
+func TestLoadFile_List(t *testing.T) {
+	c, err := LoadFile(issuesFile)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opts ListOpts
+		want []string
+	}{
+		{"all", ListOpts{}, []string{"buckshot-a1", "buckshot-b2", "buckshot-c3", "buckshot-d4"}},
+		{"status", ListOpts{Status: "open"}, []string{"buckshot-a1", "buckshot-b2", "buckshot-d4"}},
+		{"priority", ListOpts{Priority: "P1"}, []string{"buckshot-a1", "buckshot-c3"}},
+		{"labels", ListOpts{Labels: []string{"backend", "cache"}}, []string{"buckshot-a1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := c.ListIssues(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("ListIssues() error = %v", err)
+			}
+			var ids []string
+			for _, issue := range issues {
+				ids = append(ids, issue.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ListIssues() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	list, err := c.List(ctx, ListOpts{Status: "open"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := "buckshot-a1 [P1] [feature] open - Add a response cache for agent sessions\n" +
+		"buckshot-b2 [P2] [task] open - Document cache invalidation rules\n" +
+		"buckshot-d4 [P3] [task] open - Evict stale cache entries\n"
+	if list != want {
+		t.Errorf("List() =\n%s\nwant:\n%s", list, want)
+	}
+
+	if _, err := c.List(ctx, ListOpts{Status: "done"}); err == nil {
+		t.Error("List() should reject an invalid status like bd does")
+	}
+}
+
+func TestLoadFile_Show(t *testing.T) {
+	c, err := LoadFile(issuesFile)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	ctx := context.Background()
+
+	shown, err := c.Show(ctx, "buckshot-b2")
+	if err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	want := `buckshot-b2: Document cache invalidation rules
+Status: open
+Priority: P2
+Type: task
+Labels: docs
+Description: Explain when cached answers are dropped.
+
+Depends on (1):
+  → buckshot-a1: Add a response cache for agent sessions
+`
+	if shown != want {
+		t.Errorf("Show() =\n%s\nwant:\n%s", shown, want)
+	}
+
+	// Closed dependencies are marked, and related beads are not dependencies
+	shown, err = c.Show(ctx, "buckshot-d4")
+	if err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	if !strings.Contains(shown, "Depends on (1):\n  → buckshot-c3: Pick a cache key [closed]\n") {
+		t.Errorf("Show() should list only the closed blocker, got:\n%s", shown)
+	}
+
+	if _, err := c.Show(ctx, "buckshot-zz"); err == nil {
+		t.Error("Show() of a bead not in the file should fail")
+	}
+}
+
+func TestLoadFile_ListJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.json")
+	data := `[
+  {"id": "buckshot-a1", "title": "Add a response cache", "status": "open", "priority": 1, "issue_type": "feature"}
+]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	list, _ := c.List(context.Background(), ListOpts{})
+	if list != "buckshot-a1 [P1] [feature] open - Add a response cache\n" {
+		t.Errorf("List() = %q", list)
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issues.jsonl")
+	if err := os.WriteFile(path, []byte("{\"id\":\"buckshot-a1\"}\nnot json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("LoadFile() error = %v, want one naming line 2", err)
+	}
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("LoadFile() of a missing file should fail")
+	}
+}
+
+func TestLoadFile_ReadOnly(t *testing.T) {
+	c, err := LoadFile(issuesFile)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := c.Create(ctx, CreateOpts{Title: "New"}); !errors.Is(err, errReadOnly) {
+		t.Errorf("Create() error = %v, want read-only", err)
+	}
+	if err := c.Update(ctx, "buckshot-a1", UpdateOpts{Status: "closed"}); !errors.Is(err, errReadOnly) {
+		t.Errorf("Update() error = %v, want read-only", err)
+	}
+	if err := c.Comment(ctx, "buckshot-a1", "claude", "Looks good"); !errors.Is(err, errReadOnly) {
+		t.Errorf("Comment() error = %v, want read-only", err)
+	}
+	if !c.Available(ctx) {
+		t.Error("Available() should not depend on bd")
+	}
+}
@@ -2,15 +2,27 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/michaellady/buckshot/internal/agent"
 	"github.com/spf13/cobra"
 )
 
-var agentsCmd = &cobra.Command{
-	Use:   "agents",
-	Short: "List available AI coding agents",
-	Long: `List all detected AI coding agents and their status.
+// agentsOptions holds the flags of one agents invocation.
+type agentsOptions struct {
+	*rootOptions
+	// verbose enables the PATH conflict report.
+	verbose bool
+}
+
+// newAgentsCmd builds the agents command.
+func newAgentsCmd(root *rootOptions) *cobra.Command {
+	opts := &agentsOptions{rootOptions: root}
+	cmd := &cobra.Command{
+		Use:   "agents",
+		Short: "List available AI coding agents",
+		Long: `List all detected AI coding agents and their status.
 
 Buckshot looks for the following CLI tools:
   - claude (Claude Code)
@@ -20,17 +32,33 @@ Buckshot looks for the following CLI tools:
   - gemini (Google Gemini CLI)
   - amp (Amp CLI)
 
-Each agent is checked for installation and authentication status.`,
-	RunE: runAgents,
+Custom agents defined under "agents" in the --config file are detected too.
+
+Each agent is checked for installation and authentication status; an agent
+whose check hangs for more than 5 seconds is listed with status unknown. With
+--verbose, agents installed in more than one PATH directory are listed with
+every copy and its version, since only the first copy runs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAgents(cmd, opts)
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Also warn about agents installed in more than one PATH directory")
+	return cmd
 }
 
-func runAgents(cmd *cobra.Command, args []string) error {
+func runAgents(cmd *cobra.Command, opts *agentsOptions) error {
 	out := cmd.OutOrStdout()
 
+	if _, err := opts.loadConfig(); err != nil {
+		return err
+	}
+
 	_, _ = fmt.Fprintf(out, "Detecting available agents...\n\n")
 
 	detector := agent.NewDetector()
-	agents, err := detector.DetectAll()
+	detector.SetRegistry(opts.registry)
+	// Listing agents is how users check their setup, so never reuse old checks
+	agents, err := detector.DetectAll(cmd.Context(), true)
 	if err != nil {
 		return fmt.Errorf("failed to detect agents: %w", err)
 	}
@@ -38,7 +66,7 @@ func runAgents(cmd *cobra.Command, args []string) error {
 	if len(agents) == 0 {
 		_, _ = fmt.Fprintf(out, "No agents found.\n")
 		_, _ = fmt.Fprintf(out, "\nSupported agents:\n")
-		for name := range agent.KnownAgents() {
+		for name := range opts.registry.KnownAgents() {
 			_, _ = fmt.Fprintf(out, "  - %s\n", name)
 		}
 		return nil
@@ -48,16 +76,52 @@ func runAgents(cmd *cobra.Command, args []string) error {
 
 	for _, a := range agents {
 		status := "✗ not authenticated"
-		if a.Authenticated {
+		switch {
+		case a.Authenticated:
 			status = "✓ ready"
+		case a.CheckTimedOut:
+			status = "? unknown (check timed out)"
 		}
 
 		_, _ = fmt.Fprintf(out, "  %s\n", a.Name)
 		_, _ = fmt.Fprintf(out, "    Path: %s\n", a.Path)
 		_, _ = fmt.Fprintf(out, "    Version: %s\n", a.Version)
 		_, _ = fmt.Fprintf(out, "    Status: %s\n", status)
+		_, _ = fmt.Fprintf(out, "    Capabilities: %s\n", formatCapabilities(a.Pattern.Capabilities()))
 		_, _ = fmt.Fprintf(out, "\n")
 	}
 
+	if opts.verbose {
+		writeConflicts(out, detector.DetectConflicts())
+	}
+
 	return nil
 }
+
+// writeConflicts warns about agents found in several places on PATH.
+func writeConflicts(out io.Writer, conflicts []agent.Conflict) {
+	for _, c := range conflicts {
+		_, _ = fmt.Fprintf(out, "Warning: %s is installed in %d places on PATH; the first one runs:\n", c.Name, len(c.Candidates))
+		for _, cand := range c.Candidates {
+			version := cand.Version
+			if version == "" {
+				version = "unknown version"
+			}
+			_, _ = fmt.Fprintf(out, "    %s (%s)\n", cand.Path, version)
+		}
+		_, _ = fmt.Fprintf(out, "\n")
+	}
+}
+
+// formatCapabilities renders a capability set as a comma-separated list.
+func formatCapabilities(caps agent.CapabilitySet) string {
+	list := caps.List()
+	if len(list) == 0 {
+		return "none"
+	}
+	names := make([]string, len(list))
+	for i, c := range list {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/session"
+	"github.com/spf13/cobra"
+)
+
+// chatQuit ends a chat session.
+const chatQuit = "/quit"
+
+// chatOptions holds the flags of one chat invocation.
+type chatOptions struct {
+	*rootOptions
+	agent     string
+	respawnAt float64
+}
+
+// newChatCmd builds the chat command.
+func newChatCmd(root *rootOptions) *cobra.Command {
+	opts := &chatOptions{rootOptions: root}
+	cmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Talk to one agent interactively",
+		Long: `Open a persistent session with one agent and send it prompts typed at
+the terminal, one per line.
+
+Responses stream as the agent writes them, followed by the session's
+context usage. When the context passes --respawn-at the session is
+replaced with a fresh one. Type /quit or send EOF (Ctrl-D) to leave.
+
+Example:
+  buckshot chat --agent claude`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChat(cmd, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.agent, "agent", "", "Agent to chat with (required)")
+	cmd.Flags().Float64Var(&opts.respawnAt, "respawn-at", 0.8, "Context usage (0-1) at which to start a fresh session")
+	_ = cmd.MarkFlagRequired("agent")
+	return cmd
+}
+
+// lockedWriter serializes writes from the agent's output readers and the prompt loop.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func runChat(cmd *cobra.Command, opts *chatOptions) error {
+	if opts.respawnAt <= 0 || opts.respawnAt > 1 {
+		return fmt.Errorf("--respawn-at must be between 0 and 1, got %g", opts.respawnAt)
+	}
+
+	if _, err := opts.loadConfig(); err != nil {
+		return err
+	}
+
+	agents, err := opts.detectAgents()
+	if err != nil {
+		return fmt.Errorf("failed to detect agents: %w", err)
+	}
+	found := filterAgents(agents, []string{opts.agent})
+	if len(found) == 0 {
+		return fmt.Errorf("agent %q not found", opts.agent)
+	}
+	ag := found[0]
+	if !ag.Authenticated {
+		return fmt.Errorf("agent %q is not authenticated", opts.agent)
+	}
+
+	instructionsPath, err := resolveAgentsFile(opts.agentsPath, "", false, opts.logger)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	out := &lockedWriter{w: cmd.OutOrStdout()}
+	mgr := session.NewManagerWithOptions(session.Options{Logger: opts.logger})
+
+	sess, err := startChatSession(ctx, mgr, ag, instructionsPath, out)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sess.Close() }()
+
+	opts.logger.Infof("Chatting with %s; type %s or press Ctrl-D to leave", ag.Name, chatQuit)
+
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	for {
+		_, _ = fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			_, _ = fmt.Fprintln(out)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == chatQuit {
+			break
+		}
+
+		resp, err := sess.Send(ctx, line)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, session.ErrProcessExited):
+			opts.logger.Warnf("%s exited; starting a new session", ag.Name)
+		case err != nil:
+			opts.logger.Errorf("%s: %v", ag.Name, err)
+			continue
+		default:
+			if _, streaming := sess.(session.Streamer); !streaming {
+				_, _ = fmt.Fprintln(out, resp.Output)
+			}
+			_, _ = fmt.Fprintf(out, "[context: %.0f%% used]\n", sess.ContextUsage()*100)
+			if !mgr.ShouldRespawn(sess, opts.respawnAt) {
+				continue
+			}
+			opts.logger.Infof("Context above %.0f%%; starting a fresh %s session", opts.respawnAt*100, ag.Name)
+		}
+
+		_ = sess.Close()
+		if sess, err = startChatSession(ctx, mgr, ag, instructionsPath, out); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// startChatSession starts a session for ag that streams its output to out.
+func startChatSession(ctx context.Context, mgr session.Manager, ag agent.Agent, instructionsPath string, out io.Writer) (session.Session, error) {
+	sess, err := mgr.CreateSession(ag)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := sess.(session.Streamer); ok {
+		s.SetStream(func(text string) {
+			_, _ = fmt.Fprintln(out, text)
+		})
+	}
+	if err := sess.Start(ctx, instructionsPath); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", ag.Name, err)
+	}
+	return sess, nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+// writeChatAgent writes a mock agent that answers each stdin line and adds
+// 45% context usage per turn. Every launch appends a line to the returned file.
+func writeChatAgent(t *testing.T) (agent.Agent, string) {
+	t.Helper()
+	dir := t.TempDir()
+	starts := filepath.Join(dir, "starts")
+	script := `#!/bin/bash
+echo started >> "` + starts + `"
+usage=1
+echo "Context: ${usage}% used"
+while IFS= read -r line; do
+    echo "Mock response to: $line"
+    usage=$((usage + 45))
+    echo "Context: ${usage}% used"
+done
+`
+	path := filepath.Join(dir, "mock-chat")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write mock agent: %v", err)
+	}
+	return agent.Agent{Name: "mock", Path: path, Authenticated: true, Pattern: agent.CLIPattern{Binary: "mock"}}, starts
+}
+
+// TestChatCommand_ScriptedSession drives the prompt loop with scripted stdin
+func TestChatCommand_ScriptedSession(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ag, starts := writeChatAgent(t)
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{ag}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	// The second turn pushes context to 91%, so the third runs in a fresh session
+	rootCmd.SetArgs([]string{"chat", "--agent", "mock"})
+	rootCmd.SetIn(strings.NewReader("hello\n\nsecond\nthird\n/quit\nnever sent\n"))
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("chat should not error, got: %v", err)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"Mock response to: hello",
+		"[context: 46% used]",
+		"Mock response to: second",
+		"[context: 91% used]",
+		"Mock response to: third",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "never sent") {
+		t.Errorf("input after /quit should not be sent, got:\n%s", out)
+	}
+	if !strings.Contains(stderr.String(), "starting a fresh mock session") {
+		t.Errorf("expected a respawn notice, got: %s", stderr.String())
+	}
+
+	data, err := os.ReadFile(starts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "started"); n != 2 {
+		t.Errorf("agent started %d times, want 2 (one respawn)", n)
+	}
+}
+
+// TestChatCommand_EOF tests that end of input closes the chat cleanly
+func TestChatCommand_EOF(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ag, _ := writeChatAgent(t)
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{ag}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"chat", "--agent", "mock"})
+	rootCmd.SetIn(strings.NewReader("hello\n"))
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("chat should exit cleanly on EOF, got: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Mock response to: hello") {
+		t.Errorf("expected the response before EOF, got:\n%s", stdout.String())
+	}
+}
+
+// TestChatCommand_InvalidRespawnAt tests --respawn-at validation
+func TestChatCommand_InvalidRespawnAt(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"chat", "--agent", "mock", "--respawn-at", "1.5"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--respawn-at must be between 0 and 1") {
+		t.Errorf("expected a --respawn-at error, got: %v", err)
+	}
+}
@@ -2,12 +2,32 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/michaellady/buckshot/internal/config"
+	"github.com/michaellady/buckshot/internal/convergence"
+	"github.com/michaellady/buckshot/internal/dispatch"
+	"github.com/michaellady/buckshot/internal/logging"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/michaellady/buckshot/internal/session"
+	"github.com/spf13/cobra"
 )
 
 // TestRootCommand tests the root command exists and has expected structure
 func TestRootCommand(t *testing.T) {
+	rootCmd := newRootCmd()
 	if rootCmd == nil {
 		t.Fatal("rootCmd is nil")
 	}
@@ -25,10 +45,7 @@ func TestRootCommand(t *testing.T) {
 
 // TestPlanCommand_Exists tests the plan command exists
 func TestPlanCommand_Exists(t *testing.T) {
-	if planCmd == nil {
-		t.Fatal("planCmd is nil")
-	}
-
+	planCmd := subcommand(t, newRootCmd(), "plan")
 	if planCmd.Use != "plan [prompt]" {
 		t.Errorf("planCmd.Use = %q, want %q", planCmd.Use, "plan [prompt]")
 	}
@@ -36,7 +53,7 @@ func TestPlanCommand_Exists(t *testing.T) {
 
 // TestPlanCommand_RequiresPrompt tests that plan command requires a prompt argument
 func TestPlanCommand_RequiresPrompt(t *testing.T) {
-	// Reset command state
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan"})
 
 	buf := new(bytes.Buffer)
@@ -57,13 +74,14 @@ func TestPlanCommand_RequiresPrompt(t *testing.T) {
 
 // TestPlanCommand_AcceptsPrompt tests that plan command accepts a prompt
 func TestPlanCommand_AcceptsPrompt(t *testing.T) {
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "Create a new feature"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command with prompt should not error, got: %v", err)
 	}
@@ -76,6 +94,7 @@ func TestPlanCommand_AcceptsPrompt(t *testing.T) {
 
 // TestPlanCommand_RoundsFlag tests the --rounds flag
 func TestPlanCommand_RoundsFlag(t *testing.T) {
+	planCmd := subcommand(t, newRootCmd(), "plan")
 	// Check default value
 	flag := planCmd.Flags().Lookup("rounds")
 	if flag == nil {
@@ -94,28 +113,27 @@ func TestPlanCommand_RoundsFlag(t *testing.T) {
 
 // TestPlanCommand_RoundsFlagCustomValue tests setting custom rounds
 func TestPlanCommand_RoundsFlagCustomValue(t *testing.T) {
-	// Reset to default
-	rounds = 3
-
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "--rounds", "5", "Test prompt"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command with --rounds should not error, got: %v", err)
 	}
 
-	if rounds != 5 {
+	if rounds, _ := subcommand(t, rootCmd, "plan").Flags().GetInt("rounds"); rounds != 5 {
 		t.Errorf("rounds = %d, want 5", rounds)
 	}
 }
 
 // TestPlanCommand_AgentsPathFlag tests the --agents-path flag
 func TestPlanCommand_AgentsPathFlag(t *testing.T) {
-	flag := planCmd.Flags().Lookup("agents-path")
+	planCmd := subcommand(t, newRootCmd(), "plan")
+	flag := planCmd.Flag("agents-path")
 	if flag == nil {
 		t.Fatal("--agents-path flag not found")
 	}
@@ -127,27 +145,103 @@ func TestPlanCommand_AgentsPathFlag(t *testing.T) {
 
 // TestPlanCommand_AgentsPathFlagCustomValue tests setting custom agents path
 func TestPlanCommand_AgentsPathFlagCustomValue(t *testing.T) {
-	// Reset
-	agentsPath = ""
-
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "--agents-path", "/custom/AGENTS.md", "Test prompt"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command with --agents-path should not error, got: %v", err)
 	}
 
-	if agentsPath != "/custom/AGENTS.md" {
+	if agentsPath := rootCmd.Flag("agents-path").Value.String(); agentsPath != "/custom/AGENTS.md" {
+		t.Errorf("agentsPath = %q, want %q", agentsPath, "/custom/AGENTS.md")
+	}
+}
+
+// TestRootCommand_AgentsPathPersistent tests that --agents-path is defined
+// once on the root command, inherited by each command that reads AGENTS.md,
+// and may be given before the subcommand
+func TestRootCommand_AgentsPathPersistent(t *testing.T) {
+	rootCmd := newRootCmd()
+	planCmd := subcommand(t, rootCmd, "plan")
+	root := rootCmd.PersistentFlags().Lookup("agents-path")
+	if root == nil {
+		t.Fatal("--agents-path should be a persistent flag on the root command")
+	}
+	for _, cmd := range []*cobra.Command{planCmd, subcommand(t, rootCmd, "feedback"), subcommand(t, rootCmd, "chat")} {
+		if cmd.LocalNonPersistentFlags().Lookup("agents-path") != nil {
+			t.Errorf("%s should not define its own --agents-path", cmd.Name())
+		}
+		if cmd.Flag("agents-path") != root {
+			t.Errorf("%s should inherit the root --agents-path", cmd.Name())
+		}
+	}
+
+	rootCmd.SetArgs([]string{"--agents-path", "/custom/AGENTS.md", "plan", "Test prompt"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := ignoreNoAgents(rootCmd.Execute()); err != nil {
+		t.Fatalf("--agents-path before the subcommand should not error, got: %v", err)
+	}
+	if agentsPath := rootCmd.Flag("agents-path").Value.String(); agentsPath != "/custom/AGENTS.md" {
 		t.Errorf("agentsPath = %q, want %q", agentsPath, "/custom/AGENTS.md")
 	}
 }
 
+// TestPlanCommand_RunsTwiceWithoutReset tests that each plan invocation
+// starts from the flag defaults, whatever an earlier run was given
+func TestPlanCommand_RunsTwiceWithoutReset(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	run := func(args ...string) (string, string) {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append(append([]string{"plan", "--no-agents-file"}, args...), "Design API"))
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		rootCmd.SetOut(stdout)
+		rootCmd.SetErr(stderr)
+		if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatalf("plan %v should not error, got: %v", args, err)
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, stderr := run("--rounds", "1", "--progress-json", "-q")
+	if !strings.Contains(stdout, "Completed 1 round(s)") || !strings.Contains(stderr, `"round":1`) {
+		t.Fatalf("first run should stop after one round with JSON progress, got:\n%s\n%s", stdout, stderr)
+	}
+
+	stdout, stderr = run()
+	if !strings.Contains(stdout, "Completed 3 round(s)") {
+		t.Errorf("second run should use the default 3 rounds, got:\n%s", stdout)
+	}
+	if strings.Contains(stderr, `"round"`) || !strings.Contains(stderr, "Planning: Design API") {
+		t.Errorf("second run should log normally without JSON progress, got:\n%s", stderr)
+	}
+
+	// An explicit --rounds from an earlier run must not cap this one
+	stdout, _ = run("--until-converged", "--converged-rounds", "2")
+	if !strings.Contains(stdout, "Converged after 2 round(s)") {
+		t.Errorf("third run should converge after 2 rounds, got:\n%s", stdout)
+	}
+}
+
 // TestPlanCommand_AgentsFlag tests the --agents flag
 func TestPlanCommand_AgentsFlag(t *testing.T) {
+	planCmd := subcommand(t, newRootCmd(), "plan")
 	flag := planCmd.Flags().Lookup("agents")
 	if flag == nil {
 		t.Fatal("--agents flag not found")
@@ -156,20 +250,19 @@ func TestPlanCommand_AgentsFlag(t *testing.T) {
 
 // TestPlanCommand_AgentsFlagMultipleValues tests selecting multiple agents
 func TestPlanCommand_AgentsFlagMultipleValues(t *testing.T) {
-	// Reset
-	selectedAgents = nil
-
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "--agents", "claude,codex", "Test prompt"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command with --agents should not error, got: %v", err)
 	}
 
+	selectedAgents, _ := subcommand(t, rootCmd, "plan").Flags().GetStringSlice("agents")
 	if len(selectedAgents) != 2 {
 		t.Errorf("selectedAgents has %d items, want 2", len(selectedAgents))
 	}
@@ -181,6 +274,7 @@ func TestPlanCommand_AgentsFlagMultipleValues(t *testing.T) {
 
 // TestPlanCommand_UntilConvergedFlag tests the --until-converged flag
 func TestPlanCommand_UntilConvergedFlag(t *testing.T) {
+	planCmd := subcommand(t, newRootCmd(), "plan")
 	flag := planCmd.Flags().Lookup("until-converged")
 	if flag == nil {
 		t.Fatal("--until-converged flag not found")
@@ -193,31 +287,26 @@ func TestPlanCommand_UntilConvergedFlag(t *testing.T) {
 
 // TestPlanCommand_UntilConvergedFlagSet tests enabling until-converged
 func TestPlanCommand_UntilConvergedFlagSet(t *testing.T) {
-	// Reset
-	untilConverged = false
-
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "--until-converged", "Test prompt"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command with --until-converged should not error, got: %v", err)
 	}
 
-	if !untilConverged {
+	if untilConverged, _ := subcommand(t, rootCmd, "plan").Flags().GetBool("until-converged"); !untilConverged {
 		t.Error("untilConverged = false, want true")
 	}
 }
 
 // TestAgentsCommand_Exists tests the agents command exists
 func TestAgentsCommand_Exists(t *testing.T) {
-	if agentsCmd == nil {
-		t.Fatal("agentsCmd is nil")
-	}
-
+	agentsCmd := subcommand(t, newRootCmd(), "agents")
 	if agentsCmd.Use != "agents" {
 		t.Errorf("agentsCmd.Use = %q, want %q", agentsCmd.Use, "agents")
 	}
@@ -225,6 +314,7 @@ func TestAgentsCommand_Exists(t *testing.T) {
 
 // TestAgentsCommand_ListsAgents tests that agents command runs and lists agents
 func TestAgentsCommand_ListsAgents(t *testing.T) {
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"agents"})
 
 	buf := new(bytes.Buffer)
@@ -242,8 +332,41 @@ func TestAgentsCommand_ListsAgents(t *testing.T) {
 	}
 }
 
+// TestAgentsCommand_VerboseWarnsAboutConflicts tests that --verbose reports an
+// agent installed in two PATH directories
+func TestAgentsCommand_VerboseWarnsAboutConflicts(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	for _, dir := range []string{first, second} {
+		if err := os.WriteFile(filepath.Join(dir, "claude"), []byte("#!/bin/sh\necho 'claude 1.0'\n"), 0755); err != nil {
+			t.Fatalf("Failed to create mock binary: %v", err)
+		}
+	}
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"agents", "--verbose"})
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("agents --verbose should not error, got: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Warning: claude is installed in 2 places on PATH",
+		filepath.Join(first, "claude") + " (claude 1.0)",
+		filepath.Join(second, "claude") + " (claude 1.0)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
 // TestAgentsCommand_ShowsStatus tests that agents command shows agent status
 func TestAgentsCommand_ShowsStatus(t *testing.T) {
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"agents"})
 
 	buf := new(bytes.Buffer)
@@ -265,6 +388,7 @@ func TestAgentsCommand_ShowsStatus(t *testing.T) {
 
 // TestVersion tests the --version flag
 func TestVersion(t *testing.T) {
+	rootCmd := newRootCmd()
 	rootCmd.Version = "1.0.0"
 	rootCmd.SetArgs([]string{"--version"})
 
@@ -285,6 +409,7 @@ func TestVersion(t *testing.T) {
 
 // TestExecute tests the Execute function
 func TestExecute(t *testing.T) {
+	rootCmd := newRootCmd()
 	// Execute with no args should show help (not error)
 	rootCmd.SetArgs([]string{})
 
@@ -297,13 +422,14 @@ func TestExecute(t *testing.T) {
 
 // TestPlanCommand_OutputFormat tests the expected output format
 func TestPlanCommand_OutputFormat(t *testing.T) {
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "Build a REST API"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command should not error, got: %v", err)
 	}
@@ -323,31 +449,29 @@ func TestPlanCommand_OutputFormat(t *testing.T) {
 
 // TestPlanCommand_ShorthandFlags tests shorthand flag usage
 func TestPlanCommand_ShorthandFlags(t *testing.T) {
-	// Reset
-	rounds = 3
-	agentsPath = ""
-
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "-r", "7", "-a", "/path/to/agents.md", "Test"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("shorthand flags should work, got: %v", err)
 	}
 
-	if rounds != 7 {
+	if rounds, _ := subcommand(t, rootCmd, "plan").Flags().GetInt("rounds"); rounds != 7 {
 		t.Errorf("rounds = %d, want 7", rounds)
 	}
-	if agentsPath != "/path/to/agents.md" {
+	if agentsPath := rootCmd.Flag("agents-path").Value.String(); agentsPath != "/path/to/agents.md" {
 		t.Errorf("agentsPath = %q, want %q", agentsPath, "/path/to/agents.md")
 	}
 }
 
 // TestPlanCommand_SaveFlag tests the --save flag
 func TestPlanCommand_SaveFlag(t *testing.T) {
+	planCmd := subcommand(t, newRootCmd(), "plan")
 	flag := planCmd.Flags().Lookup("save")
 	if flag == nil {
 		t.Fatal("--save flag not found")
@@ -360,22 +484,20 @@ func TestPlanCommand_SaveFlag(t *testing.T) {
 
 // TestPlanCommand_SaveFlagCustomValue tests setting a bead ID to save to
 func TestPlanCommand_SaveFlagCustomValue(t *testing.T) {
-	// Reset
-	saveToBead = ""
-
+	rootCmd := newRootCmd()
 	rootCmd.SetArgs([]string{"plan", "--save", "buckshot-123", "Test prompt"})
 
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 
-	err := rootCmd.Execute()
+	err := ignoreNoAgents(rootCmd.Execute())
 	if err != nil {
 		t.Errorf("plan command with --save should not error, got: %v", err)
 	}
 
 	// Verify the flag value was set correctly
-	if saveToBead != "buckshot-123" {
+	if saveToBead, _ := subcommand(t, rootCmd, "plan").Flags().GetString("save"); saveToBead != "buckshot-123" {
 		t.Errorf("saveToBead = %q, want %q", saveToBead, "buckshot-123")
 	}
 
@@ -383,3 +505,1836 @@ func TestPlanCommand_SaveFlagCustomValue(t *testing.T) {
 	// authenticated agents are available. In CI/test environments without
 	// agents, the command exits early with "No authenticated agents available".
 }
+
+// TestPlanCommand_ConvergedPhrasesConfig tests that converged_phrases in
+// --config, like --converged-phrase, makes convergence wait for an agent to
+// say one
+func TestPlanCommand_ConvergedPhrasesConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{list: "buckshot-1 [P1] [task] open - Cache responses"})
+
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "buckshot.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"converged_phrases": ["lgtm"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(reply string) string {
+		t.Helper()
+		script := filepath.Join(dir, "agent")
+		if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo '"+reply+"'; echo '10% used'; done\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		restore := setAgentDetector(func() ([]agent.Agent, error) {
+			return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+		})
+		defer restore()
+
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs([]string{"plan", "--config", cfgPath, "--until-converged", "--rounds", "2", "--no-agents-file", "Design API"})
+		out := new(bytes.Buffer)
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(out)
+		_ = rootCmd.ExecuteContext(context.Background())
+		return out.String()
+	}
+
+	if out := run("LGTM"); !strings.Contains(out, "Converged after 1 round(s)") {
+		t.Errorf("an agent saying lgtm should converge, got:\n%s", out)
+	}
+	if out := run("Reviewed the beads"); strings.Contains(out, "Converged") {
+		t.Errorf("an agent not saying lgtm should not converge, got:\n%s", out)
+	}
+}
+
+// TestPlanCommand_ConvergedPhraseFlag tests the --converged-phrase flag
+func TestPlanCommand_ConvergedPhraseFlag(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--converged-phrase", "lgtm", "--converged-phrase", "plan is stable", "Test prompt"})
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	err := ignoreNoAgents(rootCmd.Execute())
+	if err != nil {
+		t.Errorf("plan command with --converged-phrase should not error, got: %v", err)
+	}
+
+	convergedPhrases, _ := subcommand(t, rootCmd, "plan").Flags().GetStringSlice("converged-phrase")
+	if len(convergedPhrases) != 2 || convergedPhrases[1] != "plan is stable" {
+		t.Errorf("convergedPhrases = %v, want [lgtm, plan is stable]", convergedPhrases)
+	}
+
+}
+
+// TestPlanCommand_PromptFromStdin tests reading a multi-line prompt from stdin with "-"
+func TestPlanCommand_PromptFromStdin(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "-"})
+	rootCmd.SetIn(strings.NewReader("Line one of the prompt\nLine two of the prompt\n"))
+	defer rootCmd.SetIn(nil)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	if err := ignoreNoAgents(rootCmd.Execute()); err != nil {
+		t.Fatalf("plan - should not error, got: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Line one of the prompt\nLine two of the prompt") {
+		t.Errorf("Output should contain multi-line prompt from stdin, got: %s", buf.String())
+	}
+}
+
+// TestPlanCommand_PromptFile tests reading the prompt with --prompt-file
+func TestPlanCommand_PromptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(path, []byte("Design the API\n\n- REST\n- JWT auth\n"), 0644); err != nil {
+		t.Fatalf("Failed to write prompt file: %v", err)
+	}
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--prompt-file", path})
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	if err := ignoreNoAgents(rootCmd.Execute()); err != nil {
+		t.Fatalf("plan --prompt-file should not error, got: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "Design the API\n\n- REST\n- JWT auth") {
+		t.Errorf("Output should contain prompt file content, got: %s", buf.String())
+	}
+}
+
+// TestPlanCommand_PromptSourcesMutuallyExclusive tests that a positional prompt and --prompt-file conflict
+func TestPlanCommand_PromptSourcesMutuallyExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(path, []byte("From file"), 0644); err != nil {
+		t.Fatalf("Failed to write prompt file: %v", err)
+	}
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--prompt-file", path, "From arg"})
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatal("plan with both --prompt-file and a positional prompt should error")
+	}
+	if !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Error should mention mutual exclusion, got: %v", err)
+	}
+}
+
+// TestFilterAgents_ExcludeOnly tests removing agents from the detected set
+func TestFilterAgents_ExcludeOnly(t *testing.T) {
+	agents := []agent.Agent{{Name: "claude"}, {Name: "codex"}, {Name: "gemini"}}
+
+	got := excludeAgents(agents, []string{"codex"})
+
+	if len(got) != 2 || got[0].Name != "claude" || got[1].Name != "gemini" {
+		t.Errorf("excludeAgents() = %v, want [claude gemini]", agentNames(got))
+	}
+}
+
+// TestFilterAgents_IncludeThenExclude tests composing --agents with --exclude-agents
+func TestFilterAgents_IncludeThenExclude(t *testing.T) {
+	agents := []agent.Agent{{Name: "claude"}, {Name: "codex"}, {Name: "gemini"}, {Name: "amp"}}
+
+	got := excludeAgents(filterAgents(agents, []string{"claude", "codex", "gemini"}), []string{"gemini"})
+
+	if len(got) != 2 || got[0].Name != "claude" || got[1].Name != "codex" {
+		t.Errorf("filter then exclude = %v, want [claude codex]", agentNames(got))
+	}
+}
+
+// TestResolveAgentGroups tests expanding @group names in --agents
+func TestResolveAgentGroups(t *testing.T) {
+	agents := []agent.Agent{{Name: "claude"}, {Name: "codex"}, {Name: "gemini"}, {Name: "amp"}}
+	groups := config.Config{Groups: map[string][]string{"reviewers": {"gemini", "claude"}, "fast": {"codex"}}}.AgentGroups()
+
+	tests := []struct {
+		name     string
+		selected []string
+		want     []string
+	}{
+		{"built-in group", []string{"@anthropic"}, []string{"claude", "amp"}},
+		{"every agent", []string{"@all"}, []string{"claude", "codex", "gemini", "amp"}},
+		{"config group", []string{"@reviewers"}, []string{"gemini", "claude"}},
+		{"config overrides built-in", []string{"@fast"}, []string{"codex"}},
+		{"mixed with names", []string{"codex", "@reviewers", "claude"}, []string{"codex", "gemini", "claude"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAgentGroups(tt.selected, groups, agents)
+			if err != nil {
+				t.Fatalf("resolveAgentGroups() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveAgentGroups(%v) = %v, want %v", tt.selected, got, tt.want)
+			}
+		})
+	}
+
+	_, err := resolveAgentGroups([]string{"@cheap"}, groups, agents)
+	if err == nil || !strings.Contains(err.Error(), `unknown agent group "@cheap"`) || !strings.Contains(err.Error(), "@all, @anthropic, @fast") {
+		t.Errorf("resolveAgentGroups(@cheap) error = %v, want one naming the group and the known groups", err)
+	}
+}
+
+// TestPlanCommand_AgentsGroupFromConfig tests selecting agents with a group
+// defined in --config
+func TestPlanCommand_AgentsGroupFromConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(t.TempDir(), "buckshot.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"groups": {"reviewers": ["codex"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pattern := agent.CLIPattern{PromptFraming: agent.FramingJSON}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: script, Authenticated: true, Pattern: pattern},
+			{Name: "codex", Path: script, Authenticated: true, Pattern: pattern},
+		}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--config", cfgPath, "--agents", "@reviewers", "--rounds", "1", "--no-agents-file", "Design API"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan --agents @reviewers should not error, got: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Using 1 agent(s): codex") {
+		t.Errorf("want only the group's agent, got:\n%s", stderr.String())
+	}
+}
+
+// TestPlanCommand_ExcludeAgentsConflict tests that an agent can't be both included and excluded
+func TestPlanCommand_ExcludeAgentsConflict(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--agents", "claude,codex", "--exclude-agents", "codex", "Test prompt"})
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatal("plan with an agent in both --agents and --exclude-agents should error")
+	}
+	if !strings.Contains(err.Error(), `"codex"`) {
+		t.Errorf("Error should name the conflicting agent, got: %v", err)
+	}
+}
+
+// TestPlanCommand_QuietSuppressesInfo tests that --quiet drops diagnostics but keeps results on stdout
+func TestPlanCommand_QuietSuppressesInfo(t *testing.T) {
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "fake", Path: "/nonexistent/fake", Authenticated: true}}, nil
+	})
+	defer restore()
+
+	run := func(args ...string) (string, string) {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append([]string{"plan", "--rounds", "1"}, args...))
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		rootCmd.SetOut(stdout)
+		rootCmd.SetErr(stderr)
+		if err := rootCmd.Execute(); ExitCode(err) != ExitAllFailed {
+			t.Fatalf("plan with an agent that can't start should exit %d, got: %v", ExitAllFailed, err)
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, stderr := run("Quiet test prompt")
+	if !strings.Contains(stderr, "Planning: Quiet test prompt") {
+		t.Errorf("stderr should contain info lines by default, got: %s", stderr)
+	}
+	if strings.Contains(stdout, "Planning:") {
+		t.Errorf("stdout should not contain info lines, got: %s", stdout)
+	}
+
+	stdout, stderr = run("--quiet", "Quiet test prompt")
+	if strings.Contains(stderr, "Planning:") || strings.Contains(stderr, "=== Round") {
+		t.Errorf("--quiet should suppress info lines, got stderr: %s", stderr)
+	}
+	if !strings.Contains(stdout, "Planning complete.") {
+		t.Errorf("--quiet should keep the final result on stdout, got: %s", stdout)
+	}
+}
+
+// TestPlanCommand_SystemPromptWarnsUnsupported tests the warning for agents without SystemPromptArg
+func TestPlanCommand_SystemPromptWarnsUnsupported(t *testing.T) {
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: "/nonexistent/claude", Authenticated: true, Pattern: agent.KnownAgents()["claude"]},
+			{Name: "codex", Path: "/nonexistent/codex", Authenticated: true, Pattern: agent.KnownAgents()["codex"]},
+		}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "1", "--system-prompt", "Be terse.", "Test prompt"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.Execute(); ExitCode(err) != ExitAllFailed {
+		t.Fatalf("plan with agents that can't start should exit %d, got: %v", ExitAllFailed, err)
+	}
+
+	if !strings.Contains(stderr.String(), "codex does not support a system prompt") {
+		t.Errorf("expected a warning for codex, got: %s", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "claude does not support") {
+		t.Errorf("claude supports a system prompt and should not be warned about, got: %s", stderr.String())
+	}
+}
+
+// TestPlanCommand_ResumeWarnsUnsupported tests the preflight warning for agents that can't resume
+func TestPlanCommand_ResumeWarnsUnsupported(t *testing.T) {
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: "/nonexistent/claude", Authenticated: true, Pattern: agent.KnownAgents()["claude"]},
+			{Name: "bare", Path: "/nonexistent/bare", Authenticated: true, Pattern: agent.CLIPattern{Binary: "bare"}},
+		}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "1", "--resume", "Test prompt"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.Execute(); ExitCode(err) != ExitAllFailed {
+		t.Fatalf("plan with agents that can't start should exit %d, got: %v", ExitAllFailed, err)
+	}
+
+	if !strings.Contains(stderr.String(), "bare does not support resuming sessions") {
+		t.Errorf("expected a warning for bare, got: %s", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "claude does not support") {
+		t.Errorf("claude can resume and should not be warned about, got: %s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "no agent could be started") {
+		t.Errorf("expected a warning that neither agent launched, got: %s", stderr.String())
+	}
+}
+
+// TestFormatCapabilities tests the agents command's capability listing
+func TestFormatCapabilities(t *testing.T) {
+	if got := formatCapabilities(agent.CLIPattern{}.Capabilities()); got != "none" {
+		t.Errorf("formatCapabilities(empty) = %q, want none", got)
+	}
+	got := formatCapabilities(agent.KnownAgents()["gemini"].Capabilities())
+	if got != "json-output, skip-approvals, resume" {
+		t.Errorf("formatCapabilities(gemini) = %q", got)
+	}
+}
+
+// TestRestoreConvergence tests resuming recorded convergence progress
+func TestRestoreConvergence(t *testing.T) {
+	t.Chdir(t.TempDir())
+	log := logging.New(new(bytes.Buffer), logging.LevelInfo)
+
+	// Nothing recorded: start from the beginning
+	detector := convergence.NewDetector()
+	start, err := restoreConvergence(detector, "beads", log)
+	if err != nil || start != 1 {
+		t.Fatalf("restoreConvergence() with no state = %d, %v; want 1, nil", start, err)
+	}
+
+	snapshot := convergence.Snapshot{Round: 4, ConsecutiveNoChange: 2, BeadsFingerprint: convergence.Fingerprint("beads")}
+	if err := convergence.SaveSnapshot(convergence.DefaultStatePath, snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same beads: carry on counting
+	detector = convergence.NewDetector()
+	start, err = restoreConvergence(detector, "beads", log)
+	if err != nil || start != 5 {
+		t.Fatalf("restoreConvergence() = %d, %v; want 5, nil", start, err)
+	}
+	if detector.ConsecutiveNoChangeRounds() != 2 {
+		t.Errorf("ConsecutiveNoChangeRounds() = %d, want 2", detector.ConsecutiveNoChangeRounds())
+	}
+
+	// Beads changed in between: keep the round but restart the count
+	detector = convergence.NewDetector()
+	start, err = restoreConvergence(detector, "edited beads", log)
+	if err != nil || start != 5 {
+		t.Fatalf("restoreConvergence() = %d, %v; want 5, nil", start, err)
+	}
+	if detector.ConsecutiveNoChangeRounds() != 0 {
+		t.Errorf("ConsecutiveNoChangeRounds() after beads changed = %d, want 0", detector.ConsecutiveNoChangeRounds())
+	}
+}
+
+// TestStartFailure tests that a round only aborts the run when no agent launched
+func TestStartFailure(t *testing.T) {
+	startErr := fmt.Errorf("%w: exec: not found", session.ErrStartFailed)
+	tests := []struct {
+		name    string
+		results []orchestrator.AgentResult
+		want    bool
+	}{
+		{"all failed to start", []orchestrator.AgentResult{{Error: startErr}, {Skipped: true}, {Error: startErr}}, true},
+		{"one agent ran", []orchestrator.AgentResult{{Error: startErr}, {}}, false},
+		{"other failure", []orchestrator.AgentResult{{Error: session.ErrNoOutput}}, false},
+		{"all skipped", []orchestrator.AgentResult{{Skipped: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := startFailure(orchestrator.RoundResult{AgentResults: tt.results})
+			if got := errors.Is(err, session.ErrStartFailed); got != tt.want {
+				t.Errorf("startFailure() = %v, want start failure %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlanCommand_InvalidBeadsFilter tests that unknown selectors are rejected
+func TestPlanCommand_InvalidBeadsFilter(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--filter-beads", "urgent", "Test prompt"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `unknown beads filter "urgent"`) {
+		t.Errorf("expected an unknown-filter error, got: %v", err)
+	}
+}
+
+// TestResolveWorkDir tests --workdir validation
+func TestResolveWorkDir(t *testing.T) {
+	if dir, err := resolveWorkDir(""); err != nil || dir != "" {
+		t.Errorf("resolveWorkDir(\"\") = %q, %v; want empty, nil", dir, err)
+	}
+
+	tmp := t.TempDir()
+	dir, err := resolveWorkDir(tmp)
+	if err != nil || dir != tmp {
+		t.Errorf("resolveWorkDir(%q) = %q, %v; want %q, nil", tmp, dir, err, tmp)
+	}
+
+	file := filepath.Join(tmp, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveWorkDir(file); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("resolveWorkDir(file) error = %v, want not a directory", err)
+	}
+	if _, err := resolveWorkDir(filepath.Join(tmp, "missing")); err == nil {
+		t.Error("resolveWorkDir(missing) should return an error")
+	}
+}
+
+// TestResolveAgentsFile tests explicit, discovered, and skipped instruction files
+func TestResolveAgentsFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := logging.New(new(bytes.Buffer), logging.LevelInfo)
+
+	// Nothing to discover: fall back to default instructions
+	if got, err := resolveAgentsFile("", nested, false, log); err != nil || got != "" {
+		t.Errorf("resolveAgentsFile() = %q, %v; want default instructions", got, err)
+	}
+
+	claudeMD := filepath.Join(root, "CLAUDE.md")
+	if err := os.WriteFile(claudeMD, []byte("# rules"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := resolveAgentsFile("", nested, false, log); got != claudeMD {
+		t.Errorf("resolveAgentsFile() = %q, want discovered %q", got, claudeMD)
+	}
+
+	if got, _ := resolveAgentsFile("/custom/AGENTS.md", nested, false, log); got != "/custom/AGENTS.md" {
+		t.Errorf("resolveAgentsFile() = %q, want the explicit path", got)
+	}
+
+	if got, err := resolveAgentsFile("", nested, true, log); err != nil || got != "" {
+		t.Errorf("resolveAgentsFile(skip) = %q, %v; want default instructions", got, err)
+	}
+	if _, err := resolveAgentsFile("/custom/AGENTS.md", nested, true, log); err == nil {
+		t.Error("--agents-path with --no-agents-file should be rejected")
+	}
+}
+
+// TestDetectAgents_Raw tests that --raw swaps in pass-through parsers
+func TestDetectAgents_Raw(t *testing.T) {
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Parser: &agent.ClaudeParser{}}}, nil
+	})
+	defer restore()
+
+	stream := `{"type":"result","result":"Hi"}`
+
+	agents, _ := (&rootOptions{}).detectAgents()
+	if got := agents[0].Parser.Parse(stream); got != "Hi" {
+		t.Errorf("Parse() = %q, want parsed output by default", got)
+	}
+
+	agents, _ = (&rootOptions{rawOutput: true}).detectAgents()
+	if got := agents[0].Parser.Parse(stream); got != stream {
+		t.Errorf("Parse() = %q, want raw output with --raw", got)
+	}
+}
+
+// TestRootCommand_InvalidLogLevel tests that an unknown --log-level is rejected
+func TestRootCommand_InvalidLogLevel(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--log-level", "chatty", "Test prompt"})
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "unknown log level") {
+		t.Errorf("Expected unknown log level error, got: %v", err)
+	}
+}
+
+// TestRootCommand_StateEndsWithInvocation tests that a command's logger and
+// config agents don't leak into the process or the next command
+func TestRootCommand_StateEndsWithInvocation(t *testing.T) {
+	agentDetectorMu.Lock()
+	orig := agentDetector
+	var registries []*agent.Registry
+	agentDetector = func(reg *agent.Registry) ([]agent.Agent, error) {
+		registries = append(registries, reg)
+		return []agent.Agent{{Name: "fake", Path: "/nonexistent/fake", Authenticated: true}}, nil
+	}
+	defer func() {
+		agentDetector = orig
+		agentDetectorMu.Unlock()
+	}()
+	cfgPath := filepath.Join(t.TempDir(), "buckshot.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"agents": {"local-llm": {"version_args": ["--version"], "non_interactive_args": ["run"]}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defaultLogger := logging.Default()
+
+	run := func(args ...string) string {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append([]string{"plan", "--rounds", "1", "--no-agents-file"}, args...))
+		stderr := new(bytes.Buffer)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(stderr)
+		if err := rootCmd.Execute(); ExitCode(err) != ExitAllFailed {
+			t.Fatalf("plan with an agent that can't start should exit %d, got: %v", ExitAllFailed, err)
+		}
+		return stderr.String()
+	}
+
+	run("--config", cfgPath, "--log-level", "debug", "First prompt")
+	if _, ok := registries[0].KnownAgents()["local-llm"]; !ok {
+		t.Error("the detector should be given the config's agents")
+	}
+	if logging.Default() != defaultLogger {
+		t.Error("the command should not replace the process-wide logger")
+	}
+
+	if stderr := run("--quiet", "Second prompt"); strings.Contains(stderr, "Planning:") {
+		t.Errorf("--quiet should apply to its own invocation, got stderr: %s", stderr)
+	}
+	if stderr := run("Third prompt"); !strings.Contains(stderr, "Planning: Third prompt") {
+		t.Errorf("a later invocation should not inherit --quiet, got stderr: %s", stderr)
+	}
+	if _, ok := registries[1].KnownAgents()["local-llm"]; ok {
+		t.Error("a later invocation should not see the first one's config agents")
+	}
+}
+
+// TestParseAgentArgs tests that --agent-arg values are grouped by agent and validated
+func TestParseAgentArgs(t *testing.T) {
+	got, err := parseAgentArgs([]string{"claude:--model=opus", "codex:-c", "claude:--verbose", "amp:a:b"})
+	if err != nil {
+		t.Fatalf("parseAgentArgs() error = %v", err)
+	}
+	want := map[string][]string{
+		"claude": {"--model=opus", "--verbose"},
+		"codex":  {"-c"},
+		"amp":    {"a:b"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("parseAgentArgs() = %v, want %v", got, want)
+	}
+
+	for _, spec := range []string{"--model=opus", ":--model=opus", "claude:"} {
+		if _, err := parseAgentArgs([]string{spec}); err == nil || !strings.Contains(err.Error(), "invalid --agent-arg") {
+			t.Errorf("parseAgentArgs(%q) error = %v, want invalid --agent-arg", spec, err)
+		}
+	}
+}
+
+// TestPlanCommand_AgentArgTargetsOneAgent tests that an --agent-arg reaches
+// only the named agent's command line
+func TestPlanCommand_AgentArgTargetsOneAgent(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	echoArgs := filepath.Join(t.TempDir(), "echo-args")
+	if err := os.WriteFile(echoArgs, []byte("#!/bin/sh\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: echoArgs, Authenticated: true, Pattern: agent.CLIPattern{NonInteractiveArgs: []string{"-p"}}},
+			{Name: "codex", Path: echoArgs, Authenticated: true, Pattern: agent.CLIPattern{NonInteractiveArgs: []string{"exec"}}},
+		}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--output", "json", "--agent-arg", "claude:--model=opus", "Question"})
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan --agent-arg should not error, got: %v", err)
+	}
+
+	var results []struct {
+		Agent    string `json:"agent"`
+		Response string `json:"response"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	commandLines := make(map[string]string)
+	for _, r := range results {
+		commandLines[r.Agent] = strings.TrimSpace(r.Response)
+	}
+	if got := commandLines["claude"]; got != "-p Question --model=opus" {
+		t.Errorf("claude command line = %q, want the extra arg appended", got)
+	}
+	if got := commandLines["codex"]; got != "exec Question" {
+		t.Errorf("codex command line = %q, want no extra arg", got)
+	}
+}
+
+// TestPlanCommand_Env tests that --env reaches the agents and --clean-env
+// keeps the rest of buckshot's environment from them
+func TestPlanCommand_Env(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("BUCKSHOT_SECRET", "leaked")
+
+	echoEnv := filepath.Join(t.TempDir(), "echo-env")
+	if err := os.WriteFile(echoEnv, []byte("#!/bin/sh\necho \"[$BUCKSHOT_CUSTOM|$BUCKSHOT_SECRET]\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: echoEnv, Authenticated: true}}, nil
+	})
+	defer restore()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--env", "BUCKSHOT_CUSTOM=a=b"}, "[a=b|leaked]"},
+		{[]string{"--env", "BUCKSHOT_CUSTOM=set", "--clean-env"}, "[set|]"},
+	}
+	for _, tt := range tests {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append(append([]string{"plan", "--single", "--output", "json"}, tt.args...), "Question"))
+		stdout := new(bytes.Buffer)
+		rootCmd.SetOut(stdout)
+		rootCmd.SetErr(new(bytes.Buffer))
+		if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+			t.Fatalf("plan %v failed: %v", tt.args, err)
+		}
+
+		var results []struct {
+			Response string `json:"response"`
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+		}
+		if len(results) != 1 || strings.TrimSpace(results[0].Response) != tt.want {
+			t.Errorf("plan %v: agent saw %+v, want %q", tt.args, results, tt.want)
+		}
+	}
+}
+
+// TestPlanCommand_InvalidEnv tests that --env values must be KEY=VALUE
+func TestPlanCommand_InvalidEnv(t *testing.T) {
+	for _, entry := range []string{"NOVALUE", "=value", "BAD KEY=1"} {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs([]string{"plan", "--env", entry, "Question"})
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --env") {
+			t.Errorf("--env %q: error = %v, want invalid --env", entry, err)
+		}
+	}
+}
+
+// TestPlanCommand_InvalidBeadsDiff tests that an unknown --beads-diff is rejected
+func TestPlanCommand_InvalidBeadsDiff(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--beads-diff", "json", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--beads-diff") {
+		t.Errorf("--beads-diff json: error = %v, want it rejected", err)
+	}
+}
+
+// TestPlanCommand_VeryVerboseLogsCommandLine tests that -vv logs the exact
+// agent command line to stderr and -v does not
+func TestPlanCommand_VeryVerboseLogsCommandLine(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	echoArgs := filepath.Join(t.TempDir(), "echo-args")
+	if err := os.WriteFile(echoArgs, []byte("#!/bin/sh\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: echoArgs, Authenticated: true, Pattern: agent.CLIPattern{NonInteractiveArgs: []string{"-p"}}},
+		}, nil
+	})
+	defer restore()
+
+	command := echoArgs + " -p 'The question'"
+	for _, tt := range []struct {
+		flag string
+		want bool
+	}{
+		{"-v", false},
+		{"-vv", true},
+		{"--verbose=2", true},
+	} {
+		t.Run(tt.flag, func(t *testing.T) {
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs([]string{"plan", "--single", tt.flag, "The question"})
+			stderr := new(bytes.Buffer)
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(stderr)
+			if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+				t.Fatalf("plan %s should not error, got: %v", tt.flag, err)
+			}
+
+			if got := strings.Contains(stderr.String(), command); got != tt.want {
+				t.Errorf("stderr contains %q = %v, want %v\n%s", command, got, tt.want, stderr.String())
+			}
+			if got := strings.Contains(stderr.String(), "[claude] output:"); got != tt.want {
+				t.Errorf("stderr contains raw output = %v, want %v\n%s", got, tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+// TestPlanCommand_VerboseSummarizesToolResults tests that -v keeps a summary
+// of each tool result an agent got, which is dropped otherwise
+func TestPlanCommand_VerboseSummarizesToolResults(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	script := filepath.Join(t.TempDir(), "claude")
+	body := "#!/bin/sh\ncat <<'EOF'\n" +
+		`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/repo/api.go"}}]}}` + "\n" +
+		`{"type":"user","message":{"content":[{"tool_use_id":"t1","type":"tool_result","content":"package api\n"}]}}` + "\n" +
+		`{"type":"result","result":"The API looks fine."}` + "\nEOF\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Parser: &agent.ClaudeParser{}}}, nil
+	})
+	defer restore()
+
+	for _, tt := range []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-v"}, true},
+	} {
+		t.Run(strings.Join(append([]string{"plan"}, tt.args...), " "), func(t *testing.T) {
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs(append([]string{"plan", "--single", "Review the API"}, tt.args...))
+			stdout := new(bytes.Buffer)
+			rootCmd.SetOut(stdout)
+			rootCmd.SetErr(new(bytes.Buffer))
+			if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+				t.Fatalf("plan should not error, got: %v", err)
+			}
+
+			if !strings.Contains(stdout.String(), "The API looks fine.") {
+				t.Errorf("answer missing from output:\n%s", stdout.String())
+			}
+			if got := strings.Contains(stdout.String(), "[read api.go: 1 line]"); got != tt.want {
+				t.Errorf("output contains the tool summary = %v, want %v\n%s", got, tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+// TestPlanCommand_InvalidAgentArg tests that a malformed --agent-arg is rejected
+func TestPlanCommand_InvalidAgentArg(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--agent-arg", "--model=opus", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "invalid --agent-arg") {
+		t.Errorf("Expected invalid --agent-arg error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_InvalidSkipIfContextAbove tests that the threshold must be a fraction
+func TestPlanCommand_InvalidSkipIfContextAbove(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--skip-if-context-above", "90", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--skip-if-context-above must be between 0 and 1") {
+		t.Errorf("Expected --skip-if-context-above range error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_NegativeOutputLimits tests that response limits can't be negative
+func TestPlanCommand_NegativeOutputLimits(t *testing.T) {
+	for _, flag := range []string{"--response-budget", "--max-output-tokens"} {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs([]string{"plan", flag, "-1", "Question"})
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		err := rootCmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "must not be negative") {
+			t.Errorf("%s -1: expected a negative value error, got: %v", flag, err)
+		}
+	}
+}
+
+// TestPlanCommand_RoundTimeout tests that --round-timeout cuts a round short,
+// skipping the agents still waiting, and the run moves on to the next round
+func TestPlanCommand_RoundTimeout(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do sleep 0.4; echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var agents []agent.Agent
+	for _, name := range []string{"claude", "codex", "gemini"} {
+		agents = append(agents, agent.Agent{Name: name, Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}})
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) { return agents, nil })
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "2", "--round-timeout", "600ms", "--no-agents-file", "Design API"})
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan failed: %v\n%s", err, out.String())
+	}
+
+	if got := strings.Count(out.String(), "Changes: 0, Failed: 1, Skipped: 1"); got != 2 {
+		t.Errorf("want both rounds cut short after the first agent, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), orchestrator.SkipRoundTimeout) {
+		t.Errorf("summary should give the skip reason, got:\n%s", out.String())
+	}
+}
+
+// TestPlanCommand_InvalidApprovalMode tests that an unknown --approval-mode is rejected
+func TestPlanCommand_InvalidApprovalMode(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--approval-mode", "sometimes", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `unknown approval mode "sometimes"`) {
+		t.Errorf("Expected unknown approval mode error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_InvalidPermissions tests that --permissions entries must be
+// tool:allow or tool:deny
+func TestPlanCommand_InvalidPermissions(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--permissions", "launch-process:allow,web-fetch", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `--permissions: invalid tool permission "web-fetch"`) {
+		t.Errorf("Expected an invalid tool permission error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_PermissionsWarnsForBlanketAgents tests that agents without
+// per-tool permissions are warned about and still run
+func TestPlanCommand_PermissionsWarnsForBlanketAgents(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setupSingle(t, "Answer")
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--agents", "claude", "--permissions", "launch-process:allow", "Question"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("plan --permissions should not error, got: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "claude has no per-tool permissions; ignoring --permissions") {
+		t.Errorf("want a warning for claude, got:\n%s", stderr.String())
+	}
+}
+
+// TestPlanCommand_InvalidSaveMode tests that an unknown --save-mode is rejected
+func TestPlanCommand_InvalidSaveMode(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--save", "buckshot-1", "--save-mode", "merge", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `--save-mode: invalid save mode "merge"`) {
+		t.Errorf("Expected invalid save mode error, got: %v", err)
+	}
+}
+
+// TestParseRateLimits tests parsing --rate-limit values
+func TestParseRateLimits(t *testing.T) {
+	got, err := parseRateLimits([]string{"claude=3/min", " anthropic = 10/30s"})
+	if err != nil {
+		t.Fatalf("parseRateLimits() error = %v", err)
+	}
+	want := map[string]dispatch.Rate{
+		"claude":    {Count: 3, Per: time.Minute},
+		"anthropic": {Count: 10, Per: 30 * time.Second},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRateLimits() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"claude", "=3/min", "claude=3", "claude=fast"} {
+		if _, err := parseRateLimits([]string{bad}); err == nil || !strings.Contains(err.Error(), "invalid --rate-limit") {
+			t.Errorf("parseRateLimits(%q) error = %v, want invalid --rate-limit", bad, err)
+		}
+	}
+}
+
+// listedBeads is a beads client that only answers bd list.
+type listedBeads struct {
+	list string
+	err  error
+}
+
+func (b listedBeads) Create(ctx context.Context, opts beads.CreateOpts) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (b listedBeads) Update(ctx context.Context, id string, opts beads.UpdateOpts) error {
+	return errors.New("not implemented")
+}
+
+func (b listedBeads) List(ctx context.Context, opts beads.ListOpts) (string, error) {
+	return b.list, b.err
+}
+
+func (b listedBeads) ListIssues(ctx context.Context, opts beads.ListOpts) ([]beads.Issue, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b listedBeads) Show(ctx context.Context, id string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (b listedBeads) Comment(ctx context.Context, id, author, text string) error {
+	return errors.New("not implemented")
+}
+
+func (b listedBeads) Available(ctx context.Context) bool {
+	return true
+}
+
+// missingBeads is a beads client for a machine without bd.
+type missingBeads struct {
+	listedBeads
+}
+
+func (missingBeads) Available(ctx context.Context) bool {
+	return false
+}
+
+// TestPlanCommand_NoBeads tests that without bd the agents still run on the
+// prompt alone, --save is dropped and convergence comes from agent output
+func TestPlanCommand_NoBeads(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, missingBeads{listedBeads{err: errors.New("bd: not found")}})
+
+	// The agent answers one prompt from stdin and exits
+	script := filepath.Join(t.TempDir(), "agent")
+	body := "#!/bin/sh\necho '10% used'\nread -r line\necho 'No changes needed, the plan is complete'\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--until-converged", "--save", "buckshot-1", "--no-agents-file", "Design API"})
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan without bd should not error, got: %v\n%s", err, stderr.String())
+	}
+
+	if n := strings.Count(stderr.String(), "bd is not installed"); n != 1 {
+		t.Errorf("no-beads notice printed %d time(s), want once:\n%s", n, stderr.String())
+	}
+	if strings.Contains(stderr.String(), "Saving perspectives") {
+		t.Errorf("--save should be disabled without bd:\n%s", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Converged after 1 round(s)") {
+		t.Errorf("want convergence from the agent's no-change signal, got:\n%s", stdout.String())
+	}
+}
+
+// commentedBeads is a beads client that records bd comments.
+type commentedBeads struct {
+	listedBeads
+	authors []string
+}
+
+func (b *commentedBeads) Comment(ctx context.Context, id, author, text string) error {
+	b.authors = append(b.authors, id+" "+author)
+	return nil
+}
+
+// TestPlanCommand_SaveAsComments tests that --save-as-comments posts one
+// comment per agent, authored by that agent
+func TestPlanCommand_SaveAsComments(t *testing.T) {
+	t.Chdir(t.TempDir())
+	client := &commentedBeads{}
+	setBeadsClient(t, client)
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nread -r line\necho 'Split the work in two'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: script, Authenticated: true},
+			{Name: "codex", Path: script, Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "1", "--save", "buckshot-1", "--save-as-comments", "--no-agents-file", "Design API"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan --save-as-comments should not error, got: %v\n%s", err, stderr.String())
+	}
+
+	want := []string{"buckshot-1 claude", "buckshot-1 codex"}
+	if strings.Join(client.authors, ",") != strings.Join(want, ",") {
+		t.Errorf("comments = %q, want %q", client.authors, want)
+	}
+}
+
+// TestPlanCommand_SaveMinSuccess tests that --no-save-on-failure and
+// --save-min-success skip saving rounds with too few successful agents
+func TestPlanCommand_SaveMinSuccess(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nread -r line\necho 'Split the work in two'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	for _, tt := range []struct {
+		name      string
+		succeeded int
+		args      []string
+		saved     bool
+	}{
+		{"no guard saves failed rounds", 0, nil, true},
+		{"zero successes", 0, []string{"--no-save-on-failure"}, false},
+		{"one success", 1, []string{"--no-save-on-failure"}, true},
+		{"below threshold", 1, []string{"--save-min-success", "2"}, false},
+		{"at threshold", 2, []string{"--save-min-success", "2"}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			setBeadsClient(t, &commentedBeads{})
+			restore := setAgentDetector(func() ([]agent.Agent, error) {
+				agents := []agent.Agent{
+					{Name: "claude", Path: missing, Authenticated: true},
+					{Name: "codex", Path: missing, Authenticated: true},
+				}
+				for i := 0; i < tt.succeeded; i++ {
+					agents[i].Path = script
+				}
+				return agents, nil
+			})
+			defer restore()
+
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs(append([]string{"plan", "--rounds", "1", "--save", "buckshot-1", "--save-as-comments", "--no-agents-file", "Design API"}, tt.args...))
+			stderr := new(bytes.Buffer)
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(stderr)
+			_ = rootCmd.ExecuteContext(context.Background())
+
+			if got := strings.Contains(stderr.String(), "Saved round 1 perspectives"); got != tt.saved {
+				t.Errorf("saved = %v, want %v\n%s", got, tt.saved, stderr.String())
+			}
+			if !tt.saved && !strings.Contains(stderr.String(), "not saving round 1 perspectives") {
+				t.Errorf("skipping the save should be reported, got:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+// TestPlanCommand_InvalidSaveMinSuccess tests that the save guards need
+// --save and a non-negative threshold
+func TestPlanCommand_InvalidSaveMinSuccess(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--no-save-on-failure"}, "require --save"},
+		{[]string{"--save-min-success", "2"}, "require --save"},
+		{[]string{"--save", "buckshot-1", "--save-min-success", "-1"}, "--save-min-success must not be negative"},
+	} {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append([]string{"plan", "Question"}, tt.args...))
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected %q error, got: %v", tt.args, tt.want, err)
+		}
+	}
+}
+
+// TestPlanCommand_InvalidNoteLimits tests that --note-max-length and
+// --note-sidecar-dir are checked against each other and --save
+func TestPlanCommand_InvalidNoteLimits(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--save", "buckshot-1", "--note-max-length", "-1"}, "--note-max-length must not be negative"},
+		{[]string{"--note-max-length", "2000"}, "--note-max-length requires --save"},
+		{[]string{"--save", "buckshot-1", "--note-sidecar-dir", "notes"}, "--note-sidecar-dir requires --note-max-length"},
+		{[]string{"--save", "buckshot-1", "--save-as-comments", "--note-max-length", "2000"}, "cannot be used with --save-as-comments"},
+	} {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append([]string{"plan", "Question"}, tt.args...))
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected %q error, got: %v", tt.args, tt.want, err)
+		}
+	}
+}
+
+// TestPlanCommand_SaveAsCommentsNeedsSave tests that --save-as-comments
+// without a bead to save to is rejected
+func TestPlanCommand_SaveAsCommentsNeedsSave(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--save-as-comments", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--save-as-comments requires --save") {
+		t.Errorf("Expected a missing --save error, got: %v", err)
+	}
+}
+
+// thresholdDetector records the threshold the plan command sets.
+type thresholdDetector struct {
+	convergence.Detector
+	threshold int
+}
+
+func (d *thresholdDetector) SetThreshold(n int) {
+	d.threshold = n
+	d.Detector.SetThreshold(n)
+}
+
+// TestPlanCommand_ConvergedRounds tests that --converged-rounds reaches the
+// detector and that an explicit --rounds still caps the run
+func TestPlanCommand_ConvergedRounds(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	detector := &thresholdDetector{Detector: convergence.NewDetector()}
+	orig := newConvergenceDetector
+	newConvergenceDetector = func() convergence.Detector { return detector }
+	defer func() { newConvergenceDetector = orig }()
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nread -r line\necho 'No changes needed'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--until-converged", "--converged-rounds", "3", "--rounds", "2", "--no-agents-file", "Design API"})
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); ExitCode(err) != ExitNotConverged {
+		t.Fatalf("plan capped before converging should exit %d, got: %v\n%s", ExitNotConverged, err, stderr.String())
+	}
+
+	if detector.threshold != 3 {
+		t.Errorf("SetThreshold(%d), want 3", detector.threshold)
+	}
+	if !strings.Contains(stdout.String(), "Stopped after round 2 without converging") {
+		t.Errorf("want the run capped at --rounds before 3 quiet rounds, got:\n%s", stdout.String())
+	}
+}
+
+// TestPlanCommand_MaxRounds tests that --max-rounds bounds a run that never
+// converges and that hitting it is reported apart from converging
+func TestPlanCommand_MaxRounds(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	// The agent creates a bead every turn, so the plan never settles
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Created issue: buckshot-a1'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--until-converged", "--max-rounds", "3", "--no-agents-file", "Design API"})
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.ExecuteContext(context.Background())
+
+	if ExitCode(err) != ExitNotConverged {
+		t.Errorf("exit code = %d (%v), want %d", ExitCode(err), err, ExitNotConverged)
+	}
+	if got := strings.Count(stdout.String(), "Changes: "); got != 3 {
+		t.Errorf("ran %d rounds, want 3", got)
+	}
+	if !strings.Contains(stdout.String(), "Stopped at max rounds (3) without convergence") || strings.Contains(stdout.String(), "Converged after") {
+		t.Errorf("want the max-rounds cap reported, got:\n%s", stdout.String())
+	}
+}
+
+// TestPlanCommand_InvalidMaxRounds tests that --max-rounds must be positive
+func TestPlanCommand_InvalidMaxRounds(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--until-converged", "--max-rounds", "0", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--max-rounds must be at least 1") {
+		t.Errorf("--max-rounds 0: error = %v, want it rejected", err)
+	}
+}
+
+// TestPlanCommand_InvalidConvergedRounds tests that --converged-rounds must be positive
+func TestPlanCommand_InvalidConvergedRounds(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--until-converged", "--converged-rounds", "0", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--converged-rounds must be at least 1") {
+		t.Errorf("Expected a --converged-rounds error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_ReusesSessions tests that each agent is started once and
+// answers every round in the same session, with or without --warm-up
+func TestPlanCommand_ReusesSessions(t *testing.T) {
+	for _, flags := range [][]string{nil, {"--warm-up"}} {
+		t.Run(strings.Join(append([]string{"plan"}, flags...), " "), func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			setBeadsClient(t, listedBeads{})
+
+			dir := t.TempDir()
+			script := filepath.Join(dir, "agent")
+			starts := filepath.Join(dir, "starts")
+			body := "#!/bin/sh\necho started >> " + starts + "\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"
+			if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			restore := setAgentDetector(func() ([]agent.Agent, error) {
+				return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+			})
+			defer restore()
+
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs(append([]string{"plan", "--rounds", "3", "--no-agents-file", "Design API"}, flags...))
+			stderr := new(bytes.Buffer)
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(stderr)
+			if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+				t.Fatalf("plan should not error, got: %v\n%s", err, stderr.String())
+			}
+
+			data, err := os.ReadFile(starts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := strings.Count(string(data), "started"); n != 1 {
+				t.Errorf("agent started %d times over 3 rounds, want 1", n)
+			}
+		})
+	}
+}
+
+// TestPlanCommand_RoundDelay tests that --round-delay pauses between rounds
+// and that cancelling during the pause stops the run promptly
+func TestPlanCommand_RoundDelay(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	run := func(ctx context.Context, delay string) (string, error) {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs([]string{"plan", "--rounds", "2", "--round-delay", delay, "--no-agents-file", "Design API"})
+		stdout := new(bytes.Buffer)
+		rootCmd.SetOut(stdout)
+		rootCmd.SetErr(new(bytes.Buffer))
+		err := rootCmd.ExecuteContext(ctx)
+		return stdout.String(), err
+	}
+
+	start := time.Now()
+	out, err := run(context.Background(), "300ms")
+	if err != nil {
+		t.Fatalf("plan --round-delay should not error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("run took %s, want at least the 300ms pause", elapsed)
+	}
+	if !strings.Contains(out, "Completed 2 round(s)") {
+		t.Errorf("want both rounds to run, got:\n%s", out)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start = time.Now()
+	if _, err := run(ctx, "1m"); err == nil || !strings.Contains(err.Error(), "plan stopped before round 2") {
+		t.Errorf("Expected the run to stop during the pause, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelled run took %s, want it to return promptly", elapsed)
+	}
+}
+
+// TestResolveBeadPrefix tests that the flag beats the config, which beats detection
+func TestResolveBeadPrefix(t *testing.T) {
+	listed := listedBeads{list: "proj-a1 [P1] [task] open - Cache\n"}
+	tests := []struct {
+		name       string
+		flag       string
+		configured string
+		client     beads.Client
+		want       string
+	}{
+		{"flag", "app-", "cfg", listed, "app"},
+		{"config", "", "cfg", listed, "cfg"},
+		{"detected", "", "", listed, "proj"},
+		{"nothing listed", "", "", listedBeads{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBeadPrefix(context.Background(), tt.flag, tt.configured, tt.client)
+			if err != nil {
+				t.Fatalf("resolveBeadPrefix() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveBeadPrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := resolveBeadPrefix(context.Background(), "a b", "", listed); err == nil {
+		t.Error("resolveBeadPrefix should reject an invalid --bead-prefix")
+	}
+	failing := listedBeads{err: errors.New("bd: database locked")}
+	if _, err := resolveBeadPrefix(context.Background(), "", "", failing); err == nil || !strings.Contains(err.Error(), "database locked") {
+		t.Errorf("resolveBeadPrefix() error = %v, want the bd list failure", err)
+	}
+	if got, err := resolveBeadPrefix(context.Background(), "", "cfg", failing); err != nil || got != "cfg" {
+		t.Errorf("resolveBeadPrefix() = %q, %v; a configured prefix needs no bd list", got, err)
+	}
+}
+
+// TestPlanCommand_SaveChecksBeadPrefix tests that --save must name a bead in
+// this project
+func TestPlanCommand_SaveChecksBeadPrefix(t *testing.T) {
+	setBeadsClient(t, listedBeads{})
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Authenticated: true}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--bead-prefix", "proj", "--save", "buckshot-1", "--no-agents-file", "Test prompt"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.ExecuteContext(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `"buckshot-1" does not start with proj-`) {
+		t.Errorf("Expected a --save prefix error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_BadPromptTemplate tests that a broken --prompt-template is
+// reported before any agent runs
+func TestPlanCommand_BadPromptTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("Prompt: {{.Promt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--prompt-template", path, "Test prompt"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "invalid prompt template "+path) || !strings.Contains(err.Error(), "Promt") {
+		t.Errorf("Expected an invalid prompt template error naming the file and field, got: %v", err)
+	}
+}
+
+// TestPlanCommand_PromptPrefixSuffix tests that --prompt-prefix and
+// --prompt-suffix reach the agent in every round
+func TestPlanCommand_PromptPrefixSuffix(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "agent")
+	prompts := filepath.Join(dir, "prompts")
+	body := "#!/bin/sh\necho '10% used'\nwhile read -r line; do printf '%s\\n' \"$line\" >> " + prompts + "; echo 'Looks good'; echo '10% used'; done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "2", "--no-agents-file", "--prompt-prefix", "Run bd list first.", "--prompt-suffix", "Be brief.", "Design API"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan should not error, got: %v\n%s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("agent got %d prompts, want 2:\n%s", len(lines), data)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, `"Run bd list first.\n\n`) || !strings.HasSuffix(line, `\n\nBe brief.\n"`) {
+			t.Errorf("round %d prompt not bracketed by the prefix and suffix: %s", i+1, line)
+		}
+	}
+}
+
+// TestLimitAgents tests that priority names win and detection order fills the rest
+func TestLimitAgents(t *testing.T) {
+	agents := []agent.Agent{{Name: "amp"}, {Name: "claude"}, {Name: "codex"}, {Name: "gemini"}}
+	tests := []struct {
+		name     string
+		n        int
+		priority []string
+		want     string
+	}{
+		{"name order", 2, nil, "amp,claude"},
+		{"priority first, run in detection order", 2, []string{"gemini", "codex"}, "codex,gemini"},
+		{"priority then fill by name", 3, []string{"gemini"}, "amp,claude,gemini"},
+		{"unknown priority names ignored", 1, []string{"cursor-agent", "codex"}, "codex"},
+		{"more places than agents", 9, nil, "amp,claude,codex,gemini"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(agentNames(limitAgents(agents, tt.n, tt.priority)), ",")
+			if got != tt.want {
+				t.Errorf("limitAgents() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLimitAgents_StableAcrossDetectionOrder tests that without a priority
+// list the same agents are kept however detection happened to order them
+func TestLimitAgents_StableAcrossDetectionOrder(t *testing.T) {
+	orders := [][]string{
+		{"gemini", "codex", "claude", "amp"},
+		{"codex", "amp", "gemini", "claude"},
+		{"claude", "gemini", "amp", "codex"},
+	}
+	for _, order := range orders {
+		var agents []agent.Agent
+		for _, name := range order {
+			agents = append(agents, agent.Agent{Name: name})
+		}
+		kept := agentNames(limitAgents(agents, 2, nil))
+		slices.Sort(kept)
+		if got := strings.Join(kept, ","); got != "amp,claude" {
+			t.Errorf("limitAgents(%v) kept %s, want amp,claude", order, got)
+		}
+	}
+}
+
+// TestPlanCommand_AgentBounds tests --max-agents capping, its interaction with
+// --agents, and --min-agents failing fast
+func TestPlanCommand_AgentBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]int
+		wantErr string
+	}{
+		{"max caps in detection order", []string{"--max-agents", "2"}, map[string]int{"claude": 1, "codex": 1}, ""},
+		{"max prefers --agents order", []string{"--agents", "gemini,claude", "--max-agents", "1"}, map[string]int{"gemini": 1}, ""},
+		{"max above available", []string{"--agents", "codex", "--max-agents", "2"}, map[string]int{"codex": 1}, ""},
+		{"min met", []string{"--min-agents", "3"}, map[string]int{"claude": 1, "codex": 1, "gemini": 1}, ""},
+		{"min not met", []string{"--min-agents", "4"}, nil, "only 3 authenticated agent(s) available, --min-agents requires 4"},
+		{"min counts after --agents", []string{"--agents", "claude", "--min-agents", "2"}, nil, "only 1 authenticated agent(s) available"},
+		{"min above max", []string{"--min-agents", "3", "--max-agents", "2"}, nil, "--min-agents (3) is greater than --max-agents (2)"},
+		{"negative", []string{"--max-agents", "-1"}, nil, "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			sends := setupSingle(t, "Answer")
+
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs(append([]string{"plan", "--single"}, append(tt.args, "Question")...))
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(new(bytes.Buffer))
+			err := rootCmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				if len(sends) != 0 {
+					t.Errorf("sends = %v, want no agent asked", sends)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("plan error = %v", err)
+			}
+			if fmt.Sprint(sends) != fmt.Sprint(tt.want) {
+				t.Errorf("sends = %v, want %v", sends, tt.want)
+			}
+		})
+	}
+}
+
+// TestPlanCommand_PromptBudget tests that a prompt filling more than
+// --prompt-budget of the smallest agent's context window is warned about
+func TestPlanCommand_PromptBudget(t *testing.T) {
+	var list strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&list, "buckshot-%d [P2] [task] open - Bead %d, padded out to look like a real title\n", i, i)
+	}
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nread -r line\necho 'Split the work in two'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		budget string
+		warns  bool
+	}{
+		{"over budget", "0.05", true},
+		{"under budget", "0.5", false},
+		{"disabled", "0", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			setBeadsClient(t, listedBeads{list: list.String()})
+			restore := setAgentDetector(func() ([]agent.Agent, error) {
+				return []agent.Agent{
+					{Name: "gemini", Path: script, Authenticated: true},
+					{Name: "claude", Path: script, Authenticated: true},
+				}, nil
+			})
+			defer restore()
+
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs([]string{"plan", "--rounds", "1", "--no-agents-file", "--prompt-budget", tt.budget, "Design API"})
+			stderr := new(bytes.Buffer)
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(stderr)
+			_ = rootCmd.ExecuteContext(context.Background())
+
+			warned := strings.Contains(stderr.String(), "over 5% of claude's 200000-token context window; narrow the beads shown with --filter-beads")
+			if warned != tt.warns {
+				t.Errorf("warned = %v, want %v\n%s", warned, tt.warns, stderr.String())
+			}
+			if got := strings.Count(stderr.String(), "--filter-beads"); got > 1 {
+				t.Errorf("warned %d times, want once", got)
+			}
+		})
+	}
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--prompt-budget", "1.5", "Design API"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--prompt-budget must be between 0 and 1") {
+		t.Errorf("--prompt-budget 1.5 should fail, got %v", err)
+	}
+}
+
+// TestPlanCommand_BeadsJSON tests that --beads-json puts the file's beads in
+// the agents' prompts in place of bd's, and can't be used to save
+func TestPlanCommand_BeadsJSON(t *testing.T) {
+	beadsFile, err := filepath.Abs(filepath.Join("..", "..", "testdata", "beads", "issues.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{list: "buckshot-zz [P0] [bug] open - From bd"})
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "agent")
+	prompts := filepath.Join(dir, "prompts")
+	body := "#!/bin/sh\necho '10% used'\nwhile read -r line; do printf '%s\\n' \"$line\" >> " + prompts + "; echo 'Looks good'; echo '10% used'; done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "1", "--no-agents-file", "--beads-json", beadsFile, "--filter-beads", "open", "Review the cache plan"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan should not error, got: %v\n%s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt := string(data)
+	for _, want := range []string{
+		"buckshot-a1 [P1] [feature] open - Add a response cache for agent sessions",
+		"Description: Explain when cached answers are dropped.",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should contain %q, got:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "From bd") {
+		t.Errorf("bd should not be read with --beads-json, got:\n%s", prompt)
+	}
+
+	rootCmd = newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--beads-json", beadsFile, "--save", "buckshot-a1", "Design API"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.ExecuteContext(context.Background()); err == nil || !strings.Contains(err.Error(), "--save cannot be used with --beads-json") {
+		t.Errorf("--save with --beads-json should fail, got %v", err)
+	}
+}
+
+// TestPlanCommand_Parallel tests that --parallel --until-converged stops once
+// a round proposes the same changes as the one before, even though every
+// round changes beads, and that --parallel can't be combined with --single
+func TestPlanCommand_Parallel(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{list: "buckshot-1 [P1] [task] open - Cache responses"})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	body := "#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'bd update buckshot-1 --status in_progress'; echo '10% used'; done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: script, Authenticated: true},
+			{Name: "codex", Path: script, Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--parallel", "--until-converged", "--rounds", "5", "--no-agents-file", "Design API"})
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan should converge, got: %v\n%s", err, stderr.String())
+	}
+	if out := stdout.String() + stderr.String(); !strings.Contains(out, "Converged after 2 round(s)") {
+		t.Errorf("plan should converge after the second round, got:\n%s", out)
+	}
+
+	rootCmd = newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--parallel", "--single", "Design API"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "single") {
+		t.Errorf("--parallel with --single should fail, got %v", err)
+	}
+}
+
+// fileListedBeads is a beads client whose bd list is the contents of a file
+// agents write to, or the embedded list until one does.
+type fileListedBeads struct {
+	listedBeads
+	path string
+}
+
+func (b fileListedBeads) List(ctx context.Context, opts beads.ListOpts) (string, error) {
+	if data, err := os.ReadFile(b.path); err == nil {
+		return string(data), nil
+	}
+	return b.list, nil
+}
+
+// TestPlanCommand_ParallelRefreshesBeads tests that without --until-converged
+// each --parallel round's prompt still shows the beads the last round left
+func TestPlanCommand_ParallelRefreshesBeads(t *testing.T) {
+	t.Chdir(t.TempDir())
+	dir := t.TempDir()
+	state := filepath.Join(dir, "beads")
+	prompts := filepath.Join(dir, "prompts")
+	setBeadsClient(t, fileListedBeads{listedBeads: listedBeads{list: "buckshot-1 [P1] [task] open - Cache responses"}, path: state})
+
+	script := filepath.Join(dir, "agent")
+	body := "#!/bin/sh\necho '10% used'\nwhile read -r line; do printf '%s\\n' \"$line\" >> " + prompts + "; echo 'buckshot-1 [P1] [task] in_progress - Cache responses' > " + state + "; echo 'bd update buckshot-1 --status in_progress'; echo '10% used'; done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--parallel", "--rounds", "2", "--no-agents-file", "Design API"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan should not error, got: %v\n%s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(sent) != 2 {
+		t.Fatalf("sent %d prompts, want 2:\n%s", len(sent), data)
+	}
+	if !strings.Contains(sent[0], "open - Cache responses") {
+		t.Errorf("round 1 prompt should show the bead open, got:\n%s", sent[0])
+	}
+	if !strings.Contains(sent[1], "in_progress - Cache responses") {
+		t.Errorf("round 2 prompt should show round 1's change, got:\n%s", sent[1])
+	}
+}
+
+// TestPlanCommand_RoundSummary tests that --append-round-summary-to-prompt
+// opens round 2's prompt with what happened in round 1
+func TestPlanCommand_RoundSummary(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{list: "buckshot-1 [P1] [task] open - Cache responses"})
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "agent")
+	prompts := filepath.Join(dir, "prompts")
+	body := "#!/bin/sh\necho '10% used'\nwhile read -r line; do printf '%s\\n' \"$line\" >> " + prompts + "; echo 'bd update buckshot-1 --status in_progress'; echo 'Cache is in progress.'; echo '10% used'; done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--append-round-summary-to-prompt", "--rounds", "2", "--no-agents-file", "Design API"})
+	stderr := new(bytes.Buffer)
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan should not error, got: %v\n%s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(sent) != 2 {
+		t.Fatalf("sent %d prompts, want 2:\n%s", len(sent), data)
+	}
+	if strings.Contains(sent[0], "Previous round summary") {
+		t.Errorf("round 1 prompt should have no summary, got:\n%s", sent[0])
+	}
+	for _, want := range []string{"Previous round summary", "claude: updated buckshot-1", "Conclusion: bd update buckshot-1 --status in_progress Cache is in progress."} {
+		if !strings.Contains(sent[1], want) {
+			t.Errorf("round 2 prompt should contain %q, got:\n%s", want, sent[1])
+		}
+	}
+}
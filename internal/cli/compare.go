@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/dispatch"
+	"github.com/michaellady/buckshot/internal/presentation"
+	"github.com/michaellady/buckshot/internal/session"
+	"github.com/spf13/cobra"
+)
+
+// compareOptions holds the flags of one compare invocation.
+type compareOptions struct {
+	*rootOptions
+	agents []string
+}
+
+// newCompareCmd builds the compare command.
+func newCompareCmd(root *rootOptions) *cobra.Command {
+	opts := &compareOptions{rootOptions: root}
+	cmd := &cobra.Command{
+		Use:   "compare <prompt>",
+		Short: "Diff two agents' perspectives on the same prompt",
+		Long: `Send the same prompt to exactly two agents and show where they agree
+and where they diverge.
+
+Responses are compared sentence by sentence: shared points are shown
+unmarked, points only the first agent made are prefixed with "-", and
+points only the second agent made with "+".
+
+Example:
+  buckshot compare --agent claude --agent codex "How should we cache sessions?"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompare(cmd, args, opts)
+		},
+	}
+	cmd.Flags().StringArrayVar(&opts.agents, "agent", nil, "Agent to compare (pass exactly twice)")
+	return cmd
+}
+
+// runOneShot runs an agent in one-shot mode. It can be overridden in tests.
+var runOneShot = session.RunOneShot
+
+func runCompare(cmd *cobra.Command, args []string, opts *compareOptions) error {
+	out := cmd.OutOrStdout()
+	prompt := args[0]
+
+	if len(opts.agents) != 2 {
+		return fmt.Errorf("compare needs exactly two agents (got %d); pass --agent twice", len(opts.agents))
+	}
+	if opts.agents[0] == opts.agents[1] {
+		return fmt.Errorf("compare needs two different agents, got %q twice", opts.agents[0])
+	}
+
+	if _, err := opts.loadConfig(); err != nil {
+		return err
+	}
+
+	agents, err := opts.detectAgents()
+	if err != nil {
+		return fmt.Errorf("failed to detect agents: %w", err)
+	}
+
+	// Resolve both agents in the order given
+	pair := make([]agent.Agent, 0, 2)
+	for _, name := range opts.agents {
+		found := filterAgents(agents, []string{name})
+		if len(found) == 0 {
+			return fmt.Errorf("agent %q not found", name)
+		}
+		if !found[0].Authenticated {
+			return fmt.Errorf("agent %q is not authenticated", name)
+		}
+		pair = append(pair, found[0])
+	}
+
+	opts.logger.Infof("Comparing %s and %s...", pair[0].Name, pair[1].Name)
+
+	results := make([]presentation.AgentResult, len(pair))
+	var wg sync.WaitGroup
+	for i, ag := range pair {
+		wg.Add(1)
+		go func(i int, ag agent.Agent) {
+			defer wg.Done()
+			start := time.Now()
+			res, err := runOneShot(cmd.Context(), ag, prompt)
+			results[i] = presentation.AgentResult{
+				Result: dispatch.Result{
+					Agent:    ag,
+					Response: session.Response{Output: res.Output, TokenUsage: res.TokenUsage},
+					Error:    err,
+				},
+				Duration: time.Since(start),
+			}
+		}(i, ag)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Error != nil {
+			return fmt.Errorf("agent %s failed: %w", r.Agent.Name, r.Error)
+		}
+	}
+
+	_, _ = fmt.Fprint(out, presentation.FormatComparison(presentation.Compare(results[0], results[1]), opts.colorEnabled(out)))
+	return nil
+}
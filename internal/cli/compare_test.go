@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// TestCompareCommand_DiffsTwoAgents tests that both agents get the prompt and the diff is printed
+func TestCompareCommand_DiffsTwoAgents(t *testing.T) {
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Authenticated: true},
+			{Name: "codex", Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	responses := map[string]string{
+		"claude": "Cache sessions in Redis. Expire after an hour.",
+		"codex":  "Cache sessions in Redis. Use sliding expiry.",
+	}
+	origRun := runOneShot
+	runOneShot = func(ctx context.Context, ag agent.Agent, prompt string) (session.OneShotResult, error) {
+		if prompt != "How should we cache?" {
+			t.Errorf("%s got prompt %q", ag.Name, prompt)
+		}
+		return session.OneShotResult{Output: responses[ag.Name]}, nil
+	}
+	defer func() {
+		runOneShot = origRun
+	}()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"compare", "--agent", "claude", "--agent", "codex", "How should we cache?"})
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("compare should not error, got: %v", err)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"--- claude\n+++ codex\n",
+		"  Cache sessions in Redis.\n",
+		"- Expire after an hour.\n",
+		"+ Use sliding expiry.\n",
+		"Shared: 1, only claude: 1, only codex: 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestCompareCommand_RequiresTwoAgents tests agent count validation
+func TestCompareCommand_RequiresTwoAgents(t *testing.T) {
+	for _, args := range [][]string{
+		{"compare", "--agent", "claude", "prompt"},
+		{"compare", "--agent", "claude", "--agent", "claude", "prompt"},
+		{"compare", "--agent", "a", "--agent", "b", "--agent", "c", "prompt"},
+	} {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(args)
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("%v should be rejected", args)
+		}
+	}
+}
+
+// TestCompareCommand_Color tests that the diff is colored on a terminal unless --no-color is passed
+func TestCompareCommand_Color(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Authenticated: true},
+			{Name: "codex", Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	origRun := runOneShot
+	runOneShot = func(ctx context.Context, ag agent.Agent, prompt string) (session.OneShotResult, error) {
+		return session.OneShotResult{Output: ag.Name + " says hi."}, nil
+	}
+	forceTTY = true
+	defer func() {
+		runOneShot = origRun
+		forceTTY = false
+	}()
+
+	run := func(extra ...string) string {
+		args := append([]string{"compare", "--agent", "claude", "--agent", "codex"}, extra...)
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append(args, "Say hi"))
+		stdout := new(bytes.Buffer)
+		rootCmd.SetOut(stdout)
+		rootCmd.SetErr(new(bytes.Buffer))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("compare should not error, got: %v", err)
+		}
+		return stdout.String()
+	}
+
+	if out := run(); !strings.Contains(out, "\x1b[31m- claude says hi.") {
+		t.Errorf("expected colored diff on a terminal, got %q", out)
+	}
+	if out := run("--no-color"); strings.Contains(out, "\x1b[") {
+		t.Errorf("--no-color output should have no escape codes, got %q", out)
+	}
+}
+
+// TestColorEnabled tests the terminal, --no-color and NO_COLOR rules
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	defer func() {
+		forceTTY = false
+	}()
+
+	opts := &rootOptions{}
+	if opts.colorEnabled(new(bytes.Buffer)) {
+		t.Error("a buffer is not a terminal and should not be colored")
+	}
+
+	forceTTY = true
+	if !opts.colorEnabled(new(bytes.Buffer)) {
+		t.Error("forced TTY should be colored")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if opts.colorEnabled(new(bytes.Buffer)) {
+		t.Error("NO_COLOR should disable color")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	opts.noColor = true
+	if opts.colorEnabled(new(bytes.Buffer)) {
+		t.Error("--no-color should disable color")
+	}
+}
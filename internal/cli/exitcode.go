@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for how a plan run ended, so scripts can tell them apart.
+// Any other error exits 1.
+const (
+	// ExitConverged means the run converged, or ran the rounds it was given
+	// without --until-converged.
+	ExitConverged = 0
+	// ExitNotConverged means an --until-converged run hit its round cap.
+	ExitNotConverged = 10
+	// ExitAllFailed means every agent failed on every turn.
+	ExitAllFailed = 20
+	// ExitNoAgents means no authenticated agent was available.
+	ExitNoAgents = 30
+)
+
+// ExitError is a plan outcome that should end the process with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the process exit code for an error returned by Execute:
+// 0 for nil, the code of an ExitError and 1 for anything else.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	}
+	return 1
+}
+
+// exitWith ends cmd with an outcome's exit code. The outcome has already
+// been reported, so cobra is kept from printing err and usage again.
+func exitWith(cmd *cobra.Command, code int, err error) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &ExitError{Code: code, Err: err}
+}
+
+// allFailed reports whether every agent turn in the run failed. Skipped
+// agents count neither way.
+func allFailed(history []orchestrator.RoundResult) bool {
+	failed := false
+	for _, result := range history {
+		for _, ar := range result.AgentResults {
+			switch {
+			case ar.Skipped:
+			case ar.Error != nil:
+				failed = true
+			default:
+				return false
+			}
+		}
+	}
+	return failed
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// TestPlanCommand_ExitCodes tests the exit code each way a plan run can end
+func TestPlanCommand_ExitCodes(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	working := agent.Agent{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}
+
+	tests := []struct {
+		name   string
+		agents []agent.Agent
+		args   []string
+		want   int
+	}{
+		{"converged", []agent.Agent{working}, []string{"--until-converged"}, ExitConverged},
+		{"fixed rounds", []agent.Agent{working}, []string{"--rounds", "2"}, ExitConverged},
+		{"round cap", []agent.Agent{working}, []string{"--until-converged", "--converged-rounds", "3", "--rounds", "2"}, ExitNotConverged},
+		{"all agents failed", []agent.Agent{{Name: "claude", Path: "/nonexistent/claude", Authenticated: true}}, []string{"--rounds", "2"}, ExitAllFailed},
+		{"no authenticated agents", []agent.Agent{{Name: "claude", Path: script}}, nil, ExitNoAgents},
+		{"no authenticated agents with --min-agents", []agent.Agent{{Name: "claude", Path: script}}, []string{"--min-agents", "2"}, ExitNoAgents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			setBeadsClient(t, listedBeads{})
+			restore := setAgentDetector(func() ([]agent.Agent, error) { return tt.agents, nil })
+			defer restore()
+
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs(append(append([]string{"plan", "--no-agents-file"}, tt.args...), "Design API"))
+			stderr := new(bytes.Buffer)
+			rootCmd.SetOut(new(bytes.Buffer))
+			rootCmd.SetErr(stderr)
+			err := rootCmd.ExecuteContext(context.Background())
+
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("exit code = %d (%v), want %d", got, err, tt.want)
+			}
+			if bytes.Contains(stderr.Bytes(), []byte("Usage:")) {
+				t.Errorf("an outcome should not print usage, got:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+// TestExitCode tests the exit code for errors that aren't plan outcomes
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"outcome", &ExitError{Code: ExitNoAgents, Err: errors.New("no agents")}, ExitNoAgents},
+		{"wrapped outcome", fmt.Errorf("plan: %w", &ExitError{Code: ExitAllFailed, Err: errors.New("failed")}), ExitAllFailed},
+		{"other error", errors.New("bad flag"), 1},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestAllFailed tests that a run counts as failed only if no turn succeeded
+func TestAllFailed(t *testing.T) {
+	failed := orchestrator.AgentResult{Error: errors.New("crashed")}
+	skipped := orchestrator.AgentResult{Skipped: true}
+	ok := orchestrator.AgentResult{}
+
+	tests := []struct {
+		name    string
+		history []orchestrator.RoundResult
+		want    bool
+	}{
+		{"every turn failed", []orchestrator.RoundResult{{AgentResults: []orchestrator.AgentResult{failed, skipped}}, {AgentResults: []orchestrator.AgentResult{failed}}}, true},
+		{"one turn succeeded", []orchestrator.RoundResult{{AgentResults: []orchestrator.AgentResult{failed}}, {AgentResults: []orchestrator.AgentResult{ok}}}, false},
+		{"all skipped", []orchestrator.RoundResult{{AgentResults: []orchestrator.AgentResult{skipped}}}, false},
+		{"no rounds", nil, false},
+	}
+	for _, tt := range tests {
+		if got := allFailed(tt.history); got != tt.want {
+			t.Errorf("%s: allFailed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
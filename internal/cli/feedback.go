@@ -1,98 +1,243 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
 	buckctx "github.com/michaellady/buckshot/internal/context"
-	"github.com/michaellady/buckshot/internal/session"
+	"github.com/michaellady/buckshot/internal/convergence"
+	"github.com/michaellady/buckshot/internal/logging"
+	"github.com/michaellady/buckshot/internal/notes"
 	"github.com/spf13/cobra"
 )
 
-var (
-	feedbackAgent string
-)
+// feedbackOptions holds the flags of one feedback invocation.
+type feedbackOptions struct {
+	*rootOptions
+	agent          string
+	all            bool
+	rounds         int
+	untilConverged bool
+	dedupComments  bool
+	template       string
+	promptPrefix   string
+	promptSuffix   string
+}
 
-var feedbackCmd = &cobra.Command{
-	Use:   "feedback",
-	Short: "Run single-agent feedback mode (comment-only)",
-	Long: `Run a single agent in feedback mode to review and comment on beads.
+// newFeedbackCmd builds the feedback command.
+func newFeedbackCmd(root *rootOptions) *cobra.Command {
+	opts := &feedbackOptions{rootOptions: root}
+	cmd := &cobra.Command{
+		Use:   "feedback",
+		Short: "Run single-agent feedback mode (comment-only)",
+		Long: `Run a single agent in feedback mode to review and comment on beads.
 
 In feedback mode, agents can only add comments to existing beads - they cannot
 create new beads or modify descriptions. This provides a safe way to gather
 feedback from different AI agents.
 
-Example:
-  buckshot feedback --agent claude --agents-path /path/to/AGENTS.md`,
-	RunE: runFeedback,
+With --all, every authenticated agent comments in turn, each seeing the
+comments left before it.
+
+With --rounds, the agents comment again each round, answering the comments
+left since their last turn. With --until-converged, rounds continue until one
+adds no new comments (an explicit --rounds still caps the run).
+
+With --dedup-comments, agents write their comments in their reply and
+buckshot posts them, skipping any that repeat a comment already on the bead.
+
+Examples:
+  buckshot feedback --agent claude --agents-path /path/to/AGENTS.md
+  buckshot feedback --all
+  buckshot feedback --all --until-converged --rounds 5`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeedback(cmd, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.agent, "agent", "", "Agent to run in feedback mode")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Run every authenticated agent in feedback mode, one after another")
+	cmd.Flags().IntVarP(&opts.rounds, "rounds", "r", 1, "Number of feedback rounds (with --until-converged, a cap on them)")
+	cmd.Flags().BoolVar(&opts.untilConverged, "until-converged", false, "Run rounds until one adds no new comments")
+	cmd.Flags().BoolVar(&opts.dedupComments, "dedup-comments", false, "Post the agents' comments for them, skipping any that repeat one already on the bead")
+	cmd.Flags().StringVar(&opts.template, "prompt-template", "", "Go text/template file to render the feedback prompt from instead of the built-in one")
+	cmd.Flags().StringVar(&opts.promptPrefix, "prompt-prefix", "", "Standing instruction put before the feedback prompt")
+	cmd.Flags().StringVar(&opts.promptSuffix, "prompt-suffix", "", "Standing instruction put after the feedback prompt")
+	cmd.MarkFlagsMutuallyExclusive("agent", "all")
+	cmd.MarkFlagsOneRequired("agent", "all")
+	return cmd
 }
 
-func runFeedback(cmd *cobra.Command, args []string) error {
+func runFeedback(cmd *cobra.Command, opts *feedbackOptions) error {
 	out := cmd.OutOrStdout()
 
-	_, _ = fmt.Fprintf(out, "Feedback mode: %s\n", feedbackAgent)
+	if opts.all {
+		opts.logger.Infof("Feedback mode: all agents")
+	} else {
+		opts.logger.Infof("Feedback mode: %s", opts.agent)
+	}
 
-	// Detect available agents
-	agents, err := agentDetector()
-	if err != nil {
-		return fmt.Errorf("failed to detect agents: %w", err)
+	if opts.rounds < 1 {
+		return fmt.Errorf("--rounds must be at least 1")
 	}
 
-	// Find the requested agent
-	var targetAgent *agent.Agent
-	for i, a := range agents {
-		if a.Name == feedbackAgent {
-			targetAgent = &agents[i]
-			break
-		}
+	if _, err := opts.loadConfig(); err != nil {
+		return err
 	}
 
-	if targetAgent == nil {
-		return fmt.Errorf("agent %q not found", feedbackAgent)
+	// Detect available agents
+	agents, err := opts.detectAgents()
+	if err != nil {
+		return fmt.Errorf("failed to detect agents: %w", err)
 	}
 
-	if !targetAgent.Authenticated {
-		return fmt.Errorf("agent %q is not authenticated", feedbackAgent)
+	targets, err := feedbackAgents(agents, opts)
+	if err != nil {
+		return err
 	}
 
-	_, _ = fmt.Fprintf(out, "Using agent: %s\n", targetAgent.Name)
+	instructionsPath, err := resolveAgentsFile(opts.agentsPath, "", false, opts.logger)
+	if err != nil {
+		return err
+	}
 
 	// Build feedback context
-	builder := buckctx.NewBuilder()
-	planCtx, err := builder.Build("", agentsPath, 1, true)
+	beadsClient := newBeadsClient()
+	builderOpts := []buckctx.Option{
+		buckctx.WithBeadsClient(beadsClient),
+		buckctx.WithPromptPrefix(opts.promptPrefix),
+		buckctx.WithPromptSuffix(opts.promptSuffix),
+		buckctx.WithLogger(opts.logger),
+	}
+	if opts.template != "" {
+		tmpl, err := buckctx.LoadTemplate(opts.template)
+		if err != nil {
+			return err
+		}
+		builderOpts = append(builderOpts, buckctx.WithFeedbackTemplate(tmpl))
+	}
+	builder := buckctx.NewBuilder(builderOpts...)
+	planCtx, err := builder.Build(cmd.Context(), "", instructionsPath, 1, true)
 	if err != nil {
 		return fmt.Errorf("failed to build context: %w", err)
 	}
-
-	// Set feedback mode fields
 	planCtx.FeedbackMode = true
-	planCtx.AgentName = targetAgent.Name
-
-	// Format the feedback prompt
-	prompt := builder.FormatFeedback(planCtx)
+	planCtx.PostComments = opts.dedupComments
+
+	// Run rounds; --until-converged stops once a round adds no comments
+	maxRounds := opts.rounds
+	planCtx.TotalRounds = opts.rounds
+	if opts.untilConverged && !cmd.Flags().Changed("rounds") {
+		maxRounds = 100 // Safety limit
+		planCtx.TotalRounds = 0
+	}
 
-	_, _ = fmt.Fprintf(out, "Running %s in one-shot mode...\n", targetAgent.Name)
+	var errs []error
+	converged := false
+	for round := 1; round <= maxRounds; round++ {
+		if maxRounds > 1 {
+			opts.logger.Infof("\n=== Round %d ===", round)
+		}
+		planCtx.Round = round
+		before := convergence.Fingerprint(planCtx.BeadsState)
+
+		for i, target := range targets {
+			// Later agents see the comments left by the ones before them
+			if i > 0 {
+				_ = builder.RefreshBeadsState(cmd.Context(), &planCtx)
+			}
+			opts.logger.Infof("Using agent: %s", target.Name)
+			planCtx.AgentName = target.Name
+			prompt := builder.FormatFeedback(planCtx)
+
+			opts.logger.Infof("Running %s in one-shot mode...", target.Name)
+
+			// Use RunOneShot for one-shot execution (waits for process exit)
+			result, err := runOneShot(cmd.Context(), target, prompt)
+			// Still show output even if there was an error
+			if err == nil || result.Output != "" {
+				_, _ = fmt.Fprintf(out, "\n=== %s Response ===\n", target.Name)
+				_, _ = fmt.Fprintln(out, result.Output)
+			}
+			if err != nil {
+				err = fmt.Errorf("agent %s failed (exit code %d): %w", target.Name, result.ExitCode, err)
+				if !opts.all {
+					return err
+				}
+				opts.logger.Warnf("%v", err)
+				errs = append(errs, err)
+				continue
+			}
+
+			if opts.dedupComments {
+				postComments(cmd.Context(), beadsClient, target.Name, result.Output, opts.logger)
+			}
+		}
 
-	// Use RunOneShot for one-shot execution (waits for process exit)
-	result, err := session.RunOneShot(cmd.Context(), *targetAgent, prompt)
-	if err != nil {
-		// Still show output even if there was an error
-		if result.Output != "" {
-			_, _ = fmt.Fprintf(out, "\n=== %s Response ===\n", targetAgent.Name)
-			_, _ = fmt.Fprintln(out, result.Output)
+		if round == maxRounds && !opts.untilConverged {
+			break
+		}
+		// Pick up this round's comments for the next one
+		if err := builder.RefreshBeadsState(cmd.Context(), &planCtx); err != nil {
+			opts.logger.Warnf("failed to refresh beads state: %v", err)
+		}
+		if opts.untilConverged && convergence.Fingerprint(planCtx.BeadsState) == before {
+			_, _ = fmt.Fprintf(out, "\nConverged after %d round(s): no new comments\n", round)
+			converged = true
+			break
 		}
-		return fmt.Errorf("agent %s failed (exit code %d): %w", targetAgent.Name, result.ExitCode, err)
 	}
-
-	_, _ = fmt.Fprintf(out, "\n=== %s Response ===\n", targetAgent.Name)
-	_, _ = fmt.Fprintln(out, result.Output)
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
 
 	_, _ = fmt.Fprintf(out, "\nFeedback complete.\n")
+	if opts.untilConverged && !converged {
+		return exitWith(cmd, ExitNotConverged, fmt.Errorf("stopped after round %d without converging", maxRounds))
+	}
 	return nil
 }
 
-func init() {
-	feedbackCmd.Flags().StringVar(&feedbackAgent, "agent", "", "Agent to run in feedback mode (required)")
-	feedbackCmd.Flags().StringVarP(&agentsPath, "agents-path", "a", "", "Path to AGENTS.md file")
-	_ = feedbackCmd.MarkFlagRequired("agent")
+// postComments posts the comments written in an agent's reply, leaving out
+// any that repeat one already on the bead.
+func postComments(ctx context.Context, client beads.Client, author, output string, logger *logging.Logger) {
+	comments := notes.ParseComments(output)
+	if len(comments) == 0 {
+		return
+	}
+	posted, skipped, err := notes.PostComments(ctx, client, author, comments)
+	if err != nil {
+		logger.Warnf("%v", err)
+	}
+	logger.Infof("Posted %d comment(s) from %s, skipped %d repeating existing ones", posted, author, skipped)
+}
+
+// feedbackAgents returns the agents a feedback run uses: the one named by
+// --agent, or every authenticated agent with --all.
+func feedbackAgents(agents []agent.Agent, opts *feedbackOptions) ([]agent.Agent, error) {
+	if opts.all {
+		var authenticated []agent.Agent
+		for _, a := range agents {
+			if a.Authenticated {
+				authenticated = append(authenticated, a)
+			}
+		}
+		if len(authenticated) == 0 {
+			return nil, errors.New("no authenticated agents available")
+		}
+		return authenticated, nil
+	}
+
+	for _, a := range agents {
+		if a.Name != opts.agent {
+			continue
+		}
+		if !a.Authenticated {
+			return nil, fmt.Errorf("agent %q is not authenticated", opts.agent)
+		}
+		return []agent.Agent{a}, nil
+	}
+	return nil, fmt.Errorf("agent %q not found", opts.agent)
 }
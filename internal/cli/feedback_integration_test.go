@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/michaellady/buckshot/internal/session"
 	"github.com/michaellady/buckshot/internal/testutil"
 )
 
@@ -21,9 +23,6 @@ func TestFeedbackCommand_Integration_WithMockAgent(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetFeedbackFlags()
-
 	// Setup mock agent
 	mockSetup := testutil.SetupMockAgent(t, "mock-claude", testutil.DefaultMockConfig())
 
@@ -49,6 +48,7 @@ func TestFeedbackCommand_Integration_WithMockAgent(t *testing.T) {
 	defer restore()
 
 	// Run feedback command
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -88,10 +88,7 @@ func TestFeedbackCommand_RequiresAgentFlag(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset flags and also reset the cobra flag value directly
-	resetFeedbackFlags()
-	feedbackCmd.Flags().Set("agent", "")
-
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -121,9 +118,6 @@ func TestFeedbackCommand_UsesFeedbackPrompt(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	resetFeedbackFlags()
-	feedbackCmd.Flags().Set("agent", "")
-
 	// Setup mock agent that captures the prompt it receives
 	mockConfig := testutil.DefaultMockConfig()
 	mockSetup := testutil.SetupMockAgent(t, "mock-claude", mockConfig)
@@ -146,6 +140,7 @@ func TestFeedbackCommand_UsesFeedbackPrompt(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -174,9 +169,6 @@ func TestFeedbackCommand_SingleAgentOnly(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	resetFeedbackFlags()
-	feedbackCmd.Flags().Set("agent", "")
-
 	// Setup two mock agents
 	mockSetup1 := testutil.SetupMockAgent(t, "agent1", testutil.DefaultMockConfig())
 	mockSetup2 := testutil.SetupMockAgent(t, "agent2", testutil.DefaultMockConfig())
@@ -199,6 +191,7 @@ func TestFeedbackCommand_SingleAgentOnly(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -224,3 +217,91 @@ func TestFeedbackCommand_SingleAgentOnly(t *testing.T) {
 		t.Error("Output should NOT contain 'Using agent: agent2' - only agent1 should run")
 	}
 }
+
+// countedBeads is a listedBeads that counts bd list calls.
+type countedBeads struct {
+	listedBeads
+	lists *int
+}
+
+func (b countedBeads) List(ctx context.Context, opts beads.ListOpts) (string, error) {
+	*b.lists++
+	return b.listedBeads.List(ctx, opts)
+}
+
+// TestFeedbackCommand_AllAgents tests that --all runs every authenticated
+// agent in feedback mode, refreshing the beads state between them.
+func TestFeedbackCommand_AllAgents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	mockSetup1 := testutil.SetupMockAgent(t, "agent1", testutil.DefaultMockConfig())
+	mockSetup2 := testutil.SetupMockAgent(t, "agent2", testutil.DefaultMockConfig())
+	unauthenticated := mockSetup2.Agent
+	unauthenticated.Name = "agent3"
+	unauthenticated.Authenticated = false
+
+	agentsPath := testutil.CreateTestAgentsFile(t, "")
+	t.Chdir(testutil.CreateTestBeadsDir(t))
+
+	lists := 0
+	setBeadsClient(t, countedBeads{listedBeads{list: "bd-1 [P1] Open task"}, &lists})
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{mockSetup1.Agent, mockSetup2.Agent, unauthenticated}, nil
+	})
+	defer restore()
+
+	prompts := map[string]string{}
+	origRun := runOneShot
+	runOneShot = func(ctx context.Context, ag agent.Agent, prompt string) (session.OneShotResult, error) {
+		prompts[ag.Name] = prompt
+		return origRun(ctx, ag, prompt)
+	}
+	defer func() { runOneShot = origRun }()
+
+	rootCmd := newRootCmd()
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"feedback", "--all", "--agents-path", agentsPath})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		t.Fatalf("Feedback command failed: %v\nOutput: %s", err, buf.String())
+	}
+
+	for _, name := range []string{"agent1", "agent2"} {
+		prompt, ok := prompts[name]
+		if !ok {
+			t.Errorf("%s should have run", name)
+			continue
+		}
+		if !strings.Contains(prompt, "comment-only mode") || !strings.Contains(prompt, "--author "+name) {
+			t.Errorf("%s should get a feedback prompt as its own author, got:\n%s", name, prompt)
+		}
+		if !strings.Contains(buf.String(), "=== "+name+" Response ===") {
+			t.Errorf("Output should show %s's response, got:\n%s", name, buf.String())
+		}
+	}
+	if _, ok := prompts["agent3"]; ok {
+		t.Error("An unauthenticated agent should not run")
+	}
+	if lists != 2 {
+		t.Errorf("bd list called %d times, want 2 (build, then a refresh before agent2)", lists)
+	}
+}
+
+// TestFeedbackCommand_AgentAndAll tests that --agent and --all can't be combined.
+func TestFeedbackCommand_AgentAndAll(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"feedback", "--agent", "claude", "--all"})
+
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "none of the others") {
+		t.Errorf("--agent with --all should fail, got %v", err)
+	}
+}
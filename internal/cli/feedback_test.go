@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// growingBeads is a beads client whose bd list shows the comments left so far.
+type growingBeads struct {
+	listedBeads
+	comments *[]string
+}
+
+func (b growingBeads) List(ctx context.Context, opts beads.ListOpts) (string, error) {
+	return "bd-1 [P1] Open task\n" + strings.Join(*b.comments, "\n"), nil
+}
+
+// runFeedbackRounds runs feedback with args against claude and codex, each
+// leaving a comment on its first commentTurns turns, and returns the output,
+// the turns each agent took and the exit code.
+func runFeedbackRounds(t *testing.T, commentTurns int, args ...string) (string, map[string]int, int) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	var comments []string
+	setBeadsClient(t, growingBeads{comments: &comments})
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Authenticated: true},
+			{Name: "codex", Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	turns := map[string]int{}
+	origRun := runOneShot
+	runOneShot = func(ctx context.Context, ag agent.Agent, prompt string) (session.OneShotResult, error) {
+		turns[ag.Name]++
+		if turns[ag.Name] <= commentTurns {
+			comments = append(comments, fmt.Sprintf("%s comment %d", ag.Name, turns[ag.Name]))
+		}
+		return session.OneShotResult{Output: "Commented"}, nil
+	}
+	defer func() { runOneShot = origRun }()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs(append([]string{"feedback", "--all"}, args...))
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.ExecuteContext(context.Background())
+	return out.String(), turns, ExitCode(err)
+}
+
+// TestFeedbackCommand_Rounds tests that --rounds runs every agent each round
+func TestFeedbackCommand_Rounds(t *testing.T) {
+	out, turns, code := runFeedbackRounds(t, 100, "--rounds", "3")
+
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0; output:\n%s", code, out)
+	}
+	if turns["claude"] != 3 || turns["codex"] != 3 {
+		t.Errorf("turns = %v, want 3 each", turns)
+	}
+}
+
+// TestFeedbackCommand_UntilConverged tests that --until-converged stops after
+// the first round that adds no comments, and exits ExitNotConverged if
+// --rounds runs out first
+func TestFeedbackCommand_UntilConverged(t *testing.T) {
+	t.Run("converges", func(t *testing.T) {
+		out, turns, code := runFeedbackRounds(t, 2, "--until-converged")
+
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; output:\n%s", code, out)
+		}
+		if turns["claude"] != 3 || turns["codex"] != 3 {
+			t.Errorf("turns = %v, want 3 each (two commenting rounds, then a quiet one)", turns)
+		}
+		if !strings.Contains(out, "Converged after 3 round(s)") {
+			t.Errorf("output should report convergence, got:\n%s", out)
+		}
+	})
+
+	t.Run("round cap", func(t *testing.T) {
+		out, turns, code := runFeedbackRounds(t, 100, "--until-converged", "--rounds", "2")
+
+		if code != ExitNotConverged {
+			t.Errorf("exit code = %d, want %d; output:\n%s", code, ExitNotConverged, out)
+		}
+		if turns["claude"] != 2 {
+			t.Errorf("turns = %v, want 2 each", turns)
+		}
+	})
+}
+
+// TestFeedbackCommand_InvalidRounds tests that --rounds below 1 is rejected
+func TestFeedbackCommand_InvalidRounds(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"feedback", "--all", "--rounds", "0"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--rounds") {
+		t.Errorf("--rounds 0 should fail, got %v", err)
+	}
+}
+
+// postedBeads is a beads client that records comments and shows them on bd show.
+type postedBeads struct {
+	listedBeads
+	comments []string
+}
+
+func (b *postedBeads) Show(ctx context.Context, id string) (string, error) {
+	return id + ": Open task\n\n" + strings.Join(b.comments, "\n"), nil
+}
+
+func (b *postedBeads) Comment(ctx context.Context, id, author, text string) error {
+	b.comments = append(b.comments, fmt.Sprintf("[%s] %s: %s", author, id, text))
+	return nil
+}
+
+// TestFeedbackCommand_DedupComments tests that --dedup-comments has agents
+// write comments in their reply and posts them, skipping repeats
+func TestFeedbackCommand_DedupComments(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	client := &postedBeads{listedBeads: listedBeads{list: "bd-1 [P1] Open task"}}
+	setBeadsClient(t, client)
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Authenticated: true},
+			{Name: "codex", Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	replies := map[string]string{
+		"claude": "COMMENT bd-1\nAdd retries to the bd client.\nEND COMMENT\n",
+		"codex":  "COMMENT bd-1\nadd retries to the bd client\nEND COMMENT\nCOMMENT bd-1\nTime out bd calls after 30s.\nEND COMMENT\n",
+	}
+	origRun := runOneShot
+	runOneShot = func(ctx context.Context, ag agent.Agent, prompt string) (session.OneShotResult, error) {
+		if !strings.Contains(prompt, "COMMENT <issue-id>") {
+			t.Errorf("%s should be asked to write comments in its reply, got:\n%s", ag.Name, prompt)
+		}
+		return session.OneShotResult{Output: replies[ag.Name]}, nil
+	}
+	defer func() { runOneShot = origRun }()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"feedback", "--all", "--dedup-comments"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("feedback failed: %v", err)
+	}
+
+	want := []string{
+		"[claude] bd-1: Add retries to the bd client.",
+		"[codex] bd-1: Time out bd calls after 30s.",
+	}
+	if !reflect.DeepEqual(client.comments, want) {
+		t.Errorf("comments = %q, want %q", client.comments, want)
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+// runFixturePlan runs plan against the recorded fixture and returns stdout.
+func runFixturePlan(t *testing.T, fixtureDir string) string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--fixture", fixtureDir, "--rounds", "2", "--no-agents-file", "Design a response cache"})
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	// A fresh context: an earlier ExecuteContext may have left a cancelled one on rootCmd
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan --fixture failed: %v\nstderr: %s", err, stderr.String())
+	}
+	return stdout.String()
+}
+
+// TestPlanCommand_FixtureGolden replays a recorded run and checks it matches
+// the golden output, run after run. Regenerate with go test -run FixtureGolden -update.
+func TestPlanCommand_FixtureGolden(t *testing.T) {
+	fixtureDir, err := filepath.Abs(filepath.Join("..", "..", "testdata", "fixtures", "plan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := filepath.Abs(filepath.Join("testdata", "plan_fixture.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := runFixturePlan(t, fixtureDir)
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(first), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if first != string(want) {
+		t.Errorf("plan output does not match %s\ngot:\n%s\nwant:\n%s", golden, first, want)
+	}
+
+	if second := runFixturePlan(t, fixtureDir); second != first {
+		t.Errorf("replaying the fixture again gave different output\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+}
+
+// TestPlanCommand_FixtureRejectsSave tests that --save can't write to recorded beads
+func TestPlanCommand_FixtureRejectsSave(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--fixture", t.TempDir(), "--save", "buckshot-1", "Test prompt"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--save cannot be used with --fixture") {
+		t.Errorf("expected a --save/--fixture conflict, got: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/convergence"
+	"github.com/michaellady/buckshot/internal/logging"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// validateJudge checks that --semantic-convergence and --judge are given
+// together, in a run that checks for convergence.
+func validateJudge(semantic bool, judge string, converge bool) error {
+	switch {
+	case semantic && judge == "":
+		return fmt.Errorf("--semantic-convergence requires --judge")
+	case !semantic && judge != "":
+		return fmt.Errorf("--judge requires --semantic-convergence")
+	case semantic && !converge:
+		return fmt.Errorf("--semantic-convergence requires --until-converged")
+	}
+	return nil
+}
+
+// findJudge returns the detected agent named name, which must be authenticated.
+func findJudge(agents []agent.Agent, name string) (agent.Agent, error) {
+	for _, a := range agents {
+		if a.Name != name {
+			continue
+		}
+		if !a.Authenticated {
+			return agent.Agent{}, fmt.Errorf("judge %s is not authenticated", name)
+		}
+		return a, nil
+	}
+	return agent.Agent{}, fmt.Errorf("judge %s is not installed", name)
+}
+
+// newAgentJudge returns a judge that asks ag, in one-shot mode, whether a
+// quiet round's responses show the plans have stabilized. A failed run or an
+// answer that is not a clear yes counts as no.
+func newAgentJudge(ctx context.Context, ag agent.Agent, logger *logging.Logger) convergence.Judge {
+	return func(result orchestrator.RoundResult) bool {
+		res, err := runOneShot(ctx, ag, convergence.JudgePrompt(result))
+		if err != nil {
+			logger.Warnf("Judge %s failed: %v; round %d does not count as converged", ag.Name, err, result.Round)
+			return false
+		}
+		stable, err := convergence.ParseVerdict(res.Output)
+		if err != nil {
+			logger.Warnf("%v; round %d does not count as converged", err, result.Round)
+			return false
+		}
+		if stable {
+			logger.Infof("Judge %s: the plans have stabilized", ag.Name)
+		} else {
+			logger.Infof("Judge %s: the plans are still changing", ag.Name)
+		}
+		return stable
+	}
+}
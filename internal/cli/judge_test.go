@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// runJudgedPlan runs plan --until-converged with a script agent answering
+// "Looks good" and codex as the judge giving each of verdicts in turn. It
+// returns stdout, the prompts the judge was sent and the run's exit code.
+func runJudgedPlan(t *testing.T, verdicts []string, extraArgs ...string) (string, []string, int) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}},
+			{Name: "codex", Path: "/nonexistent/codex", Authenticated: true},
+		}, nil
+	})
+	defer restore()
+
+	var prompts []string
+	origRun := runOneShot
+	runOneShot = func(ctx context.Context, ag agent.Agent, prompt string) (session.OneShotResult, error) {
+		if ag.Name != "codex" {
+			t.Errorf("judge run on %s, want codex", ag.Name)
+		}
+		prompts = append(prompts, prompt)
+		verdict := verdicts[len(verdicts)-1]
+		if len(prompts) <= len(verdicts) {
+			verdict = verdicts[len(prompts)-1]
+		}
+		return session.OneShotResult{Output: verdict}, nil
+	}
+	defer func() { runOneShot = origRun }()
+
+	args := append([]string{"plan", "--agents", "claude", "--until-converged", "--semantic-convergence", "--judge", "codex", "--no-agents-file"}, extraArgs...)
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs(append(args, "Design API"))
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.ExecuteContext(context.Background())
+	if code := ExitCode(err); code != ExitConverged && code != ExitNotConverged {
+		t.Fatalf("plan --semantic-convergence should not error, got: %v", err)
+	}
+	return stdout.String(), prompts, ExitCode(err)
+}
+
+// TestPlanCommand_SemanticConvergence tests that a quiet round only converges
+// once the judge says the plans have stabilized
+func TestPlanCommand_SemanticConvergence(t *testing.T) {
+	out, prompts, code := runJudgedPlan(t, []string{"NO, still settling", "YES"})
+
+	if code != ExitConverged || !strings.Contains(out, "Converged after 2 round(s)") {
+		t.Errorf("want convergence at the judge's first yes, got exit %d:\n%s", code, out)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("judge asked %d times, want once per quiet round", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "=== claude ===\nLooks good") {
+		t.Errorf("judge prompt should quote the round's answers, got:\n%s", prompts[0])
+	}
+}
+
+// TestPlanCommand_SemanticConvergenceJudgeSaysNo tests that the judge's no
+// keeps the run going until the round cap
+func TestPlanCommand_SemanticConvergenceJudgeSaysNo(t *testing.T) {
+	out, prompts, code := runJudgedPlan(t, []string{"No."}, "--rounds", "3")
+
+	if code != ExitNotConverged || strings.Contains(out, "Converged") || !strings.Contains(out, "Stopped after round 3 without converging") {
+		t.Errorf("want no convergence while the judge says no (exit %d), got exit %d:\n%s", ExitNotConverged, code, out)
+	}
+	if len(prompts) != 3 {
+		t.Errorf("judge asked %d times, want 3", len(prompts))
+	}
+}
+
+// TestPlanCommand_SemanticConvergenceFlags tests the flag combinations that
+// are rejected
+func TestPlanCommand_SemanticConvergenceFlags(t *testing.T) {
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex"}}, nil
+	})
+	defer restore()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--until-converged", "--semantic-convergence"}, "--semantic-convergence requires --judge"},
+		{[]string{"--until-converged", "--judge", "claude"}, "--judge requires --semantic-convergence"},
+		{[]string{"--semantic-convergence", "--judge", "claude"}, "--semantic-convergence requires --until-converged"},
+		{[]string{"--until-converged", "--semantic-convergence", "--judge", "codex"}, "judge codex is not authenticated"},
+		{[]string{"--until-converged", "--semantic-convergence", "--judge", "gemini"}, "judge gemini is not installed"},
+	}
+	for _, tt := range tests {
+		rootCmd := newRootCmd()
+		rootCmd.SetArgs(append(append([]string{"plan"}, tt.args...), "Design API"))
+		rootCmd.SetOut(new(bytes.Buffer))
+		rootCmd.SetErr(new(bytes.Buffer))
+		err := rootCmd.ExecuteContext(context.Background())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("plan %v error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
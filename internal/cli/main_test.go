@@ -0,0 +1,20 @@
+//go:build !e2e
+
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+// TestMain keeps unit and integration tests from launching real agent CLIs
+// installed on the machine. Tests that need agents inject them with
+// setAgentDetector; e2e tests use real detection.
+func TestMain(m *testing.M) {
+	agentDetector = func(*agent.Registry) ([]agent.Agent, error) {
+		return nil, nil
+	}
+	os.Exit(m.Run())
+}
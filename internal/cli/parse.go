@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/spf13/cobra"
+)
+
+// parseAuto is the --agent value that tries every parser.
+const parseAuto = "auto"
+
+// parseOptions holds the flags of one parse invocation.
+type parseOptions struct {
+	*rootOptions
+	agentName string
+}
+
+// newParseCmd builds the parse command.
+func newParseCmd(root *rootOptions) *cobra.Command {
+	opts := &parseOptions{rootOptions: root}
+	cmd := &cobra.Command{
+		Use:   "parse",
+		Short: "Parse raw agent output read from stdin",
+		Long: `Read raw agent output from stdin and print what buckshot extracts from it,
+without running the agent. Useful when integrating a new agent or debugging a
+parser.
+
+--agent names the agent whose parser to use; custom agents defined under
+"agents" in the --config file count too. With --agent auto every parser is
+tried, and the one that extracted the most content is used and reported.
+
+Examples:
+  buckshot parse --agent codex < raw.jsonl
+  buckshot parse --agent auto < raw.jsonl`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runParse(cmd, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.agentName, "agent", "", "Agent whose parser to use, or auto to try them all")
+	_ = cmd.MarkFlagRequired("agent")
+	return cmd
+}
+
+func runParse(cmd *cobra.Command, opts *parseOptions) error {
+	if _, err := opts.loadConfig(); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return fmt.Errorf("failed to read agent output from stdin: %w", err)
+	}
+	raw := string(data)
+
+	var parsed string
+	if opts.agentName == parseAuto {
+		best, names, err := parseWithBest(opts.registry, raw)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Best match: %s (%d characters extracted)\n", strings.Join(names, ", "), len(best))
+		parsed = best
+	} else {
+		if _, ok := opts.registry.KnownAgents()[opts.agentName]; !ok {
+			return fmt.Errorf("unknown agent %q (known: %s, or %s)", opts.agentName, strings.Join(knownAgentNames(opts.registry), ", "), parseAuto)
+		}
+		parsed = opts.registry.Parser(opts.agentName).Parse(raw)
+	}
+
+	out := cmd.OutOrStdout()
+	_, _ = fmt.Fprint(out, parsed)
+	if !strings.HasSuffix(parsed, "\n") {
+		_, _ = fmt.Fprintln(out)
+	}
+	return nil
+}
+
+// parseWithBest runs raw through every agent's parser in reg and returns the
+// most content any of them extracted, with the names of the agents whose
+// parsers extracted it. Parsers hand back input they extracted nothing from,
+// trimmed or not, so that counts as nothing.
+func parseWithBest(reg *agent.Registry, raw string) (string, []string, error) {
+	var best string
+	var names []string
+	for _, name := range knownAgentNames(reg) {
+		parsed := strings.TrimSpace(reg.Parser(name).Parse(raw))
+		switch {
+		case parsed == "" || parsed == strings.TrimSpace(raw):
+		case len(parsed) > len(best):
+			best, names = parsed, []string{name}
+		case len(parsed) == len(best):
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("no parser extracted anything from the input")
+	}
+	return best, names, nil
+}
+
+// knownAgentNames returns the built-in agent names and those in reg, sorted.
+func knownAgentNames(reg *agent.Registry) []string {
+	var names []string
+	for name := range reg.KnownAgents() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
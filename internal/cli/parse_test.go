@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runParseCmd pipes the fixture for name into buckshot parse with args and
+// returns stdout, stderr and the error.
+func runParseCmd(t *testing.T, fixture string, args ...string) (string, string, error) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "parse", fixture+".jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs(append([]string{"parse"}, args...))
+	rootCmd.SetIn(bytes.NewReader(data))
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	err = rootCmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+// TestParseCommand tests that each agent's parser extracts its reply from a
+// fixture of that agent's raw output
+func TestParseCommand(t *testing.T) {
+	tests := map[string]string{
+		"claude":       "Split the API bead into handlers and storage.",
+		"codex":        "Add a bead for rate limiting the API.",
+		"gemini":       "The plan needs a migration bead.",
+		"auggie":       "Document the retry policy on the client bead.",
+		"cursor-agent": "Merge the two logging beads.",
+		"amp":          "Close the duplicate cache bead.",
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, _, err := runParseCmd(t, name, "--agent", name)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if !strings.Contains(out, want) {
+				t.Errorf("output = %q, want it to contain %q", out, want)
+			}
+			if strings.Contains(out, `"type"`) {
+				t.Errorf("output should not contain raw JSON, got %q", out)
+			}
+		})
+	}
+}
+
+// TestParseCommand_Auto tests that --agent auto reports the parser that
+// extracted the most content
+func TestParseCommand_Auto(t *testing.T) {
+	tests := []struct {
+		fixture string
+		match   string
+		want    string
+	}{
+		{"codex", "Best match: codex (", "Add a bead for rate limiting the API."},
+		{"gemini", "Best match: gemini (", "The plan needs a migration bead."},
+		{"claude", "Best match: amp, claude, cursor-agent (", "Split the API bead into handlers and storage."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			out, stderr, err := runParseCmd(t, tt.fixture, "--agent", "auto")
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if !strings.Contains(stderr, tt.match) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.match)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output = %q, want it to contain %q", out, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseCommand_Errors tests unknown agents and input no parser understands
+func TestParseCommand_Errors(t *testing.T) {
+	if _, _, err := runParseCmd(t, "codex", "--agent", "nope"); err == nil || !strings.Contains(err.Error(), `unknown agent "nope"`) {
+		t.Errorf("expected an unknown agent error, got: %v", err)
+	}
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"parse", "--agent", "auto"})
+	rootCmd.SetIn(strings.NewReader("plain text, not JSON\n"))
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no parser extracted anything") {
+		t.Errorf("expected a no-match error, got: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// expandPath expands a leading ~ or ~user to that user's home directory,
+// then $VAR and ${VAR} references to their environment values. Unset
+// variables expand to "", as in a shell.
+func expandPath(path string) (string, error) {
+	if strings.HasPrefix(path, "~") {
+		name, rest, _ := strings.Cut(path[1:], string(filepath.Separator))
+
+		var home string
+		if name == "" {
+			dir, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to expand %s: %w", path, err)
+			}
+			home = dir
+		} else {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", fmt.Errorf("failed to expand %s: %w", path, err)
+			}
+			home = u.HomeDir
+		}
+		path = filepath.Join(home, rest)
+	}
+	return os.ExpandEnv(path), nil
+}
+
+// pathValue is a string flag whose value is passed through expandPath when
+// set, so paths quoted past the shell still resolve.
+type pathValue struct {
+	p *string
+}
+
+// newPathValue binds a path flag to p.
+func newPathValue(p *string) *pathValue {
+	return &pathValue{p: p}
+}
+
+func (v *pathValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *pathValue) Set(s string) error {
+	expanded, err := expandPath(s)
+	if err != nil {
+		return err
+	}
+	*v.p = expanded
+	return nil
+}
+
+func (v *pathValue) Type() string {
+	return "string"
+}
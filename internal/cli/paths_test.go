@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExpandPath tests tilde and environment variable expansion
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("BUCKSHOT_DIR", "/srv/plans")
+	t.Setenv("BUCKSHOT_SUB", "plans")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"AGENTS.md", "AGENTS.md"},
+		{"~", home},
+		{"~/dev/AGENTS.md", filepath.Join(home, "dev", "AGENTS.md")},
+		{"$HOME/dev/AGENTS.md", home + "/dev/AGENTS.md"},
+		{"${BUCKSHOT_DIR}/prompt.md", "/srv/plans/prompt.md"},
+		{"~/$BUCKSHOT_SUB/x", filepath.Join(home, "plans", "x")},
+		{"/tmp/~keep", "/tmp/~keep"},
+	}
+	for _, tt := range tests {
+		got, err := expandPath(tt.path)
+		if err != nil {
+			t.Errorf("expandPath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestExpandPath_User tests ~user expansion and unknown users
+func TestExpandPath_User(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skipf("no current user: %v", err)
+	}
+
+	got, err := expandPath("~" + u.Username + "/AGENTS.md")
+	if err != nil {
+		t.Fatalf("expandPath() error = %v", err)
+	}
+	if want := filepath.Join(u.HomeDir, "AGENTS.md"); got != want {
+		t.Errorf("expandPath() = %q, want %q", got, want)
+	}
+
+	if _, err := expandPath("~no-such-buckshot-user/AGENTS.md"); err == nil {
+		t.Error("expandPath() should fail for an unknown user")
+	}
+}
+
+// TestPlanCommand_PromptFileExpanded tests that path flags are expanded when bound
+func TestPlanCommand_PromptFileExpanded(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prompt.md"), []byte("Design the cache"), 0644); err != nil {
+		t.Fatalf("Failed to write prompt file: %v", err)
+	}
+	t.Setenv("BUCKSHOT_PROMPTS", dir)
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--prompt-file", "${BUCKSHOT_PROMPTS}/prompt.md"})
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	if err := ignoreNoAgents(rootCmd.Execute()); err != nil {
+		t.Fatalf("plan --prompt-file should not error, got: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Design the cache") {
+		t.Errorf("Output should contain prompt file content, got: %s", buf.String())
+	}
+}
@@ -1,58 +1,161 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/michaellady/buckshot/internal/accounting"
 	"github.com/michaellady/buckshot/internal/agent"
-	"github.com/michaellady/buckshot/internal/convergence"
+	"github.com/michaellady/buckshot/internal/beads"
 	buckctx "github.com/michaellady/buckshot/internal/context"
+	"github.com/michaellady/buckshot/internal/convergence"
+	"github.com/michaellady/buckshot/internal/dispatch"
+	"github.com/michaellady/buckshot/internal/fixture"
+	"github.com/michaellady/buckshot/internal/logging"
 	"github.com/michaellady/buckshot/internal/notes"
 	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/michaellady/buckshot/internal/presentation"
 	"github.com/michaellady/buckshot/internal/session"
 	"github.com/spf13/cobra"
 )
 
-var (
-	rounds         int
-	agentsPath     string
-	selectedAgents []string
-	untilConverged bool
-	saveToBead     string
-	verbose        bool
-)
+// planOptions holds the flags of one plan invocation.
+type planOptions struct {
+	*rootOptions
+	rounds            int
+	selectedAgents    []string
+	untilConverged    bool
+	resumeConverge    bool
+	saveToBead        string
+	verbose           int
+	convergedPhrases  []string
+	promptFile        string
+	excludedAgents    []string
+	progressMode      string
+	progressJSONOut   bool
+	systemPrompt      string
+	workDir           string
+	resumeSessions    bool
+	noAgentsFile      bool
+	planTimeout       time.Duration
+	roundDelay        time.Duration
+	beadsFilters      []string
+	cacheResponses    bool
+	fixtureDir        string
+	beadsJSON         string
+	single            bool
+	outputFormat      string
+	jsonOutput        bool
+	maxResponseLength int
+	truncateStrategy  string
+	agentArgs         []string
+	env               []string
+	beadsDiff         string
+	cleanEnv          bool
+	beadPrefix        string
+	promptTemplate    string
+	promptPrefix      string
+	bootstrapPrompt   string
+	promptSuffix      string
+	responseBudget    int
+	maxOutputTokens   int
+	minAgents         int
+	maxAgents         int
+	agentTimeout      time.Duration
+	roundTimeout      time.Duration
+	skipContextAbove  float64
+	promptBudget      float64
+	rateLimits        []string
+	approvalMode      string
+	toolPermissions   []string
+	keepANSI          bool
+	saveMode          string
+	saveAsComments    bool
+	noSaveOnFailure   bool
+	saveMinSuccess    int
+	noteMaxLength     int
+	noteSidecarDir    string
+	maxRounds         int
+	convergedRounds   int
+	warmUp            bool
+	rotateOrder       bool
+	parallel          bool
+	rereadAgents      bool
+	roundSummary      bool
+	verifyAgentsRead  bool
+	semanticConverge  bool
+	judgeAgent        string
+}
 
 // terminalProgressReporter implements orchestrator.ProgressReporter for terminal output.
 type terminalProgressReporter struct {
-	out       io.Writer
-	startTime time.Time
+	log         *logging.Logger
+	startTime   time.Time
+	roundStart  time.Time
+	totalRounds int
+}
+
+func newTerminalProgressReporter(log *logging.Logger) *terminalProgressReporter {
+	return &terminalProgressReporter{log: log}
 }
 
-func newTerminalProgressReporter(out io.Writer) *terminalProgressReporter {
-	return &terminalProgressReporter{out: out}
+func (r *terminalProgressReporter) OnRoundStart(round, totalRounds, agents int) {
+	r.roundStart = time.Now()
+	r.totalRounds = totalRounds
+	r.log.Infof("\n  [%s] Starting with %d agent(s)", roundLabel(round, totalRounds), agents)
+}
+
+func (r *terminalProgressReporter) OnRoundComplete(round int, result orchestrator.RoundResult) {
+	elapsed := time.Since(r.roundStart)
+	r.log.Infof("  [%s] Complete: %d change(s), %d failed, %d skipped (%.1fs)",
+		roundLabel(round, r.totalRounds), result.TotalChanges, result.FailedCount, result.SkippedCount, elapsed.Seconds())
+}
+
+// roundLabel formats "Round N/M", or "Round N" when the run is open-ended.
+func roundLabel(round, totalRounds int) string {
+	if totalRounds > 0 {
+		return fmt.Sprintf("Round %d/%d", round, totalRounds)
+	}
+	return fmt.Sprintf("Round %d", round)
 }
 
 func (r *terminalProgressReporter) OnAgentStart(round, agentIndex, totalAgents int, ag agent.Agent) {
 	r.startTime = time.Now()
-	_, _ = fmt.Fprintf(r.out, "\n  [Round %d] Agent %d/%d: %s - STARTED\n", round, agentIndex, totalAgents, ag.Name)
+	r.log.Infof("\n  [Round %d] Agent %d/%d: %s - STARTED", round, agentIndex, totalAgents, ag.Name)
 }
 
 func (r *terminalProgressReporter) OnAgentComplete(round, agentIndex, totalAgents int, result orchestrator.AgentResult, beadsDiff string) {
 	elapsed := time.Since(r.startTime)
+	if result.Duration > 0 {
+		elapsed = result.Duration
+	}
 	status := "COMPLETED"
 	if result.Error != nil {
 		status = fmt.Sprintf("FAILED: %v", result.Error)
 	} else if result.Skipped {
 		status = "SKIPPED"
+		if result.SkipReason != "" {
+			status += " (" + result.SkipReason + ")"
+		}
+	} else if result.Cached {
+		status = "CACHED"
 	}
-	_, _ = fmt.Fprintf(r.out, "  [Round %d] Agent %d/%d: %s - %s (%.1fs)\n", round, agentIndex, totalAgents, result.Agent.Name, status, elapsed.Seconds())
+	r.log.Infof("  [Round %d] Agent %d/%d: %s - %s (%.1fs)", round, agentIndex, totalAgents, result.Agent.Name, status, elapsed.Seconds())
 	if beadsDiff != "" && beadsDiff != "(no changes)" && !result.Skipped {
-		_, _ = fmt.Fprintf(r.out, "  Beads diff:\n")
+		r.log.Infof("  Beads diff:")
 		// Indent the diff output
 		for _, line := range splitDiffLines(beadsDiff) {
 			if line != "" {
-				_, _ = fmt.Fprintf(r.out, "    %s\n", line)
+				r.log.Infof("    %s", line)
 			}
 		}
 	}
@@ -73,47 +176,382 @@ func splitDiffLines(s string) []string {
 	return lines
 }
 
+// newOneShotSession creates the sessions used by --single. It can be overridden in tests.
+var newOneShotSession = session.NewOneShotSession
+
+// newBeadsClient creates the bd client for plan runs. It can be overridden in tests.
+var newBeadsClient = func() beads.Client { return beads.NewClient() }
+
+// newConvergenceDetector creates the detector for --until-converged runs.
+// It can be overridden in tests.
+var newConvergenceDetector = convergence.NewDetector
+
 // agentDetector is the function used to detect agents.
 // It can be overridden in tests to inject mock agents.
 var agentDetector = defaultAgentDetector
 
-// defaultAgentDetector returns agents using the standard detector
-func defaultAgentDetector() ([]agent.Agent, error) {
+// defaultAgentDetector returns the built-in agents and those in reg using
+// the standard detector. Each check is bounded by agent.CheckTimeout.
+func defaultAgentDetector(reg *agent.Registry) ([]agent.Agent, error) {
 	detector := agent.NewDetector()
-	return detector.DetectAll()
+	detector.SetRegistry(reg)
+	return detector.DetectAll(context.Background(), false)
 }
 
-var planCmd = &cobra.Command{
-	Use:   "plan [prompt]",
-	Short: "Run multi-agent planning protocol",
-	Long: `Run the multi-agent planning protocol on a given prompt.
+// newPlanCmd builds the plan command.
+func newPlanCmd(root *rootOptions) *cobra.Command {
+	opts := &planOptions{rootOptions: root}
+	cmd := &cobra.Command{
+		Use:   "plan [prompt]",
+		Short: "Run multi-agent planning protocol",
+		Long: `Run the multi-agent planning protocol on a given prompt.
 
 Each round, all available agents take turns analyzing the prompt and current
 beads state, creating/modifying/reorganizing the plan. Agents persist across
 rounds if their context usage stays below 50%.
 
-The protocol continues for the specified number of rounds or until all agents
-report no further changes (convergence).`,
-	Args: cobra.ExactArgs(1),
-	RunE: runPlan,
+The protocol continues for the specified number of rounds or, with
+--until-converged, until all agents report no further changes for
+--converged-rounds rounds in a row (an explicit --rounds still caps the run).
+
+The prompt may be given as an argument, read from stdin by passing "-", or
+read from a file with --prompt-file.`,
+		Args: opts.args,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlan(cmd, args, opts)
+		},
+	}
+	cmd.Flags().IntVarP(&opts.rounds, "rounds", "r", 3, "Number of planning rounds (0 is the same as --single)")
+	cmd.Flags().BoolVar(&opts.single, "single", false, "Ask each agent once, in parallel, and show their answers (no rounds, beads refresh, or convergence)")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", outputTerminal, "With --single, how to show answers: terminal, json, markdown, or markdown-table")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json-output", false, "Show --single answers as JSON (same as --output json), warning about agents whose responses can only be captured as plain text")
+	cmd.MarkFlagsMutuallyExclusive("output", "json-output")
+	cmd.Flags().IntVar(&opts.maxResponseLength, "max-response-length", 1000, "With --single, truncate terminal answers longer than this (0 for no limit)")
+	cmd.Flags().StringVar(&opts.truncateStrategy, "truncate-strategy", string(presentation.TruncateHead), "With --single, which part of a long answer to keep: head, tail (where conclusions usually are), or head-tail")
+	cmd.Flags().BoolVar(&opts.noAgentsFile, "no-agents-file", false, "Don't use an AGENTS.md; send default instructions instead")
+	cmd.Flags().StringSliceVar(&opts.selectedAgents, "agents", nil, "Specific agents to use, or @group for a named group such as @anthropic, @fast or @all (default: all available)")
+	cmd.Flags().StringVar(&opts.beadsDiff, "beads-diff", "field", "How progress output describes each agent's beads changes: field (changed fields), line (bd list lines) or status (status changes only)")
+	cmd.Flags().StringArrayVar(&opts.env, "env", nil, "Set an environment variable for the agents as KEY=VALUE (repeatable)")
+	cmd.Flags().BoolVar(&opts.cleanEnv, "clean-env", false, "Run agents with only PATH, HOME and --env values instead of buckshot's whole environment")
+	cmd.Flags().StringArrayVar(&opts.agentArgs, "agent-arg", nil, "Extra arg for one agent's command line as name:arg, e.g. claude:--model=opus (repeatable)")
+	cmd.Flags().IntVar(&opts.minAgents, "min-agents", 0, "Fail unless at least this many authenticated agents are available")
+	cmd.Flags().IntVar(&opts.maxAgents, "max-agents", 0, "Use at most this many agents, preferring those listed first in --agents (default: no limit)")
+	cmd.Flags().StringSliceVar(&opts.excludedAgents, "exclude-agents", nil, "Agents to leave out (applied after --agents)")
+	cmd.Flags().BoolVar(&opts.untilConverged, "until-converged", false, "Run until all agents report no changes")
+	cmd.Flags().IntVar(&opts.maxRounds, "max-rounds", 100, "Most rounds an --until-converged run may take before it stops without converging")
+	cmd.Flags().IntVar(&opts.convergedRounds, "converged-rounds", 1, "Consecutive no-change rounds needed to declare convergence (an explicit --rounds still caps the run)")
+	cmd.Flags().BoolVar(&opts.warmUp, "warm-up", false, "Start all agent sessions in parallel before round 1 instead of each on its first turn")
+	cmd.Flags().BoolVar(&opts.rotateOrder, "rotate-order", false, "Rotate the agent order by one each round so every agent gets to go first")
+	cmd.Flags().BoolVar(&opts.parallel, "parallel", false, "Run every agent in a round at once; agents see each other's changes from the next round on, and --until-converged waits for a round proposing the same changes as the last")
+	cmd.Flags().BoolVar(&opts.roundSummary, "append-round-summary-to-prompt", false, "Open each round's prompt after the first with a summary of the previous round's bead changes and agent conclusions")
+	cmd.Flags().BoolVar(&opts.rereadAgents, "reread-agents-each-round", false, "Ask agents to read AGENTS.md every round, not just on their first turn in each session")
+	cmd.Flags().BoolVar(&opts.verifyAgentsRead, "verify-agents-read", false, "Ask each agent to echo the first line of AGENTS.md once started, and warn if it doesn't")
+	cmd.Flags().BoolVar(&opts.resumeConverge, "resume-convergence", false, "Continue an interrupted --until-converged run from its recorded round (implies --until-converged)")
+	cmd.Flags().StringVar(&opts.beadPrefix, "bead-prefix", "", "Bead ID prefix of this project, e.g. proj for proj-a1 (default: from config, else the first bead bd lists)")
+	cmd.Flags().StringVar(&opts.saveToBead, "save", "", "Save agent perspectives to specified bead ID")
+	cmd.Flags().BoolVar(&opts.saveAsComments, "save-as-comments", false, "With --save, post each agent's response as its own bd comment authored by that agent instead of writing notes")
+	cmd.Flags().BoolVar(&opts.noSaveOnFailure, "no-save-on-failure", false, "With --save, skip saving rounds in which no agent succeeded")
+	cmd.Flags().IntVar(&opts.saveMinSuccess, "save-min-success", 0, "With --save, only save rounds in which at least this many agents succeeded")
+	cmd.Flags().IntVar(&opts.noteMaxLength, "note-max-length", 0, "With --save, cut each agent's response in the notes to this many characters, marking the cut (0 for no limit)")
+	cmd.Flags().Var(newPathValue(&opts.noteSidecarDir), "note-sidecar-dir", "With --note-max-length, write each cut response in full to a file in this directory and reference it from the note")
+	cmd.Flags().StringVar(&opts.saveMode, "save-mode", string(notes.ModeAppend), "How --save treats the bead's existing notes: append (keep every round) or replace (keep only the latest round)")
+	cmd.Flags().CountVarP(&opts.verbose, "verbose", "v", "Show detailed progress with agent timing, beads diff and a summary of each tool an agent ran; -vv also logs each agent's command line, prompts and raw output")
+	cmd.Flags().StringVar(&opts.progressMode, "progress", progressLine, "Progress display: line, table (redraws in place on a terminal) or json (one object per finished turn)")
+	cmd.Flags().BoolVar(&opts.progressJSONOut, "progress-json", false, "Write one JSON object per finished agent turn to stderr (same as --progress json)")
+	cmd.Flags().BoolVar(&opts.resumeSessions, "resume", false, "Reattach agents to the sessions recorded by the previous run")
+	cmd.Flags().Var(newPathValue(&opts.workDir), "workdir", "Directory agents should work in (default: current directory)")
+	cmd.Flags().StringSliceVar(&opts.toolPermissions, "permissions", nil, "Per-tool permissions as tool:allow or tool:deny, for agents that approve tools one at a time (auggie); ignored by others")
+	cmd.Flags().StringVar(&opts.approvalMode, "approval-mode", string(agent.ApprovalYolo), "How much agents may do unasked: yolo (skip all prompts), auto_edit, or default (keep prompts); agents without a matching mode keep their own prompts")
+	cmd.Flags().BoolVar(&opts.keepANSI, "keep-ansi", false, "Keep ANSI escape codes and carriage returns in agent responses instead of stripping them")
+	cmd.Flags().StringVar(&opts.systemPrompt, "system-prompt", "", "System prompt appended for agents that support one (e.g., claude, auggie)")
+	cmd.Flags().StringVar(&opts.promptTemplate, "prompt-template", "", "Go text/template file to render each agent's prompt from instead of the built-in one")
+	cmd.Flags().StringVar(&opts.promptPrefix, "prompt-prefix", "", "Standing instruction put before every agent's prompt, every round")
+	cmd.Flags().StringVar(&opts.bootstrapPrompt, "bootstrap-prompt", "", "Extra instruction for the agent that goes first in round 1 (or the next, if it fails), e.g. \"Do a thorough initial analysis of the repository\"")
+	cmd.Flags().IntVar(&opts.responseBudget, "response-budget", 0, "Ask agents to keep each response under about this many tokens (0 to not ask)")
+	cmd.Flags().IntVar(&opts.maxOutputTokens, "max-output-tokens", 0, "Cut an agent's response off once it passes about this many tokens, restarting the agent for its next turn (0 for no limit)")
+	cmd.Flags().StringVar(&opts.promptSuffix, "prompt-suffix", "", "Standing instruction put after every agent's prompt, every round, e.g. \"Respond in under 200 words\"")
+	cmd.Flags().Var(newPathValue(&opts.promptFile), "prompt-file", "Read the prompt from a file instead of an argument")
+	cmd.Flags().StringSliceVar(&opts.beadsFilters, "filter-beads", nil, "Limit the beads shown to agents by status (open, blocked), priority (P1), or label:<name>")
+	cmd.Flags().BoolVar(&opts.cacheResponses, "cache-agent-responses", false, "Reuse an agent's answer when it is sent the same prompt again and the beads haven't changed")
+	cmd.Flags().StringVar(&opts.fixtureDir, "fixture", "", "Replay agents, their responses, and bd output recorded in this directory instead of running them")
+	cmd.Flags().Var(newPathValue(&opts.beadsJSON), "beads-json", "Read beads from this file (bd list --json output or .beads/issues.jsonl) instead of running bd")
+	cmd.MarkFlagsMutuallyExclusive("fixture", "beads-json")
+	cmd.MarkFlagsMutuallyExclusive("single", "parallel")
+	cmd.Flags().StringSliceVar(&opts.rateLimits, "rate-limit", nil, "Space sends to an agent or provider group (see providers in --config) as group=N/unit, e.g. claude=3/min")
+	cmd.Flags().DurationVar(&opts.roundDelay, "round-delay", 0, "Pause this long between rounds, e.g. 10s, to ease API load or let beads settle")
+	cmd.Flags().DurationVar(&opts.planTimeout, "timeout", 0, "Stop the whole run after this long, e.g. 30m (default: no limit)")
+	cmd.Flags().DurationVar(&opts.agentTimeout, "agent-timeout", 0, "Give up on an agent's turn after this long and move on, e.g. 5m (default: no limit)")
+	cmd.Flags().DurationVar(&opts.roundTimeout, "round-timeout", 0, "Cap each round at this long, skipping the agents still waiting and moving on to the next round, e.g. 15m (default: no limit)")
+	cmd.Flags().Float64Var(&opts.promptBudget, "prompt-budget", 0.5, "Warn when a prompt is estimated to fill more than this fraction of the smallest agent context window (0 to not warn)")
+	cmd.Flags().Float64Var(&opts.skipContextAbove, "skip-if-context-above", 0, "Skip an agent's turn when its session already uses more than this fraction of its context, e.g. 0.9 (default: never)")
+	cmd.Flags().StringSliceVar(&opts.convergedPhrases, "converged-phrase", nil, "Extra phrase signalling an agent made no changes (enables output-signal convergence)")
+	cmd.Flags().BoolVar(&opts.semanticConverge, "semantic-convergence", false, "After a round with no bead changes, ask the --judge agent whether the plans have stabilized; only a yes converges")
+	cmd.Flags().StringVar(&opts.judgeAgent, "judge", "", "Agent asked by --semantic-convergence, e.g. claude (need not be one of --agents)")
+	return cmd
 }
 
-func runPlan(cmd *cobra.Command, args []string) error {
-	prompt := args[0]
+// args requires a positional prompt unless --prompt-file is set.
+func (o *planOptions) args(cmd *cobra.Command, args []string) error {
+	if o.promptFile != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--prompt-file and a positional prompt are mutually exclusive")
+		}
+		return nil
+	}
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
+// resolvePrompt returns the prompt from the positional arg, stdin ("-"), or --prompt-file.
+// Multi-line content is preserved; only surrounding whitespace is trimmed.
+func (o *planOptions) resolvePrompt(cmd *cobra.Command, args []string) (string, error) {
+	var prompt string
+	switch {
+	case o.promptFile != "":
+		data, err := os.ReadFile(o.promptFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		prompt = string(data)
+	case args[0] == "-":
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt from stdin: %w", err)
+		}
+		prompt = string(data)
+	default:
+		prompt = args[0]
+	}
+
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return "", fmt.Errorf("prompt is empty")
+	}
+	return prompt, nil
+}
+
+func runPlan(cmd *cobra.Command, args []string, opts *planOptions) error {
+	prompt, err := opts.resolvePrompt(cmd, args)
+	if err != nil {
+		return err
+	}
 	out := cmd.OutOrStdout()
 
-	_, _ = fmt.Fprintf(out, "Planning: %s\n", prompt)
-	_, _ = fmt.Fprintf(out, "Rounds: %d, Agents path: %s\n", rounds, agentsPath)
+	if err := validateAgentSelection(opts.selectedAgents, opts.excludedAgents); err != nil {
+		return err
+	}
+	if err := validateAgentBounds(opts.minAgents, opts.maxAgents); err != nil {
+		return err
+	}
+	display := opts.progressMode
+	if opts.progressJSONOut {
+		display = progressJSON
+	}
+	// Set up progress reporter if verbose mode or a table or JSON display is requested
+	var reporter orchestrator.ProgressReporter
+	if opts.verbose > 0 || display != progressLine {
+		r, err := newProgressReporter(display, cmd.ErrOrStderr(), opts.logger)
+		if err != nil {
+			return err
+		}
+		reporter = r
+	}
+	// The table redraws in place, so logs go through it to land above it
+	if table, ok := reporter.(*tableProgressReporter); ok {
+		opts.logger = logging.New(table, opts.logger.Level())
+	}
 
-	// Detect available agents (uses agentDetector which can be overridden in tests)
-	agents, err := agentDetector()
+	if opts.planTimeout < 0 || opts.agentTimeout < 0 || opts.roundTimeout < 0 || opts.roundDelay < 0 {
+		return fmt.Errorf("--timeout, --agent-timeout, --round-timeout and --round-delay must not be negative")
+	}
+	if opts.responseBudget < 0 || opts.maxOutputTokens < 0 {
+		return fmt.Errorf("--response-budget and --max-output-tokens must not be negative")
+	}
+	if opts.skipContextAbove < 0 || opts.skipContextAbove > 1 {
+		return fmt.Errorf("--skip-if-context-above must be between 0 and 1, got %g", opts.skipContextAbove)
+	}
+	if opts.promptBudget < 0 || opts.promptBudget > 1 {
+		return fmt.Errorf("--prompt-budget must be between 0 and 1, got %g", opts.promptBudget)
+	}
+
+	beadsFilter, err := buckctx.ParseBeadsFilter(opts.beadsFilters)
+	if err != nil {
+		return err
+	}
+
+	extraArgs, err := parseAgentArgs(opts.agentArgs)
+	if err != nil {
+		return err
+	}
+
+	if err := validateEnv(opts.env); err != nil {
+		return err
+	}
+
+	differ, err := orchestrator.ParseBeadsDiffer(opts.beadsDiff)
+	if err != nil {
+		return fmt.Errorf("--beads-diff: %w", err)
+	}
+
+	rates, err := parseRateLimits(opts.rateLimits)
+	if err != nil {
+		return err
+	}
+
+	approval, err := agent.ParseApprovalMode(opts.approvalMode)
+	if err != nil {
+		return err
+	}
+	for _, perm := range opts.toolPermissions {
+		if err := agent.ValidateToolPermission(perm); err != nil {
+			return fmt.Errorf("--permissions: %w", err)
+		}
+	}
+
+	noteMode, err := notes.ParseMode(opts.saveMode)
+	if err != nil {
+		return fmt.Errorf("--save-mode: %w", err)
+	}
+	if opts.saveAsComments && opts.saveToBead == "" {
+		return fmt.Errorf("--save-as-comments requires --save")
+	}
+	saveMinSuccess, err := saveThreshold(opts)
+	if err != nil {
+		return err
+	}
+	if err := validateNoteLimits(opts); err != nil {
+		return err
+	}
+
+	var promptTmpl *template.Template
+	if opts.promptTemplate != "" {
+		if promptTmpl, err = buckctx.LoadTemplate(opts.promptTemplate); err != nil {
+			return err
+		}
+	}
+
+	// --rounds 0 is shorthand for --single
+	singleMode := opts.single || opts.rounds == 0
+	if opts.jsonOutput {
+		opts.outputFormat = outputJSON
+	}
+	format, err := parseOutputFormat(opts.outputFormat)
+	if err != nil {
+		return err
+	}
+	if _, err := presentation.ParseTruncateStrategy(opts.truncateStrategy); err != nil {
+		return fmt.Errorf("--truncate-strategy: %w", err)
+	}
+	if opts.rounds < 0 {
+		return fmt.Errorf("--rounds must not be negative")
+	}
+	if opts.maxRounds < 1 {
+		return fmt.Errorf("--max-rounds must be at least 1")
+	}
+	if opts.convergedRounds < 1 {
+		return fmt.Errorf("--converged-rounds must be at least 1")
+	}
+	if err := validateJudge(opts.semanticConverge, opts.judgeAgent, opts.untilConverged || opts.resumeConverge); err != nil {
+		return err
+	}
+
+	// The overall timeout covers every round; --agent-timeout bounds each turn within it
+	ctx := cmd.Context()
+	if opts.planTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.planTimeout)
+		defer cancel()
+	}
+
+	dir, err := resolveWorkDir(opts.workDir)
+	if err != nil {
+		return err
+	}
+	agentsPath := opts.agentsPath
+	if dir != "" && agentsPath != "" && !filepath.IsAbs(agentsPath) {
+		// Agents run from dir, so a relative AGENTS.md path must be pinned to ours
+		if agentsPath, err = filepath.Abs(agentsPath); err != nil {
+			return fmt.Errorf("failed to resolve agents path: %w", err)
+		}
+	}
+	instructionsPath, err := resolveAgentsFile(agentsPath, dir, opts.noAgentsFile, opts.logger)
+	if err != nil {
+		return err
+	}
+
+	opts.logger.Infof("Planning: %s", prompt)
+	opts.logger.Infof("Rounds: %d, Agents path: %s", opts.rounds, describeAgentsFile(instructionsPath))
+	if desc := beadsFilter.String(); desc != "" {
+		opts.logger.Infof("Beads filter: %s", desc)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// A fixture stands in for the detector, bd, and the agents themselves
+	detect := agentDetector
+	var beadsClient beads.Client
+	var sessionMgr session.Manager
+	if opts.fixtureDir != "" {
+		if opts.saveToBead != "" {
+			return fmt.Errorf("--save cannot be used with --fixture")
+		}
+		fx, err := fixture.Load(opts.fixtureDir)
+		if err != nil {
+			return err
+		}
+		opts.logger.Infof("Replaying fixture: %s", opts.fixtureDir)
+		detect = func(*agent.Registry) ([]agent.Agent, error) { return fx.Agents() }
+		beadsClient = fx.BeadsClient()
+		sessionMgr = fx.SessionManager()
+	}
+	// A beads file stands in for bd alone
+	if opts.beadsJSON != "" {
+		if opts.saveToBead != "" {
+			return fmt.Errorf("--save cannot be used with --beads-json")
+		}
+		if beadsClient, err = beads.LoadFile(opts.beadsJSON); err != nil {
+			return err
+		}
+		opts.logger.Infof("Reading beads from: %s", opts.beadsJSON)
+	}
+
+	// Detect available agents (agentDetector can be overridden in tests)
+	agents, err := opts.detectAgentsWith(detect)
 	if err != nil {
 		return fmt.Errorf("failed to detect agents: %w", err)
 	}
 
-	// Filter to selected agents if specified
-	if len(selectedAgents) > 0 {
-		agents = filterAgents(agents, selectedAgents)
+	agents = applyAgentArgs(agents, extraArgs, opts.logger)
+
+	// The judge need not be one of the planning agents
+	var judge agent.Agent
+	if opts.semanticConverge {
+		if judge, err = findJudge(agents, opts.judgeAgent); err != nil {
+			return err
+		}
+	}
+
+	// Verbose runs show what each agent did, not just what it said
+	if opts.verbose > 0 {
+		for i := range agents {
+			agents[i].Parser = agent.ShowToolResults(agents[i].Parser)
+		}
+	}
+
+	// Filter to selected agents if specified, expanding any @group
+	selected, err := resolveAgentGroups(opts.selectedAgents, cfg.AgentGroups(), agents)
+	if err != nil {
+		return err
+	}
+	if len(selected) > 0 {
+		agents = filterAgents(agents, selected)
+	}
+
+	// Then drop any explicitly excluded agents
+	if len(opts.excludedAgents) > 0 {
+		agents = excludeAgents(agents, opts.excludedAgents)
 	}
 
 	// Filter to authenticated agents only
@@ -124,93 +562,455 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Having none at all is its own outcome, whatever --min-agents asks for
 	if len(authAgents) == 0 {
-		_, _ = fmt.Fprintf(out, "No authenticated agents available\n")
-		return nil
+		opts.logger.Warnf("No authenticated agents available")
+		return exitWith(cmd, ExitNoAgents, errors.New("no authenticated agents available"))
+	}
+	if len(authAgents) < opts.minAgents {
+		return fmt.Errorf("only %d authenticated agent(s) available, --min-agents requires %d", len(authAgents), opts.minAgents)
+	}
+
+	// Cap the field for cost control, keeping the agents listed first in --agents
+	if opts.maxAgents > 0 && len(authAgents) > opts.maxAgents {
+		authAgents = limitAgents(authAgents, opts.maxAgents, selected)
+		opts.logger.Infof("Capped at %d agent(s) by --max-agents", opts.maxAgents)
+	}
+
+	opts.logger.Infof("Using %d agent(s): %s", len(authAgents), strings.Join(agentNames(authAgents), ", "))
+
+	opts.preflightCapabilities(authAgents, approval)
+
+	limiter := newRateLimiter(rates, cfg.Providers, authAgents, opts.logger)
+
+	// -vv dumps each agent's raw I/O to stderr
+	var trace func(format string, args ...interface{})
+	if opts.verbose >= 2 {
+		trace = opts.logger.Infof
+	}
+
+	if singleMode {
+		newSession := func(ag agent.Agent) (session.Session, error) {
+			return newOneShotSession(ag, session.Options{SystemPrompt: opts.systemPrompt, WorkDir: dir, ApprovalMode: approval, ToolPermissions: opts.toolPermissions, KeepANSI: opts.keepANSI, Env: opts.env, CleanEnv: opts.cleanEnv, Trace: trace}), nil
+		}
+		if sessionMgr != nil {
+			newSession = sessionMgr.CreateSession
+		}
+		return opts.runSingle(ctx, out, authAgents, prompt, format, newSession, limiter)
+	}
+
+	// Without bd, agents work from the prompt and AGENTS.md alone
+	if beadsClient == nil {
+		beadsClient = newBeadsClient()
+	}
+	noBeads := !beadsClient.Available(ctx)
+	saveTo := opts.saveToBead
+	if noBeads {
+		opts.logger.Warnf("bd is not installed; running without beads (--save is disabled and convergence relies on agents reporting no changes)")
+		saveTo = ""
 	}
 
-	_, _ = fmt.Fprintf(out, "Using %d agent(s): ", len(authAgents))
-	for i, a := range authAgents {
-		if i > 0 {
-			_, _ = fmt.Fprintf(out, ", ")
+	// The bead prefix drives change detection, detail selection, and --save checks
+	var prefix string
+	if !noBeads {
+		prefix, err = resolveBeadPrefix(ctx, opts.beadPrefix, cfg.BeadPrefix, beadsClient)
+		if err != nil {
+			return err
+		}
+	}
+	if prefix != "" {
+		opts.logger.Debugf("Bead prefix: %s", prefix)
+	}
+	if saveTo != "" {
+		if err := beads.ValidateIDPrefix(saveTo, prefix); err != nil {
+			return fmt.Errorf("--save: %w", err)
+		}
+	}
+
+	// Load agent session IDs recorded by earlier runs
+	sessionState, err := session.LoadState(session.DefaultStatePath)
+	if err != nil {
+		return err
+	}
+	var resumeIDs map[string]string
+	if opts.resumeSessions {
+		// Copied, since the state's sessions are rewritten after every round
+		resumeIDs = maps.Clone(sessionState.Sessions)
+		if len(resumeIDs) == 0 {
+			opts.logger.Warnf("No prior sessions recorded in %s; starting fresh", session.DefaultStatePath)
+		} else {
+			opts.logger.Infof("Resuming %d prior agent session(s)", len(resumeIDs))
 		}
-		_, _ = fmt.Fprintf(out, "%s", a.Name)
 	}
-	_, _ = fmt.Fprintf(out, "\n")
 
 	// Set up orchestrator
+	if sessionMgr == nil {
+		sessionMgr = session.NewManagerWithOptions(session.Options{
+			SystemPrompt:    opts.systemPrompt,
+			WorkDir:         dir,
+			ApprovalMode:    approval,
+			ToolPermissions: opts.toolPermissions,
+			KeepANSI:        opts.keepANSI,
+			ResumeIDs:       resumeIDs,
+			MaxOutputTokens: opts.maxOutputTokens,
+			Env:             opts.env,
+			CleanEnv:        opts.cleanEnv,
+			Trace:           trace,
+			Logger:          opts.logger,
+		})
+	}
+	builderOpts := []buckctx.Option{
+		buckctx.WithBeadsFilter(beadsFilter),
+		buckctx.WithBeadsClient(beadsClient),
+		buckctx.WithBeadPrefix(prefix),
+		buckctx.WithPromptTemplate(promptTmpl),
+		buckctx.WithPromptPrefix(opts.promptPrefix),
+		buckctx.WithPromptSuffix(opts.promptSuffix),
+		buckctx.WithBootstrapPrompt(opts.bootstrapPrompt),
+		buckctx.WithResponseBudget(opts.responseBudget),
+		buckctx.WithLogger(opts.logger),
+	}
+	if noBeads {
+		builderOpts = append(builderOpts, buckctx.WithoutBeads())
+	}
+	if budget, smallest := promptBudget(authAgents, opts.promptBudget); budget > 0 {
+		builderOpts = append(builderOpts, buckctx.WithPromptBudget(budget, func(tokens, budget int) {
+			opts.logger.Warnf("Prompt is about %d tokens, over %.0f%% of %s's %d-token context window; narrow the beads shown with --filter-beads", tokens, opts.promptBudget*100, smallest, agent.ContextWindow(smallest))
+		}))
+	}
 	orch := orchestrator.NewRoundOrchestrator()
-	orch.SetSessionManager(session.NewManager())
-	orch.SetContextBuilder(buckctx.NewBuilder())
+	orch.SetSessionManager(sessionMgr)
+	orch.SetContextBuilder(buckctx.NewBuilder(builderOpts...))
+	orch.SetBeadsClient(beadsClient)
+	orch.SetBeadsDiffer(differ)
+	orch.SetBeadPrefix(prefix)
+	orch.SetAgentTimeout(opts.agentTimeout)
+	orch.SetRoundTimeout(opts.roundTimeout)
+	orch.SetContextSkipThreshold(opts.skipContextAbove)
+	orch.SetRateLimiter(limiter)
+	orch.SetRotateOrder(opts.rotateOrder)
+	orch.SetParallel(opts.parallel)
+	orch.SetRereadAgents(opts.rereadAgents)
+	orch.SetRoundSummary(opts.roundSummary)
+	if opts.verifyAgentsRead {
+		orch.SetAgentsReadCheck(func(name, path string) {
+			opts.logger.Warnf("%s did not echo %s; it may not have read its instructions", name, path)
+		})
+	}
+	if opts.cacheResponses {
+		orch.SetResponseCache(orchestrator.NewResponseCache())
+	}
 
-	// Set up progress reporter if verbose mode is enabled
-	if verbose {
-		orch.SetProgressReporter(newTerminalProgressReporter(out))
+	if reporter != nil {
+		orch.SetProgressReporter(reporter)
 	}
 
 	// Set up convergence detector
-	convDetector := convergence.NewDetector()
+	convDetector := newConvergenceDetector()
+	convDetector.SetThreshold(opts.convergedRounds)
+	// Parallel agents never see each other's changes within a round, so
+	// rounds are compared by the changes they propose as a whole
+	convDetector.SetChangeSetConvergence(opts.parallel)
+	// Without beads every round looks quiet, so output phrases (or the judge) decide
+	convergedPhrases := slices.Concat(cfg.ConvergedPhrases, opts.convergedPhrases)
+	if (noBeads && !opts.semanticConverge) || len(convergedPhrases) > 0 {
+		convDetector.SetMatcher(convergence.NewPhraseMatcher(convergedPhrases...))
+	}
+	if opts.semanticConverge {
+		convDetector.SetJudge(newAgentJudge(ctx, judge, opts.logger))
+	}
+
+	// Track token usage and estimated cost per agent across rounds
+	costTracker := accounting.NewCostTracker(cfg.Prices)
 
 	// Set up notes saver if --save flag is set
 	var noteSaver notes.Saver
-	if saveToBead != "" {
-		noteSaver = notes.NewSaver()
-		_, _ = fmt.Fprintf(out, "Saving perspectives to: %s\n", saveToBead)
+	if saveTo != "" {
+		noteSaver = notes.NewSaver(notes.WithMode(noteMode), notes.WithMaxLength(opts.noteMaxLength), notes.WithSidecarDir(opts.noteSidecarDir))
+		if opts.saveAsComments {
+			noteSaver = notes.NewCommentSaver(beadsClient)
+		}
+		opts.logger.Infof("Saving perspectives to: %s", saveTo)
 	}
 
 	// Build initial planning context
-	builder := buckctx.NewBuilder()
-	planCtx, err := builder.Build(prompt, agentsPath, 1, true)
+	builder := buckctx.NewBuilder(builderOpts...)
+	planCtx, err := builder.Build(ctx, prompt, instructionsPath, 1, true)
 	if err != nil {
 		return fmt.Errorf("failed to build planning context: %w", err)
 	}
 
+	// --resume-convergence picks an interrupted --until-converged run back up
+	converge := opts.untilConverged || opts.resumeConverge
+	startRound := 1
+	if opts.resumeConverge {
+		if startRound, err = restoreConvergence(convDetector, planCtx.BeadsState, opts.logger); err != nil {
+			return err
+		}
+	}
+
 	// Run rounds
-	maxRounds := rounds
-	if untilConverged {
-		maxRounds = 100 // Safety limit
+	maxRounds := opts.rounds
+	planCtx.TotalRounds = opts.rounds
+	roundsCap := false // Whether --rounds rather than --max-rounds bounds a converging run
+	if converge {
+		maxRounds = startRound + opts.maxRounds - 1
+		planCtx.TotalRounds = 0
+		// An explicit --rounds stays a hard cap on the run
+		if cmd.Flags().Changed("rounds") && opts.rounds <= maxRounds {
+			maxRounds = opts.rounds
+			planCtx.TotalRounds = opts.rounds
+			roundsCap = true
+		}
+	}
+
+	// Agents keep their sessions from round to round; stop them when the run ends
+	defer func() { _ = orch.Close() }()
+	if opts.warmUp {
+		if err := orch.WarmUp(ctx, authAgents, planCtx.AgentsPath); err != nil {
+			opts.logger.Warnf("Warm-up: %v (those agents start on their turn instead)", err)
+		}
 	}
 
-	for round := 1; round <= maxRounds; round++ {
-		_, _ = fmt.Fprintf(out, "\n=== Round %d ===\n", round)
+	// Keep every round's result for the end-of-run summary
+	var history []orchestrator.RoundResult
+	converged := false
+
+	for round := startRound; round <= maxRounds; round++ {
+		// Pace the rounds, letting agents' asynchronous writes settle
+		if round > startRound && opts.roundDelay > 0 {
+			if err := sleepContext(ctx, opts.roundDelay); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					return fmt.Errorf("plan timed out after %s before round %d", opts.planTimeout, round)
+				}
+				return fmt.Errorf("plan stopped before round %d: %w", round, err)
+			}
+		}
+
+		opts.logger.Infof("\n=== Round %d ===", round)
 
 		planCtx.Round = round
-		planCtx.IsFirstTurn = (round == 1)
 
-		result, err := orch.RunRound(cmd.Context(), authAgents, planCtx)
+		result, err := orch.RunRound(ctx, authAgents, planCtx)
 		if err != nil {
 			return fmt.Errorf("round %d failed: %w", round, err)
 		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("plan timed out after %s during round %d", opts.planTimeout, round)
+		}
+
+		history = append(history, result)
 
 		// Report results
 		_, _ = fmt.Fprintf(out, "Changes: %d, Failed: %d, Skipped: %d\n",
 			result.TotalChanges, result.FailedCount, result.SkippedCount)
 
+		// A round where nothing launched points at setup, not at the agents
+		if err := startFailure(result); err != nil {
+			opts.logger.Warnf("no agent could be started (%v); check `buckshot agents`", err)
+		}
+
+		recorded := false
+		for _, ar := range result.AgentResults {
+			usage := ar.Response.TokenUsage
+			if usage.Model == "" {
+				usage.Model = ar.Agent.Pattern.ConfiguredModel()
+			}
+			costTracker.Add(ar.Agent.Name, usage)
+			if ar.Response.SessionID != "" {
+				sessionState.Record(ar.Agent.Name, ar.Response.SessionID)
+				recorded = true
+			}
+		}
+
+		// Record session IDs so a later run can --resume them
+		if recorded {
+			if err := session.SaveState(session.DefaultStatePath, sessionState); err != nil {
+				opts.logger.Warnf("failed to record agent sessions: %v", err)
+			}
+		}
+
 		// Save perspectives to bead if --save flag is set
 		if noteSaver != nil {
-			if err := noteSaver.SaveRoundResults(cmd.Context(), saveToBead, result); err != nil {
-				_, _ = fmt.Fprintf(out, "Warning: failed to save perspectives: %v\n", err)
+			if succeeded := result.SucceededCount(); succeeded < saveMinSuccess {
+				opts.logger.Warnf("not saving round %d perspectives: %d agent(s) succeeded, %d needed", round, succeeded, saveMinSuccess)
+			} else if err := noteSaver.SaveRoundResults(ctx, saveTo, result); err != nil {
+				opts.logger.Warnf("failed to save perspectives: %v", err)
 			} else {
-				_, _ = fmt.Fprintf(out, "Saved round %d perspectives to %s\n", round, saveToBead)
+				opts.logger.Infof("Saved round %d perspectives to %s", round, saveTo)
 			}
 		}
 
 		// Check convergence
-		if untilConverged && convDetector.CheckConvergence(result) {
-			_, _ = fmt.Fprintf(out, "\nConverged after %d round(s)\n", round)
-			break
+		if converge {
+			if convDetector.CheckConvergence(result) {
+				if err := convergence.ClearSnapshot(convergence.DefaultStatePath); err != nil {
+					opts.logger.Warnf("%v", err)
+				}
+				_, _ = fmt.Fprintf(out, "\nConverged after %d round(s)\n", round)
+				converged = true
+				break
+			}
 		}
 
-		if !untilConverged && round >= rounds {
-			_, _ = fmt.Fprintf(out, "\nCompleted %d round(s)\n", rounds)
+		if !converge && round >= opts.rounds {
+			_, _ = fmt.Fprintf(out, "\nCompleted %d round(s)\n", opts.rounds)
 			break
 		}
+
+		// The next round starts from the beads this one left. Record
+		// convergence progress so an interrupted run can resume.
+		if err := builder.RefreshBeadsState(ctx, &planCtx); err != nil {
+			opts.logger.Warnf("failed to refresh beads state: %v", err)
+		}
+		if converge {
+			snapshot := convDetector.Snapshot()
+			snapshot.BeadsFingerprint = convergence.Fingerprint(planCtx.BeadsState)
+			if err := convergence.SaveSnapshot(convergence.DefaultStatePath, snapshot); err != nil {
+				opts.logger.Warnf("failed to record convergence progress: %v", err)
+			}
+		}
 	}
 
+	// Say which cap ended an unconverged run: --rounds or --max-rounds
+	var stopped string
+	if converge && !converged && len(history) > 0 {
+		stopped = fmt.Sprintf("at max rounds (%d) without convergence", opts.maxRounds)
+		if roundsCap {
+			stopped = fmt.Sprintf("after round %d without converging", history[len(history)-1].Round)
+		}
+		_, _ = fmt.Fprintf(out, "\nStopped %s\n", stopped)
+	}
+
+	summary := convergence.Summarize(history)
+	summary.Converged = converged
+	_, _ = fmt.Fprintf(out, "\n%s", summary.Format())
+
+	_, _ = fmt.Fprintf(out, "\n%s", costTracker.FormatSummary())
+
 	_, _ = fmt.Fprintf(out, "\nPlanning complete.\n")
+
+	switch {
+	case allFailed(history):
+		opts.logger.Errorf("every agent failed; nothing was planned")
+		return exitWith(cmd, ExitAllFailed, errors.New("every agent failed"))
+	case stopped != "":
+		return exitWith(cmd, ExitNotConverged, fmt.Errorf("stopped %s", stopped))
+	}
+	return nil
+}
+
+// startFailure returns the first agent's error if every agent that was not
+// skipped failed to start, and nil otherwise.
+func startFailure(result orchestrator.RoundResult) error {
+	var first error
+	for _, ar := range result.AgentResults {
+		if ar.Skipped {
+			continue
+		}
+		if !errors.Is(ar.Error, session.ErrStartFailed) {
+			return nil
+		}
+		if first == nil {
+			first = ar.Error
+		}
+	}
+	return first
+}
+
+// saveThreshold returns how many agents must succeed in a round for --save
+// to record it: --save-min-success, or 1 with --no-save-on-failure.
+func saveThreshold(opts *planOptions) (int, error) {
+	if opts.saveMinSuccess < 0 {
+		return 0, fmt.Errorf("--save-min-success must not be negative")
+	}
+	if (opts.noSaveOnFailure || opts.saveMinSuccess > 0) && opts.saveToBead == "" {
+		return 0, fmt.Errorf("--no-save-on-failure and --save-min-success require --save")
+	}
+	if opts.noSaveOnFailure && opts.saveMinSuccess < 1 {
+		return 1, nil
+	}
+	return opts.saveMinSuccess, nil
+}
+
+// validateNoteLimits checks --note-max-length and --note-sidecar-dir, which
+// only shape notes written by --save.
+func validateNoteLimits(opts *planOptions) error {
+	switch {
+	case opts.noteMaxLength < 0:
+		return fmt.Errorf("--note-max-length must not be negative")
+	case opts.noteSidecarDir != "" && opts.noteMaxLength == 0:
+		return fmt.Errorf("--note-sidecar-dir requires --note-max-length")
+	case opts.noteMaxLength > 0 && opts.saveToBead == "":
+		return fmt.Errorf("--note-max-length requires --save")
+	case opts.noteMaxLength > 0 && opts.saveAsComments:
+		return fmt.Errorf("--note-max-length applies to notes and cannot be used with --save-as-comments")
+	}
 	return nil
 }
 
+// restoreConvergence loads the progress recorded by an interrupted
+// --until-converged run into detector and returns the round to continue from.
+// If the beads changed since that run, the no-change count starts over.
+func restoreConvergence(detector convergence.Detector, beadsState string, logger *logging.Logger) (int, error) {
+	snapshot, ok, err := convergence.LoadSnapshot(convergence.DefaultStatePath)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		logger.Warnf("No convergence progress recorded in %s; starting from round 1", convergence.DefaultStatePath)
+		return 1, nil
+	}
+
+	if snapshot.BeadsFingerprint != convergence.Fingerprint(beadsState) {
+		logger.Warnf("Beads changed since round %d; restarting the no-change count", snapshot.Round)
+		snapshot.ConsecutiveNoChange = 0
+	}
+	detector.Restore(snapshot)
+	logger.Infof("Resuming convergence after round %d (%d no-change round(s) so far)", snapshot.Round, snapshot.ConsecutiveNoChange)
+	return snapshot.Round + 1, nil
+}
+
+// preflightCapabilities warns about each agent that cannot honor a requested
+// feature, so the flag isn't silently ignored for it.
+func (o *planOptions) preflightCapabilities(agents []agent.Agent, approval agent.ApprovalMode) {
+	for _, a := range agents {
+		caps := a.Pattern.Capabilities()
+		if o.systemPrompt != "" && !caps.Has(agent.CapSystemPrompt) {
+			o.logger.Warnf("%s does not support a system prompt; ignoring --system-prompt", a.Name)
+		}
+		if o.resumeSessions && !caps.Has(agent.CapResume) {
+			o.logger.Warnf("%s does not support resuming sessions; starting it fresh despite --resume", a.Name)
+		}
+		if approval != agent.ApprovalYolo && len(a.Pattern.ApprovalArgs(approval)) == 0 && len(a.Pattern.SkipApprovalsArgs) > 0 {
+			o.logger.Warnf("%s has no --approval-mode %s; running it without skipping approvals", a.Name, approval)
+		}
+		if len(o.toolPermissions) > 0 && a.Pattern.PerToolPermissionArg == "" {
+			o.logger.Warnf("%s has no per-tool permissions; ignoring --permissions", a.Name)
+		}
+		if o.outputFormat == outputJSON {
+			switch {
+			case !caps.Has(agent.CapJSONOutput):
+				o.logger.Warnf("%s has no structured output mode; its response will be captured as plain text", a.Name)
+			case !o.rawOutput && (a.Parser == nil || agent.IsRaw(a.Parser)):
+				o.logger.Warnf("%s emits JSON events but has no parser for them; its response will be the raw events", a.Name)
+			}
+		}
+	}
+}
+
+// sleepContext waits for d, returning ctx's error early if it is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // filterAgents returns only agents whose names are in the selected list
 func filterAgents(agents []agent.Agent, selected []string) []agent.Agent {
 	selectedSet := make(map[string]bool)
@@ -227,11 +1027,302 @@ func filterAgents(agents []agent.Agent, selected []string) []agent.Agent {
 	return filtered
 }
 
-func init() {
-	planCmd.Flags().IntVarP(&rounds, "rounds", "r", 3, "Number of planning rounds")
-	planCmd.Flags().StringVarP(&agentsPath, "agents-path", "a", "", "Path to AGENTS.md file")
-	planCmd.Flags().StringSliceVar(&selectedAgents, "agents", nil, "Specific agents to use (default: all available)")
-	planCmd.Flags().BoolVar(&untilConverged, "until-converged", false, "Run until all agents report no changes")
-	planCmd.Flags().StringVar(&saveToBead, "save", "", "Save agent perspectives to specified bead ID")
-	planCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed progress with agent timing and beads diff")
+// resolveAgentGroups expands each @name in selected to the agents in that
+// group, keeping the order given and dropping repeats. @all is every agent
+// in agents unless groups defines it.
+func resolveAgentGroups(selected []string, groups map[string][]string, agents []agent.Agent) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range selected {
+		members := []string{name}
+		if group, ok := strings.CutPrefix(name, "@"); ok {
+			var known bool
+			members, known = groups[group]
+			switch {
+			case !known && group == agent.GroupAll:
+				members = agentNames(agents)
+			case !known:
+				return nil, fmt.Errorf("unknown agent group %q (known: %s)", name, strings.Join(groupNames(groups), ", "))
+			}
+		}
+		for _, member := range members {
+			if !seen[member] {
+				seen[member] = true
+				names = append(names, member)
+			}
+		}
+	}
+	return names, nil
+}
+
+// groupNames returns the @names accepted for groups, including @all, sorted.
+func groupNames(groups map[string][]string) []string {
+	names := []string{"@" + agent.GroupAll}
+	for name := range groups {
+		if name != agent.GroupAll {
+			names = append(names, "@"+name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// agentNames returns the names of the given agents
+func agentNames(agents []agent.Agent) []string {
+	names := make([]string, len(agents))
+	for i, a := range agents {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// excludeAgents returns only agents whose names are not in the excluded list
+func excludeAgents(agents []agent.Agent, excluded []string) []agent.Agent {
+	excludedSet := make(map[string]bool)
+	for _, name := range excluded {
+		excludedSet[name] = true
+	}
+
+	var filtered []agent.Agent
+	for _, a := range agents {
+		if !excludedSet[a.Name] {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// validateAgentBounds rejects negative or contradictory --min-agents and --max-agents.
+func validateAgentBounds(lo, hi int) error {
+	if lo < 0 || hi < 0 {
+		return fmt.Errorf("--min-agents and --max-agents must not be negative")
+	}
+	if hi > 0 && lo > hi {
+		return fmt.Errorf("--min-agents (%d) is greater than --max-agents (%d)", lo, hi)
+	}
+	return nil
+}
+
+// limitAgents keeps at most n agents. Agents named in priority win, in that
+// order; the rest fill any remaining places in name order, since detection
+// order varies from run to run. The kept agents still run in detection order.
+func limitAgents(agents []agent.Agent, n int, priority []string) []agent.Agent {
+	keep := make(map[string]bool, n)
+	for _, name := range priority {
+		if len(keep) == n {
+			break
+		}
+		for _, a := range agents {
+			if a.Name == name {
+				keep[name] = true
+				break
+			}
+		}
+	}
+	names := agentNames(agents)
+	slices.Sort(names)
+	for _, name := range names {
+		if len(keep) == n {
+			break
+		}
+		keep[name] = true
+	}
+
+	var limited []agent.Agent
+	for _, a := range agents {
+		if keep[a.Name] {
+			limited = append(limited, a)
+		}
+	}
+	return limited
+}
+
+// resolveAgentsFile picks the instruction file for agents. An explicit path is
+// used as-is; otherwise one is discovered by walking up from dir (or the
+// current directory). Returns "" to fall back to default instructions.
+func resolveAgentsFile(path, dir string, skip bool, logger *logging.Logger) (string, error) {
+	if skip {
+		if path != "" {
+			return "", fmt.Errorf("--agents-path and --no-agents-file are mutually exclusive")
+		}
+		return "", nil
+	}
+	if path != "" {
+		return path, nil
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	found := buckctx.DiscoverAgentsFile(dir)
+	if found == "" {
+		logger.Warnf("No AGENTS.md, CLAUDE.md, or .github/AGENTS.md found; using default instructions")
+	}
+	return found, nil
+}
+
+// describeAgentsFile labels the instruction file for log output.
+func describeAgentsFile(path string) string {
+	if path == "" {
+		return "(default instructions)"
+	}
+	return path
+}
+
+// resolveWorkDir returns the absolute form of dir, which must be an existing directory.
+// An empty dir means agents run in the current directory.
+func resolveWorkDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workdir: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("workdir not found: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("workdir %s is not a directory", abs)
+	}
+	return abs, nil
+}
+
+// validateAgentSelection rejects agents named in both --agents and --exclude-agents
+func validateAgentSelection(selected, excluded []string) error {
+	selectedSet := make(map[string]bool)
+	for _, name := range selected {
+		selectedSet[name] = true
+	}
+
+	for _, name := range excluded {
+		if selectedSet[name] {
+			return fmt.Errorf("agent %q is in both --agents and --exclude-agents", name)
+		}
+	}
+	return nil
+}
+
+// resolveBeadPrefix picks the bead ID prefix from --bead-prefix, then the
+// config file, then the first bead bd lists. Returns "" if none is known,
+// and an error if bd can't list the beads.
+func resolveBeadPrefix(ctx context.Context, flag, configured string, client beads.Client) (string, error) {
+	for _, prefix := range []string{flag, configured} {
+		prefix = strings.TrimSuffix(prefix, "-")
+		if prefix == "" {
+			continue
+		}
+		if err := beads.ValidatePrefix(prefix); err != nil {
+			return "", err
+		}
+		return prefix, nil
+	}
+
+	out, err := client.List(ctx, beads.ListOpts{})
+	if err != nil {
+		return "", fmt.Errorf("failed to detect the bead prefix (set --bead-prefix to skip): %w", err)
+	}
+	return beads.DetectPrefix(out), nil
+}
+
+// parseAgentArgs groups --agent-arg values of the form name:arg by agent name,
+// keeping their order.
+func parseAgentArgs(specs []string) (map[string][]string, error) {
+	extra := make(map[string][]string)
+	for _, spec := range specs {
+		name, arg, ok := strings.Cut(spec, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || arg == "" {
+			return nil, fmt.Errorf("invalid --agent-arg %q (want name:arg, e.g. claude:--model=opus)", spec)
+		}
+		extra[name] = append(extra[name], arg)
+	}
+	return extra, nil
+}
+
+// validateEnv checks that each --env value has the form KEY=VALUE.
+func validateEnv(entries []string) error {
+	for _, entry := range entries {
+		key, _, ok := strings.Cut(entry, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("invalid --env %q (want KEY=VALUE, e.g. ANTHROPIC_LOG=debug)", entry)
+		}
+	}
+	return nil
+}
+
+// applyAgentArgs appends each agent's extra args to its CLI pattern and warns
+// about names that match no detected agent.
+func applyAgentArgs(agents []agent.Agent, extra map[string][]string, logger *logging.Logger) []agent.Agent {
+	matched := make(map[string]bool)
+	for i, a := range agents {
+		args, ok := extra[a.Name]
+		if !ok {
+			continue
+		}
+		matched[a.Name] = true
+		agents[i].Pattern.ExtraArgs = append(slices.Clip(a.Pattern.ExtraArgs), args...)
+	}
+	for name := range extra {
+		if !matched[name] {
+			logger.Warnf("--agent-arg for %s ignored: agent not detected", name)
+		}
+	}
+	return agents
+}
+
+// parseRateLimits parses --rate-limit values of the form group=N/unit, keyed
+// by agent or provider group.
+func parseRateLimits(specs []string) (map[string]dispatch.Rate, error) {
+	rates := make(map[string]dispatch.Rate)
+	for _, spec := range specs {
+		group, value, ok := strings.Cut(spec, "=")
+		group = strings.TrimSpace(group)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid --rate-limit %q (want group=N/unit, e.g. claude=3/min)", spec)
+		}
+		rate, err := dispatch.ParseRate(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --rate-limit %q: %w", spec, err)
+		}
+		rates[group] = rate
+	}
+	return rates, nil
+}
+
+// newRateLimiter builds the limiter for rates, or returns nil when there are
+// none. It warns about groups that no running agent belongs to.
+func newRateLimiter(rates map[string]dispatch.Rate, providers map[string]string, agents []agent.Agent, logger *logging.Logger) *dispatch.RateLimiter {
+	if len(rates) == 0 {
+		return nil
+	}
+	limiter := dispatch.NewRateLimiter(rates, providers)
+	used := make(map[string]bool)
+	for _, a := range agents {
+		used[limiter.Group(a.Name)] = true
+	}
+	for group := range rates {
+		if !used[group] {
+			logger.Warnf("--rate-limit for %s ignored: no agent or provider group by that name", group)
+		}
+	}
+	return limiter
+}
+
+// promptBudget returns how many tokens of prompt fill fraction of the
+// smallest known context window among agents, with the agent it belongs to.
+// It returns 0 if fraction is 0 or no agent's window is known.
+func promptBudget(agents []agent.Agent, fraction float64) (int, string) {
+	var smallest string
+	for _, a := range agents {
+		window := agent.ContextWindow(a.Name)
+		if window > 0 && (smallest == "" || window < agent.ContextWindow(smallest)) {
+			smallest = a.Name
+		}
+	}
+	if smallest == "" {
+		return 0, ""
+	}
+	return int(fraction * float64(agent.ContextWindow(smallest))), smallest
 }
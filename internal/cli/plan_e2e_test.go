@@ -22,12 +22,9 @@ func TestPlanCommand_E2E_RealAgents(t *testing.T) {
 		t.Skip("Skipping e2e test in short mode")
 	}
 
-	// Reset flags
-	resetPlanFlags()
-
 	// Detect real agents on the system
 	detector := agent.NewDetector()
-	agents, err := detector.DetectAll()
+	agents, err := detector.DetectAll(context.Background(), false)
 	if err != nil {
 		t.Fatalf("Failed to detect agents: %v", err)
 	}
@@ -61,6 +58,7 @@ func TestPlanCommand_E2E_RealAgents(t *testing.T) {
 	}
 
 	// Run plan command with real agents
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -102,9 +100,6 @@ func TestPlanCommand_E2E_SingleAgent(t *testing.T) {
 		t.Skip("Skipping e2e test in short mode")
 	}
 
-	// Reset flags
-	resetPlanFlags()
-
 	// Try each known agent in order of preference
 	preferredAgents := []string{"claude", "codex", "cursor"}
 	detector := agent.NewDetector()
@@ -112,7 +107,7 @@ func TestPlanCommand_E2E_SingleAgent(t *testing.T) {
 	var selectedAgent *agent.Agent
 	for _, name := range preferredAgents {
 		if detector.IsInstalled(name) {
-			agents, _ := detector.DetectAll()
+			agents, _ := detector.DetectAll(context.Background(), false)
 			for _, a := range agents {
 				if a.Name == name && a.Authenticated {
 					selectedAgent = &a
@@ -150,6 +145,7 @@ func TestPlanCommand_E2E_SingleAgent(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -185,12 +181,9 @@ func TestPlanCommand_E2E_MultipleRounds(t *testing.T) {
 		t.Skip("Skipping e2e test in short mode")
 	}
 
-	// Reset flags
-	resetPlanFlags()
-
 	// Detect real agents
 	detector := agent.NewDetector()
-	agents, _ := detector.DetectAll()
+	agents, _ := detector.DetectAll(context.Background(), false)
 
 	var authAgents []agent.Agent
 	for _, a := range agents {
@@ -216,6 +209,7 @@ func TestPlanCommand_E2E_MultipleRounds(t *testing.T) {
 		t.Fatalf("Failed to change to work directory: %v", err)
 	}
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -257,12 +251,9 @@ func TestPlanCommand_E2E_AgentSelection(t *testing.T) {
 		t.Skip("Skipping e2e test in short mode")
 	}
 
-	// Reset flags
-	resetPlanFlags()
-
 	// Detect real agents
 	detector := agent.NewDetector()
-	agents, _ := detector.DetectAll()
+	agents, _ := detector.DetectAll(context.Background(), false)
 
 	var authAgents []agent.Agent
 	for _, a := range agents {
@@ -292,6 +283,7 @@ func TestPlanCommand_E2E_AgentSelection(t *testing.T) {
 		t.Fatalf("Failed to change to work directory: %v", err)
 	}
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -337,12 +329,3 @@ You are participating in an end-to-end test of the buckshot multi-agent planning
 - If asked to respond with a specific phrase, do so exactly
 - Do not create any files or make system changes
 `
-
-// agentNames returns a slice of agent names
-func agentNames(agents []agent.Agent) []string {
-	names := make([]string, len(agents))
-	for i, a := range agents {
-		names[i] = a.Name
-	}
-	return names
-}
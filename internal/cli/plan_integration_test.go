@@ -21,9 +21,6 @@ func TestPlanCommand_Integration_WithMockAgent(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup mock agent
 	mockSetup := testutil.SetupMockAgent(t, "mock-claude", testutil.DefaultMockConfig())
 
@@ -54,6 +51,7 @@ func TestPlanCommand_Integration_WithMockAgent(t *testing.T) {
 	defer restore()
 
 	// Run plan command
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -96,9 +94,6 @@ func TestPlanCommand_Integration_NoAuthenticatedAgents(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup mock agent that fails auth
 	config := testutil.DefaultMockConfig()
 	config.Mode = testutil.ModeAuthFail
@@ -112,6 +107,7 @@ func TestPlanCommand_Integration_NoAuthenticatedAgents(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -124,8 +120,8 @@ func TestPlanCommand_Integration_NoAuthenticatedAgents(t *testing.T) {
 	err := rootCmd.Execute()
 	output := buf.String()
 
-	if err != nil {
-		t.Errorf("Command should not error with no auth agents, got: %v", err)
+	if ExitCode(err) != ExitNoAgents {
+		t.Errorf("Command with no auth agents should exit %d, got: %v", ExitNoAgents, err)
 	}
 
 	if !strings.Contains(output, "No authenticated agents") {
@@ -139,9 +135,6 @@ func TestPlanCommand_Integration_MultipleAgents(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup multiple mock agents
 	configs := map[string]testutil.MockAgentConfig{
 		"mock-claude": testutil.DefaultMockConfig(),
@@ -167,6 +160,7 @@ func TestPlanCommand_Integration_MultipleAgents(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -199,9 +193,6 @@ func TestPlanCommand_Integration_AgentSelection(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup multiple agents
 	configs := map[string]testutil.MockAgentConfig{
 		"claude": testutil.DefaultMockConfig(),
@@ -227,6 +218,7 @@ func TestPlanCommand_Integration_AgentSelection(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -260,9 +252,6 @@ func TestPlanCommand_Integration_Convergence(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup mock agent that converges after first round
 	config := testutil.DefaultMockConfig()
 	config.Mode = testutil.ModeConverged
@@ -280,6 +269,7 @@ func TestPlanCommand_Integration_Convergence(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -312,9 +302,6 @@ func TestPlanCommand_Integration_ErrorHandling(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup mock agent that errors
 	config := testutil.DefaultMockConfig()
 	config.Mode = testutil.ModeError
@@ -333,6 +320,7 @@ func TestPlanCommand_Integration_ErrorHandling(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -366,9 +354,6 @@ func TestPlanCommand_Integration_ContextUsageTracking(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 
-	// Reset all flags to ensure clean state
-	resetPlanFlags()
-
 	// Setup mock agent with high context growth
 	config := testutil.DefaultMockConfig()
 	config.InitialContext = 0.10
@@ -387,6 +372,7 @@ func TestPlanCommand_Integration_ContextUsageTracking(t *testing.T) {
 	})
 	defer restore()
 
+	rootCmd := newRootCmd()
 	buf := new(bytes.Buffer)
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
@@ -412,3 +398,113 @@ func TestPlanCommand_Integration_ContextUsageTracking(t *testing.T) {
 		t.Errorf("Should complete both rounds, got:\n%s", output)
 	}
 }
+
+// TestPlanCommand_Integration_AgentTimeout tests that --agent-timeout cuts off a
+// slow agent's turn and lets the rest of the round carry on.
+func TestPlanCommand_Integration_AgentTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	// One agent responds far slower than the per-agent timeout allows
+	slowConfig := testutil.DefaultMockConfig()
+	slowConfig.ResponseDelay = 10000
+	slow := testutil.SetupMockAgent(t, "mock-slow", slowConfig)
+	fast := testutil.SetupMockAgent(t, "mock-fast", testutil.DefaultMockConfig())
+
+	agentsPath := testutil.CreateTestAgentsFile(t, "")
+	workDir := testutil.CreateTestBeadsDir(t)
+
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	os.Chdir(workDir)
+
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{slow.Agent, fast.Agent}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{
+		"plan",
+		"--rounds", "1",
+		"--agent-timeout", "500ms",
+		"--agents-path", agentsPath,
+		"Agent timeout test",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := rootCmd.ExecuteContext(ctx)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("a timed-out agent should not fail the run: %v\nOutput: %s", err, output)
+	}
+	if elapsed := time.Since(start); elapsed > 8*time.Second {
+		t.Errorf("run took %s; the slow agent should have been cut off", elapsed)
+	}
+	if !strings.Contains(output, "Failed: 1") {
+		t.Errorf("the slow agent should be recorded as failed, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Planning complete.") {
+		t.Errorf("the run should still complete, got:\n%s", output)
+	}
+}
+
+// TestPlanCommand_Integration_Timeout tests that --timeout stops the whole run.
+func TestPlanCommand_Integration_Timeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	config := testutil.DefaultMockConfig()
+	config.ResponseDelay = 10000
+	mockSetup := testutil.SetupMockAgent(t, "mock-claude", config)
+
+	agentsPath := testutil.CreateTestAgentsFile(t, "")
+	workDir := testutil.CreateTestBeadsDir(t)
+
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	os.Chdir(workDir)
+
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{mockSetup.Agent}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{
+		"plan",
+		"--rounds", "3",
+		"--timeout", "500ms",
+		"--agents-path", agentsPath,
+		"Overall timeout test",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := rootCmd.ExecuteContext(ctx)
+	output := buf.String()
+
+	if err == nil || !strings.Contains(err.Error(), "plan timed out after 500ms during round 1") {
+		t.Fatalf("expected the run to time out in round 1, got: %v\nOutput: %s", err, output)
+	}
+	if elapsed := time.Since(start); elapsed > 8*time.Second {
+		t.Errorf("run took %s; --timeout should have stopped it", elapsed)
+	}
+	if strings.Contains(output, "Round 2") {
+		t.Errorf("no further rounds should run after the timeout, got:\n%s", output)
+	}
+}
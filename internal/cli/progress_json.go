@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// progressEvent is one line of --progress json output, written when an agent
+// finishes its turn.
+type progressEvent struct {
+	Round        int      `json:"round"`
+	Agent        string   `json:"agent"`
+	Status       string   `json:"status"` // done, failed, skipped or cached
+	Duration     string   `json:"duration"`
+	DurationMs   int64    `json:"duration_ms"`
+	BeadsChanged []string `json:"beads_changed"`
+	ContextUsage float64  `json:"context_usage"` // 0.0-1.0
+	Error        string   `json:"error,omitempty"`
+	SkipReason   string   `json:"skip_reason,omitempty"`
+}
+
+// jsonProgressReporter writes one JSON object per finished agent turn, one
+// per line, so editors and other tools can follow a run as it happens.
+type jsonProgressReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newJSONProgressReporter(out io.Writer) *jsonProgressReporter {
+	return &jsonProgressReporter{enc: json.NewEncoder(out)}
+}
+
+// OnRoundStart writes nothing; each line describes a finished turn.
+func (r *jsonProgressReporter) OnRoundStart(round, totalRounds, agents int) {}
+
+// OnRoundComplete writes nothing; the round's turns were already written.
+func (r *jsonProgressReporter) OnRoundComplete(round int, result orchestrator.RoundResult) {}
+
+// OnAgentStart writes nothing; the turn is written once it finishes.
+func (r *jsonProgressReporter) OnAgentStart(round, agentIndex, totalAgents int, ag agent.Agent) {}
+
+// OnAgentComplete writes the finished turn as a single JSON line.
+func (r *jsonProgressReporter) OnAgentComplete(round, agentIndex, totalAgents int, result orchestrator.AgentResult, beadsDiff string) {
+	event := progressEvent{
+		Round:        round,
+		Agent:        result.Agent.Name,
+		Status:       agentStatus(result),
+		Duration:     result.Duration.String(),
+		DurationMs:   result.Duration.Milliseconds(),
+		BeadsChanged: result.BeadsChanged,
+		ContextUsage: result.Response.ContextUsage,
+		SkipReason:   result.SkipReason,
+	}
+	if event.BeadsChanged == nil {
+		event.BeadsChanged = []string{}
+	}
+	if result.Error != nil {
+		event.Error = result.Error.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(event) // Encode ends each object with a newline
+}
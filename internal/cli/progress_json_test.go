@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/logging"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// TestJSONProgressReporter_OneLinePerTurn tests that each finished turn is a
+// JSON object on its own line carrying the expected fields
+func TestJSONProgressReporter_OneLinePerTurn(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := newJSONProgressReporter(buf)
+	claude := agent.Agent{Name: "claude"}
+	codex := agent.Agent{Name: "codex"}
+
+	r.OnRoundStart(2, 3, 2)
+	r.OnAgentStart(2, 1, 2, claude)
+	r.OnAgentComplete(2, 1, 2, orchestrator.AgentResult{
+		Agent:        claude,
+		Response:     session.Response{ContextUsage: 0.42},
+		BeadsChanged: []string{"buckshot-1", "buckshot-2"},
+		Duration:     1500 * time.Millisecond,
+	}, "+ buckshot-1: Cache\n")
+	r.OnAgentStart(2, 2, 2, codex)
+	r.OnAgentComplete(2, 2, 2, orchestrator.AgentResult{Agent: codex, Error: errors.New("boom"), Duration: 2 * time.Second}, "")
+	r.OnRoundComplete(2, orchestrator.RoundResult{Round: 2, TotalChanges: 2, FailedCount: 1})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []progressEvent{
+		{Round: 2, Agent: "claude", Status: "done", Duration: "1.5s", DurationMs: 1500, BeadsChanged: []string{"buckshot-1", "buckshot-2"}, ContextUsage: 0.42},
+		{Round: 2, Agent: "codex", Status: "failed", Duration: "2s", DurationMs: 2000, BeadsChanged: []string{}, Error: "boom"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		var got progressEvent
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", i+1, err, line)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("line %d = %+v, want %+v", i+1, got, want[i])
+		}
+	}
+	for _, key := range []string{`"round"`, `"agent"`, `"status"`, `"duration_ms"`, `"beads_changed"`, `"context_usage"`} {
+		if !strings.Contains(lines[1], key) {
+			t.Errorf("line %q missing %s", lines[1], key)
+		}
+	}
+}
+
+// TestNewProgressReporter_JSON tests that json mode writes JSON even without a TTY
+func TestNewProgressReporter_JSON(t *testing.T) {
+	rep, err := newProgressReporter(progressJSON, new(bytes.Buffer), logging.New(new(bytes.Buffer), logging.LevelInfo))
+	if err != nil {
+		t.Fatalf("newProgressReporter() error = %v", err)
+	}
+	if _, ok := rep.(*jsonProgressReporter); !ok {
+		t.Errorf("json mode should use the JSON reporter, got %T", rep)
+	}
+}
+
+// TestPlanCommand_ProgressJSON tests that --progress-json writes one JSON
+// line per turn to stderr and keeps stdout for the results
+func TestPlanCommand_ProgressJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setBeadsClient(t, listedBeads{})
+
+	script := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '10% used'\nwhile read -r line; do echo 'Looks good'; echo '10% used'; done\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}}, nil
+	})
+	defer restore()
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "2", "--progress-json", "--no-agents-file", "Design API"})
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("plan --progress-json should not error, got: %v", err)
+	}
+
+	var events []progressEvent
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var e progressEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("progress line is not JSON: %v\n%s", err, line)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d progress events, want one per round:\n%s", len(events), stderr.String())
+	}
+	for i, e := range events {
+		if e.Round != i+1 || e.Agent != "claude" || e.Status != "done" || e.ContextUsage != 0.1 {
+			t.Errorf("event %d = %+v, want a done claude turn in round %d at 10%% context", i, e, i+1)
+		}
+	}
+	if strings.Contains(stdout.String(), `"round"`) {
+		t.Errorf("progress events should not reach stdout:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/logging"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// Progress display modes for --progress.
+const (
+	progressLine  = "line"
+	progressTable = "table"
+	progressJSON  = "json"
+)
+
+// newProgressReporter returns the reporter for the given mode. The table
+// reporter needs a terminal to redraw in place, so it degrades to the line
+// reporter when w isn't one. The JSON reporter writes to w whether or not it
+// is a terminal, since it is meant for tools.
+func newProgressReporter(mode string, w io.Writer, log *logging.Logger) (orchestrator.ProgressReporter, error) {
+	switch mode {
+	case progressLine, "":
+		return newTerminalProgressReporter(log), nil
+	case progressTable:
+		if !isTerminal(w) {
+			return newTerminalProgressReporter(log), nil
+		}
+		return newTableProgressReporter(w), nil
+	case progressJSON:
+		return newJSONProgressReporter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown progress mode %q (want %s, %s or %s)", mode, progressLine, progressTable, progressJSON)
+	}
+}
+
+// isTerminal reports whether w is a character device such as a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// tableRow is the live state of one agent in the table.
+type tableRow struct {
+	name    string
+	status  string
+	started time.Time
+	elapsed time.Duration
+	done    bool
+	context float64
+	changes int
+	note    string // Why the agent was skipped, shown after the row
+}
+
+// tableProgressReporter renders an in-place updating table with one row per
+// agent, redrawn with ANSI cursor movement on every event.
+type tableProgressReporter struct {
+	mu          sync.Mutex
+	out         io.Writer
+	round       int
+	totalRounds int
+	rows        []*tableRow
+	drawn       int // Lines drawn by the last render, to move back over
+	now         func() time.Time
+}
+
+func newTableProgressReporter(out io.Writer) *tableProgressReporter {
+	return &tableProgressReporter{out: out, now: time.Now}
+}
+
+// OnRoundStart begins a fresh table for the round.
+func (r *tableProgressReporter) OnRoundStart(round, totalRounds, agents int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.round = round
+	r.totalRounds = totalRounds
+	r.rows = make([]*tableRow, 0, agents)
+	r.drawn = 0
+	r.render()
+}
+
+// OnAgentStart adds or resets the agent's row as running.
+func (r *tableProgressReporter) OnAgentStart(round, agentIndex, totalAgents int, ag agent.Agent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row := r.row(ag.Name)
+	row.status = "running"
+	row.started = r.now()
+	row.done = false
+	r.render()
+}
+
+// OnAgentComplete records the agent's final status.
+func (r *tableProgressReporter) OnAgentComplete(round, agentIndex, totalAgents int, result orchestrator.AgentResult, beadsDiff string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	row := r.row(result.Agent.Name)
+	row.status = agentStatus(result)
+	switch {
+	case result.Duration > 0:
+		row.elapsed = result.Duration
+	case !row.started.IsZero():
+		row.elapsed = r.now().Sub(row.started)
+	}
+	row.done = true
+	row.context = result.Response.ContextUsage
+	row.changes = len(result.BeadsChanged)
+	row.note = result.SkipReason
+	r.render()
+}
+
+// OnRoundComplete draws the final table and a summary line, leaving both on screen.
+func (r *tableProgressReporter) OnRoundComplete(round int, result orchestrator.RoundResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.render()
+	_, _ = fmt.Fprintf(r.out, "Round %d: %d changes, %d failed, %d skipped\n",
+		round, result.TotalChanges, result.FailedCount, result.SkippedCount)
+	r.drawn = 0
+}
+
+// Write prints p, typically a log line, above the table and redraws the
+// table below it. Logging straight to the terminal while the table is up
+// would be overwritten or break the cursor math of the next redraw.
+func (r *tableProgressReporter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.drawn == 0 {
+		return r.out.Write(p)
+	}
+	// Clear from the top of the table down, then write where it was
+	_, _ = fmt.Fprintf(r.out, "\x1b[%dA\x1b[J", r.drawn)
+	n, err := r.out.Write(p)
+	r.drawn = 0
+	r.render()
+	return n, err
+}
+
+// agentStatus is the one-word status of a finished turn.
+func agentStatus(result orchestrator.AgentResult) string {
+	switch {
+	case result.Error != nil:
+		return "failed"
+	case result.Skipped:
+		return "skipped"
+	case result.Cached:
+		return "cached"
+	default:
+		return "done"
+	}
+}
+
+// row returns the row for name, adding it if needed. Caller holds mu.
+func (r *tableProgressReporter) row(name string) *tableRow {
+	for _, row := range r.rows {
+		if row.name == name {
+			return row
+		}
+	}
+	row := &tableRow{name: name, status: "pending"}
+	r.rows = append(r.rows, row)
+	return row
+}
+
+// render redraws the table over the previous render. Caller holds mu.
+func (r *tableProgressReporter) render() {
+	var sb strings.Builder
+
+	// Move back to the top of the previous render
+	if r.drawn > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dA", r.drawn)
+	}
+
+	lines := r.lines()
+	for _, line := range lines {
+		sb.WriteString("\x1b[2K")
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	r.drawn = len(lines)
+
+	_, _ = io.WriteString(r.out, sb.String())
+}
+
+// lines returns the table content. Caller holds mu.
+func (r *tableProgressReporter) lines() []string {
+	lines := []string{
+		roundLabel(r.round, r.totalRounds),
+		fmt.Sprintf("  %-14s %-8s %8s %8s %8s", "AGENT", "STATUS", "ELAPSED", "CONTEXT", "CHANGES"),
+	}
+	for _, row := range r.rows {
+		elapsed := row.elapsed
+		if !row.done && !row.started.IsZero() {
+			elapsed = r.now().Sub(row.started)
+		}
+
+		ctxStr, changes := "-", "-"
+		if row.done {
+			ctxStr = fmt.Sprintf("%.0f%%", row.context*100)
+			changes = fmt.Sprintf("%d", row.changes)
+		}
+
+		line := fmt.Sprintf("  %-14s %-8s %7.1fs %8s %8s",
+			row.name, row.status, elapsed.Seconds(), ctxStr, changes)
+		if row.note != "" {
+			line += "  (" + row.note + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
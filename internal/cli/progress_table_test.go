@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/logging"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// TestTableProgressReporter_RendersRows tests the table content and in-place redraws
+func TestTableProgressReporter_RendersRows(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := newTableProgressReporter(buf)
+	clock := time.Unix(0, 0)
+	r.now = func() time.Time { return clock }
+
+	claude := agent.Agent{Name: "claude"}
+	codex := agent.Agent{Name: "codex"}
+
+	r.OnRoundStart(1, 3, 2)
+	r.OnAgentStart(1, 1, 2, claude)
+	clock = clock.Add(1500 * time.Millisecond)
+	r.OnAgentComplete(1, 1, 2, orchestrator.AgentResult{
+		Agent:        claude,
+		Response:     session.Response{ContextUsage: 0.42},
+		BeadsChanged: []string{"buckshot-1", "buckshot-2"},
+	}, "")
+	r.OnAgentStart(1, 2, 2, codex)
+	r.OnAgentComplete(1, 2, 2, orchestrator.AgentResult{Agent: codex, Error: errors.New("boom"), Duration: 2300 * time.Millisecond}, "")
+	r.OnRoundComplete(1, orchestrator.RoundResult{Round: 1, TotalChanges: 2, FailedCount: 1})
+
+	out := buf.String()
+
+	for _, want := range []string{"Round 1/3", "AGENT", "claude", "done", "1.5s", "42%", "codex", "failed", "2.3s", "Round 1: 2 changes, 1 failed, 0 skipped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table output missing %q, got:\n%s", want, out)
+		}
+	}
+
+	// Every redraw after the first moves the cursor back up over the table
+	if !strings.Contains(out, "\x1b[2A") || !strings.Contains(out, "\x1b[3A") {
+		t.Errorf("table output should redraw in place with cursor-up codes, got %q", out)
+	}
+	if !strings.Contains(out, "\x1b[2K") {
+		t.Errorf("table output should clear lines before redrawing, got %q", out)
+	}
+
+	// The final row state for claude shows its change count
+	last := out[strings.LastIndex(out, "claude"):]
+	if !strings.Contains(last[:strings.Index(last, "\n")], " 2") {
+		t.Errorf("claude row should show 2 changes, got %q", last[:strings.Index(last, "\n")])
+	}
+}
+
+// TestTableProgressReporter_OpenEndedRounds tests the header without a round cap
+func TestTableProgressReporter_OpenEndedRounds(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := newTableProgressReporter(buf)
+
+	r.OnRoundStart(4, 0, 1)
+
+	if !strings.Contains(buf.String(), "Round 4\n") {
+		t.Errorf("header should omit total rounds when open-ended, got %q", buf.String())
+	}
+}
+
+// TestTableProgressReporter_LogsAboveTable tests that log lines written
+// through the table land above it and the table is redrawn below them
+func TestTableProgressReporter_LogsAboveTable(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := newTableProgressReporter(buf)
+	log := logging.New(r, logging.LevelInfo)
+
+	log.Infof("before the round")
+	if buf.String() != "before the round\n" {
+		t.Errorf("with no table drawn, logs should pass straight through, got %q", buf.String())
+	}
+
+	r.OnRoundStart(1, 1, 1)
+	r.OnAgentStart(1, 1, 1, agent.Agent{Name: "claude"})
+	buf.Reset()
+	log.Warnf("claude is slow")
+
+	out := buf.String()
+	cleared := strings.Index(out, "\x1b[3A\x1b[J")
+	logged := strings.Index(out, "claude is slow\n")
+	redrawn := strings.LastIndex(out, "claude ")
+	if cleared != 0 || logged < cleared || redrawn < logged {
+		t.Errorf("want the table cleared, the log line, then the table again, got %q", out)
+	}
+
+	// The next redraw moves back over the table only, not the log line
+	buf.Reset()
+	r.OnAgentComplete(1, 1, 1, orchestrator.AgentResult{Agent: agent.Agent{Name: "claude"}}, "")
+	if !strings.HasPrefix(buf.String(), "\x1b[3A") {
+		t.Errorf("redraw should move up over the 3 table lines, got %q", buf.String())
+	}
+}
+
+// TestNewProgressReporter_DegradesWithoutTTY tests the fallback to the line reporter
+func TestNewProgressReporter_DegradesWithoutTTY(t *testing.T) {
+	log := logging.New(new(bytes.Buffer), logging.LevelInfo)
+
+	rep, err := newProgressReporter(progressTable, new(bytes.Buffer), log)
+	if err != nil {
+		t.Fatalf("newProgressReporter() error = %v", err)
+	}
+	if _, ok := rep.(*terminalProgressReporter); !ok {
+		t.Errorf("table mode on a non-TTY should degrade to the line reporter, got %T", rep)
+	}
+
+	rep, err = newProgressReporter(progressLine, new(bytes.Buffer), log)
+	if err != nil {
+		t.Fatalf("newProgressReporter() error = %v", err)
+	}
+	if _, ok := rep.(*terminalProgressReporter); !ok {
+		t.Errorf("line mode should use the line reporter, got %T", rep)
+	}
+
+	if _, err := newProgressReporter("sparkles", new(bytes.Buffer), log); err == nil {
+		t.Error("newProgressReporter() should reject unknown modes")
+	}
+}
+
+// TestTerminalProgressReporter_RoundLines tests the line reporter's round header and summary
+func TestTerminalProgressReporter_RoundLines(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := newTerminalProgressReporter(logging.New(buf, logging.LevelInfo))
+
+	r.OnRoundStart(2, 3, 4)
+	r.OnRoundComplete(2, orchestrator.RoundResult{Round: 2, TotalChanges: 5, FailedCount: 1, SkippedCount: 1})
+	r.OnRoundStart(7, 0, 1)
+
+	out := buf.String()
+	for _, want := range []string{
+		"[Round 2/3] Starting with 4 agent(s)",
+		"[Round 2/3] Complete: 5 change(s), 1 failed, 1 skipped",
+		"[Round 7] Starting with 1 agent(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestTerminalProgressReporter_Cached tests that reused responses are labelled
+func TestTerminalProgressReporter_Cached(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := newTerminalProgressReporter(logging.New(buf, logging.LevelInfo))
+
+	r.OnAgentComplete(2, 1, 1, orchestrator.AgentResult{Agent: agent.Agent{Name: "claude"}, Cached: true}, "")
+
+	if !strings.Contains(buf.String(), "claude - CACHED") {
+		t.Errorf("expected a CACHED status, got: %s", buf.String())
+	}
+}
+
+// TestPlanCommand_ProgressFlag tests the --progress flag default
+func TestPlanCommand_ProgressFlag(t *testing.T) {
+	planCmd := subcommand(t, newRootCmd(), "plan")
+	flag := planCmd.Flags().Lookup("progress")
+	if flag == nil {
+		t.Fatal("--progress flag not found")
+	}
+	if flag.DefValue != progressLine {
+		t.Errorf("--progress default = %q, want %q", flag.DefValue, progressLine)
+	}
+}
+
+// TestProgressReporters_SkipReason tests that both reporters say why an agent was skipped
+func TestProgressReporters_SkipReason(t *testing.T) {
+	skipped := orchestrator.AgentResult{Agent: agent.Agent{Name: "codex"}, Skipped: true, SkipReason: orchestrator.SkipNotAuthenticated}
+
+	buf := new(bytes.Buffer)
+	newTerminalProgressReporter(logging.New(buf, logging.LevelInfo)).OnAgentComplete(1, 1, 1, skipped, "")
+	if !strings.Contains(buf.String(), "codex - SKIPPED (not authenticated)") {
+		t.Errorf("line reporter should show the skip reason, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	table := newTableProgressReporter(buf)
+	table.OnRoundStart(1, 1, 1)
+	table.OnAgentComplete(1, 1, 1, skipped, "")
+	if !strings.Contains(buf.String(), "(not authenticated)") {
+		t.Errorf("table reporter should show the skip reason, got: %s", buf.String())
+	}
+}
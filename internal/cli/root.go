@@ -1,26 +1,134 @@
 package cli
 
 import (
+	"io"
+	"os"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/config"
+	"github.com/michaellady/buckshot/internal/logging"
 	"github.com/spf13/cobra"
 )
 
-var rootCmd = &cobra.Command{
-	Use:   "buckshot",
-	Short: "Multi-agent planning protocol for AI coding assistants",
-	Long: `Buckshot orchestrates multiple AI coding agents (Claude Code, Codex, Cursor)
+// rootOptions holds the persistent flags every command shares. Each
+// invocation gets its own from newRootCmd, so runs never see each other's flags.
+type rootOptions struct {
+	// configPath is the path to an optional JSON config file.
+	configPath string
+	logLevel   string
+	quiet      bool
+	// rawOutput shows agents' unparsed output.
+	rawOutput bool
+	// noColor disables ANSI colors in results.
+	noColor bool
+	// agentsPath is the AGENTS.md given with --agents-path; empty means discover it.
+	agentsPath string
+	// logger receives diagnostics for the running command. It writes to the
+	// command's stderr so stdout carries only results.
+	logger *logging.Logger
+	// registry holds the custom agents loadConfig read from --config.
+	registry *agent.Registry
+}
+
+// newRootCmd builds the buckshot command tree with fresh flag state.
+func newRootCmd() *cobra.Command {
+	opts := &rootOptions{logger: logging.New(os.Stderr, logging.LevelInfo)}
+	cmd := &cobra.Command{
+		Use:   "buckshot",
+		Short: "Multi-agent planning protocol for AI coding assistants",
+		Long: `Buckshot orchestrates multiple AI coding agents (Claude Code, Codex, Cursor)
 to collaboratively plan and refine development tasks using beads (bd) for issue tracking.
 
 Each planning round, all available agents analyze the current plan and suggest
-improvements until the team converges on a complete solution.`,
+improvements until the team converges on a complete solution.
+
+Results are written to stdout; progress, warnings, and errors go to stderr.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.setupLogger(cmd)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.logLevel, "log-level", "info", "Diagnostic log level: debug, info, warn, error")
+	cmd.PersistentFlags().BoolVarP(&opts.quiet, "quiet", "q", false, "Only log errors (results are still printed)")
+	cmd.PersistentFlags().BoolVar(&opts.rawOutput, "raw", false, "Show agents' unparsed output (no JSON extraction or dedup)")
+	cmd.PersistentFlags().BoolVar(&opts.noColor, "no-color", false, "Disable colored output (also honors NO_COLOR)")
+	cmd.PersistentFlags().Var(newPathValue(&opts.configPath), "config", "Path to a JSON config file (price table overrides, custom agents, etc.)")
+	// Shared by every command that reads AGENTS.md, so it is registered once
+	cmd.PersistentFlags().VarP(newPathValue(&opts.agentsPath), "agents-path", "a", "Path to AGENTS.md file (default: discovered from the current directory upward)")
+
+	cmd.AddCommand(newPlanCmd(opts))
+	cmd.AddCommand(newAgentsCmd(opts))
+	cmd.AddCommand(newFeedbackCmd(opts))
+	cmd.AddCommand(newCompareCmd(opts))
+	cmd.AddCommand(newChatCmd(opts))
+	cmd.AddCommand(newParseCmd(opts))
+	return cmd
 }
 
-func Execute(version string) error {
-	rootCmd.Version = version
-	return rootCmd.Execute()
+// forceTTY makes colorEnabled treat every writer as a terminal. Tests set it
+// to check colored output without a real TTY.
+var forceTTY bool
+
+// setupLogger configures the logger from --log-level and --quiet.
+func (o *rootOptions) setupLogger(cmd *cobra.Command) error {
+	level, err := logging.ParseLevel(o.logLevel)
+	if err != nil {
+		return err
+	}
+	if o.quiet {
+		level = logging.LevelError
+	}
+
+	o.logger = logging.New(cmd.ErrOrStderr(), level)
+	return nil
 }
 
-func init() {
-	rootCmd.AddCommand(planCmd)
-	rootCmd.AddCommand(agentsCmd)
-	rootCmd.AddCommand(feedbackCmd)
+// loadConfig reads --config and keeps the custom agents it defines in
+// o.registry for this invocation.
+func (o *rootOptions) loadConfig() (config.Config, error) {
+	cfg, err := config.Load(o.configPath)
+	if err != nil {
+		return cfg, err
+	}
+	if o.registry, err = cfg.Registry(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// detectAgents runs agentDetector and applies --raw.
+func (o *rootOptions) detectAgents() ([]agent.Agent, error) {
+	return o.detectAgentsWith(agentDetector)
+}
+
+// detectAgentsWith runs detect with the invocation's registry and applies
+// --raw.
+func (o *rootOptions) detectAgentsWith(detect func(*agent.Registry) ([]agent.Agent, error)) ([]agent.Agent, error) {
+	agents, err := detect(o.registry)
+	if err != nil {
+		return nil, err
+	}
+	if o.rawOutput {
+		for i := range agents {
+			agents[i].Parser = agent.NewRawParser(agents[i].Parser)
+		}
+	}
+	return agents, nil
+}
+
+// colorEnabled reports whether results written to w should be colored:
+// only on a terminal, and never with --no-color or NO_COLOR set.
+func (o *rootOptions) colorEnabled(w io.Writer) bool {
+	if o.noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return forceTTY || isTerminal(w)
+}
+
+// Execute runs buckshot with the process arguments. ExitCode gives the
+// process exit code for the error it returns.
+func Execute(version string) error {
+	cmd := newRootCmd()
+	cmd.Version = version
+	return cmd.Execute()
 }
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/dispatch"
+	"github.com/michaellady/buckshot/internal/presentation"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// Output formats accepted by --output.
+const (
+	outputTerminal = "terminal"
+	outputJSON     = "json"
+	outputMarkdown = "markdown"
+	outputTable    = "markdown-table"
+)
+
+// parseOutputFormat maps an --output value to a presentation format.
+func parseOutputFormat(name string) (presentation.OutputFormat, error) {
+	switch name {
+	case outputTerminal:
+		return presentation.FormatTerminal, nil
+	case outputJSON:
+		return presentation.FormatJSON, nil
+	case outputMarkdown:
+		return presentation.FormatMarkdown, nil
+	case outputTable:
+		return presentation.FormatMarkdownTable, nil
+	default:
+		return 0, fmt.Errorf("unknown output format %q (want %s, %s, %s, or %s)", name, outputTerminal, outputJSON, outputMarkdown, outputTable)
+	}
+}
+
+// timedSession records how long its last Send took.
+type timedSession struct {
+	session.Session
+	elapsed time.Duration
+}
+
+func (s *timedSession) Send(ctx context.Context, prompt string) (session.Response, error) {
+	start := time.Now()
+	resp, err := s.Session.Send(ctx, prompt)
+	s.elapsed = time.Since(start)
+	return resp, err
+}
+
+// runSingle asks each agent the prompt once, in parallel, and prints their
+// answers. There are no rounds, beads refresh, or convergence checks.
+// newSession supplies each agent's session; limiter, if set, spaces the sends.
+func (o *planOptions) runSingle(ctx context.Context, out io.Writer, agents []agent.Agent, prompt string, format presentation.OutputFormat, newSession func(agent.Agent) (session.Session, error), limiter *dispatch.RateLimiter) error {
+	timed := make(map[string]*timedSession, len(agents))
+	sessions := make([]session.Session, 0, len(agents))
+	for _, ag := range agents {
+		sess, err := newSession(ag)
+		if err != nil {
+			return fmt.Errorf("failed to create session for %s: %w", ag.Name, err)
+		}
+		if err := sess.Start(ctx, ""); err != nil {
+			return fmt.Errorf("failed to start %s: %w", ag.Name, err)
+		}
+		defer func() { _ = sess.Close() }()
+
+		ts := &timedSession{Session: sess}
+		timed[ag.Name] = ts
+		sessions = append(sessions, ts)
+	}
+
+	o.logger.Infof("Asking %d agent(s) once...", len(sessions))
+	dispatcher := dispatch.New()
+	dispatcher.SetRateLimiter(limiter)
+	// Answers are only printed once everyone is done; say who has answered meanwhile
+	answered := 0
+	dispatched := dispatcher.DispatchStream(ctx, sessions, prompt, func(r dispatch.Result) {
+		answered++
+		elapsed := timed[r.Agent.Name].elapsed.Round(time.Millisecond)
+		if r.Error != nil {
+			o.logger.Infof("%s failed after %s (%d/%d)", r.Agent.Name, elapsed, answered, len(sessions))
+			return
+		}
+		o.logger.Infof("%s answered in %s (%d/%d)", r.Agent.Name, elapsed, answered, len(sessions))
+	})
+
+	results := make([]presentation.AgentResult, len(dispatched))
+	failed := 0
+	for i, r := range dispatched {
+		results[i] = presentation.AgentResult{Result: r, Duration: timed[r.Agent.Name].elapsed}
+		if r.Error != nil {
+			failed++
+		}
+	}
+
+	formatter := presentation.New()
+	formatter.SetMaxResponseLength(o.maxResponseLength)
+	formatter.SetTruncateStrategy(presentation.TruncateStrategy(o.truncateStrategy))
+	formatter.SetColor(o.colorEnabled(out))
+	formatter.SetLogger(o.logger)
+	_, _ = fmt.Fprintln(out, formatter.Format(results, format))
+
+	if failed == len(results) {
+		return &ExitError{Code: ExitAllFailed, Err: fmt.Errorf("all %d agent(s) failed", failed)}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// countingSession answers every prompt with a fixed reply and counts sends.
+type countingSession struct {
+	agent agent.Agent
+	reply string
+	mu    *sync.Mutex
+	sends map[string]int
+}
+
+func (s *countingSession) Start(ctx context.Context, agentsPath string) error { return nil }
+func (s *countingSession) ContextUsage() float64                              { return 0 }
+func (s *countingSession) IsAlive() bool                                      { return true }
+func (s *countingSession) Agent() agent.Agent                                 { return s.agent }
+func (s *countingSession) Close() error                                       { return nil }
+
+func (s *countingSession) Send(ctx context.Context, prompt string) (session.Response, error) {
+	s.mu.Lock()
+	s.sends[s.agent.Name]++
+	s.mu.Unlock()
+	return session.Response{Output: s.reply + " " + prompt}, nil
+}
+
+// setupSingle injects three detected agents and one-shot sessions that count
+// sends per agent, and returns the counts.
+func setupSingle(t *testing.T, reply string) map[string]int {
+	t.Helper()
+	restoreDetector := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Authenticated: true},
+			{Name: "codex", Authenticated: true},
+			{Name: "gemini", Authenticated: true},
+		}, nil
+	})
+	t.Cleanup(restoreDetector)
+
+	var mu sync.Mutex
+	sends := map[string]int{}
+	orig := newOneShotSession
+	newOneShotSession = func(ag agent.Agent, opts session.Options) session.Session {
+		return &countingSession{agent: ag, reply: reply, mu: &mu, sends: sends}
+	}
+	t.Cleanup(func() { newOneShotSession = orig })
+	return sends
+}
+
+// TestPlanCommand_Single tests that --single asks each selected agent once
+// and renders the answers with the terminal formatter
+func TestPlanCommand_Single(t *testing.T) {
+	t.Chdir(t.TempDir())
+	sends := setupSingle(t, "Answer to:")
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--agents", "claude,codex", "What is a bead?"})
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("plan --single should not error, got: %v", err)
+	}
+
+	if sends["claude"] != 1 || sends["codex"] != 1 || sends["gemini"] != 0 {
+		t.Errorf("sends = %v, want claude and codex once each and gemini not at all", sends)
+	}
+	out := stdout.String()
+	for _, want := range []string{"┌", "Answer to: What is a bead?", "Summary: 2 agents, 2 succeeded, 0 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Round 1") || strings.Contains(out, "Changes:") {
+		t.Errorf("single mode should not run rounds, got:\n%s", out)
+	}
+	// Each agent is announced as it answers, ahead of the boxes
+	for _, name := range []string{"claude", "codex"} {
+		if !strings.Contains(stderr.String(), name+" answered in ") {
+			t.Errorf("stderr should announce %s's answer, got:\n%s", name, stderr.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), "(2/2)") {
+		t.Errorf("stderr should count answers, got:\n%s", stderr.String())
+	}
+}
+
+// TestPlanCommand_RoundsZeroJSON tests that --rounds 0 means --single and honors --output
+func TestPlanCommand_RoundsZeroJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+	sends := setupSingle(t, "Answer")
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--rounds", "0", "--output", "json", "Question"})
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("plan --rounds 0 should not error, got: %v", err)
+	}
+
+	var results []struct {
+		Agent    string `json:"agent"`
+		Response string `json:"response"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(results) != 3 || results[0].Agent != "claude" || results[0].Response != "Answer Question" {
+		t.Errorf("results = %+v, want one answer per agent in name order", results)
+	}
+	for name, n := range sends {
+		if n != 1 {
+			t.Errorf("%s was sent %d prompts, want 1", name, n)
+		}
+	}
+}
+
+// TestPlanCommand_SingleMaxResponseLength tests that long answers are truncated
+func TestPlanCommand_SingleMaxResponseLength(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setupSingle(t, strings.Repeat("x", 50))
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--agents", "claude", "--max-response-length", "20", "Q"})
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("plan --single should not error, got: %v", err)
+	}
+	if !strings.Contains(stdout.String(), strings.Repeat("x", 20)+"... [truncated]") {
+		t.Errorf("expected the answer truncated to 20 characters, got:\n%s", stdout.String())
+	}
+}
+
+// TestPlanCommand_SingleTruncateTail tests that --truncate-strategy tail keeps
+// the end of long answers
+func TestPlanCommand_SingleTruncateTail(t *testing.T) {
+	t.Chdir(t.TempDir())
+	setupSingle(t, strings.Repeat("x", 50)+" Conclusion:")
+
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--agents", "claude", "--max-response-length", "19", "--truncate-strategy", "tail", "ship it"})
+	stdout := new(bytes.Buffer)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("plan --single should not error, got: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "[truncated] ...Conclusion: ship it") {
+		t.Errorf("expected the end of the answer kept, got:\n%s", stdout.String())
+	}
+}
+
+// TestPlanCommand_InvalidTruncateStrategy tests --truncate-strategy validation
+func TestPlanCommand_InvalidTruncateStrategy(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--truncate-strategy", "middle", "Q"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), `--truncate-strategy: unknown truncate strategy "middle"`) {
+		t.Errorf("Expected an unknown truncate strategy error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_InvalidOutput tests --output validation
+func TestPlanCommand_InvalidOutput(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--output", "yaml", "Q"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `unknown output format "yaml"`) {
+		t.Errorf("expected an unknown-format error, got: %v", err)
+	}
+}
+
+// TestPlanCommand_JSONOutput tests that --json-output shows answers as JSON
+// and warns about agents that can't produce structured output
+func TestPlanCommand_JSONOutput(t *testing.T) {
+	t.Chdir(t.TempDir())
+	restore := setAgentDetector(func() ([]agent.Agent, error) {
+		return []agent.Agent{
+			{Name: "claude", Authenticated: true, Pattern: agent.KnownAgents()["claude"], Parser: &agent.ClaudeParser{}},
+			{Name: "plain", Authenticated: true, Pattern: agent.CLIPattern{Binary: "plain"}},
+			{Name: "unparsed", Authenticated: true, Pattern: agent.CLIPattern{Binary: "unparsed", JSONOutputArgs: []string{"--json"}}, Parser: &agent.NoopParser{}},
+		}, nil
+	})
+	defer restore()
+	orig := newOneShotSession
+	newOneShotSession = func(ag agent.Agent, opts session.Options) session.Session {
+		return &countingSession{agent: ag, reply: "Answer", mu: &sync.Mutex{}, sends: map[string]int{}}
+	}
+	defer func() { newOneShotSession = orig }()
+
+	for _, tt := range []struct {
+		args []string
+		warn bool
+	}{
+		{[]string{"--json-output"}, true},
+		{[]string{"--output", "json"}, true},
+		{nil, false},
+	} {
+		t.Run(strings.Join(append([]string{"plan"}, tt.args...), " "), func(t *testing.T) {
+			rootCmd := newRootCmd()
+			rootCmd.SetArgs(append(append([]string{"plan", "--single"}, tt.args...), "Question"))
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			rootCmd.SetOut(stdout)
+			rootCmd.SetErr(stderr)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("plan should not error, got: %v", err)
+			}
+
+			if tt.warn && !json.Valid(stdout.Bytes()) {
+				t.Errorf("output is not JSON:\n%s", stdout.String())
+			}
+			warnings := map[string]string{
+				"plain":    "plain has no structured output mode; its response will be captured as plain text",
+				"unparsed": "unparsed emits JSON events but has no parser for them",
+			}
+			for name, want := range warnings {
+				if got := strings.Contains(stderr.String(), want); got != tt.warn {
+					t.Errorf("%s warned = %v, want %v; stderr:\n%s", name, got, tt.warn, stderr.String())
+				}
+			}
+			if strings.Contains(stderr.String(), "claude has no") || strings.Contains(stderr.String(), "claude emits") {
+				t.Errorf("claude should not be warned about, got:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+// TestPlanCommand_JSONOutputConflict tests that --json-output and --output
+// can't both be given
+func TestPlanCommand_JSONOutputConflict(t *testing.T) {
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs([]string{"plan", "--single", "--json-output", "--output", "markdown", "Question"})
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "json-output") {
+		t.Errorf("expected a flag conflict, got: %v", err)
+	}
+}
@@ -2,13 +2,14 @@ package cli
 
 import (
 	"sync"
+	"testing"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/spf13/cobra"
 )
 
 // agentDetectorMu protects agentDetector from concurrent access in tests
-//
-//nolint:unused // Used by integration tests (//go:build integration)
 var agentDetectorMu sync.Mutex
 
 // setAgentDetector safely sets the agent detector function for testing.
@@ -19,36 +20,38 @@ var agentDetectorMu sync.Mutex
 //	    return []agent.Agent{mockAgent}, nil
 //	})
 //	defer restore()
-//
-//nolint:unused // Used by integration tests (//go:build integration)
 func setAgentDetector(fn func() ([]agent.Agent, error)) func() {
 	agentDetectorMu.Lock()
 	orig := agentDetector
-	agentDetector = fn
+	agentDetector = func(*agent.Registry) ([]agent.Agent, error) { return fn() }
 	return func() {
 		agentDetector = orig
 		agentDetectorMu.Unlock()
 	}
 }
 
-// resetPlanFlags resets all plan command flags to their default values.
-// This MUST be called at the start of each integration test to ensure clean state.
-//
-//nolint:unused // Used by integration tests (//go:build integration)
-func resetPlanFlags() {
-	selectedAgents = nil
-	untilConverged = false
-	rounds = 3
-	agentsPath = ""
-	saveToBead = ""
-	verbose = false
+// setBeadsClient makes plan runs in this test use c instead of bd.
+func setBeadsClient(t *testing.T, c beads.Client) {
+	orig := newBeadsClient
+	newBeadsClient = func() beads.Client { return c }
+	t.Cleanup(func() { newBeadsClient = orig })
 }
 
-// resetFeedbackFlags resets all feedback command flags to their default values.
-// This MUST be called at the start of each integration test to ensure clean state.
-//
-//nolint:unused // Used by integration tests (//go:build integration)
-func resetFeedbackFlags() {
-	feedbackAgent = ""
-	agentsPath = ""
+// subcommand returns root's subcommand with the given name.
+func subcommand(t *testing.T, root *cobra.Command, name string) *cobra.Command {
+	t.Helper()
+	cmd, _, err := root.Find([]string{name})
+	if err != nil || cmd == root {
+		t.Fatalf("%s has no %s command", root.Name(), name)
+	}
+	return cmd
+}
+
+// ignoreNoAgents drops the ExitNoAgents error that flag-handling tests hit
+// when no agent is authenticated, keeping any other error.
+func ignoreNoAgents(err error) error {
+	if ExitCode(err) == ExitNoAgents {
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,142 @@
+// Package config loads optional buckshot settings from a JSON file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/michaellady/buckshot/internal/accounting"
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+// Config holds settings that are awkward to express as flags.
+type Config struct {
+	// Prices overrides the per-agent price table used for cost estimates.
+	Prices accounting.PriceTable `json:"prices,omitempty"`
+
+	// Agents defines custom agents, keyed by agent name.
+	Agents map[string]AgentConfig `json:"agents,omitempty"`
+
+	// BeadPrefix is the project's bead ID prefix, e.g. "proj" for proj-a1.
+	// Detected from bd list when unset.
+	BeadPrefix string `json:"bead_prefix,omitempty"`
+
+	// Providers groups agents that share an API's rate limits, mapping agent
+	// name to provider, e.g. {"claude": "anthropic", "amp": "anthropic"}.
+	// --rate-limit applies to a provider's agents together.
+	Providers map[string]string `json:"providers,omitempty"`
+
+	// Groups names sets of agents to select with --agents @name, e.g.
+	// {"reviewers": ["claude", "codex"]}. A group here replaces the built-in
+	// group of the same name.
+	Groups map[string][]string `json:"groups,omitempty"`
+
+	// ConvergedPhrases are extra phrases signalling an agent made no
+	// changes, used along with any --converged-phrase flags.
+	ConvergedPhrases []string `json:"converged_phrases,omitempty"`
+}
+
+// AgentGroups returns the built-in agent groups with those in the config
+// added or replacing them.
+func (c Config) AgentGroups() map[string][]string {
+	groups := agent.BuiltinGroups()
+	for name, agents := range c.Groups {
+		groups[name] = agents
+	}
+	return groups
+}
+
+// AgentConfig describes how to invoke a custom agent CLI.
+type AgentConfig struct {
+	Binary             string   `json:"binary"`                  // Binary name on PATH (default: agent name)
+	VersionArgs        []string `json:"version_args"`            // Args to print the version
+	NonInteractiveArgs []string `json:"non_interactive_args"`    // Args for non-interactive mode
+	JSONOutputArgs     []string `json:"json_output_args"`        // Args to enable JSON output
+	SkipApprovalsArgs  []string `json:"skip_approvals_args"`     // Args to skip permission prompts
+	Parser             string   `json:"parser"`                  // Output format: text, stream-json, codex, gemini, ...
+	PromptFraming      string   `json:"prompt_framing"`          // How stdin prompts are framed: lines, json, sentinel, or stream-json
+	PermissionArg      string   `json:"per_tool_permission_arg"` // Flag repeated per --permissions entry, for per-tool approvals
+	LastMessageArg     string   `json:"output_last_message_arg"` // Flag followed by a file the agent writes its final message to
+}
+
+// Registry validates each custom agent and returns a registry holding them,
+// so they are detected like built-ins for this run only.
+func (c Config) Registry() (*agent.Registry, error) {
+	reg := agent.NewRegistry()
+	for name, ac := range c.Agents {
+		parser, err := agent.ParserForType(ac.Parser)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", name, err)
+		}
+		pattern := ac.pattern(name)
+		if err := ac.validate(pattern); err != nil {
+			return nil, fmt.Errorf("agent %s: %w", name, err)
+		}
+		reg.Register(name, pattern, parser)
+	}
+	return reg, nil
+}
+
+// pattern converts the config to a CLI pattern, defaulting the binary to name.
+func (ac AgentConfig) pattern(name string) agent.CLIPattern {
+	binary := ac.Binary
+	if binary == "" {
+		binary = name
+	}
+	return agent.CLIPattern{
+		Binary:               binary,
+		VersionArgs:          ac.VersionArgs,
+		NonInteractiveArgs:   ac.NonInteractiveArgs,
+		JSONOutputArgs:       ac.JSONOutputArgs,
+		SkipApprovalsArgs:    ac.SkipApprovalsArgs,
+		PromptFraming:        agent.PromptFraming(ac.PromptFraming),
+		PerToolPermissionArg: ac.PermissionArg,
+		OutputLastMessageArg: ac.LastMessageArg,
+	}
+}
+
+// validate adds the checks that only apply to config-defined agents: they
+// must say how to run non-interactively, and a JSON parser needs JSON output.
+func (ac AgentConfig) validate(pattern agent.CLIPattern) error {
+	var errs []error
+	if len(ac.NonInteractiveArgs) == 0 {
+		errs = append(errs, errors.New("non_interactive_args is required"))
+	}
+	if parsesJSON(ac.Parser) && len(ac.JSONOutputArgs) == 0 {
+		errs = append(errs, fmt.Errorf("parser %q reads JSON, so json_output_args is required", ac.Parser))
+	}
+	if err := agent.ValidateCLIPattern(pattern); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// parsesJSON reports whether the named parser expects JSON output.
+func parsesJSON(parser string) bool {
+	switch parser {
+	case "", "text", "noop":
+		return false
+	}
+	return true
+}
+
+// Load reads a config file. An empty path returns an empty Config.
+func Load(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
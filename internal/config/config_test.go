@@ -0,0 +1,228 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "buckshot.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+// TestLoad_EmptyPath tests that no config path yields an empty config
+func TestLoad_EmptyPath(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load(\"\") error = %v", err)
+	}
+	if len(cfg.Prices) != 0 {
+		t.Errorf("Prices = %v, want empty", cfg.Prices)
+	}
+}
+
+// TestLoad_Prices tests loading a price table override
+func TestLoad_Prices(t *testing.T) {
+	path := writeConfig(t, `{"prices": {"claude": {"input_per_mtok": 15, "output_per_mtok": 75}}}`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	price, ok := cfg.Prices["claude"]
+	if !ok {
+		t.Fatal("Prices missing claude")
+	}
+	if price.InputPerMTok != 15 || price.OutputPerMTok != 75 {
+		t.Errorf("claude price = %+v, want 15/75", price)
+	}
+}
+
+// TestLoad_BeadPrefix tests loading the project's bead ID prefix
+func TestLoad_BeadPrefix(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"bead_prefix": "proj"}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.BeadPrefix != "proj" {
+		t.Errorf("BeadPrefix = %q, want proj", cfg.BeadPrefix)
+	}
+}
+
+// TestLoad_Providers tests loading the agent to provider grouping
+func TestLoad_Providers(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"providers": {"claude": "anthropic", "amp": "anthropic"}}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Providers["claude"] != "anthropic" || cfg.Providers["amp"] != "anthropic" {
+		t.Errorf("Providers = %v", cfg.Providers)
+	}
+}
+
+// TestLoad_ConvergedPhrases tests loading extra no-change phrases
+func TestLoad_ConvergedPhrases(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"converged_phrases": ["lgtm", "plan is stable"]}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.ConvergedPhrases, []string{"lgtm", "plan is stable"}) {
+		t.Errorf("ConvergedPhrases = %q", cfg.ConvergedPhrases)
+	}
+}
+
+// TestLoad_Groups tests that config groups add to and replace the built-in ones
+func TestLoad_Groups(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"groups": {"reviewers": ["claude", "codex"], "fast": ["gemini"]}}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	groups := cfg.AgentGroups()
+	if got := groups["reviewers"]; len(got) != 2 || got[0] != "claude" || got[1] != "codex" {
+		t.Errorf("reviewers = %v, want [claude codex]", got)
+	}
+	if got := groups["fast"]; len(got) != 1 || got[0] != "gemini" {
+		t.Errorf("fast = %v, want the config's [gemini]", got)
+	}
+	if got := groups["anthropic"]; len(got) != 2 {
+		t.Errorf("anthropic = %v, want the built-in group kept", got)
+	}
+}
+
+// TestLoad_Errors tests missing and malformed config files
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load() of missing file should error")
+	}
+
+	_, err := Load(writeConfig(t, `{"prices": `))
+	if err == nil || !strings.Contains(err.Error(), "failed to parse config") {
+		t.Errorf("Load() of malformed file error = %v, want parse error", err)
+	}
+}
+
+// TestLoad_Agents tests loading and registering custom agent definitions
+func TestLoad_Agents(t *testing.T) {
+	path := writeConfig(t, `{"agents": {"local-llm": {
+		"binary": "llm-cli",
+		"version_args": ["--version"],
+		"non_interactive_args": ["run"],
+		"json_output_args": ["--json"],
+		"per_tool_permission_arg": "--allow-tool",
+		"output_last_message_arg": "--last-message-file",
+		"parser": "codex"
+	}}}`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	reg, err := cfg.Registry()
+	if err != nil {
+		t.Fatalf("Registry() error = %v", err)
+	}
+
+	pattern, ok := reg.KnownAgents()["local-llm"]
+	if !ok {
+		t.Fatal("KnownAgents() missing local-llm")
+	}
+	if pattern.Binary != "llm-cli" || len(pattern.NonInteractiveArgs) != 1 || pattern.JSONOutputArgs[0] != "--json" || pattern.PerToolPermissionArg != "--allow-tool" || pattern.OutputLastMessageArg != "--last-message-file" {
+		t.Errorf("pattern = %+v", pattern)
+	}
+	if _, ok := reg.Parser("local-llm").(*agent.CodexParser); !ok {
+		t.Error("local-llm should use the codex parser")
+	}
+}
+
+// TestRegistry_UnknownParser tests that an unknown parser type is rejected
+func TestRegistry_UnknownParser(t *testing.T) {
+	cfg := Config{Agents: map[string]AgentConfig{"odd": {Parser: "xml"}}}
+	if _, err := cfg.Registry(); err == nil || !strings.Contains(err.Error(), "odd") {
+		t.Errorf("Registry() error = %v, want unknown parser error naming the agent", err)
+	}
+}
+
+// TestRegistry_Invalid tests that malformed agent definitions are
+// rejected with an error naming the agent and the field
+func TestRegistry_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		agent   AgentConfig
+		wantErr string
+	}{
+		{"missing version args", AgentConfig{NonInteractiveArgs: []string{"run"}}, "version_args is required"},
+		{"missing non-interactive args", AgentConfig{VersionArgs: []string{"--version"}}, "non_interactive_args is required"},
+		{"json parser without json args", AgentConfig{
+			VersionArgs: []string{"--version"}, NonInteractiveArgs: []string{"run"}, Parser: "codex",
+		}, "json_output_args is required"},
+		{"binary with spaces", AgentConfig{
+			Binary: "llm cli", VersionArgs: []string{"--version"}, NonInteractiveArgs: []string{"run"},
+		}, "must not contain whitespace"},
+		{"unknown prompt framing", AgentConfig{
+			VersionArgs: []string{"--version"}, NonInteractiveArgs: []string{"run"}, PromptFraming: "xml",
+		}, `unknown prompt framing "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Agents: map[string]AgentConfig{"odd": tt.agent}}
+			reg, err := cfg.Registry()
+			if err == nil || !strings.Contains(err.Error(), "agent odd") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Registry() error = %v, want %q", err, tt.wantErr)
+			}
+			if reg != nil {
+				t.Error("an invalid agent should not yield a registry")
+			}
+		})
+	}
+}
+
+// TestRegistry_TextParserNeedsNoJSON tests that a plain-text agent is
+// valid without json_output_args and its binary defaults to the agent name
+func TestRegistry_TextParserNeedsNoJSON(t *testing.T) {
+	cfg := Config{Agents: map[string]AgentConfig{"plain": {
+		VersionArgs: []string{"--version"}, NonInteractiveArgs: []string{"-p"}, Parser: "text",
+	}}}
+	reg, err := cfg.Registry()
+	if err != nil {
+		t.Fatalf("Registry() error = %v", err)
+	}
+	if got := reg.KnownAgents()["plain"].Binary; got != "plain" {
+		t.Errorf("Binary = %q, want plain", got)
+	}
+}
+
+// TestRegistry_OverridesStayInRegistry tests that custom agents, including
+// one overriding a built-in, are only seen through the config's registry
+func TestRegistry_OverridesStayInRegistry(t *testing.T) {
+	builtin := agent.KnownAgents()["claude"].Binary
+	cfg := Config{Agents: map[string]AgentConfig{
+		"plain":  {VersionArgs: []string{"--version"}, NonInteractiveArgs: []string{"-p"}},
+		"claude": {Binary: "my-claude", VersionArgs: []string{"--version"}, NonInteractiveArgs: []string{"-p"}},
+	}}
+	reg, err := cfg.Registry()
+	if err != nil {
+		t.Fatalf("Registry() error = %v", err)
+	}
+	if got := reg.KnownAgents()["claude"].Binary; got != "my-claude" {
+		t.Fatalf("Binary = %q, want the override", got)
+	}
+
+	known := agent.KnownAgents()
+	if _, ok := known["plain"]; ok {
+		t.Error("plain should not be known outside the registry")
+	}
+	if got := known["claude"].Binary; got != builtin {
+		t.Errorf("Binary = %q, want the built-in %q", got, builtin)
+	}
+}
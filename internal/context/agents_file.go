@@ -0,0 +1,60 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AgentsFileNames are the instruction files looked for, in priority order,
+// when no AGENTS.md path is given.
+var AgentsFileNames = []string{
+	"AGENTS.md",
+	"CLAUDE.md",
+	filepath.Join(".github", "AGENTS.md"),
+}
+
+// DefaultInstructions is sent in place of an AGENTS.md when none is available.
+const DefaultInstructions = "No AGENTS.md was provided. You are one of several AI coding agents " +
+	"collaboratively planning work in this repository. Track the plan as beads using the bd CLI, " +
+	"keep each bead small and actionable, and build on what earlier agents have already done."
+
+// DiscoverAgentsFile walks up from startDir looking for an instruction file.
+// In each directory AgentsFileNames are tried in order, so the closest
+// directory wins. Returns "" if none is found.
+func DiscoverAgentsFile(startDir string) string {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		for _, name := range AgentsFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// agentsGuidance is the first-turn line pointing the agent at its instructions.
+func agentsGuidance(agentsPath string) string {
+	if agentsPath == "" {
+		return DefaultInstructions
+	}
+	return "please read and apply " + agentsPath
+}
+
+// agentsPathLabel describes the instruction file for the prompt body.
+func agentsPathLabel(agentsPath string) string {
+	if agentsPath == "" {
+		return "(none; using default instructions)"
+	}
+	return agentsPath
+}
@@ -0,0 +1,67 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# instructions\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDiscoverAgentsFile_Order tests name priority within a directory
+func TestDiscoverAgentsFile_Order(t *testing.T) {
+	root := t.TempDir()
+	touch(t, filepath.Join(root, ".github", "AGENTS.md"))
+	if got := DiscoverAgentsFile(root); got != filepath.Join(root, ".github", "AGENTS.md") {
+		t.Errorf("DiscoverAgentsFile() = %q, want .github/AGENTS.md", got)
+	}
+
+	touch(t, filepath.Join(root, "CLAUDE.md"))
+	if got := DiscoverAgentsFile(root); got != filepath.Join(root, "CLAUDE.md") {
+		t.Errorf("DiscoverAgentsFile() = %q, want CLAUDE.md over .github/AGENTS.md", got)
+	}
+
+	touch(t, filepath.Join(root, "AGENTS.md"))
+	if got := DiscoverAgentsFile(root); got != filepath.Join(root, "AGENTS.md") {
+		t.Errorf("DiscoverAgentsFile() = %q, want AGENTS.md first", got)
+	}
+}
+
+// TestDiscoverAgentsFile_WalksUp tests discovery from a nested directory
+func TestDiscoverAgentsFile_WalksUp(t *testing.T) {
+	root := t.TempDir()
+	touch(t, filepath.Join(root, "AGENTS.md"))
+	nested := filepath.Join(root, "a", "b")
+	touch(t, filepath.Join(root, "a", "CLAUDE.md"))
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := DiscoverAgentsFile(nested); got != filepath.Join(root, "a", "CLAUDE.md") {
+		t.Errorf("DiscoverAgentsFile() = %q, want the closest file", got)
+	}
+}
+
+// TestFormat_DefaultInstructions tests the synthesized fallback without an AGENTS.md
+func TestFormat_DefaultInstructions(t *testing.T) {
+	b := NewBuilder()
+	ctx := PlanningContext{Prompt: "Plan it", Round: 1, IsFirstTurn: true}
+
+	for _, out := range []string{b.Format(ctx), b.FormatFeedback(ctx)} {
+		if !strings.HasPrefix(out, DefaultInstructions) {
+			t.Errorf("prompt should start with the default instructions, got:\n%s", out)
+		}
+		if strings.Contains(out, "please read and apply") {
+			t.Errorf("prompt should not reference a missing AGENTS.md, got:\n%s", out)
+		}
+	}
+}
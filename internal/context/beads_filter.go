@@ -0,0 +1,80 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// BeadsFilter scopes which beads appear in the planning context.
+// The zero value includes every bead.
+type BeadsFilter struct {
+	Status   string   // open, in_progress, blocked, or closed
+	Priority string   // P0-P4
+	Labels   []string // Beads must carry every label
+}
+
+// ParseBeadsFilter builds a filter from selectors such as "open", "P1" or
+// "label:backend". Statuses and priorities may each be given once.
+func ParseBeadsFilter(selectors []string) (BeadsFilter, error) {
+	var f BeadsFilter
+	for _, sel := range selectors {
+		sel = strings.TrimSpace(sel)
+		switch {
+		case sel == "":
+			continue
+		case strings.HasPrefix(sel, "label:"):
+			f.Labels = append(f.Labels, strings.TrimPrefix(sel, "label:"))
+		case isPriority(sel):
+			if f.Priority != "" {
+				return BeadsFilter{}, fmt.Errorf("only one priority filter allowed, got %s and %s", f.Priority, sel)
+			}
+			f.Priority = strings.ToUpper(sel)
+		case isStatus(sel):
+			if f.Status != "" {
+				return BeadsFilter{}, fmt.Errorf("only one status filter allowed, got %s and %s", f.Status, sel)
+			}
+			f.Status = sel
+		default:
+			return BeadsFilter{}, fmt.Errorf("unknown beads filter %q (want a status like open or blocked, a priority like P1, or label:<name>)", sel)
+		}
+	}
+
+	if _, err := beads.ListArgs(f.listOpts()); err != nil {
+		return BeadsFilter{}, err
+	}
+	return f, nil
+}
+
+// String describes the filter, e.g. "status=open, priority=P1, label=api".
+func (f BeadsFilter) String() string {
+	var parts []string
+	if f.Status != "" {
+		parts = append(parts, "status="+f.Status)
+	}
+	if f.Priority != "" {
+		parts = append(parts, "priority="+f.Priority)
+	}
+	for _, l := range f.Labels {
+		parts = append(parts, "label="+l)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// listOpts converts the filter to bd list options.
+func (f BeadsFilter) listOpts() beads.ListOpts {
+	return beads.ListOpts{Status: f.Status, Priority: f.Priority, Labels: f.Labels}
+}
+
+func isPriority(s string) bool {
+	return len(s) == 2 && (s[0] == 'P' || s[0] == 'p') && s[1] >= '0' && s[1] <= '4'
+}
+
+func isStatus(s string) bool {
+	switch s {
+	case "open", "in_progress", "blocked", "closed":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// fakeBeadsClient serves canned bd list/show output and records calls.
+type fakeBeadsClient struct {
+	issues   map[string]string // ID -> bd show output
+	list     string            // bd list output
+	all      []beads.Issue     // bd list --json output
+	listOpts []beads.ListOpts
+	shown    []string
+	listErr  error
+}
+
+func (f *fakeBeadsClient) Create(ctx context.Context, opts beads.CreateOpts) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (f *fakeBeadsClient) Update(ctx context.Context, id string, opts beads.UpdateOpts) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeBeadsClient) List(ctx context.Context, opts beads.ListOpts) (string, error) {
+	f.listOpts = append(f.listOpts, opts)
+	return f.list, f.listErr
+}
+
+func (f *fakeBeadsClient) ListIssues(ctx context.Context, opts beads.ListOpts) ([]beads.Issue, error) {
+	return f.all, f.listErr
+}
+
+func (f *fakeBeadsClient) Show(ctx context.Context, id string) (string, error) {
+	f.shown = append(f.shown, id)
+	out, ok := f.issues[id]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return out, nil
+}
+
+func (f *fakeBeadsClient) Comment(ctx context.Context, id, author, text string) error {
+	return errors.New("not implemented")
+}
+
+func (f *fakeBeadsClient) Available(ctx context.Context) bool {
+	return true
+}
+
+// TestParseBeadsFilter tests selector parsing
+func TestParseBeadsFilter(t *testing.T) {
+	f, err := ParseBeadsFilter([]string{"blocked", "p1", "label:backend", "label:api"})
+	if err != nil {
+		t.Fatalf("ParseBeadsFilter() error = %v", err)
+	}
+	if f.Status != "blocked" || f.Priority != "P1" || strings.Join(f.Labels, ",") != "backend,api" {
+		t.Errorf("ParseBeadsFilter() = %+v", f)
+	}
+	if got := f.String(); got != "status=blocked, priority=P1, label=backend, label=api" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if f, err := ParseBeadsFilter(nil); err != nil || f.String() != "" {
+		t.Errorf("ParseBeadsFilter(nil) = %+v, %v; want empty filter", f, err)
+	}
+
+	for _, bad := range [][]string{{"urgent"}, {"open", "blocked"}, {"P1", "P2"}, {"label:"}, {"P9"}} {
+		if _, err := ParseBeadsFilter(bad); err == nil {
+			t.Errorf("ParseBeadsFilter(%q) should fail", bad)
+		}
+	}
+}
+
+// TestRefreshBeadsState_PassesFilterToClient tests that the filter reaches bd list
+// and only the listed beads are detailed
+func TestRefreshBeadsState_PassesFilterToClient(t *testing.T) {
+	fake := &fakeBeadsClient{
+		list: "buckshot-1 [P1] [task] open - First\n",
+		issues: map[string]string{
+			"buckshot-1": "buckshot-1: First\nStatus: open",
+			"buckshot-2": "buckshot-2: Second\nStatus: closed",
+		},
+	}
+	filter := BeadsFilter{Status: "open", Priority: "P1", Labels: []string{"api"}}
+	builder := NewBuilder(WithBeadsClient(fake), WithBeadsFilter(filter))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+
+	if len(fake.listOpts) != 1 {
+		t.Fatalf("bd list called %d times, want 1", len(fake.listOpts))
+	}
+	got := fake.listOpts[0]
+	if got.Status != "open" || got.Priority != "P1" || strings.Join(got.Labels, ",") != "api" {
+		t.Errorf("bd list opts = %+v, want the filter", got)
+	}
+
+	if strings.Join(fake.shown, ",") != "buckshot-1" {
+		t.Errorf("bd show called for %v, want only buckshot-1", fake.shown)
+	}
+	if !strings.Contains(ctx.BeadsState, "=== Beads List (status=open, priority=P1, label=api) ===") {
+		t.Errorf("BeadsState should name the filter, got:\n%s", ctx.BeadsState)
+	}
+	if !strings.Contains(ctx.BeadsState, "buckshot-1: First") || strings.Contains(ctx.BeadsState, "buckshot-2") {
+		t.Errorf("BeadsState should detail only filtered beads, got:\n%s", ctx.BeadsState)
+	}
+}
+
+// TestRefreshBeadsState_ListFailure tests the fallback when bd list fails
+func TestRefreshBeadsState_ListFailure(t *testing.T) {
+	fake := &fakeBeadsClient{listErr: errors.New("bd: not found")}
+	builder := NewBuilder(WithBeadsClient(fake))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+	if !strings.Contains(ctx.BeadsState, "bd command unavailable") {
+		t.Errorf("BeadsState = %q, want the unavailable note", ctx.BeadsState)
+	}
+	if len(fake.shown) != 0 {
+		t.Errorf("bd show should not run when bd list fails, ran for %v", fake.shown)
+	}
+}
+
+// TestRefreshBeadsState_Cancelled tests that a cancelled run is reported
+// and leaves the previous beads state in place
+func TestRefreshBeadsState_Cancelled(t *testing.T) {
+	fake := &fakeBeadsClient{listErr: context.Canceled}
+	builder := NewBuilder(WithBeadsClient(fake))
+	runCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctx := PlanningContext{BeadsState: "previous"}
+	if err := builder.RefreshBeadsState(runCtx, &ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("RefreshBeadsState() error = %v, want context.Canceled", err)
+	}
+	if ctx.BeadsState != "previous" {
+		t.Errorf("BeadsState = %q, want it left alone", ctx.BeadsState)
+	}
+}
@@ -0,0 +1,116 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// BlockedInfo is a bead that cannot start until other beads are closed.
+type BlockedInfo struct {
+	ID        string   // The blocked bead
+	WaitingOn []string // Open beads it depends on, in bd show order
+}
+
+// String renders the bead as "buckshot-5 (waiting on buckshot-2)".
+func (b BlockedInfo) String() string {
+	return fmt.Sprintf("%s (waiting on %s)", b.ID, strings.Join(b.WaitingOn, ", "))
+}
+
+// parseDependsOn extracts the IDs a bead depends on from its bd show output.
+// It reads a "Depends on" heading followed by one indented line per
+// dependency ("→ buckshot-2: Title"), or the IDs inline after the heading.
+// Dependencies marked [closed] or (closed) are left out.
+func parseDependsOn(showOutput, prefix string) []string {
+	var deps []string
+	inSection := false
+	for _, line := range strings.Split(showOutput, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Depends on") {
+			inSection = true
+			if _, inline, ok := strings.Cut(trimmed, ":"); ok {
+				for _, field := range strings.FieldsFunc(inline, func(r rune) bool { return r == ',' || r == ' ' }) {
+					if isIssueID(field, prefix) {
+						deps = append(deps, field)
+					}
+				}
+			}
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		// The section ends at a blank line or the next heading
+		id, ok := dependencyID(trimmed, prefix)
+		if !ok {
+			inSection = false
+			continue
+		}
+		if !isClosedLine(trimmed) {
+			deps = append(deps, id)
+		}
+	}
+	return deps
+}
+
+// isClosedLine reports whether a dependency line marks its bead closed, as
+// "[closed]" or "(closed)".
+func isClosedLine(line string) bool {
+	line = strings.ToLower(line)
+	return strings.Contains(line, "[closed]") || strings.Contains(line, "(closed)")
+}
+
+// dependencyID returns the bead ID that starts a dependency line, after any
+// arrow or bullet.
+func dependencyID(line, prefix string) (string, bool) {
+	line = strings.TrimLeft(line, "→←-*• ")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	id := strings.TrimRight(fields[0], ":,")
+	return id, isIssueID(id, prefix)
+}
+
+// blockedBeads returns the beads among deps (bead ID -> IDs it depends on)
+// still waiting on a dependency, in order. A dependency bd reports as closed
+// no longer blocks, nor is a closed bead reported as blocked.
+func blockedBeads(order []string, deps map[string][]string, issues []beads.Issue) []BlockedInfo {
+	closed := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		closed[issue.ID] = issue.Status == "closed"
+	}
+
+	var blocked []BlockedInfo
+	for _, id := range order {
+		if closed[id] {
+			continue
+		}
+		var waiting []string
+		for _, dep := range deps[id] {
+			if !closed[dep] {
+				waiting = append(waiting, dep)
+			}
+		}
+		if len(waiting) > 0 {
+			blocked = append(blocked, BlockedInfo{ID: id, WaitingOn: waiting})
+		}
+	}
+	return blocked
+}
+
+// formatBlocked renders the blocked beads section, or "" if none are blocked.
+func formatBlocked(blocked []BlockedInfo) string {
+	if len(blocked) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("=== Blocked Beads ===\n")
+	for _, b := range blocked {
+		fmt.Fprintf(&sb, "Blocked: %s\n", b)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package context
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// TestParseDependsOn tests reading dependencies from bd show output
+func TestParseDependsOn(t *testing.T) {
+	tests := []struct {
+		name string
+		show string
+		want []string
+	}{
+		{
+			name: "section",
+			show: "buckshot-5: Add cache\nStatus: open\n\nDepends on (2):\n  → buckshot-2: Design schema [P1]\n  → buckshot-3: Pick a store [P2]\n\nBlocks (1):\n  ← buckshot-7: Expose metrics [P2]\n",
+			want: []string{"buckshot-2", "buckshot-3"},
+		},
+		{
+			name: "inline",
+			show: "buckshot-5: Add cache\nDepends on: buckshot-2, buckshot-3\n",
+			want: []string{"buckshot-2", "buckshot-3"},
+		},
+		{
+			name: "closed dependency",
+			show: "buckshot-5: Add cache\nDepends on (2):\n  → buckshot-2: Design schema [closed]\n  → buckshot-3: Handle closed connections [P2]\n",
+			want: []string{"buckshot-3"},
+		},
+		{
+			name: "section ends at next heading",
+			show: "buckshot-5: Add cache\nDepends on (1):\n  → buckshot-2: Design schema\nBlocks (1):\n  ← buckshot-7: Expose metrics\n",
+			want: []string{"buckshot-2"},
+		},
+		{
+			name: "blocks only",
+			show: "buckshot-2: Design schema\nBlocks (1):\n  ← buckshot-5: Add cache\n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDependsOn(tt.show, "buckshot"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDependsOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRefreshBeadsState_BlockedBeads tests that beads waiting on open
+// dependencies are collected and listed at the top of the beads state
+func TestRefreshBeadsState_BlockedBeads(t *testing.T) {
+	fake := &fakeBeadsClient{
+		list: "buckshot-2 [P1] [task] open - Design schema\n" +
+			"buckshot-5 [P2] [task] open - Add cache\n" +
+			"buckshot-6 [P2] [task] open - Migrate data\n",
+		issues: map[string]string{
+			"buckshot-2": "buckshot-2: Design schema\nBlocks (1):\n  ← buckshot-5: Add cache [P2]\n",
+			"buckshot-5": "buckshot-5: Add cache\nDepends on (1):\n  → buckshot-2: Design schema [P1]\n",
+			"buckshot-6": "buckshot-6: Migrate data\nDepends on (2):\n  → buckshot-1: Old schema\n  → buckshot-2: Design schema [P1]\n",
+		},
+		all: []beads.Issue{
+			{ID: "buckshot-1", Status: "closed"},
+			{ID: "buckshot-2", Status: "open", Priority: 1},
+			{ID: "buckshot-5", Status: "open", Priority: 2},
+			{ID: "buckshot-6", Status: "open", Priority: 2},
+		},
+	}
+	builder := NewBuilder(WithBeadsClient(fake))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+
+	want := []BlockedInfo{
+		{ID: "buckshot-5", WaitingOn: []string{"buckshot-2"}},
+		{ID: "buckshot-6", WaitingOn: []string{"buckshot-2"}},
+	}
+	if !reflect.DeepEqual(ctx.BlockedBeads, want) {
+		t.Errorf("BlockedBeads = %+v, want %+v", ctx.BlockedBeads, want)
+	}
+	header := "=== Blocked Beads ===\nBlocked: buckshot-5 (waiting on buckshot-2)\nBlocked: buckshot-6 (waiting on buckshot-2)\n\n=== Beads List ===\n"
+	if !strings.HasPrefix(ctx.BeadsState, header) {
+		t.Errorf("BeadsState should start with the blocked beads, got:\n%s", ctx.BeadsState)
+	}
+}
+
+// TestRefreshBeadsState_NoBlockedBeads tests that the section is left out
+// when nothing is waiting
+func TestRefreshBeadsState_NoBlockedBeads(t *testing.T) {
+	fake := &fakeBeadsClient{
+		list:   "buckshot-5 [P2] [task] open - Add cache\n",
+		issues: map[string]string{"buckshot-5": "buckshot-5: Add cache\nDepends on (1):\n  → buckshot-2: Design schema [closed]\n"},
+	}
+	builder := NewBuilder(WithBeadsClient(fake))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+	if len(ctx.BlockedBeads) != 0 || strings.Contains(ctx.BeadsState, "Blocked") {
+		t.Errorf("want no blocked beads, got %+v in:\n%s", ctx.BlockedBeads, ctx.BeadsState)
+	}
+}
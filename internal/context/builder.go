@@ -3,9 +3,15 @@ package context
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os/exec"
+	"sort"
 	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/michaellady/buckshot/internal/logging"
 )
 
 // PlanningContext represents the context sent to an agent.
@@ -14,15 +20,28 @@ type PlanningContext struct {
 	AgentsPath   string // Path to AGENTS.md for agent to read
 	BeadsState   string // Current state of beads (bd list + bd show)
 	Round        int    // Current round number
-	IsFirstTurn  bool   // Whether this is the first agent in the protocol
+	TotalRounds  int    // Total rounds planned (0 if open-ended)
+	IsFirstTurn  bool   // Whether to ask the agent to read AGENTS.md (its first turn in a session)
+	IsBootstrap  bool   // Whether this is the run's opening turn, which gets the bootstrap prompt
 	FeedbackMode bool   // Whether agent is in comment-only feedback mode
 	AgentName    string // Name of the agent (used as comment author in feedback mode)
+	PostComments bool   // Whether the agent writes its comments in its reply for buckshot to post
+	NoBeads      bool   // Whether bd is unavailable, leaving the prompt and AGENTS.md
+
+	// BlockedBeads lists the detailed beads still waiting on open
+	// dependencies. They are also summarized at the top of BeadsState.
+	BlockedBeads []BlockedInfo
+
+	// PreviousRound summarizes the round before this one. Format puts it
+	// before the prompt; nil leaves it out.
+	PreviousRound *RoundSummary
 }
 
 // Builder constructs planning contexts for agents.
 type Builder interface {
-	// Build creates a planning context for an agent.
-	Build(prompt string, agentsPath string, round int, isFirstTurn bool) (PlanningContext, error)
+	// Build creates a planning context for an agent. ctx bounds the bd calls
+	// that read the beads state.
+	Build(ctx context.Context, prompt string, agentsPath string, round int, isFirstTurn bool) (PlanningContext, error)
 
 	// Format converts a PlanningContext to a prompt string.
 	Format(ctx PlanningContext) string
@@ -31,145 +50,341 @@ type Builder interface {
 	// In feedback mode, agents can only add comments to beads, not modify them.
 	FormatFeedback(ctx PlanningContext) string
 
-	// RefreshBeadsState updates the beads state in the context.
-	RefreshBeadsState(ctx *PlanningContext) error
+	// RefreshBeadsState updates the beads state in planCtx. ctx bounds the
+	// bd calls.
+	RefreshBeadsState(ctx context.Context, planCtx *PlanningContext) error
 }
 
 // defaultBuilder is the default implementation of Builder.
-type defaultBuilder struct{}
+type defaultBuilder struct {
+	beads           beads.Client
+	filter          BeadsFilter
+	maxBeadsDetail  int
+	maxContextBytes int
+	beadPrefix      string
+	noBeads         bool
+	promptTmpl      *template.Template
+	feedbackTmpl    *template.Template
+	promptPrefix    string
+	promptSuffix    string
+	bootstrapPrompt string
+	responseBudget  int
+	promptBudget    int
+	overBudget      func(tokens, budget int)
+	budgetWarned    sync.Once
+	logger          *logging.Logger
+}
 
-// NewBuilder creates a new Builder instance.
-func NewBuilder() Builder {
-	return &defaultBuilder{}
+// DefaultMaxContextBytes caps the beads state so large projects don't
+// overflow an agent's context window.
+const DefaultMaxContextBytes = 100_000
+
+// Option configures a Builder.
+type Option func(*defaultBuilder)
+
+// WithBeadsClient sets the client used to read beads.
+func WithBeadsClient(c beads.Client) Option {
+	return func(b *defaultBuilder) {
+		b.beads = c
+	}
 }
 
-// Build creates a planning context.
-func (b *defaultBuilder) Build(prompt string, agentsPath string, round int, isFirstTurn bool) (PlanningContext, error) {
-	ctx := PlanningContext{
-		Prompt:      prompt,
-		AgentsPath:  agentsPath,
-		Round:       round,
-		IsFirstTurn: isFirstTurn,
+// WithBeadsFilter limits the beads listed and detailed in the context.
+func WithBeadsFilter(f BeadsFilter) Option {
+	return func(b *defaultBuilder) {
+		b.filter = f
 	}
+}
 
-	// Gather current beads state
-	if err := b.RefreshBeadsState(&ctx); err != nil {
-		return ctx, err
+// WithMaxBeadsDetail limits how many beads get a detailed bd show section.
+// Zero means no limit.
+func WithMaxBeadsDetail(n int) Option {
+	return func(b *defaultBuilder) {
+		b.maxBeadsDetail = n
 	}
+}
 
-	return ctx, nil
+// WithMaxContextBytes caps the size of the beads state. Detail sections stop
+// once the next one would exceed it. Zero means no limit.
+func WithMaxContextBytes(n int) Option {
+	return func(b *defaultBuilder) {
+		b.maxContextBytes = n
+	}
 }
 
-// Format converts a PlanningContext to a prompt string.
-func (b *defaultBuilder) Format(ctx PlanningContext) string {
-	var buf bytes.Buffer
+// WithBeadPrefix limits the beads detailed to IDs starting with prefix and a
+// hyphen. Without it, any first field containing a hyphen is taken as an ID.
+func WithBeadPrefix(prefix string) Option {
+	return func(b *defaultBuilder) {
+		b.beadPrefix = prefix
+	}
+}
 
-	// First turn includes guidance to read AGENTS.md
-	if ctx.IsFirstTurn {
-		fmt.Fprintf(&buf, "please read and apply %s\n\n", ctx.AgentsPath)
+// WithoutBeads builds contexts for running without bd: beads are never read
+// and NoBeads is set so templates leave out bd instructions.
+func WithoutBeads() Option {
+	return func(b *defaultBuilder) {
+		b.noBeads = true
 	}
+}
 
-	// Show round number for subsequent rounds
-	if ctx.Round > 1 {
-		fmt.Fprintf(&buf, "## Round %d\n\n", ctx.Round)
+// WithPromptTemplate renders Format with t instead of DefaultPromptTemplate.
+// Nil keeps the default.
+func WithPromptTemplate(t *template.Template) Option {
+	return func(b *defaultBuilder) {
+		b.promptTmpl = t
 	}
+}
 
-	// User's prompt
-	fmt.Fprintf(&buf, "Prompt: %s\n\n", ctx.Prompt)
+// WithFeedbackTemplate renders FormatFeedback with t instead of
+// DefaultFeedbackTemplate. Nil keeps the default.
+func WithFeedbackTemplate(t *template.Template) Option {
+	return func(b *defaultBuilder) {
+		b.feedbackTmpl = t
+	}
+}
 
-	// AGENTS.md path
-	fmt.Fprintf(&buf, "AGENTS.md: %s\n\n", ctx.AgentsPath)
+// WithPromptPrefix puts text before every formatted prompt, in both Format
+// and FormatFeedback.
+func WithPromptPrefix(text string) Option {
+	return func(b *defaultBuilder) {
+		b.promptPrefix = text
+	}
+}
 
-	// Current beads state
-	fmt.Fprintf(&buf, "Current Beads:\n%s\n\n", ctx.BeadsState)
+// WithPromptSuffix puts text after every formatted prompt, in both Format
+// and FormatFeedback.
+func WithPromptSuffix(text string) Option {
+	return func(b *defaultBuilder) {
+		b.promptSuffix = text
+	}
+}
 
-	// Instructions for modifying beads
-	fmt.Fprintln(&buf, "Instructions:")
-	fmt.Fprintln(&buf, "- Use `bd create` to create new beads")
-	fmt.Fprintln(&buf, "- Use `bd update` to modify existing beads")
-	fmt.Fprintln(&buf, "- Use `bd close` to close completed beads")
-	fmt.Fprintln(&buf, "- Report changes made and whether plan seems complete")
+// WithBootstrapPrompt adds text after the prompt Format renders for the
+// bootstrap turn (IsBootstrap), so the agent going first does the heavy
+// upfront analysis the others refine.
+func WithBootstrapPrompt(text string) Option {
+	return func(b *defaultBuilder) {
+		b.bootstrapPrompt = text
+	}
+}
 
-	return buf.String()
+// WithResponseBudget asks agents, after every formatted prompt, to keep
+// their response under about tokens tokens. Zero leaves it out.
+func WithResponseBudget(tokens int) Option {
+	return func(b *defaultBuilder) {
+		b.responseBudget = tokens
+	}
 }
 
-// FormatFeedback converts a PlanningContext to a feedback-only prompt string.
-// In feedback mode, agents can only add comments to beads, not modify them.
-func (b *defaultBuilder) FormatFeedback(ctx PlanningContext) string {
-	var buf bytes.Buffer
+// WithPromptBudget calls warn, the first time a formatted prompt is estimated
+// at more than budget tokens, with that estimate. Zero budget never warns.
+func WithPromptBudget(budget int, warn func(tokens, budget int)) Option {
+	return func(b *defaultBuilder) {
+		b.promptBudget = budget
+		b.overBudget = warn
+	}
+}
 
-	// First turn includes guidance to read AGENTS.md
-	if ctx.IsFirstTurn {
-		fmt.Fprintf(&buf, "please read and apply %s\n\n", ctx.AgentsPath)
+// WithLogger sets the logger warnings go to. The default is logging.Default.
+func WithLogger(logger *logging.Logger) Option {
+	return func(b *defaultBuilder) {
+		b.logger = logger
 	}
+}
 
-	// Main feedback instruction
-	fmt.Fprintln(&buf, "## Feedback Mode (Comment-Only)")
-	fmt.Fprintln(&buf, "")
-	fmt.Fprintln(&buf, "Please ultrathink to read and analyze the repository and the beads task descriptions and comments.")
-	fmt.Fprintln(&buf, "Leave comments with your CLI name as the author in any issues that require your input that is")
-	fmt.Fprintln(&buf, "substantially different or better from the content that is already there.")
-	fmt.Fprintln(&buf, "")
-	fmt.Fprintln(&buf, "**IMPORTANT: Do not edit the description or anything else related to the beads besides adding your comments.**")
-	fmt.Fprintln(&buf, "")
+// bytesPerToken approximates how many bytes of text make up a token.
+const bytesPerToken = 4
 
-	// Agent identification
-	fmt.Fprintf(&buf, "Your agent name: %s\n\n", ctx.AgentName)
+// EstimateTokens approximates how many tokens text takes up.
+func EstimateTokens(text string) int {
+	return (len(text) + bytesPerToken - 1) / bytesPerToken
+}
 
-	// AGENTS.md path
-	fmt.Fprintf(&buf, "AGENTS.md: %s\n\n", ctx.AgentsPath)
+// responseBudgetInstruction is added to prompts by WithResponseBudget.
+const responseBudgetInstruction = "Keep your response concise: under about %d tokens."
 
-	// Current beads state
-	fmt.Fprintf(&buf, "Current Beads:\n%s\n\n", ctx.BeadsState)
+// NewBuilder creates a new Builder instance.
+func NewBuilder(opts ...Option) Builder {
+	b := &defaultBuilder{
+		beads:           beads.NewClient(),
+		maxContextBytes: DefaultMaxContextBytes,
+		logger:          logging.Default(),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
 
-	// Instructions for commenting only
-	fmt.Fprintln(&buf, "Instructions:")
-	fmt.Fprintf(&buf, "- Use `bd comment <issue-id> \"<comment>\" --author %s` to add comments\n", ctx.AgentName)
-	fmt.Fprintln(&buf, "- Only comment on issues where you have substantive input that is different or better")
-	fmt.Fprintln(&buf, "- Do not use `bd update` or `bd create` - this is comment-only mode")
-	fmt.Fprintln(&buf, "- Read existing comments before adding yours to avoid redundancy")
+// Build creates a planning context.
+func (b *defaultBuilder) Build(ctx context.Context, prompt string, agentsPath string, round int, isFirstTurn bool) (PlanningContext, error) {
+	planCtx := PlanningContext{
+		Prompt:      prompt,
+		AgentsPath:  agentsPath,
+		Round:       round,
+		IsFirstTurn: isFirstTurn,
+		NoBeads:     b.noBeads,
+	}
+
+	// Gather current beads state
+	if err := b.RefreshBeadsState(ctx, &planCtx); err != nil {
+		return planCtx, err
+	}
+
+	return planCtx, nil
+}
+
+// Format converts a PlanningContext to a prompt string using the prompt
+// template, after a summary of the previous round if the context has one.
+func (b *defaultBuilder) Format(ctx PlanningContext) string {
+	prompt := b.render(b.promptTmpl, defaultPromptTmpl, ctx)
+	if ctx.PreviousRound != nil {
+		prompt = roundSummaryHeading + "\n\n" + ctx.PreviousRound.String() + "\n" + prompt
+	}
+	if ctx.IsBootstrap && b.bootstrapPrompt != "" {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n" + b.bootstrapPrompt + "\n"
+	}
+	return b.wrap(prompt)
+}
+
+// FormatFeedback converts a PlanningContext to a feedback-only prompt string.
+// In feedback mode, agents can only add comments to beads, not modify them.
+func (b *defaultBuilder) FormatFeedback(ctx PlanningContext) string {
+	return b.wrap(b.render(b.feedbackTmpl, defaultFeedbackTmpl, ctx))
+}
+
+// wrap brackets a formatted prompt with the prefix and suffix, each set off
+// by a blank line, putting any response budget just before the suffix, and
+// checks the result against the prompt budget.
+func (b *defaultBuilder) wrap(prompt string) string {
+	if b.promptPrefix != "" {
+		prompt = b.promptPrefix + "\n\n" + prompt
+	}
+	if b.responseBudget > 0 {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n" + fmt.Sprintf(responseBudgetInstruction, b.responseBudget) + "\n"
+	}
+	if b.promptSuffix != "" {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n" + b.promptSuffix + "\n"
+	}
+	b.checkBudget(prompt)
+	return prompt
+}
 
-	return buf.String()
+// checkBudget warns once if prompt is estimated over the prompt budget.
+func (b *defaultBuilder) checkBudget(prompt string) {
+	if b.promptBudget <= 0 || b.overBudget == nil {
+		return
+	}
+	if tokens := EstimateTokens(prompt); tokens > b.promptBudget {
+		b.budgetWarned.Do(func() { b.overBudget(tokens, b.promptBudget) })
+	}
 }
 
-// RefreshBeadsState updates the beads state in the context.
-func (b *defaultBuilder) RefreshBeadsState(ctx *PlanningContext) error {
+// RefreshBeadsState updates the beads state in planCtx.
+// Only beads matching the builder's filter are listed and detailed. Detailed
+// beads waiting on open dependencies are listed first, as BlockedBeads.
+func (b *defaultBuilder) RefreshBeadsState(ctx context.Context, planCtx *PlanningContext) error {
+	if b.noBeads {
+		return nil
+	}
+
 	var buf bytes.Buffer
 
 	// Get bd list output
-	listCmd := exec.Command("bd", "list")
-	listOut, err := listCmd.Output()
+	listOut, err := b.beads.List(ctx, b.filter.listOpts())
 	if err != nil {
+		// A cancelled run leaves the state as it was
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		// If bd is not available or fails, use empty state
-		ctx.BeadsState = "(No beads found or bd command unavailable)"
+		planCtx.BeadsState = "(No beads found or bd command unavailable)"
 		return nil
 	}
 
-	fmt.Fprintf(&buf, "=== Beads List ===\n%s\n", string(listOut))
-
-	// Parse bd list to get issue IDs
-	issueIDs := parseIssueIDs(string(listOut))
+	if desc := b.filter.String(); desc != "" {
+		fmt.Fprintf(&buf, "=== Beads List (%s) ===\n%s\n", desc, listOut)
+	} else {
+		fmt.Fprintf(&buf, "=== Beads List ===\n%s\n", listOut)
+	}
 
-	// Get detailed info for each bead
+	// Detail the most important beads first, within the budget
+	issueIDs := b.detailOrder(ctx, parseIssueIDs(listOut, b.beadPrefix))
+	deps := map[string][]string{}
 	if len(issueIDs) > 0 {
 		fmt.Fprintf(&buf, "\n=== Bead Details ===\n")
-		for _, id := range issueIDs {
-			showCmd := exec.Command("bd", "show", id)
-			showOut, err := showCmd.Output()
+		omitted := 0
+		for i, id := range issueIDs {
+			if b.maxBeadsDetail > 0 && i >= b.maxBeadsDetail {
+				omitted = len(issueIDs) - i
+				break
+			}
+			showOut, err := b.beads.Show(ctx, id)
 			if err != nil {
 				continue
 			}
-			fmt.Fprintf(&buf, "\n%s\n", string(showOut))
+			if d := parseDependsOn(showOut, b.beadPrefix); len(d) > 0 {
+				deps[id] = d
+			}
+			section := fmt.Sprintf("\n%s\n", showOut)
+			if b.maxContextBytes > 0 && buf.Len()+len(section) > b.maxContextBytes {
+				omitted = len(issueIDs) - i
+				break
+			}
+			buf.WriteString(section)
+		}
+		if omitted > 0 {
+			fmt.Fprintf(&buf, "\n(%d more beads omitted)\n", omitted)
 		}
 	}
 
-	ctx.BeadsState = buf.String()
+	planCtx.BlockedBeads = nil
+	if len(deps) > 0 {
+		// An unfiltered list tells which dependencies are already closed
+		issues, _ := b.beads.ListIssues(ctx, beads.ListOpts{})
+		planCtx.BlockedBeads = blockedBeads(issueIDs, deps, issues)
+	}
+
+	planCtx.BeadsState = formatBlocked(planCtx.BlockedBeads) + buf.String()
 	return nil
 }
 
+// detailOrder sorts ids so higher-priority, then more recently updated, beads
+// come first. If bd can't report priorities, the list order is kept.
+func (b *defaultBuilder) detailOrder(ctx context.Context, ids []string) []string {
+	if len(ids) < 2 {
+		return ids
+	}
+
+	issues, err := b.beads.ListIssues(ctx, b.filter.listOpts())
+	if err != nil {
+		return ids
+	}
+	byID := make(map[string]beads.Issue, len(issues))
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+	}
+
+	sorted := append([]string(nil), ids...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aok := byID[sorted[i]]
+		c, cok := byID[sorted[j]]
+		if aok != cok {
+			return aok // Beads bd didn't report go last
+		}
+		if a.Priority != c.Priority {
+			return a.Priority < c.Priority
+		}
+		return a.UpdatedAt.After(c.UpdatedAt)
+	})
+	return sorted
+}
+
 // parseIssueIDs extracts issue IDs from bd list output.
 // Format: "ISSUE-ID [P#] [type] status - Title"
-func parseIssueIDs(listOutput string) []string {
+// With a prefix, only IDs starting with "<prefix>-" are kept.
+func parseIssueIDs(listOutput, prefix string) []string {
 	var ids []string
 	lines := strings.Split(listOutput, "\n")
 
@@ -183,8 +398,7 @@ func parseIssueIDs(listOutput string) []string {
 		parts := strings.Fields(line)
 		if len(parts) > 0 {
 			id := parts[0]
-			// Basic validation: should contain a hyphen
-			if strings.Contains(id, "-") {
+			if isIssueID(id, prefix) {
 				ids = append(ids, id)
 			}
 		}
@@ -192,3 +406,12 @@ func parseIssueIDs(listOutput string) []string {
 
 	return ids
 }
+
+// isIssueID reports whether id carries the prefix, or at least a hyphen when
+// no prefix is known.
+func isIssueID(id, prefix string) bool {
+	if prefix == "" {
+		return strings.Contains(id, "-")
+	}
+	return strings.HasPrefix(id, prefix+"-")
+}
@@ -1,8 +1,15 @@
 package context
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/beads"
 )
 
 func TestBuild_CreatesContextWithPromptAndAgentsPath(t *testing.T) {
@@ -13,7 +20,7 @@ func TestBuild_CreatesContextWithPromptAndAgentsPath(t *testing.T) {
 	round := 1
 	isFirstTurn := true
 
-	ctx, err := builder.Build(prompt, agentsPath, round, isFirstTurn)
+	ctx, err := builder.Build(context.Background(), prompt, agentsPath, round, isFirstTurn)
 	if err != nil {
 		t.Fatalf("Build() failed: %v", err)
 	}
@@ -38,7 +45,7 @@ func TestBuild_CreatesContextWithPromptAndAgentsPath(t *testing.T) {
 func TestBuild_IncludesBeadsListOutput(t *testing.T) {
 	builder := NewBuilder()
 
-	ctx, err := builder.Build("test prompt", "/agents.md", 1, true)
+	ctx, err := builder.Build(context.Background(), "test prompt", "/agents.md", 1, true)
 	if err != nil {
 		t.Fatalf("Build() failed: %v", err)
 	}
@@ -57,7 +64,7 @@ func TestBuild_IncludesBeadsListOutput(t *testing.T) {
 func TestBuild_IncludesBeadsShowDetailsForEachBead(t *testing.T) {
 	builder := NewBuilder()
 
-	ctx, err := builder.Build("test prompt", "/agents.md", 1, true)
+	ctx, err := builder.Build(context.Background(), "test prompt", "/agents.md", 1, true)
 	if err != nil {
 		t.Fatalf("Build() failed: %v", err)
 	}
@@ -222,7 +229,7 @@ func TestRefreshBeadsState_UpdatesBeadsState(t *testing.T) {
 		IsFirstTurn: true,
 	}
 
-	err := builder.RefreshBeadsState(&ctx)
+	err := builder.RefreshBeadsState(context.Background(), &ctx)
 	if err != nil {
 		t.Fatalf("RefreshBeadsState() failed: %v", err)
 	}
@@ -241,7 +248,7 @@ func TestBuild_HandlesMissingBeadsGracefully(t *testing.T) {
 
 	// This test ensures that if 'bd list' returns empty or fails,
 	// the builder doesn't crash
-	ctx, err := builder.Build("prompt", "/agents.md", 1, true)
+	ctx, err := builder.Build(context.Background(), "prompt", "/agents.md", 1, true)
 	if err != nil {
 		t.Fatalf("Build() should handle missing beads gracefully, got error: %v", err)
 	}
@@ -289,7 +296,7 @@ func TestFormat_ClearSectionSeparation(t *testing.T) {
 func TestBuild_IncludesBeadDependencies(t *testing.T) {
 	builder := NewBuilder()
 
-	ctx, err := builder.Build("test", "/agents.md", 1, true)
+	ctx, err := builder.Build(context.Background(), "test", "/agents.md", 1, true)
 	if err != nil {
 		t.Fatalf("Build() failed: %v", err)
 	}
@@ -381,6 +388,28 @@ func TestFormatFeedback_IncludesAgentNameAsAuthor(t *testing.T) {
 	}
 }
 
+// TestFormatFeedback_PostComments tests that an agent whose comments buckshot
+// posts is asked to write them in its reply instead of running bd comment
+func TestFormatFeedback_PostComments(t *testing.T) {
+	builder := NewBuilder()
+
+	ctx := PlanningContext{
+		BeadsState:   "buckshot-123 [P1] [task] open - Test task",
+		FeedbackMode: true,
+		AgentName:    "codex",
+		PostComments: true,
+	}
+
+	output := builder.FormatFeedback(ctx)
+
+	if !strings.Contains(output, "COMMENT <issue-id>") || !strings.Contains(output, "END COMMENT") {
+		t.Errorf("FormatFeedback() should describe the comment block format, got:\n%s", output)
+	}
+	if strings.Contains(output, "- Use `bd comment") {
+		t.Errorf("FormatFeedback() should not ask the agent to run bd comment, got:\n%s", output)
+	}
+}
+
 func TestFormatFeedback_ProhibitsModifyingDescriptions(t *testing.T) {
 	builder := NewBuilder()
 
@@ -515,3 +544,316 @@ func TestFormatFeedback_IncludesSubstantiveCommentGuidance(t *testing.T) {
 		t.Error("FormatFeedback() should guide agent to leave substantive comments (different or better than existing)")
 	}
 }
+
+// manyBeads returns a fake client with n beads whose priority cycles P0-P4.
+// Each bd show section is roughly 200 bytes.
+func manyBeads(n int) *fakeBeadsClient {
+	fake := &fakeBeadsClient{issues: map[string]string{}}
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	var list strings.Builder
+	for i := 1; i <= n; i++ {
+		id := fmt.Sprintf("buckshot-%d", i)
+		prio := i % 5
+		fmt.Fprintf(&list, "%s [P%d] [task] open - Bead %d\n", id, prio, i)
+		fake.issues[id] = fmt.Sprintf("%s: Bead %d\nPriority: P%d\n%s", id, i, prio, strings.Repeat("x", 160))
+		fake.all = append(fake.all, beads.Issue{ID: id, Priority: prio, UpdatedAt: base.Add(time.Duration(i) * time.Hour)})
+	}
+	fake.list = list.String()
+	return fake
+}
+
+// TestRefreshBeadsState_RespectsByteBudget tests that details stop at the byte budget
+func TestRefreshBeadsState_RespectsByteBudget(t *testing.T) {
+	fake := manyBeads(50)
+	budget := len(fake.list) + 2000
+	builder := NewBuilder(WithBeadsClient(fake), WithMaxContextBytes(budget))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+
+	state := ctx.BeadsState
+	idx := strings.LastIndex(state, "\n(")
+	if idx < 0 {
+		t.Fatalf("BeadsState should end with an omission note, got:\n%s", state)
+	}
+	note := state[idx:]
+	if len(state)-len(note) > budget {
+		t.Errorf("BeadsState details use %d bytes, budget is %d", len(state)-len(note), budget)
+	}
+	detailed := strings.Count(state, "Priority: P")
+	if detailed == 0 || detailed >= 50 {
+		t.Fatalf("detailed %d beads, want some but not all", detailed)
+	}
+	if want := fmt.Sprintf("(%d more beads omitted)", 50-detailed); !strings.Contains(note, want) {
+		t.Errorf("BeadsState should end with %q, got %q", want, note)
+	}
+	if !strings.Contains(state, "buckshot-50 [P0]") {
+		t.Error("the bead list itself should always be complete")
+	}
+}
+
+// TestRefreshBeadsState_MaxBeadsDetail tests the detail count limit and ordering
+func TestRefreshBeadsState_MaxBeadsDetail(t *testing.T) {
+	fake := manyBeads(20)
+	builder := NewBuilder(WithBeadsClient(fake), WithMaxBeadsDetail(3), WithMaxContextBytes(0))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+
+	// The P0 beads are 5, 10, 15 and 20; the most recently updated come first
+	if got := strings.Join(fake.shown, ","); got != "buckshot-20,buckshot-15,buckshot-10" {
+		t.Errorf("detailed %s, want the three newest P0 beads", got)
+	}
+	if !strings.Contains(ctx.BeadsState, "(17 more beads omitted)") {
+		t.Errorf("BeadsState should note the omitted beads, got:\n%s", ctx.BeadsState)
+	}
+}
+
+// TestRefreshBeadsState_NoBudgetNoNote tests that nothing is omitted when everything fits
+func TestRefreshBeadsState_NoBudgetNoNote(t *testing.T) {
+	fake := manyBeads(5)
+	builder := NewBuilder(WithBeadsClient(fake))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+	if len(fake.shown) != 5 || strings.Contains(ctx.BeadsState, "omitted") {
+		t.Errorf("all 5 beads should be detailed with no omission note, shown %v", fake.shown)
+	}
+}
+
+// TestRefreshBeadsState_BeadPrefix tests that only beads with the project's
+// prefix are detailed
+func TestRefreshBeadsState_BeadPrefix(t *testing.T) {
+	fake := &fakeBeadsClient{
+		list: "proj-a1 [P1] [task] open - Cache\nother-b2 [P2] [task] open - Elsewhere\nproj-c3.1 [P2] [task] open - Child\n",
+		issues: map[string]string{
+			"proj-a1":   "proj-a1: Cache",
+			"other-b2":  "other-b2: Elsewhere",
+			"proj-c3.1": "proj-c3.1: Child",
+		},
+	}
+	builder := NewBuilder(WithBeadsClient(fake), WithBeadPrefix("proj"))
+
+	var ctx PlanningContext
+	if err := builder.RefreshBeadsState(context.Background(), &ctx); err != nil {
+		t.Fatalf("RefreshBeadsState() error = %v", err)
+	}
+	if got := strings.Join(fake.shown, ","); got != "proj-a1,proj-c3.1" {
+		t.Errorf("detailed %s, want only the proj- beads", got)
+	}
+}
+
+// TestBuild_WithoutBeads tests that a no-beads builder never reads beads and
+// leaves bd instructions out of the prompt
+func TestBuild_WithoutBeads(t *testing.T) {
+	fake := &fakeBeadsClient{list: "bd-1 [P1] [task] open - Cache\n"}
+	builder := NewBuilder(WithBeadsClient(fake), WithoutBeads())
+
+	ctx, err := builder.Build(context.Background(), "Design API", "/agents.md", 1, false)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !ctx.NoBeads || ctx.BeadsState != "" {
+		t.Errorf("Build() = NoBeads %v, BeadsState %q; want no beads state", ctx.NoBeads, ctx.BeadsState)
+	}
+	if len(fake.listOpts) != 0 {
+		t.Errorf("bd list ran %d time(s), want none", len(fake.listOpts))
+	}
+
+	out := builder.Format(ctx)
+	if strings.Contains(out, "bd create") || strings.Contains(out, "Current Beads") {
+		t.Errorf("Format() mentions beads without bd:\n%s", out)
+	}
+	if !strings.Contains(out, "Prompt: Design API") || !strings.Contains(out, "AGENTS.md: /agents.md") {
+		t.Errorf("Format() lost the prompt or AGENTS.md:\n%s", out)
+	}
+}
+
+// TestFormat_PromptPrefixSuffix tests that the prefix and suffix bracket both
+// prompts in every round
+func TestFormat_PromptPrefixSuffix(t *testing.T) {
+	builder := NewBuilder(WithPromptPrefix("Always run bd list first."), WithPromptSuffix("Respond in under 200 words."))
+	plain := NewBuilder()
+
+	for round := 1; round <= 2; round++ {
+		ctx := PlanningContext{Prompt: "Review code", AgentsPath: "/agents.md", Round: round, IsFirstTurn: round == 1, AgentName: "claude"}
+		formats := map[string][2]string{
+			"Format":         {builder.Format(ctx), plain.Format(ctx)},
+			"FormatFeedback": {builder.FormatFeedback(ctx), plain.FormatFeedback(ctx)},
+		}
+		for name, out := range formats {
+			want := "Always run bd list first.\n\n" + strings.TrimRight(out[1], "\n") + "\n\nRespond in under 200 words.\n"
+			if out[0] != want {
+				t.Errorf("round %d %s() =\n%s\nwant:\n%s", round, name, out[0], want)
+			}
+		}
+	}
+}
+
+// TestFormat_ResponseBudget tests that the response budget is asked for after
+// the prompt, ahead of any suffix, and left out when unset
+func TestFormat_ResponseBudget(t *testing.T) {
+	ctx := PlanningContext{Prompt: "Review code", AgentsPath: "/agents.md", Round: 1, IsFirstTurn: true, AgentName: "claude"}
+	plain := NewBuilder(WithoutBeads())
+	budget := "Keep your response concise: under about 500 tokens."
+
+	tests := []struct {
+		name    string
+		builder Builder
+		want    string
+	}{
+		{"budget", NewBuilder(WithoutBeads(), WithResponseBudget(500)), strings.TrimRight(plain.Format(ctx), "\n") + "\n\n" + budget + "\n"},
+		{"budget and suffix", NewBuilder(WithoutBeads(), WithResponseBudget(500), WithPromptSuffix("Be kind.")), strings.TrimRight(plain.Format(ctx), "\n") + "\n\n" + budget + "\n\nBe kind.\n"},
+		{"no budget", NewBuilder(WithoutBeads(), WithResponseBudget(0)), plain.Format(ctx)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.builder.Format(ctx); got != tt.want {
+				t.Errorf("Format() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormat_BootstrapPrompt tests that the bootstrap prompt follows the
+// prompt on the bootstrap turn only, ahead of any suffix, and never in
+// feedback prompts
+func TestFormat_BootstrapPrompt(t *testing.T) {
+	ctx := PlanningContext{Prompt: "Review code", AgentsPath: "/agents.md", Round: 1, IsFirstTurn: true, AgentName: "claude"}
+	boot := ctx
+	boot.IsBootstrap = true
+	plain := NewBuilder(WithoutBeads())
+	analysis := "Do a thorough initial analysis."
+
+	tests := []struct {
+		name    string
+		builder Builder
+		ctx     PlanningContext
+		want    string
+	}{
+		{"bootstrap turn", NewBuilder(WithoutBeads(), WithBootstrapPrompt(analysis)), boot, strings.TrimRight(plain.Format(ctx), "\n") + "\n\n" + analysis + "\n"},
+		{"with suffix", NewBuilder(WithoutBeads(), WithBootstrapPrompt(analysis), WithPromptSuffix("Be kind.")), boot, strings.TrimRight(plain.Format(ctx), "\n") + "\n\n" + analysis + "\n\nBe kind.\n"},
+		{"later turn", NewBuilder(WithoutBeads(), WithBootstrapPrompt(analysis)), ctx, plain.Format(ctx)},
+		{"no bootstrap prompt", plain, boot, plain.Format(ctx)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.builder.Format(tt.ctx); got != tt.want {
+				t.Errorf("Format() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if got := NewBuilder(WithoutBeads(), WithBootstrapPrompt(analysis)).FormatFeedback(boot); strings.Contains(got, analysis) {
+		t.Errorf("FormatFeedback() should leave out the bootstrap prompt, got:\n%s", got)
+	}
+}
+
+// TestFormat_PromptBudget tests that a prompt over the budget warns once,
+// from Format or FormatFeedback, and one under it never does
+func TestFormat_PromptBudget(t *testing.T) {
+	const budget = 10000
+
+	tests := []struct {
+		name  string
+		beads int
+		warns int
+	}{
+		{"large beads state", 500, 1},
+		{"small beads state", 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warned []int
+			builder := NewBuilder(WithBeadsClient(manyBeads(tt.beads)), WithMaxContextBytes(0), WithPromptBudget(budget, func(tokens, limit int) {
+				if limit != budget {
+					t.Errorf("warned with budget %d, want %d", limit, budget)
+				}
+				warned = append(warned, tokens)
+			}))
+			ctx, err := builder.Build(context.Background(), "Review code", "/agents.md", 1, true)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			prompt := builder.Format(ctx)
+			builder.Format(ctx)
+			builder.FormatFeedback(ctx)
+
+			if len(warned) != tt.warns {
+				t.Fatalf("warned %d times, want %d", len(warned), tt.warns)
+			}
+			if tt.warns > 0 && (warned[0] <= budget || warned[0] != EstimateTokens(prompt)) {
+				t.Errorf("warned at %d tokens, want the %d estimated for the prompt", warned[0], EstimateTokens(prompt))
+			}
+		})
+	}
+
+	// A feedback prompt over the budget warns too
+	var warned bool
+	builder := NewBuilder(WithBeadsClient(manyBeads(500)), WithMaxContextBytes(0), WithPromptBudget(budget, func(tokens, limit int) { warned = true }))
+	ctx, err := builder.Build(context.Background(), "", "/agents.md", 1, true)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	builder.FormatFeedback(ctx)
+	if !warned {
+		t.Error("FormatFeedback() over the budget should warn")
+	}
+}
+
+// TestBuild_BeadsFile tests that beads read from a file fill the context as
+// bd output would, details and blocked beads included
+func TestBuild_BeadsFile(t *testing.T) {
+	client, err := beads.LoadFile(filepath.Join("..", "..", "testdata", "beads", "issues.jsonl"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	filter, err := ParseBeadsFilter([]string{"open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := NewBuilder(WithBeadsClient(client), WithBeadsFilter(filter), WithBeadPrefix("buckshot"))
+
+	ctx, err := builder.Build(context.Background(), "Review the cache plan", "/agents.md", 1, true)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"buckshot-a1 [P1] [feature] open - Add a response cache for agent sessions",
+		"Description: Explain when cached answers are dropped.",
+		"buckshot-c3: Pick a cache key [closed]",
+	} {
+		if !strings.Contains(ctx.BeadsState, want) {
+			t.Errorf("BeadsState should contain %q, got:\n%s", want, ctx.BeadsState)
+		}
+	}
+	if strings.Contains(ctx.BeadsState, "buckshot-c3 [P1]") {
+		t.Errorf("the closed bead should be filtered out of the list, got:\n%s", ctx.BeadsState)
+	}
+	want := []BlockedInfo{{ID: "buckshot-b2", WaitingOn: []string{"buckshot-a1"}}}
+	if !reflect.DeepEqual(ctx.BlockedBeads, want) {
+		t.Errorf("BlockedBeads = %v, want %v", ctx.BlockedBeads, want)
+	}
+}
+
+// TestFormat_PreviousRound tests that Format puts the previous round's
+// summary before the prompt, and leaves it out when there is none
+func TestFormat_PreviousRound(t *testing.T) {
+	builder := NewBuilder(WithoutBeads())
+	ctx := PlanningContext{Prompt: "Review code", AgentsPath: "/agents.md", Round: 2}
+	plain := builder.Format(ctx)
+
+	ctx.PreviousRound = &RoundSummary{Round: 1, Agents: []AgentSummary{{Name: "claude", Changes: []string{"created buckshot-a1"}}}}
+	want := "## Previous round summary\n\nRound 1:\n- claude: created buckshot-a1\n\n" + plain
+	if got := builder.Format(ctx); got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoundSummary is what happened in a finished round, put before the next
+// round's prompt so agents needn't work it out from the beads alone.
+type RoundSummary struct {
+	Round  int            // The round summarized
+	Agents []AgentSummary // Each agent's turn, in the order they ran
+}
+
+// AgentSummary is one agent's turn in a RoundSummary.
+type AgentSummary struct {
+	Name       string   // The agent
+	Changes    []string // What it did to beads, e.g. "created buckshot-a1"
+	Conclusion string   // The closing paragraph of its response
+	Outcome    string   // Why its turn didn't finish, e.g. "failed: timeout"; empty if it did
+}
+
+// roundSummaryHeading opens the section Format renders a RoundSummary in.
+const roundSummaryHeading = "## Previous round summary"
+
+// String renders the summary as one line per agent, with its conclusion
+// indented beneath:
+//
+//	Round 1:
+//	- claude: created buckshot-a1, updated buckshot-b2
+//	  Conclusion: The plan covers the API; tests are still missing.
+//	- codex: failed: timeout
+func (s RoundSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Round %d:\n", s.Round)
+	for _, a := range s.Agents {
+		status := a.Outcome
+		if status == "" {
+			status = "no bead changes"
+			if len(a.Changes) > 0 {
+				status = strings.Join(a.Changes, ", ")
+			}
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", a.Name, status)
+		if a.Conclusion != "" {
+			fmt.Fprintf(&b, "  Conclusion: %s\n", a.Conclusion)
+		}
+	}
+	return b.String()
+}
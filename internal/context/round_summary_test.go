@@ -0,0 +1,25 @@
+package context
+
+import "testing"
+
+func TestRoundSummaryString(t *testing.T) {
+	summary := RoundSummary{
+		Round: 1,
+		Agents: []AgentSummary{
+			{Name: "claude", Changes: []string{"created buckshot-a1", "updated buckshot-b2"}, Conclusion: "The plan covers the API; tests are still missing."},
+			{Name: "gemini", Conclusion: "Nothing to add."},
+			{Name: "codex", Outcome: "failed: timeout"},
+		},
+	}
+
+	want := `Round 1:
+- claude: created buckshot-a1, updated buckshot-b2
+  Conclusion: The plan covers the API; tests are still missing.
+- gemini: no bead changes
+  Conclusion: Nothing to add.
+- codex: failed: timeout
+`
+	if got := summary.String(); got != want {
+		t.Errorf("String() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package context
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// DefaultPromptTemplate is the built-in template for planning prompts.
+//
+//go:embed templates/plan.tmpl
+var DefaultPromptTemplate string
+
+// DefaultFeedbackTemplate is the built-in template for feedback prompts.
+//
+//go:embed templates/feedback.tmpl
+var DefaultFeedbackTemplate string
+
+// templateFuncs are available to every prompt template:
+// guidance renders the first-turn instruction for an AGENTS.md path and
+// agentsLabel describes the path for the prompt body.
+var templateFuncs = template.FuncMap{
+	"guidance":    agentsGuidance,
+	"agentsLabel": agentsPathLabel,
+}
+
+var (
+	defaultPromptTmpl   = template.Must(newTemplate("plan.tmpl", DefaultPromptTemplate))
+	defaultFeedbackTmpl = template.Must(newTemplate("feedback.tmpl", DefaultFeedbackTemplate))
+)
+
+func newTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// ParseTemplate parses a prompt template. Templates render a PlanningContext,
+// so {{.Prompt}}, {{.BeadsState}}, {{.AgentsPath}}, {{.Round}},
+// {{.AgentName}}, {{.IsBootstrap}}, {{.NoBeads}} and {{.BlockedBeads}} are
+// all available. The template is also rendered once against a sample
+// context so a misspelled field fails here, not mid-run.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	t, err := newTemplate(name, text)
+	if err != nil {
+		return nil, err
+	}
+	sample := PlanningContext{Prompt: "prompt", AgentsPath: "AGENTS.md", Round: 2, TotalRounds: 3, IsFirstTurn: true, AgentName: "agent"}
+	if err := t.Execute(io.Discard, sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadTemplate reads and parses a prompt template file.
+func LoadTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	t, err := ParseTemplate(filepath.Base(path), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// render executes t with ctx. Templates are checked when parsed, so should
+// one still fail it is logged and the built-in fallback is used rather than
+// sending a half-rendered prompt.
+func (b *defaultBuilder) render(t, fallback *template.Template, ctx PlanningContext) string {
+	var buf bytes.Buffer
+	if t != nil {
+		err := t.Execute(&buf, ctx)
+		if err == nil {
+			return buf.String()
+		}
+		b.logger.Warnf("prompt template %s failed, using the built-in template instead: %v", t.Name(), err)
+		buf.Reset()
+	}
+	_ = fallback.Execute(&buf, ctx)
+	return buf.String()
+}
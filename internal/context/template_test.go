@@ -0,0 +1,121 @@
+package context
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/logging"
+)
+
+// TestFormat_CustomTemplate tests that a custom template replaces the default
+// and sees every planning variable
+func TestFormat_CustomTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("house.tmpl", "[{{.AgentName}} r{{.Round}}] {{.Prompt}} | {{.AgentsPath}} | {{.BeadsState}}\nUse bd create --type task.\n")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	builder := NewBuilder(WithPromptTemplate(tmpl))
+
+	got := builder.Format(PlanningContext{
+		Prompt:     "Design auth",
+		AgentsPath: "/repo/AGENTS.md",
+		BeadsState: "buckshot-a1 open",
+		Round:      2,
+		AgentName:  "claude",
+	})
+	want := "[claude r2] Design auth | /repo/AGENTS.md | buckshot-a1 open\nUse bd create --type task.\n"
+	if got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+
+	// The feedback prompt keeps its own default
+	if fb := builder.FormatFeedback(PlanningContext{AgentName: "claude"}); !strings.Contains(fb, "Feedback Mode") {
+		t.Errorf("FormatFeedback() should still use the default template, got:\n%s", fb)
+	}
+}
+
+// TestFormatFeedback_CustomTemplate tests overriding the feedback template
+func TestFormatFeedback_CustomTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("feedback.tmpl", `Comment as {{.AgentName}} with bd comment; AGENTS.md: {{agentsLabel .AgentsPath}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	got := NewBuilder(WithFeedbackTemplate(tmpl)).FormatFeedback(PlanningContext{AgentName: "codex"})
+	if want := "Comment as codex with bd comment; AGENTS.md: (none; using default instructions)"; got != want {
+		t.Errorf("FormatFeedback() = %q, want %q", got, want)
+	}
+}
+
+// TestParseTemplate_Errors tests that syntax errors and unknown variables are
+// reported when the template is parsed
+func TestParseTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"unclosed action", "Prompt: {{.Prompt", "unclosed action"},
+		{"unknown field", "Prompt: {{.Promt}}", "can't evaluate field Promt"},
+		{"unknown function", "{{shout .Prompt}}", `function "shout" not defined`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplate("bad.tmpl", tt.text)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTemplate() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadTemplate tests reading a template file and naming it in errors
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "prompt.tmpl")
+	if err := os.WriteFile(good, []byte("Round {{.Round}}: {{.Prompt}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := LoadTemplate(good)
+	if err != nil {
+		t.Fatalf("LoadTemplate() error = %v", err)
+	}
+	if got := NewBuilder(WithPromptTemplate(tmpl)).Format(PlanningContext{Prompt: "p", Round: 3}); got != "Round 3: p" {
+		t.Errorf("Format() = %q, want %q", got, "Round 3: p")
+	}
+
+	bad := filepath.Join(dir, "bad.tmpl")
+	if err := os.WriteFile(bad, []byte("{{.Nope}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTemplate(bad); err == nil || !strings.Contains(err.Error(), "invalid prompt template "+bad) {
+		t.Errorf("LoadTemplate() error = %v, want it to name the file", err)
+	}
+
+	if _, err := LoadTemplate(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("LoadTemplate() should fail for a missing file")
+	}
+}
+
+// TestFormat_TemplateFailsAtRunTime tests that a template that only fails
+// on a real context falls back to the built-in one with a warning
+func TestFormat_TemplateFailsAtRunTime(t *testing.T) {
+	tmpl, err := ParseTemplate("late.tmpl", "{{if .IsFirstTurn}}{{.Prompt}}{{else}}{{index .BlockedBeads 5}}{{end}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	var logs bytes.Buffer
+	builder := NewBuilder(WithPromptTemplate(tmpl), WithLogger(logging.New(&logs, logging.LevelWarn)))
+
+	got := builder.Format(PlanningContext{Prompt: "Design the API", Round: 2})
+	if got != NewBuilder().Format(PlanningContext{Prompt: "Design the API", Round: 2}) {
+		t.Errorf("Format() = %q, want the built-in template's prompt", got)
+	}
+	if !strings.Contains(logs.String(), "prompt template late.tmpl failed") {
+		t.Errorf("expected a warning naming the template, got: %q", logs.String())
+	}
+}
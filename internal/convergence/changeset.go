@@ -0,0 +1,42 @@
+package convergence
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// ChangeSet returns the union of the bead changes the round's successful
+// agents reported, as sorted, distinct "<id> <action>" entries. When agents
+// run in parallel none sees the others' changes, so comparing one round's
+// change-set with the last says more than any single agent's diff.
+func ChangeSet(result orchestrator.RoundResult) []string {
+	var set []string
+	for _, ar := range result.AgentResults {
+		if ar.Skipped || ar.Error != nil {
+			continue
+		}
+		for i, id := range ar.BeadsChanged {
+			entry := id
+			if i < len(ar.BeadChanges) && ar.BeadChanges[i].ID == id {
+				entry += " " + string(ar.BeadChanges[i].Action)
+			}
+			set = append(set, entry)
+		}
+	}
+	slices.Sort(set)
+	return slices.Compact(set)
+}
+
+// sameChangeSet reports whether result proposes the changes the previous
+// round did. A round that proposes nothing is stable however the last went.
+func (d *defaultDetector) sameChangeSet(result orchestrator.RoundResult) bool {
+	set := ChangeSet(result)
+	return len(set) == 0 || changeSetFingerprint(set) == d.lastChangeSet
+}
+
+// changeSetFingerprint returns a short, stable digest of a change-set.
+func changeSetFingerprint(set []string) string {
+	return Fingerprint(strings.Join(set, "\n"))
+}
@@ -0,0 +1,110 @@
+package convergence
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// parallelRound is a round in which each agent reported changing beads, as
+// "<id> <action>" pairs keyed by agent name.
+func parallelRound(round int, changes map[string][][2]string) orchestrator.RoundResult {
+	result := orchestrator.RoundResult{Round: round}
+	for _, name := range []string{"claude", "codex"} {
+		ar := orchestrator.AgentResult{Agent: agent.Agent{Name: name}, BeadsChanged: []string{}}
+		for _, c := range changes[name] {
+			ar.BeadsChanged = append(ar.BeadsChanged, c[0])
+			ar.BeadChanges = append(ar.BeadChanges, orchestrator.BeadChange{ID: c[0], Action: orchestrator.BeadAction(c[1])})
+		}
+		result.TotalChanges += len(ar.BeadsChanged)
+		result.AgentResults = append(result.AgentResults, ar)
+	}
+	return result
+}
+
+func TestChangeSet(t *testing.T) {
+	result := parallelRound(1, map[string][][2]string{
+		"claude": {{"buckshot-b2", "updated"}, {"buckshot-a1", "created"}},
+		"codex":  {{"buckshot-b2", "updated"}, {"buckshot-b2", "closed"}},
+	})
+	result.AgentResults = append(result.AgentResults, orchestrator.AgentResult{
+		Agent:        agent.Agent{Name: "gemini"},
+		BeadsChanged: []string{"buckshot-c3"},
+		Error:        errors.New("timed out"),
+	})
+
+	want := []string{"buckshot-a1 created", "buckshot-b2 closed", "buckshot-b2 updated"}
+	if got := ChangeSet(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangeSet() = %q, want %q", got, want)
+	}
+}
+
+// TestCheckConvergence_ChangeSets tests that with change-set convergence a
+// round converges when its agents together propose what the last round did,
+// however the changes are split between them
+func TestCheckConvergence_ChangeSets(t *testing.T) {
+	first := parallelRound(1, map[string][][2]string{
+		"claude": {{"buckshot-a1", "updated"}},
+		"codex":  {{"buckshot-b2", "closed"}},
+	})
+	same := parallelRound(2, map[string][][2]string{
+		"claude": {{"buckshot-b2", "closed"}, {"buckshot-a1", "updated"}},
+		"codex":  {{"buckshot-a1", "updated"}},
+	})
+	different := parallelRound(2, map[string][][2]string{
+		"claude": {{"buckshot-a1", "updated"}},
+		"codex":  {{"buckshot-c3", "created"}},
+	})
+
+	t.Run("identical change-sets", func(t *testing.T) {
+		detector := NewDetector()
+		detector.SetChangeSetConvergence(true)
+		if detector.CheckConvergence(first) {
+			t.Error("the first round proposing changes should not converge")
+		}
+		if !detector.CheckConvergence(same) {
+			t.Error("a round proposing the last round's changes should converge")
+		}
+	})
+
+	t.Run("differing change-sets", func(t *testing.T) {
+		detector := NewDetector()
+		detector.SetChangeSetConvergence(true)
+		detector.CheckConvergence(first)
+		if detector.CheckConvergence(different) {
+			t.Error("a round proposing new changes should not converge")
+		}
+		if !detector.CheckConvergence(parallelRound(3, nil)) {
+			t.Error("a round proposing nothing should converge")
+		}
+	})
+
+	t.Run("without change-sets", func(t *testing.T) {
+		detector := NewDetector()
+		detector.CheckConvergence(first)
+		if detector.CheckConvergence(same) {
+			t.Error("by default any change should block convergence")
+		}
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		detector := NewDetector()
+		detector.SetChangeSetConvergence(true)
+		detector.CheckConvergence(first)
+
+		resumed := NewDetector()
+		resumed.SetChangeSetConvergence(true)
+		resumed.Restore(detector.Snapshot())
+		if !resumed.CheckConvergence(same) {
+			t.Error("a restored detector should compare against the snapshot's change-set")
+		}
+
+		resumed.Reset()
+		if resumed.CheckConvergence(same) {
+			t.Error("Reset() should forget the last change-set")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package convergence
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// Proposal is one bead action and how the agents that ran stand on it.
+type Proposal struct {
+	Change   orchestrator.BeadChange
+	Agree    int      // Agents that reported this action on the bead
+	Disagree int      // Agents that reported a different action on the same bead
+	Agents   []string // Names of the agreeing agents, sorted
+}
+
+// ConsensusReport tallies which bead actions the agents of a run agree on.
+type ConsensusReport struct {
+	Participants int        // Agents that completed at least one turn
+	Proposals    []Proposal // Most agreement first
+}
+
+// Consensus tallies the bead actions reported by each agent across results,
+// counting each agent at most once per action. Pass a single round to get
+// that round's consensus. Agents that were skipped or failed every turn
+// don't count as participants.
+func Consensus(results []orchestrator.RoundResult) ConsensusReport {
+	participants := make(map[string]bool)
+	backers := make(map[orchestrator.BeadChange]map[string]bool)
+	actions := make(map[string]map[orchestrator.BeadAction]bool) // bead ID -> actions proposed
+
+	for _, result := range results {
+		for _, ar := range result.AgentResults {
+			if ar.Skipped || ar.Error != nil {
+				continue
+			}
+			participants[ar.Agent.Name] = true
+			for _, c := range ar.BeadChanges {
+				if backers[c] == nil {
+					backers[c] = make(map[string]bool)
+				}
+				backers[c][ar.Agent.Name] = true
+				if actions[c.ID] == nil {
+					actions[c.ID] = make(map[orchestrator.BeadAction]bool)
+				}
+				actions[c.ID][c.Action] = true
+			}
+		}
+	}
+
+	report := ConsensusReport{Participants: len(participants)}
+	for change, agents := range backers {
+		p := Proposal{Change: change, Agree: len(agents)}
+		for name := range agents {
+			p.Agents = append(p.Agents, name)
+		}
+		sort.Strings(p.Agents)
+
+		// An agent that took another action on the same bead disagrees,
+		// unless it also took this one
+		dissent := make(map[string]bool)
+		for action := range actions[change.ID] {
+			if action == change.Action {
+				continue
+			}
+			for name := range backers[orchestrator.BeadChange{ID: change.ID, Action: action}] {
+				if !agents[name] {
+					dissent[name] = true
+				}
+			}
+		}
+		p.Disagree = len(dissent)
+		report.Proposals = append(report.Proposals, p)
+	}
+
+	sort.Slice(report.Proposals, func(i, j int) bool {
+		a, b := report.Proposals[i], report.Proposals[j]
+		switch {
+		case a.Agree != b.Agree:
+			return a.Agree > b.Agree
+		case a.Disagree != b.Disagree:
+			return a.Disagree < b.Disagree
+		case a.Change.ID != b.Change.ID:
+			return a.Change.ID < b.Change.ID
+		}
+		return a.Change.Action < b.Change.Action
+	})
+
+	return report
+}
+
+// Agreed returns the proposals backed by more than one agent.
+func (r ConsensusReport) Agreed() []Proposal {
+	var agreed []Proposal
+	for _, p := range r.Proposals {
+		if p.Agree > 1 {
+			agreed = append(agreed, p)
+		}
+	}
+	return agreed
+}
+
+// Describe renders a proposal as e.g. "3/4 agents updated buckshot-12".
+func (r ConsensusReport) Describe(p Proposal) string {
+	line := fmt.Sprintf("%d/%d agents %s %s", p.Agree, r.Participants, p.Change.Action, p.Change.ID)
+	if p.Disagree > 0 {
+		line += fmt.Sprintf(" (%d disagree)", p.Disagree)
+	}
+	return line
+}
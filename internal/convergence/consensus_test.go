@@ -0,0 +1,81 @@
+package convergence
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// TestConsensus_Overlapping tests that agents reporting the same action are
+// tallied together and counted once each across rounds
+func TestConsensus_Overlapping(t *testing.T) {
+	update := orchestrator.BeadChange{ID: "b-12", Action: orchestrator.BeadUpdated}
+	create := orchestrator.BeadChange{ID: "b-20", Action: orchestrator.BeadCreated}
+
+	results := []orchestrator.RoundResult{
+		round(1, changed("claude", update), changed("codex", update, create), changed("gemini")),
+		round(2, changed("claude", update), changed("codex"), changed("gemini", update)),
+	}
+
+	got := Consensus(results)
+	want := ConsensusReport{
+		Participants: 3,
+		Proposals: []Proposal{
+			{Change: update, Agree: 3, Agents: []string{"claude", "codex", "gemini"}},
+			{Change: create, Agree: 1, Agents: []string{"codex"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Consensus() = %+v, want %+v", got, want)
+	}
+	if agreed := got.Agreed(); len(agreed) != 1 || got.Describe(agreed[0]) != "3/3 agents updated b-12" {
+		t.Errorf("Agreed() = %+v", agreed)
+	}
+}
+
+// TestConsensus_Conflicting tests that a different action on the same bead
+// counts as disagreement, and that skipped or failed agents don't participate
+func TestConsensus_Conflicting(t *testing.T) {
+	update := orchestrator.BeadChange{ID: "b-12", Action: orchestrator.BeadUpdated}
+	closed := orchestrator.BeadChange{ID: "b-12", Action: orchestrator.BeadClosed}
+
+	failed := changed("amp")
+	failed.Error = errors.New("timed out")
+	skipped := orchestrator.AgentResult{Agent: agent.Agent{Name: "cursor"}, Skipped: true}
+
+	results := []orchestrator.RoundResult{
+		round(1, changed("claude", closed), changed("codex", closed), changed("gemini", update), changed("auggie"), failed, skipped),
+	}
+
+	got := Consensus(results)
+	if got.Participants != 4 {
+		t.Errorf("Participants = %d, want 4", got.Participants)
+	}
+	want := []Proposal{
+		{Change: closed, Agree: 2, Disagree: 1, Agents: []string{"claude", "codex"}},
+		{Change: update, Agree: 1, Disagree: 2, Agents: []string{"gemini"}},
+	}
+	if !reflect.DeepEqual(got.Proposals, want) {
+		t.Errorf("Proposals = %+v, want %+v", got.Proposals, want)
+	}
+
+	summary := Summarize(results)
+	if out := summary.Format(); !strings.Contains(out, "  Consensus:\n    2/4 agents closed b-12 (1 disagree)\n") {
+		t.Errorf("Format() should list the agreed proposal, got:\n%s", out)
+	}
+}
+
+// TestConsensus_Empty tests a run where nobody changed anything
+func TestConsensus_Empty(t *testing.T) {
+	got := Consensus([]orchestrator.RoundResult{round(1, changed("claude"))})
+	if got.Participants != 1 || len(got.Proposals) != 0 || len(got.Agreed()) != 0 {
+		t.Errorf("Consensus() = %+v, want one participant and no proposals", got)
+	}
+	if strings.Contains(Summarize(nil).Format(), "Consensus") {
+		t.Error("Format() should omit consensus when nothing was agreed")
+	}
+}
@@ -2,9 +2,6 @@
 package convergence
 
 import (
-	"regexp"
-	"strings"
-
 	"github.com/michaellady/buckshot/internal/orchestrator"
 )
 
@@ -28,12 +25,38 @@ type Detector interface {
 	// SetThreshold sets the number of consecutive no-change rounds
 	// required to declare convergence. Default is 1.
 	SetThreshold(n int)
+
+	// SetMatcher enables output-signal convergence. When set, a successful
+	// agent only counts as unchanged if its output matches a no-change phrase.
+	SetMatcher(m *PhraseMatcher)
+
+	// SetJudge enables semantic convergence: a round with no bead changes
+	// only counts as unchanged if j agrees the plans have stabilized. Nil
+	// disables the judge.
+	SetJudge(j Judge)
+
+	// SetChangeSetConvergence judges rounds whose agents ran in parallel: a
+	// round counts as unchanged when the union of the bead changes its
+	// agents reported (see ChangeSet) matches the previous round's, instead
+	// of when no agent changed anything.
+	SetChangeSetConvergence(enabled bool)
+
+	// Snapshot captures the tracking state so an interrupted run can resume.
+	Snapshot() Snapshot
+
+	// Restore replaces the tracking state with a previously taken snapshot.
+	Restore(snapshot Snapshot)
 }
 
 // defaultDetector is a stub implementation.
 type defaultDetector struct {
-	threshold          int
+	threshold           int
 	consecutiveNoChange int
+	lastRound           int
+	matcher             *PhraseMatcher
+	judge               Judge
+	changeSets          bool
+	lastChangeSet       string // Fingerprint of the last checked round's ChangeSet
 }
 
 // NewDetector creates a new convergence detector.
@@ -43,15 +66,23 @@ func NewDetector() Detector {
 	}
 }
 
-// IsConverged returns true if the round indicates no changes from any agent.
-// Skipped and failed agents are ignored - only successful agents count.
+// IsConverged returns true if the round indicates no changes from any agent,
+// or with change-set convergence, no changes beyond the last round's.
+// Skipped and failed agents are ignored - only successful agents count. With
+// a judge, the judge must also agree, and a round where no agent succeeded
+// gives it nothing to judge.
 func (d *defaultDetector) IsConverged(result orchestrator.RoundResult) bool {
-	// If TotalChanges > 0, definitely not converged
-	if result.TotalChanges > 0 {
+	if d.changeSets {
+		if !d.sameChangeSet(result) {
+			return false
+		}
+	} else if result.TotalChanges > 0 {
+		// If TotalChanges > 0, definitely not converged
 		return false
 	}
 
 	// Check each agent result
+	answered := 0
 	for _, ar := range result.AgentResults {
 		// Skip skipped agents
 		if ar.Skipped {
@@ -62,9 +93,19 @@ func (d *defaultDetector) IsConverged(result orchestrator.RoundResult) bool {
 			continue
 		}
 		// If any successful agent made changes, not converged
-		if len(ar.BeadsChanged) > 0 {
+		if !d.changeSets && len(ar.BeadsChanged) > 0 {
+			return false
+		}
+		// With output-signal convergence, the agent must also say so
+		if d.matcher != nil && !d.matcher.Match(ar.Response.Output) {
 			return false
 		}
+		answered++
+	}
+
+	// With semantic convergence, a quiet round still needs the judge's yes
+	if d.judge != nil {
+		return answered > 0 && d.judge(result)
 	}
 
 	// All successful agents made no changes (or no agents ran)
@@ -73,11 +114,15 @@ func (d *defaultDetector) IsConverged(result orchestrator.RoundResult) bool {
 
 // CheckConvergence analyzes a round and returns true if threshold met.
 func (d *defaultDetector) CheckConvergence(result orchestrator.RoundResult) bool {
+	d.lastRound = result.Round
 	if d.IsConverged(result) {
 		d.consecutiveNoChange++
 	} else {
 		d.consecutiveNoChange = 0
 	}
+	if d.changeSets {
+		d.lastChangeSet = changeSetFingerprint(ChangeSet(result))
+	}
 
 	return d.consecutiveNoChange >= d.threshold
 }
@@ -85,6 +130,8 @@ func (d *defaultDetector) CheckConvergence(result orchestrator.RoundResult) bool
 // Reset clears the convergence tracking state.
 func (d *defaultDetector) Reset() {
 	d.consecutiveNoChange = 0
+	d.lastRound = 0
+	d.lastChangeSet = ""
 }
 
 // ConsecutiveNoChangeRounds returns the current count.
@@ -100,15 +147,43 @@ func (d *defaultDetector) SetThreshold(n int) {
 	d.threshold = n
 }
 
-// noChangePatterns matches phrases indicating no changes were made
-var noChangePatterns = regexp.MustCompile(`(?i)(no\s+changes|nothing\s+to\s+do|all\s+tasks\s+(are\s+)?done|everything\s+is\s+complete|complete)`)
+// SetMatcher sets the phrase matcher used for output-signal convergence.
+func (d *defaultDetector) SetMatcher(m *PhraseMatcher) {
+	d.matcher = m
+}
+
+// SetJudge sets the judge consulted after a round with no changes.
+func (d *defaultDetector) SetJudge(j Judge) {
+	d.judge = j
+}
+
+// SetChangeSetConvergence sets whether rounds are compared by change-set.
+func (d *defaultDetector) SetChangeSetConvergence(enabled bool) {
+	d.changeSets = enabled
+}
+
+// Snapshot captures the last checked round, the no-change count and, with
+// change-set convergence, the last round's change-set fingerprint.
+func (d *defaultDetector) Snapshot() Snapshot {
+	return Snapshot{
+		Round:                d.lastRound,
+		ConsecutiveNoChange:  d.consecutiveNoChange,
+		ChangeSetFingerprint: d.lastChangeSet,
+	}
+}
+
+// Restore replaces the tracking state with snapshot.
+func (d *defaultDetector) Restore(snapshot Snapshot) {
+	d.lastRound = snapshot.Round
+	d.consecutiveNoChange = snapshot.ConsecutiveNoChange
+	d.lastChangeSet = snapshot.ChangeSetFingerprint
+}
+
+// defaultMatcher matches the built-in no-change phrases.
+var defaultMatcher = NewPhraseMatcher()
 
 // ParseNoChangeSignal checks if agent output indicates no changes were made.
 // Looks for phrases like "no changes", "complete", "nothing to do", etc.
 func ParseNoChangeSignal(output string) bool {
-	if output == "" {
-		return false
-	}
-	lower := strings.ToLower(output)
-	return noChangePatterns.MatchString(lower)
+	return defaultMatcher.Match(output)
 }
@@ -0,0 +1,58 @@
+package convergence
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// Judge decides whether the plans have stabilized, given a round in which no
+// agent changed the beads. It is consulted only after such a quiet round, and
+// should return false if it cannot decide.
+type Judge func(result orchestrator.RoundResult) bool
+
+// maxJudgeResponseRunes caps each agent's response in the judge's prompt.
+const maxJudgeResponseRunes = 4000
+
+// JudgePrompt asks whether the plans have stabilized, quoting each answer
+// from result.
+func JudgePrompt(result orchestrator.RoundResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Several agents are refining a shared plan. In round %d none of them changed the plan's beads. ", result.Round)
+	sb.WriteString("Based on their responses below, have the plans stabilized, with no agent still proposing substantive changes?\n\n")
+	sb.WriteString("Answer YES or NO on the first line, then give one sentence of reasoning.\n")
+	for _, ar := range result.AgentResults {
+		if ar.Skipped || ar.Error != nil {
+			continue
+		}
+		response := strings.TrimSpace(ar.Response.Output)
+		if runes := []rune(response); len(runes) > maxJudgeResponseRunes {
+			response = string(runes[:maxJudgeResponseRunes]) + "\n[truncated]"
+		}
+		fmt.Fprintf(&sb, "\n=== %s ===\n%s\n", ar.Agent.Name, response)
+	}
+	return sb.String()
+}
+
+// ParseVerdict reads the judge's yes or no from the first non-empty line of
+// its answer, ignoring case, punctuation and markdown emphasis.
+func ParseVerdict(output string) (bool, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		word := strings.ToLower(strings.TrimLeft(line, "*_#> \t"))
+		word, _, _ = strings.Cut(word, " ")
+		word = strings.TrimRight(word, "*_.,:;!")
+		switch word {
+		case "yes":
+			return true, nil
+		case "no":
+			return false, nil
+		}
+		return false, fmt.Errorf("judge answered %q, want YES or NO", line)
+	}
+	return false, fmt.Errorf("judge gave an empty answer")
+}
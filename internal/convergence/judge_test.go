@@ -0,0 +1,108 @@
+package convergence
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// quietRound is a round in which both agents answered without changing beads.
+func quietRound(round int) orchestrator.RoundResult {
+	return orchestrator.RoundResult{
+		Round: round,
+		AgentResults: []orchestrator.AgentResult{
+			{Agent: agent.Agent{Name: "claude"}, Response: session.Response{Output: "Add retries to the cache client."}},
+			{Agent: agent.Agent{Name: "codex"}, Response: session.Response{Output: "Looks good to me."}},
+		},
+	}
+}
+
+// TestCheckConvergence_JudgeDecides tests that after a quiet round the
+// judge's answer decides convergence
+func TestCheckConvergence_JudgeDecides(t *testing.T) {
+	for _, verdict := range []bool{true, false} {
+		detector := NewDetector()
+		asked := 0
+		detector.SetJudge(func(result orchestrator.RoundResult) bool {
+			asked++
+			return verdict
+		})
+
+		if got := detector.CheckConvergence(quietRound(1)); got != verdict {
+			t.Errorf("judge says %v: CheckConvergence() = %v", verdict, got)
+		}
+		if asked != 1 {
+			t.Errorf("judge asked %d times, want 1", asked)
+		}
+	}
+}
+
+// TestCheckConvergence_JudgeSkippedAfterChanges tests that the judge is not
+// asked about a round that changed the beads, or one where no agent answered
+func TestCheckConvergence_JudgeSkippedAfterChanges(t *testing.T) {
+	detector := NewDetector()
+	detector.SetJudge(func(result orchestrator.RoundResult) bool {
+		t.Errorf("judge asked about round %d", result.Round)
+		return true
+	})
+
+	changed := quietRound(1)
+	changed.AgentResults[0].BeadsChanged = []string{"buckshot-1"}
+	changed.TotalChanges = 1
+	if detector.CheckConvergence(changed) {
+		t.Error("CheckConvergence() = true after bead changes")
+	}
+
+	failed := orchestrator.RoundResult{Round: 2, AgentResults: []orchestrator.AgentResult{
+		{Agent: agent.Agent{Name: "claude"}, Error: errors.New("crashed")},
+	}}
+	if detector.CheckConvergence(failed) {
+		t.Error("CheckConvergence() = true with no answers to judge")
+	}
+}
+
+// TestJudgePrompt tests that the prompt quotes each answer and leaves out
+// failed and skipped agents
+func TestJudgePrompt(t *testing.T) {
+	result := quietRound(3)
+	result.AgentResults = append(result.AgentResults,
+		orchestrator.AgentResult{Agent: agent.Agent{Name: "gemini"}, Error: errors.New("timed out")},
+		orchestrator.AgentResult{Agent: agent.Agent{Name: "amp"}, Skipped: true},
+	)
+
+	prompt := JudgePrompt(result)
+	for _, want := range []string{"round 3", "YES or NO", "=== claude ===\nAdd retries to the cache client.", "=== codex ===\nLooks good to me."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "gemini") || strings.Contains(prompt, "amp") {
+		t.Errorf("prompt should leave out failed and skipped agents:\n%s", prompt)
+	}
+}
+
+// TestParseVerdict tests reading a yes or no from the judge's answer
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    bool
+		wantErr bool
+	}{
+		{"YES\nNobody proposes changes.", true, false},
+		{"\n  **Yes.** The plans agree.", true, false},
+		{"No, codex still wants retries.", false, false},
+		{"NO", false, false},
+		{"Probably, but codex is unsure.", false, true},
+		{"", false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVerdict(tt.output)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseVerdict(%q) = %v, %v; want %v, error %v", tt.output, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package convergence
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultNoChangePhrases are the built-in phrases indicating an agent made no changes.
+var DefaultNoChangePhrases = []string{
+	"no changes",
+	"nothing to do",
+	"nothing new",
+	"already updated",
+	"already up to date",
+	"all tasks done",
+	"all tasks are done",
+	"everything is complete",
+	"complete",
+}
+
+// DefaultChangePhrases are the built-in phrases indicating an agent made changes.
+// A change phrase overrides a no-change phrase when both appear in the same
+// output, unless it is negated, as in "No changes made" or "nothing new was
+// created".
+var DefaultChangePhrases = []string{
+	"bd create",
+	"bd update",
+	"bd close",
+	"created",
+	"updated",
+	"made changes",
+	"changes made",
+}
+
+// PhraseMatcher detects no-change signals in agent output.
+// Phrases are matched case-insensitively on word boundaries, so "complete"
+// does not match "completion rate" or "incomplete".
+type PhraseMatcher struct {
+	noChange []string
+	change   []string
+
+	noChangeRe *regexp.Regexp
+	changeRe   *regexp.Regexp
+}
+
+// NewPhraseMatcher creates a matcher with the default phrases plus any extra
+// no-change phrases supplied by the user.
+func NewPhraseMatcher(extra ...string) *PhraseMatcher {
+	m := &PhraseMatcher{}
+	m.AddNoChangePhrases(DefaultNoChangePhrases...)
+	m.AddNoChangePhrases(extra...)
+	m.AddChangePhrases(DefaultChangePhrases...)
+	return m
+}
+
+// AddNoChangePhrases adds phrases that indicate no changes were made.
+func (m *PhraseMatcher) AddNoChangePhrases(phrases ...string) {
+	m.noChange = appendPhrases(m.noChange, phrases)
+	m.noChangeRe = compilePhrases(m.noChange)
+}
+
+// AddChangePhrases adds phrases that indicate changes were made.
+func (m *PhraseMatcher) AddChangePhrases(phrases ...string) {
+	m.change = appendPhrases(m.change, phrases)
+	m.changeRe = compilePhrases(m.change)
+}
+
+// Match returns true if output signals no changes and no change phrase
+// overrides it. Negated no-change phrases, as in "the plan is not complete",
+// don't count.
+func (m *PhraseMatcher) Match(output string) bool {
+	if output == "" || m.noChangeRe == nil {
+		return false
+	}
+	if !matchesUnnegated(m.noChangeRe, output, noChangeNegations) {
+		return false
+	}
+	return !matchesUnnegated(m.changeRe, output, negations)
+}
+
+// matchesUnnegated reports whether re matches output anywhere that isn't
+// negated by one of words.
+func matchesUnnegated(re *regexp.Regexp, output string, words map[string]bool) bool {
+	if re == nil {
+		return false
+	}
+	for _, loc := range re.FindAllStringIndex(output, -1) {
+		if !negated(output[:loc[0]], words) {
+			return true
+		}
+	}
+	return false
+}
+
+// negationWindow is how many words before a phrase are searched for a
+// negation.
+const negationWindow = 4
+
+// negations are words that, shortly before a change phrase in the same
+// clause, mean the change didn't happen. "already updated" means there was
+// nothing left to change.
+var negations = map[string]bool{
+	"no": true, "not": true, "nothing": true, "none": true, "never": true,
+	"neither": true, "nor": true, "without": true, "already": true,
+}
+
+// noChangeNegations are the words that negate a no-change phrase. "Already"
+// isn't one: "everything is already complete" still means no changes.
+var noChangeNegations = map[string]bool{
+	"no": true, "not": true, "nothing": true, "none": true, "never": true,
+	"neither": true, "nor": true, "without": true,
+}
+
+// negated reports whether the clause ending with before negates whatever
+// follows it: one of its last negationWindow words is in words or ends in
+// "n't".
+func negated(before string, words map[string]bool) bool {
+	if i := strings.LastIndexAny(before, ".,;:!?\n"); i >= 0 {
+		before = before[i+1:]
+	}
+	fields := strings.Fields(strings.ToLower(before))
+	for _, w := range fields[max(0, len(fields)-negationWindow):] {
+		w = strings.Trim(w, `"'()`)
+		if words[w] || strings.HasSuffix(w, "n't") || strings.HasSuffix(w, "n’t") {
+			return true
+		}
+	}
+	return false
+}
+
+// appendPhrases appends non-empty, trimmed phrases.
+func appendPhrases(dst, phrases []string) []string {
+	for _, p := range phrases {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
+
+// compilePhrases builds a single case-insensitive, word-boundary-aware regex
+// matching any of the phrases. Whitespace inside a phrase matches any run of
+// whitespace in the output. Boundaries are only asserted next to word
+// characters so phrases ending in punctuation still match.
+func compilePhrases(phrases []string) *regexp.Regexp {
+	if len(phrases) == 0 {
+		return nil
+	}
+
+	alts := make([]string, 0, len(phrases))
+	for _, p := range phrases {
+		words := strings.Fields(p)
+		for i, w := range words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		alt := strings.Join(words, `\s+`)
+		if isWordByte(p[0]) {
+			alt = `\b` + alt
+		}
+		if isWordByte(p[len(p)-1]) {
+			alt += `\b`
+		}
+		alts = append(alts, alt)
+	}
+
+	return regexp.MustCompile(`(?i)(` + strings.Join(alts, "|") + `)`)
+}
+
+// isWordByte reports whether c is a regexp word character.
+func isWordByte(c byte) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,135 @@
+package convergence
+
+import (
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// TestPhraseMatcher_WordBoundaries tests that phrases only match whole words
+func TestPhraseMatcher_WordBoundaries(t *testing.T) {
+	m := NewPhraseMatcher()
+
+	testCases := []struct {
+		output string
+		want   bool
+	}{
+		{"The plan is complete", true},
+		{"COMPLETE", true},
+		{"The completion rate is 50%", false},
+		{"The plan is incomplete", false},
+		{"I completed the review", false},
+		{"no\n  changes needed", true},
+	}
+
+	for _, tc := range testCases {
+		if got := m.Match(tc.output); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.output, got, tc.want)
+		}
+	}
+}
+
+// TestPhraseMatcher_ChangePhrasesOverride tests that change phrases win over no-change phrases
+func TestPhraseMatcher_ChangePhrasesOverride(t *testing.T) {
+	m := NewPhraseMatcher()
+
+	testCases := []struct {
+		output string
+		want   bool
+	}{
+		{"No changes needed, plan is complete", true},
+		{"Created buckshot-abc. The plan is now complete.", false},
+		{"Ran bd update buckshot-1; otherwise nothing to do", false},
+		{"I made changes to two beads; everything is complete", false},
+		{"No changes made.", true},
+		{"nothing new was created.", true},
+		{"the plan is already updated.", true},
+		{"I didn't update anything, the plan is complete", true},
+		{"Nothing was blocked, so I created buckshot-2. Otherwise no changes.", false},
+	}
+
+	for _, tc := range testCases {
+		if got := m.Match(tc.output); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.output, got, tc.want)
+		}
+	}
+}
+
+// TestPhraseMatcher_NegatedNoChangePhrases tests that a negated no-change
+// phrase is no signal unless another one isn't negated
+func TestPhraseMatcher_NegatedNoChangePhrases(t *testing.T) {
+	m := NewPhraseMatcher("done")
+
+	testCases := []struct {
+		output string
+		want   bool
+	}{
+		{"The plan is not complete", false},
+		{"Nothing is complete yet", false},
+		{"The plan isn't done", false},
+		{"The plan isn't done. Nothing to do this round.", true},
+		{"Everything is already complete", true},
+	}
+
+	for _, tc := range testCases {
+		if got := m.Match(tc.output); got != tc.want {
+			t.Errorf("Match(%q) = %v, want %v", tc.output, got, tc.want)
+		}
+	}
+}
+
+// TestPhraseMatcher_ExtraPhrases tests user-supplied no-change phrases
+func TestPhraseMatcher_ExtraPhrases(t *testing.T) {
+	m := NewPhraseMatcher("LGTM", "plan is stable", "  ")
+
+	if !m.Match("lgtm, ship it") {
+		t.Error("Match() should detect extra phrase case-insensitively")
+	}
+	if !m.Match("The plan is   stable now") {
+		t.Error("Match() should allow flexible whitespace inside phrases")
+	}
+	if m.Match("LGTMX") {
+		t.Error("Match() should respect word boundaries for extra phrases")
+	}
+	// Defaults are kept
+	if !m.Match("Nothing to do") {
+		t.Error("Match() should still detect default phrases")
+	}
+}
+
+// TestPhraseMatcher_SpecialCharacters tests that phrases are matched literally
+func TestPhraseMatcher_SpecialCharacters(t *testing.T) {
+	m := NewPhraseMatcher("done (final)")
+
+	if !m.Match("status: done (final)") {
+		t.Error("Match() should match phrases containing regex metacharacters literally")
+	}
+	if m.Match("status: done final") {
+		t.Error("Match() should not treat phrase as a regex")
+	}
+}
+
+// TestIsConverged_WithMatcherRequiresSignal tests output-signal convergence
+func TestIsConverged_WithMatcherRequiresSignal(t *testing.T) {
+	detector := NewDetector()
+	detector.SetMatcher(NewPhraseMatcher("lgtm"))
+
+	result := orchestrator.RoundResult{
+		Round: 1,
+		AgentResults: []orchestrator.AgentResult{
+			{Agent: agent.Agent{Name: "claude"}, Response: session.Response{Output: "LGTM"}},
+			{Agent: agent.Agent{Name: "codex"}, Response: session.Response{Output: "Refined the API bead"}},
+		},
+	}
+
+	if detector.IsConverged(result) {
+		t.Error("IsConverged() = true, want false when an agent did not signal no changes")
+	}
+
+	result.AgentResults[1].Response.Output = "No changes needed"
+	if !detector.IsConverged(result) {
+		t.Error("IsConverged() = false, want true when all agents signal no changes")
+	}
+}
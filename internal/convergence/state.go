@@ -0,0 +1,70 @@
+package convergence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultStatePath is where convergence progress is recorded between runs.
+const DefaultStatePath = ".buckshot/convergence.json"
+
+// Snapshot is the convergence progress of an --until-converged run.
+type Snapshot struct {
+	Round                int    `json:"round"`                            // Last round checked
+	ConsecutiveNoChange  int    `json:"consecutive_no_change"`            // No-change rounds in a row
+	BeadsFingerprint     string `json:"beads_fingerprint,omitempty"`      // Fingerprint of the beads state after Round
+	ChangeSetFingerprint string `json:"change_set_fingerprint,omitempty"` // Fingerprint of Round's ChangeSet, under change-set convergence
+}
+
+// Fingerprint returns a short, stable digest of a beads state so a resumed run
+// can tell whether the beads changed while it wasn't running.
+func Fingerprint(beadsState string) string {
+	sum := sha256.Sum256([]byte(beadsState))
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadSnapshot reads the snapshot at path. ok is false if there is none.
+func LoadSnapshot(path string) (snapshot Snapshot, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("failed to read convergence state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, false, fmt.Errorf("failed to parse convergence state: %w", err)
+	}
+	return snapshot, true, nil
+}
+
+// SaveSnapshot writes snapshot to path, creating its directory if needed.
+func SaveSnapshot(path string, snapshot Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create convergence state dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode convergence state: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write convergence state: %w", err)
+	}
+	return nil
+}
+
+// ClearSnapshot removes the snapshot at path. A missing file is not an error.
+func ClearSnapshot(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove convergence state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package convergence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// TestSnapshot_RestoreIntoNewDetector tests that a restored detector keeps counting
+// toward the threshold instead of starting over
+func TestSnapshot_RestoreIntoNewDetector(t *testing.T) {
+	noChange := func(round int) orchestrator.RoundResult {
+		return orchestrator.RoundResult{
+			Round:        round,
+			AgentResults: []orchestrator.AgentResult{{Agent: agent.Agent{Name: "claude"}, BeadsChanged: []string{}}},
+		}
+	}
+
+	first := NewDetector()
+	first.SetThreshold(3)
+	first.CheckConvergence(noChange(1))
+	first.CheckConvergence(noChange(2))
+
+	snap := first.Snapshot()
+	if snap.Round != 2 || snap.ConsecutiveNoChange != 2 {
+		t.Fatalf("Snapshot() = %+v, want round 2 with 2 no-change rounds", snap)
+	}
+
+	second := NewDetector()
+	second.SetThreshold(3)
+	second.Restore(snap)
+	if second.ConsecutiveNoChangeRounds() != 2 {
+		t.Errorf("ConsecutiveNoChangeRounds() after Restore = %d, want 2", second.ConsecutiveNoChangeRounds())
+	}
+	if !second.CheckConvergence(noChange(3)) {
+		t.Error("CheckConvergence() round 3 = false, want true after restoring two no-change rounds")
+	}
+	if got := second.Snapshot().Round; got != 3 {
+		t.Errorf("Snapshot().Round = %d, want 3", got)
+	}
+}
+
+// TestSnapshot_SaveAndLoad tests round-tripping the convergence state file
+func TestSnapshot_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".buckshot", "convergence.json")
+	want := Snapshot{Round: 4, ConsecutiveNoChange: 2, BeadsFingerprint: Fingerprint("beads")}
+
+	if err := SaveSnapshot(path, want); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	got, ok, err := LoadSnapshot(path)
+	if err != nil || !ok {
+		t.Fatalf("LoadSnapshot() = %v, %v", ok, err)
+	}
+	if got != want {
+		t.Errorf("LoadSnapshot() = %+v, want %+v", got, want)
+	}
+
+	if err := ClearSnapshot(path); err != nil {
+		t.Fatalf("ClearSnapshot() error = %v", err)
+	}
+	if _, ok, err := LoadSnapshot(path); ok || err != nil {
+		t.Errorf("LoadSnapshot() after clear = %v, %v; want none", ok, err)
+	}
+	if err := ClearSnapshot(path); err != nil {
+		t.Errorf("ClearSnapshot() on a missing file should succeed, got %v", err)
+	}
+}
+
+// TestLoadSnapshot_Invalid tests that a corrupt state file is reported
+func TestLoadSnapshot_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "convergence.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadSnapshot(path); err == nil {
+		t.Error("LoadSnapshot() should fail on invalid JSON")
+	}
+}
+
+// TestFingerprint tests that fingerprints track the beads state
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("a") != Fingerprint("a") {
+		t.Error("Fingerprint() should be stable")
+	}
+	if Fingerprint("a") == Fingerprint("b") {
+		t.Error("Fingerprint() should differ for different beads states")
+	}
+}
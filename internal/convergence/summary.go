@@ -0,0 +1,164 @@
+package convergence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// maxActiveAgents is how many agents the summary lists as most active.
+const maxActiveAgents = 3
+
+// maxConsensusLines is how many agreed proposals the summary lists.
+const maxConsensusLines = 3
+
+// Summary describes a whole planning run.
+type Summary struct {
+	Rounds    int  // Rounds run
+	Converged bool // Whether the run stopped because it converged; set by the caller
+	Streak    int  // Consecutive no-change rounds at the end of the run
+
+	// Distinct beads per action across the run. A bead created in one round
+	// and updated in a later one counts toward both.
+	Created  int
+	Modified int
+	Closed   int
+
+	Active  []AgentActivity // Agents that changed beads, most changes first
+	Skipped []AgentSkip     // Agents skipped in any round, by agent then reason
+
+	Consensus ConsensusReport // Bead actions the agents agreed on
+}
+
+// AgentActivity counts the bead changes one agent reported.
+type AgentActivity struct {
+	Agent   string
+	Changes int
+}
+
+// AgentSkip counts the rounds one agent was skipped for one reason.
+type AgentSkip struct {
+	Agent  string
+	Reason string
+	Rounds int
+}
+
+// Summarize accumulates the results of every round of a run. The no-change
+// streak uses the same rule as IsConverged without a phrase matcher.
+func Summarize(results []orchestrator.RoundResult) Summary {
+	summary := Summary{Rounds: len(results), Consensus: Consensus(results)}
+
+	detector := &defaultDetector{}
+	byAction := map[orchestrator.BeadAction]map[string]bool{
+		orchestrator.BeadCreated: {},
+		orchestrator.BeadUpdated: {},
+		orchestrator.BeadClosed:  {},
+	}
+	changes := make(map[string]int)
+	skips := make(map[AgentSkip]int)
+
+	for _, result := range results {
+		if detector.IsConverged(result) {
+			summary.Streak++
+		} else {
+			summary.Streak = 0
+		}
+
+		for _, ar := range result.AgentResults {
+			for _, c := range ar.BeadChanges {
+				if ids, ok := byAction[c.Action]; ok {
+					ids[c.ID] = true
+				}
+			}
+			if n := len(ar.BeadsChanged); n > 0 {
+				changes[ar.Agent.Name] += n
+			}
+			if ar.Skipped {
+				skips[AgentSkip{Agent: ar.Agent.Name, Reason: ar.SkipReason}]++
+			}
+		}
+	}
+
+	summary.Created = len(byAction[orchestrator.BeadCreated])
+	summary.Modified = len(byAction[orchestrator.BeadUpdated])
+	summary.Closed = len(byAction[orchestrator.BeadClosed])
+
+	for name, n := range changes {
+		summary.Active = append(summary.Active, AgentActivity{Agent: name, Changes: n})
+	}
+	sort.Slice(summary.Active, func(i, j int) bool {
+		a, b := summary.Active[i], summary.Active[j]
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		return a.Agent < b.Agent
+	})
+
+	for skip, rounds := range skips {
+		skip.Rounds = rounds
+		summary.Skipped = append(summary.Skipped, skip)
+	}
+	sort.Slice(summary.Skipped, func(i, j int) bool {
+		a, b := summary.Skipped[i], summary.Skipped[j]
+		if a.Agent != b.Agent {
+			return a.Agent < b.Agent
+		}
+		return a.Reason < b.Reason
+	})
+
+	return summary
+}
+
+// Format renders the summary as the end-of-run report.
+func (s Summary) Format() string {
+	var sb strings.Builder
+
+	outcome := "round limit reached"
+	if s.Converged {
+		outcome = "converged"
+	}
+	sb.WriteString("Run summary:\n")
+	fmt.Fprintf(&sb, "  Rounds: %d (%s)\n", s.Rounds, outcome)
+	fmt.Fprintf(&sb, "  No-change streak: %d round(s)\n", s.Streak)
+	fmt.Fprintf(&sb, "  Beads: %d created, %d modified, %d closed\n", s.Created, s.Modified, s.Closed)
+
+	if len(s.Active) == 0 {
+		sb.WriteString("  Most active: none\n")
+	} else {
+		active := s.Active
+		if len(active) > maxActiveAgents {
+			active = active[:maxActiveAgents]
+		}
+		names := make([]string, len(active))
+		for i, a := range active {
+			names[i] = fmt.Sprintf("%s (%d)", a.Agent, a.Changes)
+		}
+		fmt.Fprintf(&sb, "  Most active: %s\n", strings.Join(names, ", "))
+	}
+
+	if agreed := s.Consensus.Agreed(); len(agreed) > 0 {
+		if len(agreed) > maxConsensusLines {
+			agreed = agreed[:maxConsensusLines]
+		}
+		sb.WriteString("  Consensus:\n")
+		for _, p := range agreed {
+			fmt.Fprintf(&sb, "    %s\n", s.Consensus.Describe(p))
+		}
+	}
+
+	if len(s.Skipped) > 0 {
+		skipped := make([]string, len(s.Skipped))
+		for i, sk := range s.Skipped {
+			reason := sk.Reason
+			if reason == "" {
+				reason = "no reason given"
+			}
+			skipped[i] = fmt.Sprintf("%s (%s, %d round(s))", sk.Agent, reason, sk.Rounds)
+		}
+		fmt.Fprintf(&sb, "  Skipped: %s\n", strings.Join(skipped, ", "))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+package convergence
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/orchestrator"
+)
+
+// changed builds an agent result reporting the given bead changes.
+func changed(name string, changes ...orchestrator.BeadChange) orchestrator.AgentResult {
+	ar := orchestrator.AgentResult{Agent: agent.Agent{Name: name}, BeadsChanged: []string{}, BeadChanges: changes}
+	for _, c := range changes {
+		ar.BeadsChanged = append(ar.BeadsChanged, c.ID)
+	}
+	return ar
+}
+
+// round builds a round result from agent results.
+func round(n int, results ...orchestrator.AgentResult) orchestrator.RoundResult {
+	r := orchestrator.RoundResult{Round: n, AgentResults: results}
+	for _, ar := range results {
+		r.TotalChanges += len(ar.BeadsChanged)
+	}
+	return r
+}
+
+// TestSummarize tests totals, the trailing streak, and agent activity over a run
+func TestSummarize(t *testing.T) {
+	created := func(id string) orchestrator.BeadChange {
+		return orchestrator.BeadChange{ID: id, Action: orchestrator.BeadCreated}
+	}
+	updated := func(id string) orchestrator.BeadChange {
+		return orchestrator.BeadChange{ID: id, Action: orchestrator.BeadUpdated}
+	}
+	closed := func(id string) orchestrator.BeadChange {
+		return orchestrator.BeadChange{ID: id, Action: orchestrator.BeadClosed}
+	}
+
+	results := []orchestrator.RoundResult{
+		round(1, changed("claude", created("b-1"), created("b-2")), changed("codex", updated("b-1"))),
+		round(2, changed("claude"), changed("codex", updated("b-1"), closed("b-3"))),
+		round(3, changed("claude"), changed("codex")),
+		round(4, changed("claude"), changed("codex"), changed("gemini")),
+	}
+
+	got := Summarize(results)
+	want := Summary{
+		Rounds:   4,
+		Streak:   2,
+		Created:  2,
+		Modified: 1,
+		Closed:   1,
+		Active: []AgentActivity{
+			{Agent: "codex", Changes: 3},
+			{Agent: "claude", Changes: 2},
+		},
+		Consensus: Consensus(results),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
+
+// TestSummarize_StreakResets tests that a change ends the no-change streak
+func TestSummarize_StreakResets(t *testing.T) {
+	change := orchestrator.BeadChange{ID: "b-1", Action: orchestrator.BeadUpdated}
+	results := []orchestrator.RoundResult{
+		round(1, changed("claude")),
+		round(2, changed("claude")),
+		round(3, changed("claude", change)),
+	}
+
+	if got := Summarize(results).Streak; got != 0 {
+		t.Errorf("Streak = %d, want 0 after a round with changes", got)
+	}
+	if got := Summarize(results[:2]).Streak; got != 2 {
+		t.Errorf("Streak = %d, want 2", got)
+	}
+}
+
+// TestSummarize_Empty tests a run with no rounds
+func TestSummarize_Empty(t *testing.T) {
+	got := Summarize(nil)
+	if got.Rounds != 0 || got.Streak != 0 || len(got.Active) != 0 {
+		t.Errorf("Summarize(nil) = %+v, want an empty summary", got)
+	}
+}
+
+// TestSummary_Format tests the report layout
+func TestSummary_Format(t *testing.T) {
+	s := Summary{
+		Rounds:    3,
+		Converged: true,
+		Streak:    1,
+		Created:   2,
+		Modified:  4,
+		Closed:    1,
+		Active: []AgentActivity{
+			{Agent: "codex", Changes: 4},
+			{Agent: "claude", Changes: 2},
+			{Agent: "gemini", Changes: 1},
+			{Agent: "amp", Changes: 1},
+		},
+	}
+
+	want := strings.Join([]string{
+		"Run summary:",
+		"  Rounds: 3 (converged)",
+		"  No-change streak: 1 round(s)",
+		"  Beads: 2 created, 4 modified, 1 closed",
+		"  Most active: codex (4), claude (2), gemini (1)",
+		"",
+	}, "\n")
+	if got := s.Format(); got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := (Summary{Rounds: 2}).Format(); !strings.Contains(got, "(round limit reached)") || !strings.Contains(got, "Most active: none") {
+		t.Errorf("Format() for a quiet run = \n%s", got)
+	}
+}
+
+// TestSummarize_Skipped tests that skipped agents are counted by reason and reported
+func TestSummarize_Skipped(t *testing.T) {
+	skipped := func(name, reason string) orchestrator.AgentResult {
+		return orchestrator.AgentResult{Agent: agent.Agent{Name: name}, Skipped: true, SkipReason: reason}
+	}
+	results := []orchestrator.RoundResult{
+		round(1, changed("claude"), skipped("codex", orchestrator.SkipNotAuthenticated)),
+		round(2, skipped("claude", "context 95% used"), skipped("codex", orchestrator.SkipNotAuthenticated)),
+	}
+
+	s := Summarize(results)
+	want := []AgentSkip{
+		{Agent: "claude", Reason: "context 95% used", Rounds: 1},
+		{Agent: "codex", Reason: "not authenticated", Rounds: 2},
+	}
+	if !reflect.DeepEqual(s.Skipped, want) {
+		t.Errorf("Skipped = %+v, want %+v", s.Skipped, want)
+	}
+
+	wantLine := "  Skipped: claude (context 95% used, 1 round(s)), codex (not authenticated, 2 round(s))\n"
+	if got := s.Format(); !strings.HasSuffix(got, wantLine) {
+		t.Errorf("Format() =\n%s\nwant it to end with:\n%s", got, wantLine)
+	}
+}
@@ -23,10 +23,32 @@ type Dispatcher interface {
 	// Results are returned in deterministic order (sorted by agent name).
 	// Respects context timeout/cancellation.
 	Dispatch(ctx context.Context, sessions []session.Session, prompt string) []Result
+
+	// DispatchStream is Dispatch, but also calls onResult with each result
+	// as it arrives, before DispatchStream returns. Calls are made one at a
+	// time from a single goroutine, so onResult needs no locking. A nil
+	// onResult makes it the same as Dispatch.
+	DispatchStream(ctx context.Context, sessions []session.Session, prompt string, onResult func(Result)) []Result
+
+	// SetRetryEmpty sets how many times to re-send the prompt when an agent
+	// returns empty output without an error. Default is 0 (no retries).
+	SetRetryEmpty(n int)
+
+	// SetMaxConcurrency bounds how many sends run at once.
+	// Default is 0 (unlimited).
+	SetMaxConcurrency(n int)
+
+	// SetRateLimiter makes every send wait for its agent's provider rate
+	// limit. Nil (the default) sends immediately.
+	SetRateLimiter(limiter *RateLimiter)
 }
 
 // dispatcher is the default implementation.
-type dispatcher struct{}
+type dispatcher struct {
+	retryEmpty     int
+	maxConcurrency int
+	limiter        *RateLimiter
+}
 
 // New creates a new Dispatcher.
 func New() Dispatcher {
@@ -36,6 +58,12 @@ func New() Dispatcher {
 // Dispatch sends a prompt to multiple agents concurrently.
 // Results are always returned sorted by agent name for deterministic output.
 func (d *dispatcher) Dispatch(ctx context.Context, sessions []session.Session, prompt string) []Result {
+	return d.DispatchStream(ctx, sessions, prompt, nil)
+}
+
+// DispatchStream sends a prompt to multiple agents concurrently, reporting
+// each result in arrival order as it is collected.
+func (d *dispatcher) DispatchStream(ctx context.Context, sessions []session.Session, prompt string, onResult func(Result)) []Result {
 	if len(sessions) == 0 {
 		return []Result{}
 	}
@@ -46,6 +74,12 @@ func (d *dispatcher) Dispatch(ctx context.Context, sessions []session.Session, p
 	// WaitGroup to track completion of all goroutines
 	var wg sync.WaitGroup
 
+	// Semaphore bounding in-flight sends (nil means unlimited)
+	var sem chan struct{}
+	if d.maxConcurrency > 0 {
+		sem = make(chan struct{}, d.maxConcurrency)
+	}
+
 	// Fan-out: spawn a goroutine for each session
 	for _, sess := range sessions {
 		wg.Add(1)
@@ -56,8 +90,25 @@ func (d *dispatcher) Dispatch(ctx context.Context, sessions []session.Session, p
 				Agent: s.Agent(),
 			}
 
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					result.Error = ctx.Err()
+					resultCh <- result
+					return
+				}
+			}
+
 			// Send prompt and capture response/error
-			resp, err := s.Send(ctx, prompt)
+			resp, err := d.send(ctx, s, prompt)
+
+			// Empty output without an error is usually a transient glitch
+			for attempt := 0; attempt < d.retryEmpty && err == nil && resp.Output == "" && ctx.Err() == nil; attempt++ {
+				resp, err = d.send(ctx, s, prompt)
+			}
+
 			result.Response = resp
 			result.Error = err
 
@@ -71,9 +122,12 @@ func (d *dispatcher) Dispatch(ctx context.Context, sessions []session.Session, p
 		close(resultCh)
 	}()
 
-	// Fan-in: collect all results
+	// Fan-in: collect all results, reporting each from this goroutine alone
 	results := make([]Result, 0, len(sessions))
 	for result := range resultCh {
+		if onResult != nil {
+			onResult(result)
+		}
 		results = append(results, result)
 	}
 
@@ -84,3 +138,32 @@ func (d *dispatcher) Dispatch(ctx context.Context, sessions []session.Session, p
 
 	return results
 }
+
+// send waits for the agent's rate limit, then sends the prompt.
+func (d *dispatcher) send(ctx context.Context, s session.Session, prompt string) (session.Response, error) {
+	if err := d.limiter.Wait(ctx, s.Agent().Name); err != nil {
+		return session.Response{Error: err}, err
+	}
+	return s.Send(ctx, prompt)
+}
+
+// SetRetryEmpty sets the number of retries for empty, error-free responses.
+func (d *dispatcher) SetRetryEmpty(n int) {
+	if n < 0 {
+		n = 0
+	}
+	d.retryEmpty = n
+}
+
+// SetMaxConcurrency sets the maximum number of concurrent sends.
+func (d *dispatcher) SetMaxConcurrency(n int) {
+	if n < 0 {
+		n = 0
+	}
+	d.maxConcurrency = n
+}
+
+// SetRateLimiter sets the limiter consulted before each send.
+func (d *dispatcher) SetRateLimiter(limiter *RateLimiter) {
+	d.limiter = limiter
+}
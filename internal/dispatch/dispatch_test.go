@@ -3,6 +3,7 @@ package dispatch
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -402,3 +403,237 @@ func TestDispatchSingleAgent(t *testing.T) {
 		t.Errorf("Expected no error, got %v", results[0].Error)
 	}
 }
+
+// TestDispatchRetriesEmptyOutput verifies empty, error-free responses are re-sent.
+func TestDispatchRetriesEmptyOutput(t *testing.T) {
+	var calls int32
+	flaky := newMockSession("flaky")
+	flaky.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return session.Response{Output: ""}, nil
+		}
+		return session.Response{Output: "recovered"}, nil
+	}
+
+	sessions := []session.Session{newMockSession("zebra"), flaky, newMockSession("alpha")}
+
+	d := New()
+	d.SetRetryEmpty(2)
+	results := d.Dispatch(context.Background(), sessions, "test")
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected flaky session to be sent 2 times, got %d", got)
+	}
+
+	expectedOrder := []string{"alpha", "flaky", "zebra"}
+	for i, r := range results {
+		if r.Agent.Name != expectedOrder[i] {
+			t.Errorf("Result %d: expected agent %s, got %s", i, expectedOrder[i], r.Agent.Name)
+		}
+	}
+
+	if results[1].Response.Output != "recovered" {
+		t.Errorf("Expected retried output 'recovered', got %q", results[1].Response.Output)
+	}
+}
+
+// TestDispatchRetryEmptyLimits verifies retries stop after N attempts and skip errors.
+func TestDispatchRetryEmptyLimits(t *testing.T) {
+	var emptyCalls, errCalls int32
+	empty := newMockSession("empty")
+	empty.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+		atomic.AddInt32(&emptyCalls, 1)
+		return session.Response{}, nil
+	}
+	failing := newMockSession("failing")
+	failing.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+		atomic.AddInt32(&errCalls, 1)
+		return session.Response{}, errors.New("boom")
+	}
+
+	d := New()
+	d.SetRetryEmpty(3)
+	results := d.Dispatch(context.Background(), []session.Session{empty, failing}, "test")
+
+	if got := atomic.LoadInt32(&emptyCalls); got != 4 {
+		t.Errorf("Expected 1 send + 3 retries for empty session, got %d", got)
+	}
+	if got := atomic.LoadInt32(&errCalls); got != 1 {
+		t.Errorf("Expected errored session not to be retried, got %d sends", got)
+	}
+	if results[0].Response.Output != "" || results[0].Error != nil {
+		t.Errorf("Expected empty result without error after retries, got %+v", results[0])
+	}
+}
+
+// TestDispatchNoRetryByDefault verifies empty output is not retried unless enabled.
+func TestDispatchNoRetryByDefault(t *testing.T) {
+	var calls int32
+	empty := newMockSession("empty")
+	empty.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return session.Response{}, nil
+	}
+
+	New().Dispatch(context.Background(), []session.Session{empty}, "test")
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected 1 send without retries, got %d", got)
+	}
+}
+
+// TestDispatchMaxConcurrency verifies no more than N sends run at once.
+func TestDispatchMaxConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	names := []string{"a", "b", "c", "d", "e", "f"}
+
+	sessions := make([]session.Session, len(names))
+	for i, name := range names {
+		mock := newMockSession(name)
+		mock.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				prev := atomic.LoadInt32(&maxInFlight)
+				if current <= prev || atomic.CompareAndSwapInt32(&maxInFlight, prev, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return session.Response{Output: "done"}, nil
+		}
+		sessions[i] = mock
+	}
+
+	d := New()
+	d.SetMaxConcurrency(2)
+	results := d.Dispatch(context.Background(), sessions, "test")
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Errorf("Max in-flight sends was %d, want at most 2", got)
+	}
+	if len(results) != len(names) {
+		t.Fatalf("Expected %d results, got %d", len(names), len(results))
+	}
+	for i, r := range results {
+		if r.Agent.Name != names[i] {
+			t.Errorf("Result %d: expected agent %s, got %s", i, names[i], r.Agent.Name)
+		}
+		if r.Error != nil {
+			t.Errorf("Result %d: unexpected error %v", i, r.Error)
+		}
+	}
+}
+
+// TestDispatchMaxConcurrencyCancelledWhileQueued verifies queued sends honor cancellation.
+func TestDispatchMaxConcurrencyCancelledWhileQueued(t *testing.T) {
+	var sends int32
+	blocking := func(ctx context.Context, prompt string) (session.Response, error) {
+		atomic.AddInt32(&sends, 1)
+		<-ctx.Done()
+		return session.Response{}, ctx.Err()
+	}
+	first := newMockSession("first")
+	first.sendFunc = blocking
+	second := newMockSession("second")
+	second.sendFunc = blocking
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	d := New()
+	d.SetMaxConcurrency(1)
+	results := d.Dispatch(ctx, []session.Session{first, second}, "test")
+
+	if got := atomic.LoadInt32(&sends); got != 1 {
+		t.Errorf("Expected only 1 send to start, got %d", got)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Error, context.DeadlineExceeded) {
+			t.Errorf("Agent %s: expected deadline exceeded, got %v", r.Agent.Name, r.Error)
+		}
+	}
+}
+
+// TestDispatchRateLimited verifies that sends to agents sharing a provider are
+// spaced by the provider's rate even though they are dispatched concurrently.
+func TestDispatchRateLimited(t *testing.T) {
+	interval := 40 * time.Millisecond
+	var mu sync.Mutex
+	var sentAt []time.Time
+
+	names := []string{"claude", "amp", "cursor"}
+	sessions := make([]session.Session, len(names))
+	for i, name := range names {
+		mock := newMockSession(name)
+		mock.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+			mu.Lock()
+			sentAt = append(sentAt, time.Now())
+			mu.Unlock()
+			return session.Response{Output: "ok"}, nil
+		}
+		sessions[i] = mock
+	}
+
+	d := New()
+	d.SetRateLimiter(NewRateLimiter(
+		map[string]Rate{"anthropic": {Count: 1, Per: interval}},
+		map[string]string{"claude": "anthropic", "amp": "anthropic", "cursor": "anthropic"},
+	))
+	results := d.Dispatch(context.Background(), sessions, "test")
+
+	for _, r := range results {
+		if r.Error != nil {
+			t.Errorf("Agent %s: unexpected error %v", r.Agent.Name, r.Error)
+		}
+	}
+	if len(sentAt) != len(names) {
+		t.Fatalf("got %d sends, want %d", len(sentAt), len(names))
+	}
+	// Allow a little slack for timer granularity
+	sort.Slice(sentAt, func(i, j int) bool { return sentAt[i].Before(sentAt[j]) })
+	for i := 1; i < len(sentAt); i++ {
+		if gap := sentAt[i].Sub(sentAt[i-1]); gap < interval-5*time.Millisecond {
+			t.Errorf("send %d came %v after the previous one, want about %v", i, gap, interval)
+		}
+	}
+}
+
+// TestDispatchStreamReportsResultsAsTheyArrive verifies that a fast agent's
+// result reaches the callback while a slow agent is still working
+func TestDispatchStreamReportsResultsAsTheyArrive(t *testing.T) {
+	fastReported := make(chan struct{})
+	slow := newMockSession("alpha")
+	slow.sendFunc = func(ctx context.Context, prompt string) (session.Response, error) {
+		select {
+		case <-fastReported:
+			return session.Response{Output: "slow answer"}, nil
+		case <-time.After(2 * time.Second):
+			return session.Response{}, errors.New("fast result was not reported before the slow agent finished")
+		}
+	}
+	fast := newMockSession("zebra")
+
+	var (
+		order   []string
+		running int32
+	)
+	d := New()
+	results := d.DispatchStream(context.Background(), []session.Session{slow, fast}, "test", func(r Result) {
+		if atomic.AddInt32(&running, 1) != 1 {
+			t.Error("onResult called concurrently")
+		}
+		defer atomic.AddInt32(&running, -1)
+		order = append(order, r.Agent.Name)
+		if r.Agent.Name == "zebra" {
+			close(fastReported)
+		}
+	})
+
+	if len(order) != 2 || order[0] != "zebra" || order[1] != "alpha" {
+		t.Errorf("onResult saw %v before returning, want [zebra alpha]", order)
+	}
+	if len(results) != 2 || results[0].Agent.Name != "alpha" || results[0].Error != nil {
+		t.Errorf("results = %+v, want both, sorted, without errors", results)
+	}
+}
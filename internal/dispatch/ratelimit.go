@@ -0,0 +1,109 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate is how many sends are allowed per interval.
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+// Interval is the spacing between sends at this rate.
+func (r Rate) Interval() time.Duration {
+	return r.Per / time.Duration(r.Count)
+}
+
+// rateUnits maps the unit after the slash in a rate to its duration.
+var rateUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hour": time.Hour,
+}
+
+// ParseRate parses a rate such as "3/min", "1/s", or "10/30s".
+func ParseRate(s string) (Rate, error) {
+	count, unit, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q (want N/unit, e.g. 3/min)", s)
+	}
+	per, ok := rateUnits[unit]
+	if !ok {
+		if per, err = time.ParseDuration(unit); err != nil || per <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate %q (want N/unit, e.g. 3/min)", s)
+		}
+	}
+	return Rate{Count: n, Per: per}, nil
+}
+
+// RateLimiter spaces sends to agents that share a provider, so a group of
+// agents stays under that provider's API limits. Each group is a token bucket
+// holding a single token refilled at the group's rate: 3/min allows one send
+// every 20s. An agent not mapped to a provider is its own group, and groups
+// without a rate are not limited.
+type RateLimiter struct {
+	rates     map[string]Rate
+	providers map[string]string
+
+	mu   sync.Mutex
+	next map[string]time.Time // When each group's next send may start
+}
+
+// NewRateLimiter creates a limiter applying rates, keyed by provider group,
+// where providers maps agent names to their group.
+func NewRateLimiter(rates map[string]Rate, providers map[string]string) *RateLimiter {
+	return &RateLimiter{
+		rates:     rates,
+		providers: providers,
+		next:      make(map[string]time.Time),
+	}
+}
+
+// Group returns the provider group whose limit applies to agentName.
+func (l *RateLimiter) Group(agentName string) string {
+	if group, ok := l.providers[agentName]; ok {
+		return group
+	}
+	return agentName
+}
+
+// Wait blocks until agentName may send or ctx is done. A nil limiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context, agentName string) error {
+	if l == nil {
+		return nil
+	}
+	group := l.Group(agentName)
+	rate, ok := l.rates[group]
+	if !ok {
+		return nil
+	}
+
+	// Reserve the group's next slot; later callers queue up behind it
+	l.mu.Lock()
+	start := l.next[group]
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	l.next[group] = start.Add(rate.Interval())
+	l.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,87 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestParseRate tests the accepted rate forms and rejected ones
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{"3/min", Rate{Count: 3, Per: time.Minute}, false},
+		{"1/s", Rate{Count: 1, Per: time.Second}, false},
+		{"100/hour", Rate{Count: 100, Per: time.Hour}, false},
+		{"10/30s", Rate{Count: 10, Per: 30 * time.Second}, false},
+		{"3", Rate{}, true},
+		{"0/min", Rate{}, true},
+		{"x/min", Rate{}, true},
+		{"3/fortnight", Rate{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRate(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	if got := (Rate{Count: 3, Per: time.Minute}).Interval(); got != 20*time.Second {
+		t.Errorf("Interval() = %v, want 20s", got)
+	}
+}
+
+// TestRateLimiter_SpacesSharedProvider tests that agents in one provider
+// group share its rate while other agents are not held back
+func TestRateLimiter_SpacesSharedProvider(t *testing.T) {
+	interval := 40 * time.Millisecond
+	l := NewRateLimiter(
+		map[string]Rate{"anthropic": {Count: 1, Per: interval}},
+		map[string]string{"claude": "anthropic", "amp": "anthropic"},
+	)
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, name := range []string{"claude", "amp", "claude"} {
+		if err := l.Wait(ctx, name); err != nil {
+			t.Fatalf("Wait(%s) error = %v", name, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("three sends to one provider took %v, want at least %v", elapsed, 2*interval)
+	}
+
+	start = time.Now()
+	if err := l.Wait(ctx, "codex"); err != nil {
+		t.Fatalf("Wait(codex) error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > interval/2 {
+		t.Errorf("an unlimited agent waited %v", elapsed)
+	}
+}
+
+// TestRateLimiter_Cancelled tests that a waiting send gives up with its context
+func TestRateLimiter_Cancelled(t *testing.T) {
+	l := NewRateLimiter(map[string]Rate{"claude": {Count: 1, Per: time.Hour}}, nil)
+	if err := l.Wait(context.Background(), "claude"); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "claude"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want deadline exceeded", err)
+	}
+
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Wait(context.Background(), "claude"); err != nil {
+		t.Errorf("nil limiter Wait() error = %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package fixture replays recorded agent output and beads state so buckshot
+// can run deterministically, with no agents or bd installed.
+//
+// A fixture directory is laid out as:
+//
+//	agents.json                agents to report as detected
+//	beads/list.txt             bd list output
+//	beads/list.json            bd list --json output (optional; orders bead details)
+//	beads/show/<id>.txt        bd show output for each bead
+//	responses/<agent>/<n>.txt  raw output for the agent's nth prompt, from 1
+//
+// An agent asked more often than it has responses repeats its last one.
+// Beads state is a snapshot: list filters are not applied and bd commands
+// that modify beads are rejected.
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// AgentsFile lists the agents a fixture reports as detected.
+const AgentsFile = "agents.json"
+
+// AgentSpec describes a recorded agent in agents.json.
+type AgentSpec struct {
+	Name          string `json:"name"`
+	Version       string `json:"version,omitempty"`
+	Authenticated bool   `json:"authenticated"`
+}
+
+// Fixture is a directory of recorded agent and beads output.
+type Fixture struct {
+	dir string
+}
+
+// Load opens the fixture at dir.
+func Load(dir string) (*Fixture, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fixture not found: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("fixture %s is not a directory", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, AgentsFile)); err != nil {
+		return nil, fmt.Errorf("fixture %s has no %s: %w", dir, AgentsFile, err)
+	}
+	return &Fixture{dir: dir}, nil
+}
+
+// Agents returns the recorded agents, set up as the detector would set up
+// agents of the same names.
+func (f *Fixture) Agents() ([]agent.Agent, error) {
+	data, err := os.ReadFile(filepath.Join(f.dir, AgentsFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture agents: %w", err)
+	}
+	var specs []AgentSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture agents: %w", err)
+	}
+
+	known := agent.KnownAgents()
+	agents := make([]agent.Agent, 0, len(specs))
+	for _, spec := range specs {
+		if spec.Name == "" {
+			return nil, errors.New("fixture agent is missing a name")
+		}
+		pattern, ok := known[spec.Name]
+		if !ok {
+			pattern = agent.CLIPattern{Binary: spec.Name}
+		}
+		agents = append(agents, agent.Agent{
+			Name:          spec.Name,
+			Version:       spec.Version,
+			Authenticated: spec.Authenticated,
+			Pattern:       pattern,
+			Parser:        agent.GetParserForAgent(spec.Name),
+		})
+	}
+	return agents, nil
+}
+
+// BeadsClient returns a beads client that reads the recorded bd output.
+func (f *Fixture) BeadsClient() beads.Client {
+	return beads.NewClient(beads.WithExecutor(&executor{dir: filepath.Join(f.dir, "beads")}))
+}
+
+// SessionManager returns a session manager whose sessions answer with the
+// recorded responses.
+func (f *Fixture) SessionManager() session.Manager {
+	return newManager(filepath.Join(f.dir, "responses"))
+}
+
+// executor answers bd list and bd show from recorded files.
+type executor struct {
+	dir string
+}
+
+// Execute returns the recorded output for a bd command.
+func (e *executor) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	if name != "bd" || len(args) == 0 {
+		return "", fmt.Errorf("fixture cannot run %s", name)
+	}
+
+	var path string
+	switch args[0] {
+	case "list":
+		path = filepath.Join(e.dir, "list.txt")
+		if args[len(args)-1] == "--json" {
+			path = filepath.Join(e.dir, "list.json")
+		}
+	case "show":
+		if len(args) < 2 {
+			return "", errors.New("fixture bd show needs a bead ID")
+		}
+		path = filepath.Join(e.dir, "show", args[1]+".txt")
+	default:
+		return "", fmt.Errorf("fixture does not record bd %s", args[0])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("bd %s not recorded: %w", args[0], err)
+	}
+	return string(data), nil
+}
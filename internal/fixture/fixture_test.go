@@ -0,0 +1,157 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// planFixture is the recorded run shared with the CLI golden test.
+var planFixture = filepath.Join("..", "..", "testdata", "fixtures", "plan")
+
+func loadPlanFixture(t *testing.T) *Fixture {
+	t.Helper()
+	fx, err := Load(planFixture)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return fx
+}
+
+func TestLoad_Missing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Load() of a missing directory should fail")
+	}
+	if _, err := Load(t.TempDir()); err == nil || !strings.Contains(err.Error(), AgentsFile) {
+		t.Errorf("Load() without %s error = %v", AgentsFile, err)
+	}
+}
+
+func TestFixture_Agents(t *testing.T) {
+	agents, err := loadPlanFixture(t).Agents()
+	if err != nil {
+		t.Fatalf("Agents() error = %v", err)
+	}
+	if len(agents) != 3 {
+		t.Fatalf("Agents() returned %d agents, want 3", len(agents))
+	}
+
+	claude := agents[0]
+	if claude.Name != "claude" || !claude.Authenticated || claude.Version != "2.0.0" {
+		t.Errorf("claude = %+v", claude)
+	}
+	if _, ok := claude.Parser.(*agent.ClaudeParser); !ok {
+		t.Errorf("claude parser = %T, want the live agent's parser", claude.Parser)
+	}
+	if claude.Pattern.Binary != agent.KnownAgents()["claude"].Binary {
+		t.Errorf("claude pattern = %+v, want the known claude pattern", claude.Pattern)
+	}
+	if agents[2].Authenticated {
+		t.Error("amp is recorded as unauthenticated")
+	}
+}
+
+func TestFixture_BeadsClient(t *testing.T) {
+	client := loadPlanFixture(t).BeadsClient()
+	ctx := context.Background()
+
+	list, err := client.List(ctx, beads.ListOpts{Status: "open"})
+	if err != nil || !strings.Contains(list, "buckshot-a1") {
+		t.Errorf("List() = %q, %v; want the recorded list", list, err)
+	}
+
+	issues, err := client.ListIssues(ctx, beads.ListOpts{})
+	if err != nil || len(issues) != 2 || issues[0].Priority != 1 {
+		t.Errorf("ListIssues() = %+v, %v; want the recorded JSON", issues, err)
+	}
+
+	show, err := client.Show(ctx, "buckshot-b2")
+	if err != nil || !strings.Contains(show, "Document cache invalidation rules") {
+		t.Errorf("Show() = %q, %v; want the recorded bead", show, err)
+	}
+	if _, err := client.Show(ctx, "buckshot-zz"); err == nil {
+		t.Error("Show() of an unrecorded bead should fail")
+	}
+
+	if _, err := client.Create(ctx, beads.CreateOpts{Title: "New"}); err == nil {
+		t.Error("Create() should be rejected by a fixture")
+	}
+}
+
+func TestFixture_SessionReplaysResponses(t *testing.T) {
+	agents, err := loadPlanFixture(t).Agents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := loadPlanFixture(t).SessionManager()
+	ctx := context.Background()
+
+	var outputs []string
+	for turn := 1; turn <= 3; turn++ {
+		sess, err := mgr.CreateSession(agents[0])
+		if err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		if err := sess.Start(ctx, ""); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		resp, err := sess.Send(ctx, "prompt")
+		if err != nil {
+			t.Fatalf("Send() turn %d error = %v", turn, err)
+		}
+		_ = sess.Close()
+		outputs = append(outputs, resp.Output)
+
+		if turn == 1 {
+			if resp.TokenUsage.InputTokens != 1200 || resp.SessionID != "claude-fixture-session" {
+				t.Errorf("turn 1 usage/session = %+v/%q, want values parsed from the recording", resp.TokenUsage, resp.SessionID)
+			}
+		}
+	}
+
+	if !strings.Contains(outputs[0], "eviction policy") || strings.Contains(outputs[0], `"type"`) {
+		t.Errorf("turn 1 output = %q, want the parsed first response", outputs[0])
+	}
+	if !strings.Contains(outputs[1], "No changes needed") {
+		t.Errorf("turn 2 output = %q, want the second response", outputs[1])
+	}
+	if outputs[2] != outputs[1] {
+		t.Errorf("turn 3 output = %q, want the last response repeated", outputs[2])
+	}
+}
+
+func TestFixture_SessionErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, AgentsFile), []byte(`[{"name":"quiet","authenticated":true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fx, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agents, err := fx.Agents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := fx.SessionManager()
+
+	sess, err := mgr.CreateSession(agents[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sess.Start(context.Background(), "")
+	if _, err := sess.Send(context.Background(), "prompt"); !errors.Is(err, session.ErrNoOutput) {
+		t.Errorf("Send() with no recordings error = %v, want ErrNoOutput", err)
+	}
+
+	if _, err := mgr.CreateSession(agent.Agent{Name: "quiet"}); !errors.Is(err, session.ErrNotAuthenticated) {
+		t.Errorf("CreateSession() for an unauthenticated agent error = %v, want ErrNotAuthenticated", err)
+	}
+}
@@ -0,0 +1,116 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// manager hands out sessions that replay recorded responses. Turns are
+// counted per agent across sessions, so each round gets the next response.
+type manager struct {
+	dir   string
+	mu    sync.Mutex
+	turns map[string]int
+}
+
+func newManager(dir string) *manager {
+	return &manager{dir: dir, turns: make(map[string]int)}
+}
+
+// CreateSession creates a replaying session for the agent.
+func (m *manager) CreateSession(ag agent.Agent) (session.Session, error) {
+	if !ag.Authenticated {
+		return nil, fmt.Errorf("%w: %s", session.ErrNotAuthenticated, ag.Name)
+	}
+	return &replaySession{agent: ag, mgr: m}, nil
+}
+
+// ShouldRespawn returns true if session context > threshold.
+func (m *manager) ShouldRespawn(s session.Session, threshold float64) bool {
+	return s.ContextUsage() > threshold
+}
+
+// next returns the recorded output for the agent's next turn.
+func (m *manager) next(name string) (string, error) {
+	m.mu.Lock()
+	m.turns[name]++
+	turn := m.turns[name]
+	m.mu.Unlock()
+
+	for n := turn; n >= 1; n-- {
+		data, err := os.ReadFile(filepath.Join(m.dir, name, strconv.Itoa(n)+".txt"))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to read fixture response: %w", err)
+		}
+	}
+	return "", fmt.Errorf("%w: no fixture responses recorded for %s", session.ErrNoOutput, name)
+}
+
+// replaySession answers each prompt with the agent's next recorded response.
+type replaySession struct {
+	agent   agent.Agent
+	mgr     *manager
+	started bool
+}
+
+// Start marks the session as running.
+func (s *replaySession) Start(ctx context.Context, agentsPath string) error {
+	s.started = true
+	return nil
+}
+
+// Send returns the next recorded response, parsed as the live agent's would be.
+func (s *replaySession) Send(ctx context.Context, prompt string) (session.Response, error) {
+	if !s.started {
+		return session.Response{}, errors.New("session not started")
+	}
+	if err := ctx.Err(); err != nil {
+		return session.Response{Error: err}, err
+	}
+
+	output, err := s.mgr.next(s.agent.Name)
+	if err != nil {
+		return session.Response{Error: err}, err
+	}
+
+	resp := session.Response{Output: output}
+	if p := s.agent.Parser; p != nil {
+		resp.TokenUsage = agent.ParseUsage(p, output)
+		resp.SessionID = agent.ParseSessionID(p, output)
+		resp.Error = agent.ParseError(p, output)
+		resp.Output = p.Parse(output)
+	}
+	return resp, resp.Error
+}
+
+// ContextUsage returns 0; recordings don't track context.
+func (s *replaySession) ContextUsage() float64 {
+	return 0
+}
+
+// IsAlive returns whether the session has been started and not closed.
+func (s *replaySession) IsAlive() bool {
+	return s.started
+}
+
+// Agent returns the recorded agent.
+func (s *replaySession) Agent() agent.Agent {
+	return s.agent
+}
+
+// Close ends the session.
+func (s *replaySession) Close() error {
+	s.started = false
+	return nil
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestLogger_FiltersByLevel tests that messages below the level are dropped
+func TestLogger_FiltersByLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, LevelWarn)
+
+	l.Debugf("debug %d", 1)
+	l.Infof("info %d", 2)
+	l.Warnf("warn %d", 3)
+	l.Errorf("error %d", 4)
+
+	out := buf.String()
+	if strings.Contains(out, "debug 1") || strings.Contains(out, "info 2") {
+		t.Errorf("Logger at warn level wrote lower-level messages: %q", out)
+	}
+	if !strings.Contains(out, "Warning: warn 3\n") {
+		t.Errorf("Logger should write prefixed warning, got: %q", out)
+	}
+	if !strings.Contains(out, "Error: error 4\n") {
+		t.Errorf("Logger should write prefixed error, got: %q", out)
+	}
+}
+
+// TestLogger_InfoHasNoPrefix tests info formatting and newline handling
+func TestLogger_InfoHasNoPrefix(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(buf, LevelDebug)
+
+	l.Infof("Planning: %s", "x")
+	l.Infof("already terminated\n")
+	l.Debugf("details")
+
+	want := "Planning: x\nalready terminated\ndebug: details\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestParseLevel tests parsing level names
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"", LevelInfo, false},
+		{"warning", LevelWarn, false},
+		{"error", LevelError, false},
+		{"loud", LevelInfo, true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseLevel(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestSetDefault tests replacing the process-wide logger
+func TestSetDefault(t *testing.T) {
+	orig := Default()
+	defer SetDefault(orig)
+
+	buf := new(bytes.Buffer)
+	SetDefault(New(buf, LevelInfo))
+	Default().Infof("hello")
+
+	if buf.String() != "hello\n" {
+		t.Errorf("Default() did not use replaced logger, got %q", buf.String())
+	}
+}
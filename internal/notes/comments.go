@@ -0,0 +1,140 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// DuplicateThreshold is the Similarity at or above which a comment counts as
+// a repeat of text already on the bead.
+const DuplicateThreshold = 0.7
+
+// Comment is a comment an agent asked to leave on a bead.
+type Comment struct {
+	BeadID string
+	Text   string
+}
+
+var commentStartRegex = regexp.MustCompile(`^COMMENT\s+(\S+)\s*$`)
+
+// ParseComments extracts the comments written in an agent's reply as
+//
+//	COMMENT <bead-id>
+//	<comment, one or more lines>
+//	END COMMENT
+//
+// A block left open at the end of the reply still counts. Empty comments are
+// dropped.
+func ParseComments(output string) []Comment {
+	var comments []Comment
+	var current *Comment
+	var text []string
+
+	finish := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(strings.Join(text, "\n"))
+			if current.Text != "" {
+				comments = append(comments, *current)
+			}
+		}
+		current, text = nil, nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := commentStartRegex.FindStringSubmatch(trimmed); m != nil {
+			finish()
+			current = &Comment{BeadID: m[1]}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if trimmed == "END COMMENT" {
+			finish()
+			continue
+		}
+		text = append(text, line)
+	}
+	finish()
+	return comments
+}
+
+var wordRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// words returns the set of lowercased words in s, ignoring punctuation.
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range wordRegex.FindAllString(strings.ToLower(s), -1) {
+		set[w] = true
+	}
+	return set
+}
+
+// Similarity returns the Jaccard similarity of the words of a and b: 1 when
+// they use the same words, whatever the case, punctuation or order, and 0
+// when they share none.
+func Similarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// IsDuplicate reports whether comment is substantially similar to text
+// already on a bead. shown is the bead's bd show output; each of its lines
+// and each blank-line-separated block is compared, so a comment matches one
+// already there however bd lays it out.
+func IsDuplicate(comment, shown string) bool {
+	for _, block := range strings.Split(shown, "\n\n") {
+		if Similarity(comment, block) >= DuplicateThreshold {
+			return true
+		}
+		for _, line := range strings.Split(block, "\n") {
+			if Similarity(comment, line) >= DuplicateThreshold {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PostComments posts comments attributed to author, skipping any that
+// repeat what is already on the bead. Each bead is read with bd show just
+// before posting, so comments posted earlier in the run count too. It
+// returns how many were posted and skipped.
+func PostComments(ctx context.Context, client beads.Client, author string, comments []Comment) (posted, skipped int, err error) {
+	var errs []error
+	for _, c := range comments {
+		shown, err := client.Show(ctx, c.BeadID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if IsDuplicate(c.Text, shown) {
+			skipped++
+			continue
+		}
+		if err := client.Comment(ctx, c.BeadID, author, c.Text); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		posted++
+	}
+	if err := errors.Join(errs...); err != nil {
+		return posted, skipped, fmt.Errorf("failed to post comments for %s: %w", author, err)
+	}
+	return posted, skipped, nil
+}
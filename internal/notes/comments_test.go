@@ -0,0 +1,126 @@
+package notes
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseComments(t *testing.T) {
+	output := `I read the beads and have two comments.
+
+COMMENT buckshot-a1
+Cache keys should include the AGENTS.md hash.
+END COMMENT
+
+COMMENT buckshot-b2
+Document the TTL.
+Mention it in the README too.
+END COMMENT
+
+COMMENT buckshot-c3
+END COMMENT
+
+COMMENT buckshot-d4
+Left open at the end`
+
+	want := []Comment{
+		{BeadID: "buckshot-a1", Text: "Cache keys should include the AGENTS.md hash."},
+		{BeadID: "buckshot-b2", Text: "Document the TTL.\nMention it in the README too."},
+		{BeadID: "buckshot-d4", Text: "Left open at the end"},
+	}
+	if got := ParseComments(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseComments() = %q, want %q", got, want)
+	}
+	if got := ParseComments("No comments this time."); got != nil {
+		t.Errorf("ParseComments() = %q, want none", got)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		duplicate bool
+	}{
+		{"identical", "Add retries to the bd client.", "Add retries to the bd client.", true},
+		{"case and punctuation", "Add retries to the bd client!", "add retries to the BD client", true},
+		{"reworded slightly", "We should add retries to the bd client", "Add retries to the bd client", true},
+		{"reordered", "the bd client needs retries", "retries the bd client needs", true},
+		{"different point", "Add retries to the bd client", "Split this bead into a parser and a writer", false},
+		{"same topic, new point", "Add retries to the bd client", "The bd client should time out after 30 seconds", false},
+		{"empty", "", "Add retries", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Similarity(tt.a, tt.b)
+			if (got >= DuplicateThreshold) != tt.duplicate {
+				t.Errorf("Similarity(%q, %q) = %.2f, want duplicate = %v", tt.a, tt.b, got, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	shown := `buckshot-a1: Add a response cache
+Status: open
+Description: Reuse answers to identical prompts.
+
+Comments (1):
+  [claude] 2026-01-02 10:00
+  Cache keys should include the AGENTS.md hash.`
+
+	if !IsDuplicate("cache keys should include the AGENTS.md hash", shown) {
+		t.Error("a comment already on the bead should be a duplicate")
+	}
+	if IsDuplicate("Evict entries when bd list changes.", shown) {
+		t.Error("a new point should not be a duplicate")
+	}
+}
+
+// showingBeads is a commentingBeads whose bd show reports the comments
+// posted so far.
+type showingBeads struct {
+	commentingBeads
+}
+
+func (s *showingBeads) Show(ctx context.Context, id string) (string, error) {
+	var b strings.Builder
+	b.WriteString(id + ": Add a response cache\n\nComments:\n")
+	for _, c := range s.comments {
+		if c[0] == id {
+			b.WriteString("  [" + c[1] + "]\n  " + c[2] + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// TestPostComments tests that comments repeating one already on the bead,
+// including one posted earlier in the same batch, are skipped
+func TestPostComments(t *testing.T) {
+	client := &showingBeads{}
+	client.comments = [][3]string{{"buckshot-a1", "codex", "Cache keys should include the AGENTS.md hash."}}
+
+	comments := []Comment{
+		{BeadID: "buckshot-a1", Text: "Cache keys should include the AGENTS.md hash!"},
+		{BeadID: "buckshot-a1", Text: "Evict entries when bd list changes."},
+		{BeadID: "buckshot-b2", Text: "Evict entries when bd list changes."},
+		{BeadID: "buckshot-b2", Text: "evict entries when the bd list changes"},
+	}
+	posted, skipped, err := PostComments(context.Background(), client, "claude", comments)
+	if err != nil {
+		t.Fatalf("PostComments() error = %v", err)
+	}
+	if posted != 2 || skipped != 2 {
+		t.Errorf("posted %d, skipped %d; want 2 and 2", posted, skipped)
+	}
+	want := [][3]string{
+		{"buckshot-a1", "codex", "Cache keys should include the AGENTS.md hash."},
+		{"buckshot-a1", "claude", "Evict entries when bd list changes."},
+		{"buckshot-b2", "claude", "Evict entries when bd list changes."},
+	}
+	if !reflect.DeepEqual(client.comments, want) {
+		t.Errorf("comments = %q, want %q", client.comments, want)
+	}
+}
@@ -3,11 +3,16 @@ package notes
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/michaellady/buckshot/internal/beads"
 	"github.com/michaellady/buckshot/internal/orchestrator"
 )
 
@@ -22,6 +27,25 @@ type Saver interface {
 	SaveRoundResults(ctx context.Context, beadID string, result orchestrator.RoundResult) error
 }
 
+// Mode decides what happens to notes already on the bead.
+type Mode string
+
+const (
+	// ModeAppend adds each round's notes after the bead's existing notes.
+	ModeAppend Mode = "append"
+	// ModeReplace overwrites the bead's notes with each round's notes.
+	ModeReplace Mode = "replace"
+)
+
+// ParseMode validates a --save-mode value.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModeAppend, ModeReplace:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid save mode %q (want append or replace)", s)
+}
+
 // Option configures a Saver.
 type Option func(*saver)
 
@@ -32,15 +56,42 @@ func WithExecutor(exec Executor) Option {
 	}
 }
 
+// WithMode sets whether rounds are appended to or replace existing notes.
+func WithMode(mode Mode) Option {
+	return func(s *saver) {
+		s.mode = mode
+	}
+}
+
+// WithMaxLength caps each agent's response in the notes at max characters,
+// marking where it was cut. Zero means no cap.
+func WithMaxLength(max int) Option {
+	return func(s *saver) {
+		s.maxLength = max
+	}
+}
+
+// WithSidecarDir writes the full text of each response cut by WithMaxLength
+// to a file in dir, referenced from the note.
+func WithSidecarDir(dir string) Option {
+	return func(s *saver) {
+		s.sidecarDir = dir
+	}
+}
+
 // saver is the default implementation.
 type saver struct {
-	executor Executor
+	executor   Executor
+	mode       Mode
+	maxLength  int
+	sidecarDir string
 }
 
-// NewSaver creates a new Saver.
+// NewSaver creates a new Saver. Notes are appended unless WithMode says otherwise.
 func NewSaver(opts ...Option) Saver {
 	s := &saver{
 		executor: &defaultExecutor{},
+		mode:     ModeAppend,
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -55,11 +106,27 @@ func (s *saver) SaveRoundResults(ctx context.Context, beadID string, result orch
 		return nil
 	}
 
+	result, err := s.capResponses(beadID, result)
+	if err != nil {
+		return err
+	}
+
 	// Format all results as notes
 	notes := FormatRoundNotes(result, time.Now())
 
+	// bd update --notes overwrites, so keep what the bead already has
+	if s.mode == ModeAppend {
+		existing, err := s.currentNotes(ctx, beadID)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(existing) != "" {
+			notes = strings.TrimRight(existing, "\n") + "\n\n" + notes
+		}
+	}
+
 	// Execute bd update --notes
-	_, err := s.executor.Execute(ctx, "bd", "update", beadID, "--notes", notes)
+	_, err = s.executor.Execute(ctx, "bd", "update", beadID, "--notes", notes)
 	if err != nil {
 		return fmt.Errorf("failed to save notes to bead %s: %w", beadID, err)
 	}
@@ -67,6 +134,118 @@ func (s *saver) SaveRoundResults(ctx context.Context, beadID string, result orch
 	return nil
 }
 
+// capResponses returns result with each response over the length cap cut
+// down, saving the full text to the sidecar directory if there is one.
+func (s *saver) capResponses(beadID string, result orchestrator.RoundResult) (orchestrator.RoundResult, error) {
+	if s.maxLength <= 0 {
+		return result, nil
+	}
+	capped := result
+	capped.AgentResults = make([]orchestrator.AgentResult, len(result.AgentResults))
+	for i, ar := range result.AgentResults {
+		capped.AgentResults[i] = ar
+		output := ar.Response.Output
+		if len([]rune(output)) <= s.maxLength {
+			continue
+		}
+		var sidecar string
+		if s.sidecarDir != "" {
+			sidecar = filepath.Join(s.sidecarDir, fmt.Sprintf("%s-round%d-%s.md", beadID, result.Round, ar.Agent.Name))
+			if err := writeSidecar(sidecar, output); err != nil {
+				return result, err
+			}
+		}
+		capped.AgentResults[i].Response.Output = TruncateNote(output, s.maxLength, sidecar)
+	}
+	return capped, nil
+}
+
+// writeSidecar saves a full response to path, creating its directory.
+func writeSidecar(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write full response: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return fmt.Errorf("failed to write full response: %w", err)
+	}
+	return nil
+}
+
+// TruncateNote cuts text to max characters and appends a marker saying how
+// much was kept and, if sidecar is set, where the full text is. Text that
+// already fits, or a zero max, is returned unchanged.
+func TruncateNote(text string, max int, sidecar string) string {
+	runes := []rune(text)
+	if max <= 0 || len(runes) <= max {
+		return text
+	}
+	marker := fmt.Sprintf("[truncated: %d of %d characters shown", max, len(runes))
+	if sidecar != "" {
+		marker += "; full response in " + sidecar
+	}
+	return strings.TrimRight(string(runes[:max]), " \n") + "\n\n" + marker + "]"
+}
+
+// commentSaver posts each agent's response as its own bead comment.
+type commentSaver struct {
+	client beads.Client
+}
+
+// NewCommentSaver creates a Saver that posts each agent's response as a
+// separate bd comment authored by that agent, instead of writing notes.
+func NewCommentSaver(client beads.Client) Saver {
+	return &commentSaver{client: client}
+}
+
+// SaveRoundResults comments once per agent that answered. Skipped and failed
+// agents have no perspective to attribute, so they are left out.
+func (s *commentSaver) SaveRoundResults(ctx context.Context, beadID string, result orchestrator.RoundResult) error {
+	var errs []error
+	for _, ar := range result.AgentResults {
+		if ar.Skipped || ar.Error != nil || strings.TrimSpace(ar.Response.Output) == "" {
+			continue
+		}
+		text := fmt.Sprintf("Round %d:\n\n%s", result.Round, strings.ToValidUTF8(ar.Response.Output, "\uFFFD"))
+		if err := s.client.Comment(ctx, beadID, ar.Agent.Name, text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to save comments to bead %s: %w", beadID, err)
+	}
+	return nil
+}
+
+// currentNotes reads a bead's notes with bd show --json.
+func (s *saver) currentNotes(ctx context.Context, beadID string) (string, error) {
+	output, err := s.executor.Execute(ctx, "bd", "show", beadID, "--json")
+	if err != nil {
+		return "", fmt.Errorf("failed to read notes from bead %s: %w", beadID, err)
+	}
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return "", nil
+	}
+
+	// bd reports a single bead as an object or a one-element array
+	type shown struct {
+		Notes string `json:"notes"`
+	}
+	var issue shown
+	if strings.HasPrefix(output, "[") {
+		var issues []shown
+		if err := json.Unmarshal([]byte(output), &issues); err != nil {
+			return "", fmt.Errorf("failed to parse bd show output for %s: %w", beadID, err)
+		}
+		if len(issues) > 0 {
+			issue = issues[0]
+		}
+	} else if err := json.Unmarshal([]byte(output), &issue); err != nil {
+		return "", fmt.Errorf("failed to parse bd show output for %s: %w", beadID, err)
+	}
+	return issue.Notes, nil
+}
+
 // FormatNote formats a single agent's response as a note entry.
 func FormatNote(agentName, response string, timestamp time.Time) string {
 	timeStr := timestamp.Format("2006-01-02 15:04:05")
@@ -76,7 +255,8 @@ func FormatNote(agentName, response string, timestamp time.Time) string {
 		return header + "\n(no response)"
 	}
 
-	return header + "\n" + response
+	// bd stores notes as text, so invalid bytes from the agent are replaced
+	return header + "\n" + strings.ToValidUTF8(response, "\uFFFD")
 }
 
 // FormatRoundNotes formats all agent results from a round as notes.
@@ -95,7 +275,12 @@ func FormatRoundNotes(result orchestrator.RoundResult, timestamp time.Time) stri
 			response = fmt.Sprintf("[ERROR: %s]", agentResult.Error.Error())
 		}
 
-		note := FormatNote(agentResult.Agent.Name, response, timestamp)
+		name := agentResult.Agent.Name
+		if agentResult.Duration > 0 {
+			name = fmt.Sprintf("%s (%.1fs)", name, agentResult.Duration.Seconds())
+		}
+
+		note := FormatNote(name, response, timestamp)
 		sb.WriteString(note)
 		sb.WriteString("\n")
 	}
@@ -3,12 +3,17 @@ package notes
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
 	"github.com/michaellady/buckshot/internal/orchestrator"
 	"github.com/michaellady/buckshot/internal/session"
 )
@@ -42,6 +47,15 @@ func TestFormatNote(t *testing.T) {
 			wantTime:   "2025-11-26 14:00:00",
 			wantOutput: "Line 1\nLine 2\nLine 3",
 		},
+		{
+			name:       "invalid UTF-8",
+			agentName:  "gemini",
+			response:   "Read \xff\xfe from the file",
+			timestamp:  time.Date(2025, 11, 26, 9, 0, 0, 0, time.UTC),
+			wantAgent:  "gemini",
+			wantTime:   "2025-11-26 09:00:00",
+			wantOutput: "Read \uFFFD from the file",
+		},
 		{
 			name:       "empty response",
 			agentName:  "cursor-agent",
@@ -227,6 +241,7 @@ func TestFormatRoundNotes(t *testing.T) {
 			{
 				Agent:    agent.Agent{Name: "claude"},
 				Response: session.Response{Output: "Claude's perspective"},
+				Duration: 12300 * time.Millisecond,
 			},
 			{
 				Agent:    agent.Agent{Name: "codex"},
@@ -237,6 +252,11 @@ func TestFormatRoundNotes(t *testing.T) {
 
 	notes := FormatRoundNotes(roundResult, time.Date(2025, 11, 26, 12, 0, 0, 0, time.UTC))
 
+	// Should include agent timing when known
+	if !strings.Contains(notes, "### claude (12.3s) @") {
+		t.Errorf("FormatRoundNotes() should include agent duration, got:\n%s", notes)
+	}
+
 	// Should contain round header
 	if !strings.Contains(notes, "Round 2") {
 		t.Errorf("FormatRoundNotes() should include round number, got:\n%s", notes)
@@ -277,3 +297,241 @@ func (m *mockExecutor) Execute(ctx context.Context, name string, args ...string)
 	}
 	return "", nil
 }
+
+// fakeBd keeps one bead's notes, answering bd show --json and bd update --notes.
+type fakeBd struct {
+	notes string
+	shows int
+}
+
+func (f *fakeBd) Execute(ctx context.Context, name string, args ...string) (string, error) {
+	switch {
+	case len(args) == 3 && args[0] == "show" && args[2] == "--json":
+		f.shows++
+		out, err := json.Marshal([]map[string]string{{"id": args[1], "notes": f.notes}})
+		return string(out), err
+	case len(args) == 4 && args[0] == "update" && args[2] == "--notes":
+		f.notes = args[3]
+		return "✓ Updated issue: " + args[1], nil
+	}
+	return "", errors.New("unexpected bd " + strings.Join(args, " "))
+}
+
+// TestSaver_SaveModes tests that append keeps earlier notes and replace
+// overwrites them
+func TestSaver_SaveModes(t *testing.T) {
+	round := func(n int, output string) orchestrator.RoundResult {
+		return orchestrator.RoundResult{
+			Round:        n,
+			AgentResults: []orchestrator.AgentResult{{Agent: agent.Agent{Name: "claude"}, Response: session.Response{Output: output}}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		want      []string
+		notWant   []string
+		wantShows int
+	}{
+		{"default appends", nil, []string{"Earlier notes", "## Round 1", "First take", "## Round 2", "Second take"}, nil, 2},
+		{"append", []Option{WithMode(ModeAppend)}, []string{"Earlier notes", "First take", "Second take"}, nil, 2},
+		{"replace", []Option{WithMode(ModeReplace)}, []string{"## Round 2", "Second take"}, []string{"Earlier notes", "First take"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bd := &fakeBd{notes: "Earlier notes"}
+			saver := NewSaver(append([]Option{WithExecutor(bd)}, tt.opts...)...)
+			for i, output := range []string{"First take", "Second take"} {
+				if err := saver.SaveRoundResults(context.Background(), "buckshot-1", round(i+1, output)); err != nil {
+					t.Fatalf("SaveRoundResults() error = %v", err)
+				}
+			}
+
+			last := -1
+			for _, s := range tt.want {
+				i := strings.Index(bd.notes, s)
+				if i <= last {
+					t.Errorf("notes missing %q in order:\n%s", s, bd.notes)
+				}
+				last = i
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(bd.notes, s) {
+					t.Errorf("notes should not keep %q:\n%s", s, bd.notes)
+				}
+			}
+			if bd.shows != tt.wantShows {
+				t.Errorf("bd show ran %d time(s), want %d", bd.shows, tt.wantShows)
+			}
+		})
+	}
+}
+
+func TestTruncateNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		max     int
+		sidecar string
+		want    string
+	}{
+		{"fits", "Short answer", 20, "", "Short answer"},
+		{"no cap", "Short answer", 0, "", "Short answer"},
+		{"cut", "Split the work in two", 9, "", "Split the\n\n[truncated: 9 of 21 characters shown]"},
+		{"cut at a space", "Split the work in two", 10, "", "Split the\n\n[truncated: 10 of 21 characters shown]"},
+		{"sidecar", "Split the work in two", 5, "notes/b-round1-claude.md", "Split\n\n[truncated: 5 of 21 characters shown; full response in notes/b-round1-claude.md]"},
+		{"multibyte", "ééééé", 2, "", "éé\n\n[truncated: 2 of 5 characters shown]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateNote(tt.text, tt.max, tt.sidecar); got != tt.want {
+				t.Errorf("TruncateNote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSaver_MaxLength tests that long responses are cut with a marker, and
+// written in full to the sidecar directory when one is set
+func TestSaver_MaxLength(t *testing.T) {
+	long := strings.Repeat("Split the work. ", 20)
+	result := orchestrator.RoundResult{
+		Round: 2,
+		AgentResults: []orchestrator.AgentResult{
+			{Agent: agent.Agent{Name: "claude"}, Response: session.Response{Output: long}},
+			{Agent: agent.Agent{Name: "codex"}, Response: session.Response{Output: "Agreed."}},
+		},
+	}
+
+	t.Run("marker", func(t *testing.T) {
+		bd := &fakeBd{}
+		saver := NewSaver(WithExecutor(bd), WithMaxLength(50))
+		if err := saver.SaveRoundResults(context.Background(), "buckshot-1", result); err != nil {
+			t.Fatalf("SaveRoundResults() error = %v", err)
+		}
+		if strings.Contains(bd.notes, long) {
+			t.Errorf("notes should not hold the whole response:\n%s", bd.notes)
+		}
+		if !strings.Contains(bd.notes, "[truncated: 50 of 320 characters shown]") || !strings.Contains(bd.notes, "Agreed.") {
+			t.Errorf("notes should mark the cut response and keep the short one:\n%s", bd.notes)
+		}
+		if result.AgentResults[0].Response.Output != long {
+			t.Error("the round result itself should not be modified")
+		}
+	})
+
+	t.Run("sidecar", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "notes")
+		bd := &fakeBd{}
+		saver := NewSaver(WithExecutor(bd), WithMaxLength(50), WithSidecarDir(dir))
+		if err := saver.SaveRoundResults(context.Background(), "buckshot-1", result); err != nil {
+			t.Fatalf("SaveRoundResults() error = %v", err)
+		}
+		path := filepath.Join(dir, "buckshot-1-round2-claude.md")
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != long {
+			t.Errorf("sidecar = %q (%v), want the full response", data, err)
+		}
+		if !strings.Contains(bd.notes, "full response in "+path) {
+			t.Errorf("notes should reference the sidecar:\n%s", bd.notes)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "buckshot-1-round2-codex.md")); !os.IsNotExist(err) {
+			t.Errorf("responses that fit should get no sidecar, stat err = %v", err)
+		}
+	})
+}
+
+// TestSaver_AppendShowFails tests that append never overwrites notes it
+// could not read
+func TestSaver_AppendShowFails(t *testing.T) {
+	mockExec := &mockExecutor{results: map[string]execResult{"bd show": {err: errors.New("bd: database locked")}}}
+	saver := NewSaver(WithExecutor(mockExec))
+
+	result := orchestrator.RoundResult{Round: 1, AgentResults: []orchestrator.AgentResult{{Agent: agent.Agent{Name: "claude"}}}}
+	if err := saver.SaveRoundResults(context.Background(), "buckshot-1", result); err == nil {
+		t.Error("SaveRoundResults() should fail when current notes can't be read")
+	}
+	for _, cmd := range mockExec.commands {
+		if strings.HasPrefix(cmd, "bd update") {
+			t.Errorf("bd update ran after bd show failed: %v", mockExec.commands)
+		}
+	}
+}
+
+// TestParseMode tests --save-mode validation
+func TestParseMode(t *testing.T) {
+	for _, s := range []string{"append", "replace"} {
+		if m, err := ParseMode(s); err != nil || string(m) != s {
+			t.Errorf("ParseMode(%q) = %q, %v", s, m, err)
+		}
+	}
+	if _, err := ParseMode("merge"); err == nil {
+		t.Error("ParseMode(merge) should fail")
+	}
+}
+
+// commentingBeads records bd comments, failing for one author.
+type commentingBeads struct {
+	beads.Client
+	comments [][3]string // bead ID, author, text
+	failFor  string
+}
+
+func (c *commentingBeads) Comment(ctx context.Context, id, author, text string) error {
+	if author == c.failFor {
+		return errors.New("bd comment failed")
+	}
+	c.comments = append(c.comments, [3]string{id, author, text})
+	return nil
+}
+
+// TestCommentSaver_OneCommentPerAgent tests that each agent that answered
+// gets its own comment under its own name
+func TestCommentSaver_OneCommentPerAgent(t *testing.T) {
+	client := &commentingBeads{}
+	saver := NewCommentSaver(client)
+
+	result := orchestrator.RoundResult{
+		Round: 2,
+		AgentResults: []orchestrator.AgentResult{
+			{Agent: agent.Agent{Name: "claude"}, Response: session.Response{Output: "Split the cache work"}},
+			{Agent: agent.Agent{Name: "codex"}, Response: session.Response{Output: "Agreed"}},
+			{Agent: agent.Agent{Name: "gemini"}, Error: errors.New("timed out")},
+			{Agent: agent.Agent{Name: "amp"}, Skipped: true, SkipReason: "not authenticated"},
+		},
+	}
+	if err := saver.SaveRoundResults(context.Background(), "buckshot-7", result); err != nil {
+		t.Fatalf("SaveRoundResults() error = %v", err)
+	}
+
+	want := [][3]string{
+		{"buckshot-7", "claude", "Round 2:\n\nSplit the cache work"},
+		{"buckshot-7", "codex", "Round 2:\n\nAgreed"},
+	}
+	if !reflect.DeepEqual(client.comments, want) {
+		t.Errorf("comments = %q, want %q", client.comments, want)
+	}
+}
+
+// TestCommentSaver_ReportsFailures tests that one failed comment doesn't stop
+// the others and is still reported
+func TestCommentSaver_ReportsFailures(t *testing.T) {
+	client := &commentingBeads{failFor: "claude"}
+	saver := NewCommentSaver(client)
+
+	result := orchestrator.RoundResult{
+		Round: 1,
+		AgentResults: []orchestrator.AgentResult{
+			{Agent: agent.Agent{Name: "claude"}, Response: session.Response{Output: "One"}},
+			{Agent: agent.Agent{Name: "codex"}, Response: session.Response{Output: "Two"}},
+		},
+	}
+	err := saver.SaveRoundResults(context.Background(), "buckshot-7", result)
+	if err == nil || !strings.Contains(err.Error(), "bd comment failed") {
+		t.Errorf("SaveRoundResults() error = %v, want the failed comment", err)
+	}
+	if len(client.comments) != 1 || client.comments[0][1] != "codex" {
+		t.Errorf("comments = %q, want codex's comment still posted", client.comments)
+	}
+}
@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// agentsReadPrompt asks a freshly started agent to prove it read AGENTS.md.
+const agentsReadPrompt = "Before we begin, reply with the first non-blank line of %s, quoted exactly."
+
+// agentsSentinel returns the first non-blank line of the instruction file,
+// which an agent that read it should be able to echo. Returns "" if the file
+// can't be read or has nothing to echo.
+func agentsSentinel(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := normalizeSentinel(scanner.Text()); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// normalizeSentinel lowercases s, drops markdown heading and list markers and
+// collapses whitespace, so an echo that lost its formatting still matches.
+func normalizeSentinel(s string) string {
+	s = strings.TrimLeft(strings.TrimSpace(s), "#>*- \t")
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// acknowledgedAgents reports whether output echoes the sentinel line.
+func acknowledgedAgents(output, sentinel string) bool {
+	return strings.Contains(normalizeSentinel(output), sentinel)
+}
+
+// checkAgentsRead asks a newly started session to echo AGENTS.md and reports
+// the agent to o.agentsReadWarn if its reply doesn't. Nothing is checked when
+// the check is off, there is no instruction file or the file has no text.
+func (o *defaultOrchestrator) checkAgentsRead(ctx context.Context, sess session.Session, agentsPath string) {
+	if o.agentsReadWarn == nil || agentsPath == "" {
+		return
+	}
+	sentinel := agentsSentinel(agentsPath)
+	if sentinel == "" {
+		return
+	}
+
+	resp, err := o.send(ctx, sess, fmt.Sprintf(agentsReadPrompt, agentsPath))
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil || !acknowledgedAgents(resp.Output, sentinel) {
+		o.agentsReadWarn(sess.Agent().Name, agentsPath)
+	}
+}
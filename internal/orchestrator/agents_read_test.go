@@ -0,0 +1,182 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	buckctx "github.com/michaellady/buckshot/internal/context"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// writeAgentsFile writes an AGENTS.md with the given content to a temp dir.
+func writeAgentsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "AGENTS.md")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestRunRound_AgentsReadCheck tests that a started agent is asked to echo
+// AGENTS.md and only one that doesn't is warned about
+func TestRunRound_AgentsReadCheck(t *testing.T) {
+	path := writeAgentsFile(t, "\n# Buckshot  Planning Rules\n\nKeep beads small.\n")
+
+	tests := []struct {
+		name   string
+		output string
+		warned []string
+	}{
+		{"acknowledges", "The first line is: \"buckshot planning rules\"", nil},
+		{"ignores", "Mock response", []string{"claude " + path}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &mockSessionManager{output: tt.output}
+			orch := NewRoundOrchestrator()
+			orch.SetSessionManager(mgr)
+			orch.SetContextBuilder(&mockContextBuilder{})
+			var warned []string
+			orch.SetAgentsReadCheck(func(name, agentsPath string) {
+				warned = append(warned, name+" "+agentsPath)
+			})
+
+			agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+			for round := 1; round <= 2; round++ {
+				planCtx := buckctx.PlanningContext{Prompt: "Plan", AgentsPath: path, Round: round}
+				if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+					t.Fatalf("RunRound() error = %v", err)
+				}
+			}
+
+			if !reflect.DeepEqual(warned, tt.warned) {
+				t.Errorf("warned = %v, want %v", warned, tt.warned)
+			}
+			// One check when the session starts, then one turn per round
+			if len(mgr.prompts) != 3 || !strings.Contains(mgr.prompts[0], path) {
+				t.Errorf("prompts = %q, want the check then two turns", mgr.prompts)
+			}
+		})
+	}
+}
+
+// TestRunRound_AgentsReadCheckOff tests that no check is sent unless enabled
+// or without an instruction file
+func TestRunRound_AgentsReadCheckOff(t *testing.T) {
+	path := writeAgentsFile(t, "Rules\n")
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+
+	tests := []struct {
+		name       string
+		check      bool
+		agentsPath string
+	}{
+		{"disabled", false, path},
+		{"default instructions", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &mockSessionManager{}
+			orch := NewRoundOrchestrator()
+			orch.SetSessionManager(mgr)
+			orch.SetContextBuilder(&mockContextBuilder{})
+			if tt.check {
+				orch.SetAgentsReadCheck(func(name, agentsPath string) {
+					t.Errorf("warned about %s", name)
+				})
+			}
+
+			planCtx := buckctx.PlanningContext{Prompt: "Plan", AgentsPath: tt.agentsPath, Round: 1}
+			if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+				t.Fatalf("RunRound() error = %v", err)
+			}
+			if len(mgr.prompts) != 1 {
+				t.Errorf("sent %d prompts, want only the turn", len(mgr.prompts))
+			}
+		})
+	}
+}
+
+// TestWarmUp_AgentsReadCheck tests that warmed-up sessions are checked too
+func TestWarmUp_AgentsReadCheck(t *testing.T) {
+	path := writeAgentsFile(t, "Rules\n")
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	var warned []string
+	orch.SetAgentsReadCheck(func(name, agentsPath string) {
+		warned = append(warned, name)
+	})
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+	if err := orch.WarmUp(context.Background(), agents, path); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if !reflect.DeepEqual(warned, []string{"claude"}) {
+		t.Errorf("warned = %v, want [claude]", warned)
+	}
+}
+
+// TestAgentsSentinel tests the line agents are asked to echo
+func TestAgentsSentinel(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"# Rules\nmore\n", "rules"},
+		{"\n\n  - Keep   beads SMALL\n", "keep beads small"},
+		{"\n#\n", ""},
+	}
+	for _, tt := range tests {
+		if got := agentsSentinel(writeAgentsFile(t, tt.content)); got != tt.want {
+			t.Errorf("agentsSentinel(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+	if got := agentsSentinel(filepath.Join(t.TempDir(), "missing.md")); got != "" {
+		t.Errorf("agentsSentinel(missing) = %q, want empty", got)
+	}
+}
+
+// TestRunRound_AgentsReadCheckSlowStartup tests that the check is answered by
+// the agent's reply to it, not by a late reply to the startup instruction
+func TestRunRound_AgentsReadCheckSlowStartup(t *testing.T) {
+	path := writeAgentsFile(t, "# Buckshot Planning Rules\n")
+	script := filepath.Join(t.TempDir(), "agent")
+	body := `#!/bin/sh
+sleep 0.3
+echo 'Read the instructions.'
+echo '10% used'
+while read -r line; do
+  case "$line" in
+    *"first non-blank line"*) echo 'Buckshot Planning Rules' ;;
+    *) echo 'Plan output' ;;
+  esac
+  echo '10% used'
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(session.NewManager())
+	orch.SetContextBuilder(&mockContextBuilder{})
+	orch.SetAgentsReadCheck(func(name, agentsPath string) {
+		t.Errorf("warned about %s, but it echoed %s", name, agentsPath)
+	})
+
+	ag := agent.Agent{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}
+	planCtx := buckctx.PlanningContext{Prompt: "Plan", AgentsPath: path, Round: 1}
+	result, err := orch.RunRound(context.Background(), []agent.Agent{ag}, planCtx)
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	defer orch.Close()
+	if out := result.AgentResults[0].Response.Output; !strings.Contains(out, "Plan output") || strings.Contains(out, "Planning Rules") {
+		t.Errorf("turn output = %q, want only the reply to the plan prompt", out)
+	}
+}
@@ -0,0 +1,190 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// BeadsDiffer describes how the beads changed between the snapshots taken
+// before and after an agent's turn. A nil snapshot means bd could not be
+// read at that point.
+type BeadsDiffer interface {
+	Diff(before, after []beads.Issue) string
+}
+
+// Built-in differs, selectable by name with ParseBeadsDiffer.
+var (
+	// FieldDiffer reports each bead created, closed or modified, with the
+	// fields that changed. It is the default.
+	FieldDiffer BeadsDiffer = snapshotDiffer(diffIssues)
+	// LineDiffer compares the snapshots as bd list lines, reporting each
+	// line found in only one of them.
+	LineDiffer BeadsDiffer = snapshotDiffer(diffLines)
+	// StatusDiffer reports only beads created, dropped, or moved to another
+	// status.
+	StatusDiffer BeadsDiffer = snapshotDiffer(diffStatuses)
+)
+
+// ParseBeadsDiffer returns the differ named by --beads-diff: field, line or
+// status.
+func ParseBeadsDiffer(name string) (BeadsDiffer, error) {
+	switch name {
+	case "field":
+		return FieldDiffer, nil
+	case "line":
+		return LineDiffer, nil
+	case "status":
+		return StatusDiffer, nil
+	}
+	return nil, fmt.Errorf("invalid beads diff %q (want field, line or status)", name)
+}
+
+// snapshotDiffer is a BeadsDiffer built from a diff of two readable
+// snapshots. It handles the unreadable ones and reports an empty diff as
+// "(no changes)".
+type snapshotDiffer func(before, after []beads.Issue) string
+
+func (d snapshotDiffer) Diff(before, after []beads.Issue) string {
+	switch {
+	case before == nil && after == nil:
+		return "(no changes)"
+	case before == nil:
+		return "(beads initialized)\n" + d([]beads.Issue{}, after)
+	case after == nil:
+		return "(beads cleared)"
+	}
+
+	diff := d(before, after)
+	if diff == "" {
+		return "(no changes)"
+	}
+	return diff
+}
+
+// byID indexes issues by ID and returns every ID in either snapshot, sorted.
+func byID(before, after []beads.Issue) (old, current map[string]beads.Issue, ids []string) {
+	old = make(map[string]beads.Issue, len(before))
+	for _, issue := range before {
+		old[issue.ID] = issue
+	}
+	current = make(map[string]beads.Issue, len(after))
+	for _, issue := range after {
+		current[issue.ID] = issue
+	}
+
+	ids = make([]string, 0, len(old)+len(current))
+	for id := range old {
+		ids = append(ids, id)
+	}
+	for id := range current {
+		if _, ok := old[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return old, current, ids
+}
+
+// diffIssues classifies every bead that differs between before and after,
+// one line per bead in ID order: "+ id: title" if it was created, "- id: title"
+// if it was closed (or dropped out of bd list), and "~ id: priority P2→P1" with
+// each changed field if it was modified.
+func diffIssues(before, after []beads.Issue) string {
+	old, current, ids := byID(before, after)
+
+	var b strings.Builder
+	for _, id := range ids {
+		was, existed := old[id]
+		now, exists := current[id]
+		switch {
+		case !existed:
+			fmt.Fprintf(&b, "+ %s: %s\n", id, now.Title)
+		case !exists || (now.Status == "closed" && was.Status != "closed"):
+			fmt.Fprintf(&b, "- %s: %s\n", id, was.Title)
+		default:
+			if fields := changedFields(was, now); len(fields) > 0 {
+				fmt.Fprintf(&b, "~ %s: %s\n", id, strings.Join(fields, ", "))
+			}
+		}
+	}
+	return b.String()
+}
+
+// changedFields renders each field that differs between two versions of a
+// bead as "field old→new".
+func changedFields(was, now beads.Issue) []string {
+	var fields []string
+	if was.Title != now.Title {
+		fields = append(fields, fmt.Sprintf("title %q→%q", was.Title, now.Title))
+	}
+	if was.Status != now.Status {
+		fields = append(fields, fmt.Sprintf("status %s→%s", was.Status, now.Status))
+	}
+	if was.Priority != now.Priority {
+		fields = append(fields, fmt.Sprintf("priority P%d→P%d", was.Priority, now.Priority))
+	}
+	if was.IssueType != now.IssueType {
+		fields = append(fields, fmt.Sprintf("type %s→%s", was.IssueType, now.IssueType))
+	}
+	return fields
+}
+
+// diffLines renders both snapshots as bd list lines and reports each line
+// found in only one, in ID order: "- line" for the old version of a bead and
+// "+ line" for the new one.
+func diffLines(before, after []beads.Issue) string {
+	old, current, ids := byID(before, after)
+
+	var b strings.Builder
+	for _, id := range ids {
+		was, existed := old[id]
+		now, exists := current[id]
+		oldLine, newLine := "", ""
+		if existed {
+			oldLine = listLine(was)
+		}
+		if exists {
+			newLine = listLine(now)
+		}
+		if oldLine == newLine {
+			continue
+		}
+		if existed {
+			fmt.Fprintf(&b, "- %s\n", oldLine)
+		}
+		if exists {
+			fmt.Fprintf(&b, "+ %s\n", newLine)
+		}
+	}
+	return b.String()
+}
+
+// listLine renders a bead the way bd list shows it.
+func listLine(issue beads.Issue) string {
+	return fmt.Sprintf("%s [P%d] [%s] %s - %s", issue.ID, issue.Priority, issue.IssueType, issue.Status, issue.Title)
+}
+
+// diffStatuses reports, in ID order, "+ id: title" for a bead created,
+// "- id: title" for one that dropped out of bd list, and "~ id: status
+// old→new" for one whose status changed. Other edits are left out.
+func diffStatuses(before, after []beads.Issue) string {
+	old, current, ids := byID(before, after)
+
+	var b strings.Builder
+	for _, id := range ids {
+		was, existed := old[id]
+		now, exists := current[id]
+		switch {
+		case !existed:
+			fmt.Fprintf(&b, "+ %s: %s\n", id, now.Title)
+		case !exists:
+			fmt.Fprintf(&b, "- %s: %s\n", id, was.Title)
+		case was.Status != now.Status:
+			fmt.Fprintf(&b, "~ %s: status %s→%s\n", id, was.Status, now.Status)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/beads"
+)
+
+// loadIssues reads a recorded bd list --json snapshot from testdata.
+func loadIssues(t *testing.T, name string) []beads.Issue {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issues []beads.Issue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatal(err)
+	}
+	return issues
+}
+
+// TestFieldDiffer_Classifies tests that each changed bead is reported once
+// as created, closed or modified, with modified fields spelled out
+func TestFieldDiffer_Classifies(t *testing.T) {
+	before := loadIssues(t, "beads_before.json")
+	after := loadIssues(t, "beads_after.json")
+
+	want := "- buckshot-10: Write migration guide\n" +
+		"~ buckshot-12: title \"Add response cache\"→\"Add LRU response cache\", status open→in_progress, priority P2→P1\n" +
+		"+ buckshot-13: Expose cache metrics\n" +
+		"~ buckshot-14: type task→bug\n" +
+		"- buckshot-9: Drop legacy client\n"
+	if got := FieldDiffer.Diff(before, after); got != want {
+		t.Errorf("FieldDiffer.Diff() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFieldDiffer_Reopened tests that reopening a closed bead is a
+// modification, not a creation
+func TestFieldDiffer_Reopened(t *testing.T) {
+	before := []beads.Issue{{ID: "buckshot-1", Title: "Cache", Status: "closed", Priority: 2}}
+	after := []beads.Issue{{ID: "buckshot-1", Title: "Cache", Status: "open", Priority: 2}}
+
+	if got, want := FieldDiffer.Diff(before, after), "~ buckshot-1: status closed→open\n"; got != want {
+		t.Errorf("FieldDiffer.Diff() = %q, want %q", got, want)
+	}
+}
+
+// TestFieldDiffer_Unchanged tests the placeholders for identical and
+// unreadable snapshots
+func TestFieldDiffer_Unchanged(t *testing.T) {
+	issues := loadIssues(t, "beads_before.json")
+	one := []beads.Issue{{ID: "buckshot-1", Title: "Cache", Status: "open"}}
+
+	tests := []struct {
+		name          string
+		before, after []beads.Issue
+		want          string
+	}{
+		{"same beads", issues, loadIssues(t, "beads_before.json"), "(no changes)"},
+		{"bd unreadable", nil, nil, "(no changes)"},
+		{"empty to empty", []beads.Issue{}, []beads.Issue{}, "(no changes)"},
+		{"initialized", nil, one, "(beads initialized)\n+ buckshot-1: Cache\n"},
+		{"cleared", one, nil, "(beads cleared)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FieldDiffer.Diff(tt.before, tt.after); got != tt.want {
+				t.Errorf("FieldDiffer.Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLineDiffer tests that each bead whose bd list line changed is shown
+// as its old and new lines
+func TestLineDiffer(t *testing.T) {
+	before := loadIssues(t, "beads_before.json")
+	after := loadIssues(t, "beads_after.json")
+
+	want := "- buckshot-10 [P2] [task] in_progress - Write migration guide\n" +
+		"+ buckshot-10 [P2] [task] closed - Write migration guide\n" +
+		"- buckshot-12 [P2] [task] open - Add response cache\n" +
+		"+ buckshot-12 [P1] [task] in_progress - Add LRU response cache\n" +
+		"+ buckshot-13 [P2] [task] open - Expose cache metrics\n" +
+		"- buckshot-14 [P2] [task] open - Rate limit sends\n" +
+		"+ buckshot-14 [P2] [bug] open - Rate limit sends\n" +
+		"- buckshot-9 [P3] [chore] open - Drop legacy client\n"
+	if got := LineDiffer.Diff(before, after); got != want {
+		t.Errorf("LineDiffer.Diff() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestStatusDiffer tests that only creations, removals and status changes
+// are reported
+func TestStatusDiffer(t *testing.T) {
+	before := loadIssues(t, "beads_before.json")
+	after := loadIssues(t, "beads_after.json")
+
+	want := "~ buckshot-10: status in_progress→closed\n" +
+		"~ buckshot-12: status open→in_progress\n" +
+		"+ buckshot-13: Expose cache metrics\n" +
+		"- buckshot-9: Drop legacy client\n"
+	if got := StatusDiffer.Diff(before, after); got != want {
+		t.Errorf("StatusDiffer.Diff() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// A retitled bead with the same status is not a change
+	retitled := []beads.Issue{{ID: "buckshot-1", Title: "Cache v2", Status: "open"}}
+	if got := StatusDiffer.Diff([]beads.Issue{{ID: "buckshot-1", Title: "Cache", Status: "open"}}, retitled); got != "(no changes)" {
+		t.Errorf("StatusDiffer.Diff() = %q, want (no changes)", got)
+	}
+}
+
+// TestBeadsDiffers_Placeholders tests that every differ reports identical
+// and unreadable snapshots the same way
+func TestBeadsDiffers_Placeholders(t *testing.T) {
+	issues := loadIssues(t, "beads_before.json")
+	for name, differ := range map[string]BeadsDiffer{"field": FieldDiffer, "line": LineDiffer, "status": StatusDiffer} {
+		if got := differ.Diff(issues, loadIssues(t, "beads_before.json")); got != "(no changes)" {
+			t.Errorf("%s: same beads = %q, want (no changes)", name, got)
+		}
+		if got := differ.Diff(nil, nil); got != "(no changes)" {
+			t.Errorf("%s: bd unreadable = %q, want (no changes)", name, got)
+		}
+		if got := differ.Diff(issues, nil); got != "(beads cleared)" {
+			t.Errorf("%s: cleared = %q, want (beads cleared)", name, got)
+		}
+		if got := differ.Diff(nil, issues); !strings.HasPrefix(got, "(beads initialized)\n+ buckshot-10") {
+			t.Errorf("%s: initialized = %q, want every bead added", name, got)
+		}
+	}
+}
+
+func TestParseBeadsDiffer(t *testing.T) {
+	for name, want := range map[string]BeadsDiffer{"field": FieldDiffer, "line": LineDiffer, "status": StatusDiffer} {
+		got, err := ParseBeadsDiffer(name)
+		if err != nil {
+			t.Fatalf("ParseBeadsDiffer(%q) error = %v", name, err)
+		}
+		// Compare by behavior; funcs can't be compared directly
+		before, after := loadIssues(t, "beads_before.json"), loadIssues(t, "beads_after.json")
+		if got.Diff(before, after) != want.Diff(before, after) {
+			t.Errorf("ParseBeadsDiffer(%q) returned the wrong differ", name)
+		}
+	}
+	if _, err := ParseBeadsDiffer("json"); err == nil || !strings.Contains(err.Error(), "invalid beads diff") {
+		t.Errorf("ParseBeadsDiffer(json) error = %v, want invalid beads diff", err)
+	}
+}
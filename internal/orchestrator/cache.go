@@ -0,0 +1,63 @@
+package orchestrator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// ResponseCache remembers agent responses within a single run so an agent
+// isn't re-invoked with a prompt it has already answered. Entries are keyed
+// on the agent name and a SHA-256 of the prompt, and are dropped whenever the
+// beads state changes.
+type ResponseCache struct {
+	mu         sync.Mutex
+	entries    map[string]session.Response
+	beadsState string
+}
+
+// NewResponseCache creates an empty cache.
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{entries: make(map[string]session.Response)}
+}
+
+// ObserveBeadsState clears the cache if beadsState differs from the state
+// seen last, so responses given against stale beads are never reused.
+func (c *ResponseCache) ObserveBeadsState(beadsState string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if beadsState != c.beadsState {
+		c.entries = make(map[string]session.Response)
+		c.beadsState = beadsState
+	}
+}
+
+// Get returns the cached response for agentName and prompt, if any.
+func (c *ResponseCache) Get(agentName, prompt string) (session.Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.entries[cacheKey(agentName, prompt)]
+	return resp, ok
+}
+
+// Put stores a response for agentName and prompt.
+func (c *ResponseCache) Put(agentName, prompt string, resp session.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[cacheKey(agentName, prompt)] = resp
+}
+
+// Len returns the number of cached responses.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// cacheKey combines the agent name with the prompt's digest.
+func cacheKey(agentName, prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return agentName + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,40 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+// TestResponseCache_KeysOnAgentAndPrompt tests cache lookups
+func TestResponseCache_KeysOnAgentAndPrompt(t *testing.T) {
+	c := NewResponseCache()
+	c.Put("claude", "prompt", session.Response{Output: "answer"})
+
+	if resp, ok := c.Get("claude", "prompt"); !ok || resp.Output != "answer" {
+		t.Errorf("Get(claude, prompt) = %+v, %v; want the stored answer", resp, ok)
+	}
+	if _, ok := c.Get("codex", "prompt"); ok {
+		t.Error("another agent should not see claude's answer")
+	}
+	if _, ok := c.Get("claude", "prompt!"); ok {
+		t.Error("a different prompt should miss")
+	}
+}
+
+// TestResponseCache_ObserveBeadsState tests invalidation on beads changes
+func TestResponseCache_ObserveBeadsState(t *testing.T) {
+	c := NewResponseCache()
+	c.ObserveBeadsState("A")
+	c.Put("claude", "prompt", session.Response{Output: "answer"})
+
+	c.ObserveBeadsState("A")
+	if c.Len() != 1 {
+		t.Errorf("unchanged beads should keep entries, have %d", c.Len())
+	}
+
+	c.ObserveBeadsState("B")
+	if c.Len() != 0 {
+		t.Errorf("changed beads should clear entries, have %d", c.Len())
+	}
+}
@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultBeadPrefix is the bead ID prefix assumed when none is configured.
+const DefaultBeadPrefix = "buckshot"
+
+// BeadAction is what an agent did to a bead.
+type BeadAction string
+
+// Bead actions recognized in agent output.
+const (
+	BeadCreated BeadAction = "created"
+	BeadUpdated BeadAction = "updated"
+	BeadClosed  BeadAction = "closed"
+)
+
+// BeadChange records a bead an agent reported changing.
+type BeadChange struct {
+	ID     string
+	Action BeadAction
+}
+
+// confirmationRegex matches lines where an agent ran a bd write command or bd
+// confirmed one, e.g. "bd update buckshot-1 --status done" or
+// "✓ Created issue: buckshot-a1b2". The first non-empty group names the action.
+var confirmationRegex = regexp.MustCompile(`(?i)\bbd\s+(create|update|close)\b|\b(created|updated|closed)\s+issue\b|✓\s*(closed)\b`)
+
+// beadIDRegex matches bead IDs with the given prefix, including child IDs
+// such as buckshot-abc.1.
+func beadIDRegex(prefix string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(prefix) + `-[a-z0-9]+(\.[0-9]+)*\b`)
+}
+
+// parseBeadActions returns the distinct beads, in order of first mention, on
+// bd create/update/close lines of an agent's output, each with the action of
+// the line it first appeared on. IDs merely mentioned elsewhere (e.g. while
+// reading the beads list) don't count as changes.
+func parseBeadActions(output, prefix string) []BeadChange {
+	if prefix == "" {
+		prefix = DefaultBeadPrefix
+	}
+	idRe := beadIDRegex(prefix)
+
+	changes := []BeadChange{}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		m := confirmationRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		action := lineAction(m[1:])
+		for _, id := range idRe.FindAllString(line, -1) {
+			if !seen[id] {
+				seen[id] = true
+				changes = append(changes, BeadChange{ID: id, Action: action})
+			}
+		}
+	}
+	return changes
+}
+
+// lineAction maps the matched verb of a confirmation line to its action.
+func lineAction(groups []string) BeadAction {
+	for _, verb := range groups {
+		switch strings.ToLower(verb) {
+		case "create", "created":
+			return BeadCreated
+		case "close", "closed":
+			return BeadClosed
+		case "update", "updated":
+			return BeadUpdated
+		}
+	}
+	return BeadUpdated
+}
@@ -0,0 +1,95 @@
+package orchestrator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseBeadActions tests that IDs are taken only from bd write commands
+// and their confirmations
+func TestParseBeadActions(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "created confirmation",
+			output: "✓ Created issue: buckshot-a1b2\n  Title: Add cache\n",
+			want:   []string{"buckshot-a1b2"},
+		},
+		{
+			name:   "mock agent format",
+			output: "Reading the plan...\nCreated issue: buckshot-xxx\nDone.",
+			want:   []string{"buckshot-xxx"},
+		},
+		{
+			name:   "update and close commands",
+			output: "Ran `bd update buckshot-1 --status in_progress`\nthen bd close buckshot-2 --reason done\n✓ Closed buckshot-3: done",
+			want:   []string{"buckshot-1", "buckshot-2", "buckshot-3"},
+		},
+		{
+			name:   "distinct in first-mention order",
+			output: "bd update buckshot-2 --priority 1\n✓ Updated issue: buckshot-2\nbd update buckshot-1 --notes x",
+			want:   []string{"buckshot-2", "buckshot-1"},
+		},
+		{
+			name:   "child IDs",
+			output: "✓ Created issue: buckshot-abc.1.",
+			want:   []string{"buckshot-abc.1"},
+		},
+		{
+			name:   "mentions outside bd lines are ignored",
+			output: "buckshot-a1 already covers this; no changes needed.\nSee buckshot-b2 too.",
+			want:   []string{},
+		},
+		{
+			name:   "custom prefix",
+			output: "✓ Created issue: proj-9z\nbd update buckshot-1 --status done",
+			prefix: "proj",
+			want:   []string{"proj-9z"},
+		},
+		{
+			name:   "empty output",
+			output: "",
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, c := range parseBeadActions(tt.output, tt.prefix) {
+				got = append(got, c.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBeadActions() IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseBeadActions_Actions tests that each bead is tagged with the action
+// of the line it first appears on
+func TestParseBeadActions_Actions(t *testing.T) {
+	output := strings.Join([]string{
+		"$ bd create \"Add cache\" -t task",
+		"✓ Created issue: buckshot-a1",
+		"bd update buckshot-b2 --priority 1",
+		"✓ Updated issue: buckshot-a1",
+		"BD CLOSE buckshot-c3 --reason done",
+		"✓ Closed buckshot-d4: superseded",
+	}, "\n")
+
+	want := []BeadChange{
+		{ID: "buckshot-a1", Action: BeadCreated},
+		{ID: "buckshot-b2", Action: BeadUpdated},
+		{ID: "buckshot-c3", Action: BeadClosed},
+		{ID: "buckshot-d4", Action: BeadClosed},
+	}
+	if got := parseBeadActions(output, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBeadActions() = %v, want %v", got, want)
+	}
+}
@@ -3,10 +3,17 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os/exec"
+	"sync"
+	"time"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
 	buckctx "github.com/michaellady/buckshot/internal/context"
+	"github.com/michaellady/buckshot/internal/dispatch"
 	"github.com/michaellady/buckshot/internal/session"
 )
 
@@ -17,6 +24,11 @@ func newOSCmd(name string, args ...string) *exec.Cmd {
 
 // ProgressReporter receives progress updates during round execution.
 type ProgressReporter interface {
+	// OnRoundStart is called before the first agent of a round runs.
+	// totalRounds is 0 when the run is open-ended (e.g., until convergence).
+	OnRoundStart(round, totalRounds, agents int)
+	// OnRoundComplete is called after every agent in the round has finished.
+	OnRoundComplete(round int, result RoundResult)
 	// OnAgentStart is called when an agent begins its turn.
 	OnAgentStart(round, agentIndex, totalAgents int, agent agent.Agent)
 	// OnAgentComplete is called when an agent finishes its turn.
@@ -25,26 +37,45 @@ type ProgressReporter interface {
 
 // AgentResult represents the outcome of a single agent's turn.
 type AgentResult struct {
-	Agent         agent.Agent       // The agent that ran
-	Response      session.Response  // The agent's response
-	BeadsChanged  []string          // IDs of beads created/modified
-	Error         error             // Error if agent failed
-	Skipped       bool              // True if agent was skipped (e.g., due to previous failure)
+	Agent        agent.Agent      // The agent that ran
+	Response     session.Response // The agent's response
+	BeadsChanged []string         // IDs of beads created/modified
+	BeadChanges  []BeadChange     // What the agent did to each bead in BeadsChanged
+	Error        error            // Error if agent failed
+	Skipped      bool             // True if agent was skipped (e.g., due to previous failure)
+	SkipReason   string           // Why the agent was skipped, e.g. SkipNotAuthenticated
+	Cached       bool             // True if the response was reused from an identical earlier prompt
+	StartedAt    time.Time        // When the prompt was sent
+	Duration     time.Duration    // How long the agent took to respond
 }
 
+// SkipNotAuthenticated is the SkipReason for agents without valid credentials.
+const SkipNotAuthenticated = "not authenticated"
+
+// SkipRoundTimeout is the SkipReason for agents whose turn never came because
+// the round ran out of time.
+const SkipRoundTimeout = "round timed out"
+
 // RoundResult represents the outcome of a complete round.
 type RoundResult struct {
-	Round         int            // Round number (1-indexed)
-	AgentResults  []AgentResult  // Results from each agent
-	TotalChanges  int            // Total beads created/modified
-	FailedCount   int            // Number of agents that failed
-	SkippedCount  int            // Number of agents that were skipped
+	Round        int           // Round number (1-indexed)
+	AgentResults []AgentResult // Results from each agent
+	TotalChanges int           // Total beads created/modified
+	FailedCount  int           // Number of agents that failed
+	SkippedCount int           // Number of agents that were skipped
+}
+
+// SucceededCount returns the number of agents that finished their turn
+// without failing or being skipped.
+func (r RoundResult) SucceededCount() int {
+	return len(r.AgentResults) - r.FailedCount - r.SkippedCount
 }
 
 // RoundOrchestrator coordinates executing multiple agents in a round.
 type RoundOrchestrator interface {
 	// RunRound executes each agent in sequence with the given context.
-	// Each agent sees the beads state AFTER previous agents in the round.
+	// Each agent sees the beads state AFTER previous agents in the round,
+	// unless SetParallel runs them all at once.
 	RunRound(ctx context.Context, agents []agent.Agent, planCtx buckctx.PlanningContext) (RoundResult, error)
 
 	// SetSessionManager sets the session manager for creating agent sessions.
@@ -55,13 +86,102 @@ type RoundOrchestrator interface {
 
 	// SetProgressReporter sets the progress reporter for verbose output.
 	SetProgressReporter(reporter ProgressReporter)
+
+	// SetAgentTimeout limits how long each agent's turn may take.
+	// Zero means no per-agent limit.
+	SetAgentTimeout(timeout time.Duration)
+
+	// SetRoundTimeout limits how long a whole round may take. The agent
+	// running when it expires is cut off and the rest of the round is
+	// skipped. Zero means no per-round limit.
+	SetRoundTimeout(timeout time.Duration)
+
+	// SetResponseCache reuses responses to prompts an agent has already
+	// answered while the beads state is unchanged. Nil disables caching.
+	SetResponseCache(cache *ResponseCache)
+
+	// SetBeadsClient sets the client used to snapshot beads around each
+	// agent's turn. Nil runs bd list --json directly.
+	SetBeadsClient(client beads.Client)
+
+	// SetBeadsDiffer sets how the beads snapshots around each agent's turn
+	// are compared for the progress reporter. Nil means FieldDiffer.
+	SetBeadsDiffer(differ BeadsDiffer)
+
+	// SetBeadPrefix sets the ID prefix used to spot beads an agent changed
+	// in its output. Empty means DefaultBeadPrefix.
+	SetBeadPrefix(prefix string)
+
+	// SetContextSkipThreshold skips an agent whose session already reports
+	// context usage above threshold (0.0-1.0) once started. Zero disables.
+	SetContextSkipThreshold(threshold float64)
+
+	// SetRateLimiter makes each send wait for its agent's provider rate
+	// limit. Nil disables limiting.
+	SetRateLimiter(limiter *dispatch.RateLimiter)
+
+	// SetRotateOrder rotates the agents by one place each round, so that
+	// over a run every agent takes a turn going first.
+	SetRotateOrder(rotate bool)
+
+	// SetAgentsReadCheck asks each agent, once its session starts, to echo
+	// the first line of AGENTS.md, and calls warn with the agent's name and
+	// the path when the reply doesn't. Nil disables the check.
+	SetAgentsReadCheck(warn func(agentName, agentsPath string))
+
+	// SetRereadAgents asks agents to read AGENTS.md on every turn instead of
+	// only on their first turn in each session.
+	SetRereadAgents(reread bool)
+
+	// SetParallel runs every agent in a round at once against the beads
+	// state the round started with, so no agent sees the others' changes
+	// until the next round.
+	SetParallel(parallel bool)
+
+	// SetRoundSummary puts a summary of the previous round's bead changes
+	// and agent conclusions before each later round's prompt.
+	SetRoundSummary(summarize bool)
+
+	// WarmUp starts a session for each authenticated agent concurrently
+	// before the first round, instead of each on its first turn. Agents that
+	// fail to start are left out and start on their turn.
+	WarmUp(ctx context.Context, agents []agent.Agent, agentsPath string) error
+
+	// Close shuts down the sessions kept across rounds.
+	Close() error
 }
 
+// RespawnThreshold is the context usage above which an agent's session is
+// replaced with a fresh one before its next turn.
+const RespawnThreshold = 0.5
+
+// PromptRespawnThreshold is the context usage a session would reach with the
+// next prompt above which the prompt goes to a fresh session instead.
+const PromptRespawnThreshold = 0.9
+
 // defaultOrchestrator is the default implementation.
 type defaultOrchestrator struct {
 	sessionMgr       session.Manager
 	contextBuilder   buckctx.Builder
 	progressReporter ProgressReporter
+	agentTimeout     time.Duration
+	roundTimeout     time.Duration
+	cache            *ResponseCache
+	beads            beads.Client
+	differ           BeadsDiffer
+	beadPrefix       string
+	contextSkip      float64
+	limiter          *dispatch.RateLimiter
+	rotateOrder      bool
+	agentsReadWarn   func(agentName, agentsPath string)
+	rereadAgents     bool
+	parallel         bool
+	roundSummary     bool
+	history          []RoundResult              // Rounds run so far, for SetRoundSummary
+	mu               sync.Mutex                 // Guards briefed and sessions while agents run in parallel
+	briefed          map[string]bool            // Agents whose current session was asked to read AGENTS.md
+	sessions         map[string]session.Session // Each agent's session, kept across rounds
+	reportMu         sync.Mutex                 // Serializes progress reports from agents running in parallel
 }
 
 // NewRoundOrchestrator creates a new round orchestrator.
@@ -69,129 +189,431 @@ func NewRoundOrchestrator() RoundOrchestrator {
 	return &defaultOrchestrator{}
 }
 
-// RunRound executes agents in sequence.
-// Each agent sees the beads state AFTER previous agents in the round.
+// RunRound executes agents in sequence, or all at once with SetParallel.
+// In sequence, each agent sees the beads state AFTER previous agents in the
+// round; in parallel, every agent sees the state the round started with.
 func (o *defaultOrchestrator) RunRound(ctx context.Context, agents []agent.Agent, planCtx buckctx.PlanningContext) (RoundResult, error) {
 	result := RoundResult{
 		Round:        planCtx.Round,
 		AgentResults: make([]AgentResult, 0, len(agents)),
 	}
+	if o.rotateOrder {
+		agents = rotateAgents(agents, planCtx.Round)
+	}
+	if o.roundSummary && len(o.history) > 0 {
+		planCtx.PreviousRound = summarizeRound(o.history[len(o.history)-1])
+	}
 
-	// Process each agent in sequence
-	for i, ag := range agents {
-		agentResult := AgentResult{
-			Agent:        ag,
-			BeadsChanged: []string{},
-		}
+	if o.progressReporter != nil {
+		o.progressReporter.OnRoundStart(planCtx.Round, planCtx.TotalRounds, len(agents))
+	}
 
-		// Skip unauthenticated agents
-		if !ag.Authenticated {
-			agentResult.Skipped = true
-			result.SkippedCount++
-			result.AgentResults = append(result.AgentResults, agentResult)
-			if o.progressReporter != nil {
-				o.progressReporter.OnAgentComplete(planCtx.Round, i+1, len(agents), agentResult, "")
-			}
-			continue
-		}
+	// Agents run under the round's deadline, if it has one
+	roundCtx := ctx
+	if o.roundTimeout > 0 {
+		var cancel context.CancelFunc
+		roundCtx, cancel = context.WithTimeout(ctx, o.roundTimeout)
+		defer cancel()
+	}
 
-		// Report agent start
-		if o.progressReporter != nil {
-			o.progressReporter.OnAgentStart(planCtx.Round, i+1, len(agents), ag)
+	if o.parallel {
+		agentResults := make([]AgentResult, len(agents))
+		var wg sync.WaitGroup
+		for i, ag := range agents {
+			turnCtx := planCtx
+			turnCtx.AgentName = ag.Name
+			// No agent answers first, so the first listed does the upfront analysis
+			turnCtx.IsBootstrap = planCtx.Round == 1 && i == 0
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				agentResults[i] = o.runAgent(ctx, roundCtx, turnCtx, ag, i, len(agents), false)
+			}()
+		}
+		wg.Wait()
+		for _, ar := range agentResults {
+			result.add(ar)
+		}
+	} else {
+		for i, ag := range agents {
+			// Prompt templates can address the agent by name
+			planCtx.AgentName = ag.Name
+			// The first agent to answer in round 1 does the upfront analysis
+			planCtx.IsBootstrap = planCtx.Round == 1 && result.SucceededCount() == 0
+			// Every agent but the first sees the beads left by those before it
+			result.add(o.runAgent(ctx, roundCtx, planCtx, ag, i, len(agents), i > 0))
 		}
+	}
+
+	// Refresh beads state after all agents for next round
+	if o.contextBuilder != nil && len(agents) > 0 {
+		_ = o.contextBuilder.RefreshBeadsState(ctx, &planCtx)
+	}
 
-		// Capture beads state before this agent
-		beadsBefore := captureBeadsState()
+	if o.progressReporter != nil {
+		o.progressReporter.OnRoundComplete(planCtx.Round, result)
+	}
 
-		// Refresh beads state before each agent (except first which already has it)
-		if i > 0 && o.contextBuilder != nil {
-			_ = o.contextBuilder.RefreshBeadsState(&planCtx)
+	if o.roundSummary {
+		o.history = append(o.history, result)
+	}
+	return result, nil
+}
+
+// add appends an agent's result to the round and counts it.
+func (r *RoundResult) add(ar AgentResult) {
+	r.AgentResults = append(r.AgentResults, ar)
+	switch {
+	case ar.Skipped:
+		r.SkippedCount++
+	case ar.Error != nil:
+		r.FailedCount++
+	}
+	r.TotalChanges += len(ar.BeadsChanged)
+}
+
+// runAgent takes one agent's turn, the index-th of total in the round,
+// refreshing the beads state in planCtx first if refresh is set.
+func (o *defaultOrchestrator) runAgent(ctx, roundCtx context.Context, planCtx buckctx.PlanningContext, ag agent.Agent, index, total int, refresh bool) AgentResult {
+	agentResult := AgentResult{
+		Agent:        ag,
+		BeadsChanged: []string{},
+	}
+	roundTimedOut := func() bool { return ctx.Err() == nil && roundCtx.Err() != nil }
+
+	// Skip unauthenticated agents
+	if !ag.Authenticated {
+		agentResult.Skipped = true
+		agentResult.SkipReason = SkipNotAuthenticated
+		return o.agentComplete(planCtx.Round, index, total, agentResult, "")
+	}
+
+	// No time is left in the round for this agent
+	if roundTimedOut() {
+		agentResult.Skipped = true
+		agentResult.SkipReason = SkipRoundTimeout
+		return o.agentComplete(planCtx.Round, index, total, agentResult, "")
+	}
+
+	// Report agent start
+	if o.progressReporter != nil {
+		o.reportMu.Lock()
+		o.progressReporter.OnAgentStart(planCtx.Round, index+1, total, ag)
+		o.reportMu.Unlock()
+	}
+
+	// Capture beads state before this agent. Agents running at once change
+	// the beads together, so none of them gets a diff of its own.
+	var beadsBefore []beads.Issue
+	if !o.parallel {
+		beadsBefore = o.captureBeadsState()
+	}
+
+	if refresh && o.contextBuilder != nil {
+		_ = o.contextBuilder.RefreshBeadsState(roundCtx, &planCtx)
+	}
+
+	// Reuse the answer this agent already gave to the same prompt. Nothing
+	// ran, so nothing changed and no tokens were spent.
+	var cachePrompt string
+	if o.cache != nil {
+		o.cache.ObserveBeadsState(planCtx.BeadsState)
+		cachePrompt = o.cachePrompt(planCtx)
+		if resp, ok := o.cache.Get(ag.Name, cachePrompt); ok {
+			resp.TokenUsage = agent.TokenUsage{}
+			agentResult.Response = resp
+			agentResult.Cached = true
+			agentResult.StartedAt = time.Now()
+			return o.agentComplete(planCtx.Round, index, total, agentResult, "")
 		}
+	}
 
-		// Create session for this agent
-		if o.sessionMgr == nil {
-			agentResult.Error = context.Canceled
-			result.FailedCount++
-			result.AgentResults = append(result.AgentResults, agentResult)
-			if o.progressReporter != nil {
-				o.progressReporter.OnAgentComplete(planCtx.Round, i+1, len(agents), agentResult, "")
-			}
-			continue
+	// Create session for this agent
+	if o.sessionMgr == nil {
+		agentResult.Error = context.Canceled
+		return o.agentComplete(planCtx.Round, index, total, agentResult, "")
+	}
+
+	// Sessions outlive the round, so they start under the run's context;
+	// the round's deadline only bounds the send
+	sess, err := o.session(ctx, ag, planCtx.AgentsPath)
+	if errors.Is(err, session.ErrNotAuthenticated) {
+		// Credentials lapsed since detection; treat it like any other unauthenticated agent
+		agentResult.Skipped = true
+		agentResult.SkipReason = SkipNotAuthenticated
+		return o.agentComplete(planCtx.Round, index, total, agentResult, "")
+	}
+	if err != nil {
+		agentResult.Error = err
+		return o.agentComplete(planCtx.Round, index, total, agentResult, "")
+	}
+
+	// A session carried over from an earlier run may already be nearly
+	// full; another large prompt would be wasted on it
+	if o.contextSkip > 0 {
+		if usage := sess.ContextUsage(); usage > o.contextSkip {
+			agentResult.Skipped = true
+			agentResult.SkipReason = fmt.Sprintf("context %.0f%% used", usage*100)
+			return o.agentComplete(planCtx.Round, index, total, agentResult, "")
 		}
+	}
 
-		sess, err := o.sessionMgr.CreateSession(ag)
+	// A large prompt could overflow a session that fits under
+	// RespawnThreshold; send it to a fresh one instead
+	prompt := o.formatPrompt(planCtx)
+	if session.PredictRespawn(sess, prompt, PromptRespawnThreshold) {
+		fresh, err := o.restart(ctx, sess, planCtx.AgentsPath)
 		if err != nil {
 			agentResult.Error = err
-			result.FailedCount++
-			result.AgentResults = append(result.AgentResults, agentResult)
-			if o.progressReporter != nil {
-				o.progressReporter.OnAgentComplete(planCtx.Round, i+1, len(agents), agentResult, "")
-			}
-			continue
+			return o.agentComplete(planCtx.Round, index, total, agentResult, "")
 		}
-		defer func() { _ = sess.Close() }()
+		sess = fresh
+		prompt = o.formatPrompt(planCtx)
+	}
 
-		// Start the session
-		if err := sess.Start(ctx, planCtx.AgentsPath); err != nil {
-			agentResult.Error = err
-			result.FailedCount++
-			result.AgentResults = append(result.AgentResults, agentResult)
-			if o.progressReporter != nil {
-				o.progressReporter.OnAgentComplete(planCtx.Round, i+1, len(agents), agentResult, "")
-			}
-			continue
+	// Send the prompt
+	agentResult.StartedAt = time.Now()
+	resp, err := o.send(roundCtx, sess, prompt)
+	if errors.Is(err, session.ErrProcessExited) && roundCtx.Err() == nil {
+		// The agent died mid-turn; give it one more try in a fresh session
+		if fresh, restartErr := o.restart(ctx, sess, planCtx.AgentsPath); restartErr == nil {
+			sess = fresh
+			resp, err = o.send(roundCtx, sess, o.formatPrompt(planCtx))
 		}
+	}
+	// An agent cut off mid-turn may still be answering; its late output
+	// would be taken for the next turn's, so the session isn't kept
+	if resp.Partial || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, session.ErrNoOutput) {
+		_ = sess.Close()
+		o.forget(ag.Name)
+	}
+	if err != nil && roundTimedOut() {
+		err = fmt.Errorf("%s cut off: round timed out after %s: %w", ag.Name, o.roundTimeout, err)
+		resp.Error = err
+	}
+	if err == nil {
+		o.setBriefed(ag.Name)
+	}
+	agentResult.Duration = time.Since(agentResult.StartedAt)
+	agentResult.Response = resp
+	if err != nil {
+		agentResult.Error = err
+		return o.agentComplete(planCtx.Round, index, total, agentResult, o.beadsDiff(beadsBefore))
+	}
+
+	if o.cache != nil {
+		o.cache.Put(ag.Name, cachePrompt, resp)
+	}
+
+	// Parse response for beads the agent created, updated, or closed
+	agentResult.BeadChanges = parseBeadActions(resp.Output, o.beadPrefix)
+	for _, c := range agentResult.BeadChanges {
+		agentResult.BeadsChanged = append(agentResult.BeadsChanged, c.ID)
+	}
+
+	return o.agentComplete(planCtx.Round, index, total, agentResult, o.beadsDiff(beadsBefore))
+}
+
+// agentComplete reports the index-th agent's finished turn and returns its
+// result. Reports from agents running in parallel are taken one at a time.
+func (o *defaultOrchestrator) agentComplete(round, index, total int, result AgentResult, beadsDiff string) AgentResult {
+	if o.progressReporter != nil {
+		o.reportMu.Lock()
+		defer o.reportMu.Unlock()
+		o.progressReporter.OnAgentComplete(round, index+1, total, result, beadsDiff)
+	}
+	return result
+}
+
+// beadsDiff describes how the beads changed since before, for the progress
+// reporter. Without one, or with agents running in parallel, bd isn't read
+// at all.
+func (o *defaultOrchestrator) beadsDiff(before []beads.Issue) string {
+	if o.progressReporter == nil || o.parallel {
+		return ""
+	}
+	return o.beadsDiffer().Diff(before, o.captureBeadsState())
+}
+
+// formatPrompt renders planCtx for its agent, asking it to read AGENTS.md
+// on its first turn in a session, or on every turn with rereadAgents.
+func (o *defaultOrchestrator) formatPrompt(planCtx buckctx.PlanningContext) string {
+	o.mu.Lock()
+	planCtx.IsFirstTurn = o.rereadAgents || !o.briefed[planCtx.AgentName]
+	o.mu.Unlock()
+	if o.contextBuilder == nil {
+		return planCtx.Prompt
+	}
+	return o.contextBuilder.Format(planCtx)
+}
 
-		// Format and send the prompt
-		prompt := planCtx.Prompt
-		if o.contextBuilder != nil {
-			prompt = o.contextBuilder.Format(planCtx)
+// session returns the agent's kept session while it is alive and below
+// RespawnThreshold, or creates and starts a new one and keeps it for later
+// rounds.
+func (o *defaultOrchestrator) session(ctx context.Context, ag agent.Agent, agentsPath string) (session.Session, error) {
+	o.mu.Lock()
+	sess, ok := o.sessions[ag.Name]
+	o.mu.Unlock()
+	if ok {
+		if sess.IsAlive() && !o.sessionMgr.ShouldRespawn(sess, RespawnThreshold) {
+			return sess, nil
 		}
+		_ = sess.Close()
+		o.forget(ag.Name)
+	}
 
-		resp, err := sess.Send(ctx, prompt)
+	o.mu.Lock()
+	sess, err := o.sessionMgr.CreateSession(ag)
+	o.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := sess.Start(ctx, agentsPath); err != nil {
+		_ = sess.Close()
+		return nil, err
+	}
+	o.checkAgentsRead(ctx, sess, agentsPath)
+	o.keep(sess)
+	return sess, nil
+}
+
+// keep stores a newly started session for later rounds. Its agent hasn't
+// been asked to read AGENTS.md in it yet.
+func (o *defaultOrchestrator) keep(sess session.Session) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.sessions == nil {
+		o.sessions = make(map[string]session.Session)
+	}
+	if o.briefed == nil {
+		o.briefed = make(map[string]bool)
+	}
+	o.sessions[sess.Agent().Name] = sess
+	o.briefed[sess.Agent().Name] = false
+}
+
+// forget drops an agent's kept session.
+func (o *defaultOrchestrator) forget(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.sessions, name)
+}
+
+// setBriefed records that the agent has been asked to read AGENTS.md in its
+// current session.
+func (o *defaultOrchestrator) setBriefed(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.briefed == nil {
+		o.briefed = make(map[string]bool)
+	}
+	o.briefed[name] = true
+}
+
+// restart closes a session and starts a new one for the same agent.
+func (o *defaultOrchestrator) restart(ctx context.Context, old session.Session, agentsPath string) (session.Session, error) {
+	_ = old.Close()
+	o.forget(old.Agent().Name)
+	return o.session(ctx, old.Agent(), agentsPath)
+}
+
+// WarmUp creates each authenticated agent's session, then starts them all at
+// once so their startup overlaps.
+func (o *defaultOrchestrator) WarmUp(ctx context.Context, agents []agent.Agent, agentsPath string) error {
+	if o.sessionMgr == nil {
+		return errors.New("no session manager set")
+	}
+	if o.sessions == nil {
+		o.sessions = make(map[string]session.Session)
+	}
+
+	var (
+		pending []session.Session
+		errs    []error
+	)
+	for _, ag := range agents {
+		if _, ok := o.sessions[ag.Name]; ok || !ag.Authenticated {
+			continue
+		}
+		sess, err := o.sessionMgr.CreateSession(ag)
 		if err != nil {
-			agentResult.Error = err
-			agentResult.Response = resp
-			result.FailedCount++
-			result.AgentResults = append(result.AgentResults, agentResult)
-			if o.progressReporter != nil {
-				beadsAfter := captureBeadsState()
-				diff := diffBeadsState(beadsBefore, beadsAfter)
-				o.progressReporter.OnAgentComplete(planCtx.Round, i+1, len(agents), agentResult, diff)
-			}
+			errs = append(errs, fmt.Errorf("%s: %w", ag.Name, err))
 			continue
 		}
+		pending = append(pending, sess)
+	}
 
-		agentResult.Response = resp
-
-		// Parse response for bead changes (simplified: look for bead IDs in output)
-		agentResult.BeadsChanged = parseBeadChanges(resp.Output)
-		result.TotalChanges += len(agentResult.BeadsChanged)
+	startErrs := make([]error, len(pending))
+	var wg sync.WaitGroup
+	for i, sess := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if startErrs[i] = sess.Start(ctx, agentsPath); startErrs[i] == nil {
+				o.checkAgentsRead(ctx, sess, agentsPath)
+			}
+		}()
+	}
+	wg.Wait()
 
-		result.AgentResults = append(result.AgentResults, agentResult)
+	for i, sess := range pending {
+		if err := startErrs[i]; err != nil {
+			_ = sess.Close()
+			errs = append(errs, fmt.Errorf("%s: %w", sess.Agent().Name, err))
+			continue
+		}
+		o.keep(sess)
+	}
+	return errors.Join(errs...)
+}
 
-		// Report agent complete with beads diff
-		if o.progressReporter != nil {
-			beadsAfter := captureBeadsState()
-			diff := diffBeadsState(beadsBefore, beadsAfter)
-			o.progressReporter.OnAgentComplete(planCtx.Round, i+1, len(agents), agentResult, diff)
+// Close shuts down the kept sessions; later rounds start fresh ones.
+func (o *defaultOrchestrator) Close() error {
+	var errs []error
+	for _, sess := range o.sessions {
+		if err := sess.Close(); err != nil {
+			errs = append(errs, err)
 		}
 	}
+	o.sessions = nil
+	return errors.Join(errs...)
+}
 
-	// Refresh beads state after all agents for next round
-	if o.contextBuilder != nil && len(agents) > 0 {
-		_ = o.contextBuilder.RefreshBeadsState(&planCtx)
+// send delivers the prompt, bounding the turn by the agent timeout if one is set.
+// A turn that runs out of time is reported as a timeout rather than a bare
+// deadline error, so it reads differently from the overall run being cut short.
+func (o *defaultOrchestrator) send(ctx context.Context, sess session.Session, prompt string) (session.Response, error) {
+	// Waiting for the rate limit doesn't count against the agent's turn
+	if err := o.limiter.Wait(ctx, sess.Agent().Name); err != nil {
+		return session.Response{Error: err}, err
+	}
+	if o.agentTimeout <= 0 {
+		return sess.Send(ctx, prompt)
 	}
 
-	return result, nil
+	turnCtx, cancel := context.WithTimeout(ctx, o.agentTimeout)
+	defer cancel()
+
+	resp, err := sess.Send(turnCtx, prompt)
+	if err != nil && ctx.Err() == nil && errors.Is(turnCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%s timed out after %s: %w", sess.Agent().Name, o.agentTimeout, err)
+		resp.Error = err
+	}
+	return resp, err
 }
 
-// parseBeadChanges extracts bead IDs from agent output.
-// Looks for patterns like "buckshot-xxx" or "Created: buckshot-xxx"
-func parseBeadChanges(output string) []string {
-	// Simple implementation - can be enhanced with regex
-	// For now, returns empty slice (changes tracked by beads diff)
-	return []string{}
+// cachePrompt returns the prompt used as the response cache key. The round
+// header, previous round summary and first-turn guidance are left out so a
+// stable round matches the round before it.
+func (o *defaultOrchestrator) cachePrompt(planCtx buckctx.PlanningContext) string {
+	planCtx.Round = 0
+	planCtx.TotalRounds = 0
+	planCtx.IsFirstTurn = false
+	planCtx.PreviousRound = nil
+	if o.contextBuilder == nil {
+		return planCtx.Prompt
+	}
+	return o.contextBuilder.Format(planCtx)
 }
 
 // SetSessionManager sets the session manager.
@@ -209,32 +631,118 @@ func (o *defaultOrchestrator) SetProgressReporter(reporter ProgressReporter) {
 	o.progressReporter = reporter
 }
 
-// captureBeadsState captures the current beads state by running `bd list --json`.
-func captureBeadsState() string {
-	out, err := runBdCommand("list", "--json")
-	if err != nil {
-		return ""
+// SetAgentTimeout limits how long each agent's turn may take.
+func (o *defaultOrchestrator) SetAgentTimeout(timeout time.Duration) {
+	o.agentTimeout = timeout
+}
+
+// SetRoundTimeout limits how long a whole round may take.
+func (o *defaultOrchestrator) SetRoundTimeout(timeout time.Duration) {
+	o.roundTimeout = timeout
+}
+
+// SetResponseCache sets the cache used to skip repeated prompts.
+func (o *defaultOrchestrator) SetResponseCache(cache *ResponseCache) {
+	o.cache = cache
+}
+
+// SetBeadsClient sets the client used to snapshot beads.
+func (o *defaultOrchestrator) SetBeadsClient(client beads.Client) {
+	o.beads = client
+}
+
+// SetBeadsDiffer sets how beads snapshots are compared.
+func (o *defaultOrchestrator) SetBeadsDiffer(differ BeadsDiffer) {
+	o.differ = differ
+}
+
+// beadsDiffer returns the differ in use, FieldDiffer unless one was set.
+func (o *defaultOrchestrator) beadsDiffer() BeadsDiffer {
+	if o.differ == nil {
+		return FieldDiffer
 	}
-	return out
+	return o.differ
+}
+
+// SetBeadPrefix sets the bead ID prefix used by change detection.
+func (o *defaultOrchestrator) SetBeadPrefix(prefix string) {
+	o.beadPrefix = prefix
+}
+
+// SetContextSkipThreshold sets the context usage above which agents are skipped.
+func (o *defaultOrchestrator) SetContextSkipThreshold(threshold float64) {
+	o.contextSkip = threshold
+}
+
+// SetRateLimiter sets the limiter consulted before each send.
+func (o *defaultOrchestrator) SetRateLimiter(limiter *dispatch.RateLimiter) {
+	o.limiter = limiter
+}
+
+// SetRotateOrder sets whether the agent order rotates each round.
+func (o *defaultOrchestrator) SetRotateOrder(rotate bool) {
+	o.rotateOrder = rotate
 }
 
-// diffBeadsState computes a human-readable diff between two beads states.
-func diffBeadsState(before, after string) string {
-	if before == after {
-		return "(no changes)"
+// SetAgentsReadCheck sets the warning for agents that don't echo AGENTS.md.
+func (o *defaultOrchestrator) SetAgentsReadCheck(warn func(agentName, agentsPath string)) {
+	o.agentsReadWarn = warn
+}
+
+// SetRereadAgents sets whether agents read AGENTS.md on every turn.
+func (o *defaultOrchestrator) SetRereadAgents(reread bool) {
+	o.rereadAgents = reread
+}
+
+// SetParallel sets whether the agents in a round run at once.
+func (o *defaultOrchestrator) SetParallel(parallel bool) {
+	o.parallel = parallel
+}
+
+// SetRoundSummary sets whether prompts summarize the previous round.
+func (o *defaultOrchestrator) SetRoundSummary(summarize bool) {
+	o.roundSummary = summarize
+}
+
+// rotateAgents returns agents in their order for round (1-indexed): round 1
+// keeps the base order and each later round starts one agent further along.
+func rotateAgents(agents []agent.Agent, round int) []agent.Agent {
+	if len(agents) < 2 || round < 1 {
+		return agents
 	}
-	if before == "" && after == "" {
-		return "(no beads)"
+	shift := (round - 1) % len(agents)
+	rotated := make([]agent.Agent, 0, len(agents))
+	rotated = append(rotated, agents[shift:]...)
+	return append(rotated, agents[:shift]...)
+}
+
+// captureBeadsState captures the current beads through the beads client, or
+// by running `bd list --json` if none is set. It returns nil if bd can't be read.
+func (o *defaultOrchestrator) captureBeadsState() []beads.Issue {
+	if o.beads != nil {
+		issues, err := o.beads.ListIssues(context.Background(), beads.ListOpts{})
+		if err != nil {
+			return nil
+		}
+		return nonNil(issues)
 	}
-	if before == "" {
-		return "(beads initialized)\n" + after
+	out, err := runBdCommand("list", "--json")
+	if err != nil {
+		return nil
+	}
+	var issues []beads.Issue
+	if err := json.Unmarshal([]byte(out), &issues); err != nil {
+		return nil
 	}
-	if after == "" {
-		return "(beads cleared)"
+	return nonNil(issues)
+}
+
+// nonNil keeps an empty bead list distinct from an unreadable one.
+func nonNil(issues []beads.Issue) []beads.Issue {
+	if issues == nil {
+		return []beads.Issue{}
 	}
-	// For now, just show a simple diff indicator
-	// A more sophisticated diff could parse JSON and compare fields
-	return computeSimpleDiff(before, after)
+	return issues
 }
 
 // runBdCommand executes a bd command and returns its output.
@@ -266,55 +774,3 @@ func (c *realCmd) Output() ([]byte, error) {
 	cmd := newOSCmd(c.name, c.args...)
 	return cmd.Output()
 }
-
-// computeSimpleDiff computes a simple line-by-line diff
-func computeSimpleDiff(before, after string) string {
-	// Simple approach: show what changed
-	beforeLines := splitLines(before)
-	afterLines := splitLines(after)
-
-	var diff string
-	beforeSet := make(map[string]bool)
-	for _, line := range beforeLines {
-		beforeSet[line] = true
-	}
-
-	afterSet := make(map[string]bool)
-	for _, line := range afterLines {
-		afterSet[line] = true
-	}
-
-	// Find removed lines
-	for _, line := range beforeLines {
-		if !afterSet[line] && line != "" {
-			diff += "- " + line + "\n"
-		}
-	}
-
-	// Find added lines
-	for _, line := range afterLines {
-		if !beforeSet[line] && line != "" {
-			diff += "+ " + line + "\n"
-		}
-	}
-
-	if diff == "" {
-		return "(whitespace changes only)"
-	}
-	return diff
-}
-
-func splitLines(s string) []string {
-	var lines []string
-	start := 0
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\n' {
-			lines = append(lines, s[start:i])
-			start = i + 1
-		}
-	}
-	if start < len(s) {
-		lines = append(lines, s[start:])
-	}
-	return lines
-}
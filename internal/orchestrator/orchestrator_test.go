@@ -2,10 +2,20 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	"github.com/michaellady/buckshot/internal/beads"
 	buckctx "github.com/michaellady/buckshot/internal/context"
+	"github.com/michaellady/buckshot/internal/dispatch"
 	"github.com/michaellady/buckshot/internal/session"
 )
 
@@ -126,6 +136,52 @@ func TestRunRound_TracksChangesPerAgent(t *testing.T) {
 	}
 }
 
+// TestRunRound_ParsesBeadChanges tests that beads an agent reports changing
+// are counted, using the configured ID prefix
+func TestRunRound_ParsesBeadChanges(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{output: "$ bd create \"Cache\"\n✓ Created issue: proj-a1\nbuckshot-b2 is unrelated"})
+	orch.SetBeadPrefix("proj")
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}}
+	result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	if result.TotalChanges != 2 {
+		t.Errorf("TotalChanges = %d, want 2", result.TotalChanges)
+	}
+	for _, ar := range result.AgentResults {
+		if len(ar.BeadsChanged) != 1 || ar.BeadsChanged[0] != "proj-a1" {
+			t.Errorf("%s BeadsChanged = %v, want [proj-a1]", ar.Agent.Name, ar.BeadsChanged)
+		}
+	}
+}
+
+// TestRunRound_PromptTemplateSeesAgentName tests that each agent's prompt is
+// rendered with its own name
+func TestRunRound_PromptTemplateSeesAgentName(t *testing.T) {
+	tmpl, err := buckctx.ParseTemplate("named.tmpl", "{{.AgentName}}: {{.Prompt}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	mgr := &mockSessionManager{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	orch.SetContextBuilder(buckctx.NewBuilder(buckctx.WithBeadsClient(&listingBeadsClient{lists: [][]beads.Issue{{}}}), buckctx.WithPromptTemplate(tmpl)))
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}}
+	if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 1}); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	want := []string{"claude: Plan", "codex: Plan"}
+	if strings.Join(mgr.prompts, "|") != strings.Join(want, "|") {
+		t.Errorf("prompts = %q, want %q", mgr.prompts, want)
+	}
+}
+
 // TestRunRound_HandlesAgentFailuresGracefully tests that failures don't stop the round
 func TestRunRound_HandlesAgentFailuresGracefully(t *testing.T) {
 	orch := NewRoundOrchestrator()
@@ -274,6 +330,9 @@ func TestRunRound_SkipsUnauthenticatedAgents(t *testing.T) {
 	if !codexResult.Skipped {
 		t.Error("AgentResult for unauthenticated codex should be Skipped=true")
 	}
+	if codexResult.SkipReason != "not authenticated" {
+		t.Errorf("SkipReason = %q, want %q", codexResult.SkipReason, "not authenticated")
+	}
 
 	// SkippedCount should be 1
 	if result.SkippedCount != 1 {
@@ -281,15 +340,856 @@ func TestRunRound_SkipsUnauthenticatedAgents(t *testing.T) {
 	}
 }
 
+// TestRunRound_SkipsAgentsNearContextLimit tests that an agent whose session
+// starts above the context threshold is skipped without being prompted
+func TestRunRound_SkipsAgentsNearContextLimit(t *testing.T) {
+	mgr := &mockSessionManager{usage: map[string]float64{"codex": 0.95}}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	orch.SetContextSkipThreshold(0.9)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}}
+	result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	if mgr.sends != 1 {
+		t.Errorf("sends = %d, want 1 (codex should not be prompted)", mgr.sends)
+	}
+	if result.SkippedCount != 1 || result.FailedCount != 0 {
+		t.Errorf("SkippedCount = %d, FailedCount = %d, want 1 and 0", result.SkippedCount, result.FailedCount)
+	}
+	codex := result.AgentResults[1]
+	if !codex.Skipped || codex.SkipReason != "context 95% used" {
+		t.Errorf("codex Skipped = %v, SkipReason = %q, want true and %q", codex.Skipped, codex.SkipReason, "context 95% used")
+	}
+	if result.AgentResults[0].Skipped {
+		t.Error("claude is under the threshold and should not be skipped")
+	}
+
+	// Without a threshold nobody is skipped
+	orch.SetContextSkipThreshold(0)
+	if result, _ := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 2}); result.SkippedCount != 0 {
+		t.Errorf("SkippedCount = %d with no threshold, want 0", result.SkippedCount)
+	}
+}
+
+// TestRunRound_RespawnsBeforeLargePrompt tests that a prompt that would push
+// a session past PromptRespawnThreshold is sent to a fresh session instead
+func TestRunRound_RespawnsBeforeLargePrompt(t *testing.T) {
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+
+	// About 150000 tokens, 75% of claude's window, on top of 30% used
+	mgr := &mockSessionManager{usage: map[string]float64{"claude": 0.3}}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	large := strings.Repeat("x", 600000)
+	result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: large, Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if result.FailedCount != 0 {
+		t.Fatalf("FailedCount = %d, want 0: %v", result.FailedCount, result.AgentResults[0].Error)
+	}
+	if mgr.created != 2 {
+		t.Fatalf("created = %d sessions, want 2 (a fresh one for the large prompt)", mgr.created)
+	}
+	if mgr.sessions[0].started {
+		t.Error("the session the prompt would have overflowed should be closed")
+	}
+	if mgr.sends != 1 {
+		t.Errorf("sends = %d, want 1 (only the fresh session is prompted)", mgr.sends)
+	}
+
+	// A prompt that fits is sent to the session as it is
+	mgr = &mockSessionManager{usage: map[string]float64{"claude": 0.3}}
+	orch = NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 1}); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if mgr.created != 1 {
+		t.Errorf("created = %d sessions for a small prompt, want 1", mgr.created)
+	}
+}
+
+// TestRunRound_RoundSummary tests that with SetRoundSummary round 2's prompt
+// opens with a summary of round 1, and that round 1's has none
+func TestRunRound_RoundSummary(t *testing.T) {
+	mgr := &mockSessionManager{output: "$ bd create \"Cache\"\n✓ Created issue: buckshot-a1\n\nThe plan needs a cache."}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	orch.SetContextBuilder(buckctx.NewBuilder(buckctx.WithoutBeads()))
+	orch.SetRoundSummary(true)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}}
+	for round := 1; round <= 2; round++ {
+		if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: round}); err != nil {
+			t.Fatalf("round %d: RunRound() error = %v", round, err)
+		}
+	}
+
+	if len(mgr.prompts) != 4 {
+		t.Fatalf("sent %d prompts, want 4", len(mgr.prompts))
+	}
+	for _, prompt := range mgr.prompts[:2] {
+		if strings.Contains(prompt, "Previous round summary") {
+			t.Errorf("round 1 has no previous round to summarize, got:\n%s", prompt)
+		}
+	}
+	want := `## Previous round summary
+
+Round 1:
+- claude: created buckshot-a1
+  Conclusion: The plan needs a cache.
+- codex: created buckshot-a1
+  Conclusion: The plan needs a cache.
+`
+	for _, prompt := range mgr.prompts[2:] {
+		if !strings.HasPrefix(prompt, want) {
+			t.Errorf("round 2 prompt should open with round 1's summary, got:\n%s", prompt)
+		}
+	}
+}
+
+// TestRunRound_RateLimited tests that sends to agents sharing a provider wait
+// for the provider's rate
+func TestRunRound_RateLimited(t *testing.T) {
+	interval := 40 * time.Millisecond
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	orch.SetRateLimiter(dispatch.NewRateLimiter(
+		map[string]dispatch.Rate{"anthropic": {Count: 1, Per: interval}},
+		map[string]string{"claude": "anthropic", "amp": "anthropic"},
+	))
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "amp", Authenticated: true}}
+	start := time.Now()
+	result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if result.FailedCount != 0 {
+		t.Errorf("FailedCount = %d, want 0", result.FailedCount)
+	}
+	if elapsed := time.Since(start); elapsed < interval {
+		t.Errorf("round took %v, want the second send held back at least %v", elapsed, interval)
+	}
+}
+
+// TestRunRound_RecordsTiming tests that StartedAt and Duration are set around Send
+func TestRunRound_RecordsTiming(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	orch.SetContextBuilder(&mockContextBuilder{})
+
+	before := time.Now()
+	result, err := orch.RunRound(context.Background(), []agent.Agent{
+		{Name: "claude", Authenticated: true},
+		{Name: "codex", Authenticated: false},
+	}, buckctx.PlanningContext{Prompt: "Test prompt", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	ran := result.AgentResults[0]
+	if ran.StartedAt.Before(before) || ran.Duration <= 0 {
+		t.Errorf("StartedAt = %v, Duration = %v; want timing recorded", ran.StartedAt, ran.Duration)
+	}
+
+	skipped := result.AgentResults[1]
+	if !skipped.StartedAt.IsZero() || skipped.Duration != 0 {
+		t.Errorf("skipped agent should have no timing, got %v / %v", skipped.StartedAt, skipped.Duration)
+	}
+}
+
+// TestRunRound_ReportsRoundStartAndComplete tests that round hooks wrap the agent hooks
+func TestRunRound_ReportsRoundStartAndComplete(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	orch.SetContextBuilder(&mockContextBuilder{})
+	reporter := &recordingReporter{}
+	orch.SetProgressReporter(reporter)
+
+	agents := []agent.Agent{
+		{Name: "claude", Authenticated: true},
+		{Name: "codex", Authenticated: true},
+	}
+
+	planCtx := buckctx.PlanningContext{
+		Prompt:      "Test prompt",
+		Round:       2,
+		TotalRounds: 3,
+	}
+
+	if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	want := []string{
+		"round-start 2/3 agents=2",
+		"agent-start claude",
+		"agent-complete claude",
+		"agent-start codex",
+		"agent-complete codex",
+		"round-complete 2 results=2",
+	}
+	if len(reporter.events) != len(want) {
+		t.Fatalf("events = %v, want %v", reporter.events, want)
+	}
+	for i := range want {
+		if reporter.events[i] != want[i] {
+			t.Errorf("events[%d] = %q, want %q", i, reporter.events[i], want[i])
+		}
+	}
+}
+
+// TestRunRound_RotateOrder tests that with rotation each round starts one
+// agent further along, so every agent goes first once per cycle
+func TestRunRound_RotateOrder(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	orch.SetContextBuilder(&mockContextBuilder{})
+	orch.SetRotateOrder(true)
+
+	agents := []agent.Agent{
+		{Name: "claude", Authenticated: true},
+		{Name: "codex", Authenticated: true},
+		{Name: "gemini", Authenticated: true},
+	}
+	want := [][]string{
+		{"claude", "codex", "gemini"},
+		{"codex", "gemini", "claude"},
+		{"gemini", "claude", "codex"},
+		{"claude", "codex", "gemini"},
+	}
+
+	first := map[string]bool{}
+	for round := 1; round <= len(want); round++ {
+		result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: round})
+		if err != nil {
+			t.Fatalf("RunRound(%d) error = %v", round, err)
+		}
+		var got []string
+		for _, r := range result.AgentResults {
+			got = append(got, r.Agent.Name)
+		}
+		if !reflect.DeepEqual(got, want[round-1]) {
+			t.Errorf("round %d order = %v, want %v", round, got, want[round-1])
+		}
+		first[got[0]] = true
+	}
+	if len(first) != len(agents) {
+		t.Errorf("agents that went first = %v, want all %d", first, len(agents))
+	}
+	if agents[0].Name != "claude" {
+		t.Errorf("RunRound() reordered the caller's slice: %v", agents)
+	}
+}
+
+// TestRunRound_AgentsGuidance tests that agents are asked to read AGENTS.md
+// on their first turn in each session, or every round when rereading
+func TestRunRound_AgentsGuidance(t *testing.T) {
+	tests := []struct {
+		name   string
+		reread bool
+		want   []bool
+	}{
+		{"first turn per session", false, []bool{true, false, true}},
+		{"every round", true, []bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &mockSessionManager{}
+			orch := NewRoundOrchestrator()
+			orch.SetSessionManager(mgr)
+			orch.SetContextBuilder(buckctx.NewBuilder(buckctx.WithoutBeads()))
+			orch.SetRereadAgents(tt.reread)
+
+			agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+			for round := 1; round <= len(tt.want); round++ {
+				// The session fills up after round 2 and is replaced for round 3
+				mgr.respawn = round == 3
+				planCtx := buckctx.PlanningContext{Prompt: "Plan", AgentsPath: "AGENTS.md", Round: round}
+				if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+					t.Fatalf("RunRound(%d) error = %v", round, err)
+				}
+			}
+
+			if mgr.created != 2 || len(mgr.prompts) != len(tt.want) {
+				t.Fatalf("created %d sessions and sent %d prompts, want 2 and %d", mgr.created, len(mgr.prompts), len(tt.want))
+			}
+			for i, prompt := range mgr.prompts {
+				if got := strings.Contains(prompt, "please read and apply AGENTS.md"); got != tt.want[i] {
+					t.Errorf("round %d asked to read AGENTS.md = %v, want %v:\n%s", i+1, got, tt.want[i], prompt)
+				}
+			}
+		})
+	}
+}
+
+// TestRunRound_BootstrapPrompt tests that only the first agent to answer in
+// round 1 gets the bootstrap prompt
+func TestRunRound_BootstrapPrompt(t *testing.T) {
+	const bootstrap = "Do a thorough initial analysis"
+	tests := []struct {
+		name string
+		fail string
+		want []bool // Per prompt sent: claude, codex, gemini in round 1, then again in round 2
+	}{
+		{"first agent", "", []bool{true, false, false, false, false, false}},
+		{"first agent fails", "claude", []bool{true, true, false, false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := &mockSessionManager{failForAgent: tt.fail}
+			orch := NewRoundOrchestrator()
+			orch.SetSessionManager(mgr)
+			orch.SetContextBuilder(buckctx.NewBuilder(buckctx.WithoutBeads(), buckctx.WithBootstrapPrompt(bootstrap)))
+
+			agents := []agent.Agent{
+				{Name: "claude", Authenticated: true},
+				{Name: "codex", Authenticated: true},
+				{Name: "gemini", Authenticated: true},
+			}
+			for round := 1; round <= 2; round++ {
+				planCtx := buckctx.PlanningContext{Prompt: "Plan", Round: round}
+				if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+					t.Fatalf("RunRound(%d) error = %v", round, err)
+				}
+			}
+
+			if len(mgr.prompts) != len(tt.want) {
+				t.Fatalf("sent %d prompts, want %d", len(mgr.prompts), len(tt.want))
+			}
+			for i, prompt := range mgr.prompts {
+				if got := strings.Contains(prompt, bootstrap); got != tt.want[i] {
+					t.Errorf("prompt %d has bootstrap = %v, want %v:\n%s", i+1, got, tt.want[i], prompt)
+				}
+			}
+		})
+	}
+}
+
 // Mock implementations for testing
 
+type recordingReporter struct {
+	events []string
+	diffs  []string
+}
+
+func (r *recordingReporter) OnRoundStart(round, totalRounds, agents int) {
+	r.events = append(r.events, fmt.Sprintf("round-start %d/%d agents=%d", round, totalRounds, agents))
+}
+
+func (r *recordingReporter) OnRoundComplete(round int, result RoundResult) {
+	r.events = append(r.events, fmt.Sprintf("round-complete %d results=%d", round, len(result.AgentResults)))
+}
+
+func (r *recordingReporter) OnAgentStart(round, agentIndex, totalAgents int, a agent.Agent) {
+	r.events = append(r.events, "agent-start "+a.Name)
+}
+
+func (r *recordingReporter) OnAgentComplete(round, agentIndex, totalAgents int, result AgentResult, beadsDiff string) {
+	r.events = append(r.events, "agent-complete "+result.Agent.Name)
+	r.diffs = append(r.diffs, beadsDiff)
+}
+
+// listingBeadsClient returns each of lists in turn from ListIssues, and an
+// empty bd list for building contexts.
+type listingBeadsClient struct {
+	beads.Client
+	lists [][]beads.Issue
+	calls int
+}
+
+func (c *listingBeadsClient) List(ctx context.Context, opts beads.ListOpts) (string, error) {
+	return "", nil
+}
+
+func (c *listingBeadsClient) ListIssues(ctx context.Context, opts beads.ListOpts) ([]beads.Issue, error) {
+	out := c.lists[min(c.calls, len(c.lists)-1)]
+	c.calls++
+	return out, nil
+}
+
+// TestRunRound_BeadsClientSnapshots tests that beads are snapshotted through
+// the configured client rather than by running bd
+func TestRunRound_BeadsClientSnapshots(t *testing.T) {
+	a1 := beads.Issue{ID: "buckshot-a1", Title: "Cache", Status: "open"}
+	b2 := beads.Issue{ID: "buckshot-b2", Title: "Metrics", Status: "open"}
+	client := &listingBeadsClient{lists: [][]beads.Issue{{a1}, {a1, b2}}}
+	reporter := &recordingReporter{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	orch.SetProgressReporter(reporter)
+	orch.SetBeadsClient(client)
+
+	if _, err := orch.RunRound(context.Background(), []agent.Agent{{Name: "claude", Authenticated: true}}, buckctx.PlanningContext{Prompt: "Test", Round: 1}); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if client.calls != 2 {
+		t.Errorf("ListIssues called %d times, want before and after the agent", client.calls)
+	}
+	if len(reporter.diffs) != 1 || reporter.diffs[0] != "+ buckshot-b2: Metrics\n" {
+		t.Errorf("beads diff = %q, want the bead added between snapshots", reporter.diffs)
+	}
+}
+
+// TestRunRound_ParallelBeadsDiff tests that agents running in parallel get
+// no beads diff of their own, since the others change the beads meanwhile
+func TestRunRound_ParallelBeadsDiff(t *testing.T) {
+	a1 := beads.Issue{ID: "buckshot-a1", Title: "Cache", Status: "open"}
+	b2 := beads.Issue{ID: "buckshot-b2", Title: "Metrics", Status: "open"}
+	client := &listingBeadsClient{lists: [][]beads.Issue{{a1}, {a1, b2}}}
+	reporter := &recordingReporter{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&barrierSessionManager{expected: 2, all: make(chan struct{})})
+	orch.SetProgressReporter(reporter)
+	orch.SetBeadsClient(client)
+	orch.SetParallel(true)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}}
+	if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Test", Round: 1}); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if client.calls != 0 {
+		t.Errorf("ListIssues called %d times, want none", client.calls)
+	}
+	if !reflect.DeepEqual(reporter.diffs, []string{"", ""}) {
+		t.Errorf("beads diffs = %q, want none", reporter.diffs)
+	}
+}
+
+// TestRunRound_BeadsDiffer tests that the configured differ describes the
+// beads changes passed to the progress reporter
+func TestRunRound_BeadsDiffer(t *testing.T) {
+	a1 := beads.Issue{ID: "buckshot-a1", Title: "Cache", Status: "open"}
+	a1Renamed := beads.Issue{ID: "buckshot-a1", Title: "LRU cache", Status: "open"}
+	client := &listingBeadsClient{lists: [][]beads.Issue{{a1}, {a1Renamed}}}
+	reporter := &recordingReporter{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{})
+	orch.SetProgressReporter(reporter)
+	orch.SetBeadsClient(client)
+	orch.SetBeadsDiffer(StatusDiffer)
+
+	if _, err := orch.RunRound(context.Background(), []agent.Agent{{Name: "claude", Authenticated: true}}, buckctx.PlanningContext{Prompt: "Test", Round: 1}); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if len(reporter.diffs) != 1 || reporter.diffs[0] != "(no changes)" {
+		t.Errorf("beads diff = %q, want a retitle ignored by the status differ", reporter.diffs)
+	}
+}
+
+// TestRunRound_AgentTimeout tests that a slow agent times out and the round continues
+func TestRunRound_AgentTimeout(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{slowAgent: "slow", delay: time.Second})
+	orch.SetAgentTimeout(20 * time.Millisecond)
+
+	agents := []agent.Agent{
+		{Name: "slow", Authenticated: true},
+		{Name: "fast", Authenticated: true},
+	}
+
+	start := time.Now()
+	result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Test", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("RunRound() took %s, the slow agent should have been cut off", elapsed)
+	}
+
+	if result.FailedCount != 1 {
+		t.Errorf("FailedCount = %d, want 1", result.FailedCount)
+	}
+	slow := result.AgentResults[0]
+	if !errors.Is(slow.Error, context.DeadlineExceeded) || !strings.Contains(slow.Error.Error(), "slow timed out after 20ms") {
+		t.Errorf("slow agent error = %v, want a timeout", slow.Error)
+	}
+	if fast := result.AgentResults[1]; fast.Error != nil || fast.Response.Output != "Mock response" {
+		t.Errorf("fast agent should still run after the timeout, got %+v", fast)
+	}
+}
+
+// TestRunRound_AgentTimeoutNotHit tests that a generous timeout leaves agents alone
+func TestRunRound_AgentTimeoutNotHit(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{slowAgent: "slow", delay: 5 * time.Millisecond})
+	orch.SetAgentTimeout(time.Second)
+
+	result, err := orch.RunRound(context.Background(), []agent.Agent{{Name: "slow", Authenticated: true}}, buckctx.PlanningContext{Prompt: "Test", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if result.FailedCount != 0 || result.AgentResults[0].Error != nil {
+		t.Errorf("agent within its timeout should succeed, got %+v", result.AgentResults[0])
+	}
+}
+
+// TestRunRound_RoundTimeout tests that once a round runs out of time the
+// running agent is cut off, the rest are skipped, and the next round starts
+// with a fresh budget
+func TestRunRound_RoundTimeout(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{allSlow: true, delay: 200 * time.Millisecond})
+	orch.SetRoundTimeout(300 * time.Millisecond)
+
+	agents := []agent.Agent{
+		{Name: "first", Authenticated: true},
+		{Name: "second", Authenticated: true},
+		{Name: "third", Authenticated: true},
+	}
+
+	for round := 1; round <= 2; round++ {
+		start := time.Now()
+		result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Test", Round: round})
+		if err != nil {
+			t.Fatalf("round %d: RunRound() error = %v", round, err)
+		}
+		if elapsed := time.Since(start); elapsed > 550*time.Millisecond {
+			t.Errorf("round %d took %s, want it cut off near 300ms", round, elapsed)
+		}
+
+		if first := result.AgentResults[0]; first.Error != nil || first.Skipped {
+			t.Errorf("round %d: first agent should finish within the round, got %+v", round, first)
+		}
+		second := result.AgentResults[1]
+		if !errors.Is(second.Error, context.DeadlineExceeded) || !strings.Contains(second.Error.Error(), "second cut off: round timed out after 300ms") {
+			t.Errorf("round %d: second agent error = %v, want it cut off by the round timeout", round, second.Error)
+		}
+		if third := result.AgentResults[2]; !third.Skipped || third.SkipReason != SkipRoundTimeout {
+			t.Errorf("round %d: third agent should be skipped for the round timeout, got %+v", round, third)
+		}
+		if result.FailedCount != 1 || result.SkippedCount != 1 {
+			t.Errorf("round %d: failed %d, skipped %d; want 1 and 1", round, result.FailedCount, result.SkippedCount)
+		}
+	}
+}
+
+// TestRunRound_RoundTimeoutKeepsSessions tests that the round's deadline
+// ending doesn't end the sessions started in it, so the next round reuses them
+func TestRunRound_RoundTimeoutKeepsSessions(t *testing.T) {
+	mgr := &mockSessionManager{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	orch.SetRoundTimeout(time.Minute)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+	for round := 1; round <= 2; round++ {
+		if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Test", Round: round}); err != nil {
+			t.Fatalf("round %d: RunRound() error = %v", round, err)
+		}
+	}
+
+	if mgr.created != 1 || mgr.sends != 2 {
+		t.Errorf("created %d sessions for %d sends, want round 2 to reuse round 1's session", mgr.created, mgr.sends)
+	}
+	if !mgr.sessions[0].IsAlive() {
+		t.Error("the session should outlive the round that started it")
+	}
+}
+
+// TestRunRound_DropsAbandonedSessions tests that a session whose turn timed
+// out or returned partial output is closed rather than kept, so the agent's
+// late answer can't be taken for the next round's
+func TestRunRound_DropsAbandonedSessions(t *testing.T) {
+	tests := []struct {
+		name string
+		mgr  *mockSessionManager
+		opts func(RoundOrchestrator)
+	}{
+		{"agent timeout", &mockSessionManager{allSlow: true, delay: time.Second}, func(o RoundOrchestrator) { o.SetAgentTimeout(20 * time.Millisecond) }},
+		{"round timeout", &mockSessionManager{allSlow: true, delay: time.Second}, func(o RoundOrchestrator) { o.SetRoundTimeout(20 * time.Millisecond) }},
+		{"partial output", &mockSessionManager{partial: true}, func(o RoundOrchestrator) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch := NewRoundOrchestrator()
+			orch.SetSessionManager(tt.mgr)
+			tt.opts(orch)
+
+			agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+			for round := 1; round <= 2; round++ {
+				if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Test", Round: round}); err != nil {
+					t.Fatalf("round %d: RunRound() error = %v", round, err)
+				}
+			}
+
+			if tt.mgr.created != 2 {
+				t.Errorf("created %d sessions, want a fresh one for round 2", tt.mgr.created)
+			}
+			if tt.mgr.sessions[0].started {
+				t.Error("the abandoned session should be closed")
+			}
+		})
+	}
+}
+
+// TestRunRound_ResponseCache tests that an identical prompt reuses the cached
+// response instead of sending again, until the beads state changes
+func TestRunRound_ResponseCache(t *testing.T) {
+	mgr := &mockSessionManager{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	cache := NewResponseCache()
+	orch.SetResponseCache(cache)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+	planCtx := buckctx.PlanningContext{Prompt: "Plan it", BeadsState: "state A", Round: 1, IsFirstTurn: true}
+
+	if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	// Round 2 with unchanged beads is a cache hit
+	planCtx.Round, planCtx.IsFirstTurn = 2, false
+	result, err := orch.RunRound(context.Background(), agents, planCtx)
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if mgr.sends != 1 {
+		t.Errorf("Send called %d times, want 1 (second round should hit the cache)", mgr.sends)
+	}
+	hit := result.AgentResults[0]
+	if !hit.Cached || hit.Response.Output != "Mock response" || hit.Error != nil {
+		t.Errorf("cached result = %+v, want the earlier response", hit)
+	}
+
+	// Changed beads invalidate the cache
+	planCtx.Round, planCtx.BeadsState = 3, "state B"
+	result, err = orch.RunRound(context.Background(), agents, planCtx)
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if mgr.sends != 2 || result.AgentResults[0].Cached {
+		t.Errorf("Send called %d times, cached=%v; want a fresh send after beads changed", mgr.sends, result.AgentResults[0].Cached)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("cache holds %d entries, want only the one for the new beads state", cache.Len())
+	}
+}
+
+// TestRunRound_ResponseCacheSkipsFailures tests that failed responses are not cached
+func TestRunRound_ResponseCacheSkipsFailures(t *testing.T) {
+	mgr := &mockSessionManager{failForAgent: "claude"}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	orch.SetResponseCache(NewResponseCache())
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+	planCtx := buckctx.PlanningContext{Prompt: "Plan it", Round: 1}
+	for round := 1; round <= 2; round++ {
+		planCtx.Round = round
+		if _, err := orch.RunRound(context.Background(), agents, planCtx); err != nil {
+			t.Fatalf("RunRound() error = %v", err)
+		}
+	}
+	if mgr.sends != 2 {
+		t.Errorf("Send called %d times, want 2 (failures must not be cached)", mgr.sends)
+	}
+}
+
+// TestRunRound_NotAuthenticatedSkips tests that a session refused for missing
+// credentials counts as skipped rather than failed
+func TestRunRound_NotAuthenticatedSkips(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{createErr: fmt.Errorf("%w: claude", session.ErrNotAuthenticated)})
+
+	result, err := orch.RunRound(context.Background(), []agent.Agent{{Name: "claude", Authenticated: true}}, buckctx.PlanningContext{Prompt: "Test", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if result.SkippedCount != 1 || result.FailedCount != 0 || !result.AgentResults[0].Skipped {
+		t.Errorf("got skipped=%d failed=%d, want the agent skipped", result.SkippedCount, result.FailedCount)
+	}
+	if got := result.AgentResults[0].SkipReason; got != SkipNotAuthenticated {
+		t.Errorf("SkipReason = %q, want %q", got, SkipNotAuthenticated)
+	}
+}
+
+// TestRunRound_ProcessExitedRetries tests that an agent whose process dies
+// mid-turn is retried once in a fresh session
+func TestRunRound_ProcessExitedRetries(t *testing.T) {
+	mgr := &mockSessionManager{exits: 1}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+
+	result, err := orch.RunRound(context.Background(), []agent.Agent{{Name: "claude", Authenticated: true}}, buckctx.PlanningContext{Prompt: "Test", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if ar := result.AgentResults[0]; ar.Error != nil || ar.Response.Output != "Mock response" {
+		t.Errorf("retried agent = %+v, want the response from the fresh session", ar)
+	}
+	if mgr.created != 2 || mgr.sends != 2 {
+		t.Errorf("created %d sessions and sent %d times, want 2 of each", mgr.created, mgr.sends)
+	}
+}
+
+// TestRunRound_AgentCrashReportsExitCode tests that an agent that keeps
+// crashing mid-turn fails with its exit code and stderr, even though it
+// wrote a partial answer first
+func TestRunRound_AgentCrashReportsExitCode(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "agent")
+	body := "#!/bin/sh\necho '10% used'\nread -r line\necho 'Partial answer'\necho 'fatal: out of memory' >&2\nexit 3\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(session.NewManager())
+
+	ag := agent.Agent{Name: "claude", Path: script, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: agent.FramingJSON}}
+	result, err := orch.RunRound(context.Background(), []agent.Agent{ag}, buckctx.PlanningContext{Prompt: "Test", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	ar := result.AgentResults[0]
+	if result.FailedCount != 1 || ar.Error == nil {
+		t.Fatalf("got failed=%d error=%v, want the crash reported", result.FailedCount, ar.Error)
+	}
+	for _, want := range []string{"exited with code 3", "fatal: out of memory"} {
+		if !strings.Contains(ar.Error.Error(), want) {
+			t.Errorf("AgentResult.Error = %q, want it to mention %q", ar.Error, want)
+		}
+	}
+}
+
+// TestRunRound_ProcessExitedRetriesOnce tests that a second exit fails the agent
+func TestRunRound_ProcessExitedRetriesOnce(t *testing.T) {
+	mgr := &mockSessionManager{exits: 2}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+
+	result, err := orch.RunRound(context.Background(), []agent.Agent{{Name: "claude", Authenticated: true}}, buckctx.PlanningContext{Prompt: "Test", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+	if result.FailedCount != 1 || !errors.Is(result.AgentResults[0].Error, session.ErrProcessExited) {
+		t.Errorf("got failed=%d error=%v, want ErrProcessExited after one retry", result.FailedCount, result.AgentResults[0].Error)
+	}
+	if mgr.sends != 2 {
+		t.Errorf("sent %d times, want 2", mgr.sends)
+	}
+}
+
+// TestRunRound_ReusesSessionsAcrossRounds tests that each agent's session
+// answers every round until the orchestrator is closed
+func TestRunRound_ReusesSessionsAcrossRounds(t *testing.T) {
+	mgr := &mockSessionManager{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}}
+	for round := 1; round <= 3; round++ {
+		if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: round}); err != nil {
+			t.Fatalf("RunRound(%d) error = %v", round, err)
+		}
+	}
+	if len(mgr.sessions) != 2 {
+		t.Fatalf("created %d sessions over 3 rounds, want 2", len(mgr.sessions))
+	}
+	for _, sess := range mgr.sessions {
+		if !sess.IsAlive() {
+			t.Errorf("%s session closed before the run ended", sess.agent.Name)
+		}
+	}
+
+	if err := orch.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	for _, sess := range mgr.sessions {
+		if sess.IsAlive() {
+			t.Errorf("%s session still alive after Close", sess.agent.Name)
+		}
+	}
+}
+
+// TestWarmUp_ReusesSessions tests that warmed-up sessions carry across rounds
+// instead of being created for every turn
+func TestWarmUp_ReusesSessions(t *testing.T) {
+	mgr := &mockSessionManager{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}, {Name: "codex", Authenticated: true}, {Name: "gemini"}}
+	if err := orch.WarmUp(context.Background(), agents, ""); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if mgr.created != 2 {
+		t.Fatalf("WarmUp created %d sessions, want 2 (unauthenticated agents are not started)", mgr.created)
+	}
+
+	for round := 1; round <= 2; round++ {
+		result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: round})
+		if err != nil {
+			t.Fatalf("RunRound(%d) error = %v", round, err)
+		}
+		if result.FailedCount != 0 || result.SkippedCount != 1 {
+			t.Errorf("round %d FailedCount = %d, SkippedCount = %d, want 0 and 1", round, result.FailedCount, result.SkippedCount)
+		}
+	}
+	if mgr.created != 2 || mgr.sends != 4 {
+		t.Errorf("created %d sessions and sent %d times, want 2 and 4", mgr.created, mgr.sends)
+	}
+
+	if err := orch.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: 3}); err != nil {
+		t.Fatalf("RunRound() after Close error = %v", err)
+	}
+	if mgr.created != 4 {
+		t.Errorf("created %d sessions, want 4 (fresh sessions after Close)", mgr.created)
+	}
+}
+
+// TestWarmUp_RespawnsFullSessions tests that a kept session is replaced once
+// its context passes the respawn threshold
+func TestWarmUp_RespawnsFullSessions(t *testing.T) {
+	mgr := &mockSessionManager{}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	defer orch.Close()
+
+	agents := []agent.Agent{{Name: "claude", Authenticated: true}}
+	if err := orch.WarmUp(context.Background(), agents, ""); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+
+	mgr.respawn = true
+	for round := 1; round <= 2; round++ {
+		if _, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Plan", Round: round}); err != nil {
+			t.Fatalf("RunRound(%d) error = %v", round, err)
+		}
+	}
+	if mgr.created != 3 {
+		t.Errorf("created %d sessions, want 3 (one per round after warm-up)", mgr.created)
+	}
+}
+
+// TestWarmUp_ReportsCreateErrors tests that agents whose sessions cannot be
+// created are reported and left to be created per turn
+func TestWarmUp_ReportsCreateErrors(t *testing.T) {
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(&mockSessionManager{createErr: errors.New("boom")})
+
+	err := orch.WarmUp(context.Background(), []agent.Agent{{Name: "claude", Authenticated: true}}, "")
+	if err == nil || !strings.Contains(err.Error(), "claude: boom") {
+		t.Errorf("WarmUp() error = %v, want it to name claude", err)
+	}
+}
+
 type mockContextBuilder struct {
 	beadsStates  []string
 	refreshCalls int
 	currentIdx   int
 }
 
-func (m *mockContextBuilder) Build(prompt string, agentsPath string, round int, isFirstTurn bool) (buckctx.PlanningContext, error) {
+func (m *mockContextBuilder) Build(_ context.Context, prompt string, agentsPath string, round int, isFirstTurn bool) (buckctx.PlanningContext, error) {
 	return buckctx.PlanningContext{
 		Prompt:      prompt,
 		AgentsPath:  agentsPath,
@@ -306,10 +1206,10 @@ func (m *mockContextBuilder) FormatFeedback(ctx buckctx.PlanningContext) string
 	return ctx.Prompt
 }
 
-func (m *mockContextBuilder) RefreshBeadsState(ctx *buckctx.PlanningContext) error {
+func (m *mockContextBuilder) RefreshBeadsState(_ context.Context, planCtx *buckctx.PlanningContext) error {
 	m.refreshCalls++
 	if m.currentIdx < len(m.beadsStates) {
-		ctx.BeadsState = m.beadsStates[m.currentIdx]
+		planCtx.BeadsState = m.beadsStates[m.currentIdx]
 		m.currentIdx++
 	}
 	return nil
@@ -317,43 +1217,102 @@ func (m *mockContextBuilder) RefreshBeadsState(ctx *buckctx.PlanningContext) err
 
 type mockSessionManager struct {
 	failForAgent string
+	slowAgent    string
+	allSlow      bool // Delay every agent, not just slowAgent
+	delay        time.Duration
+	sends        int
+	created      int
+	createErr    error // Returned by CreateSession when set
+	exits        int   // Number of sends that fail with session.ErrProcessExited
+	output       string
+	prompts      []string           // Every prompt sent, in order
+	usage        map[string]float64 // Context usage reported by each agent's session
+	respawn      bool               // Returned by ShouldRespawn
+	partial      bool               // Whether sends return before the turn ends
+	sessions     []*mockSession     // Every session created, in order
 }
 
 func (m *mockSessionManager) CreateSession(a agent.Agent) (session.Session, error) {
-	return &mockSession{agent: a, shouldFail: a.Name == m.failForAgent}, nil
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	m.created++
+	sess := &mockSession{agent: a, shouldFail: a.Name == m.failForAgent, sends: &m.sends, exits: &m.exits, output: m.output, prompts: &m.prompts, usage: m.usage[a.Name], partial: m.partial}
+	if a.Name == m.slowAgent || m.allSlow {
+		sess.delay = m.delay
+	}
+	m.sessions = append(m.sessions, sess)
+	return sess, nil
 }
 
 func (m *mockSessionManager) ShouldRespawn(s session.Session, threshold float64) bool {
-	return false
+	return m.respawn
 }
 
 type mockSession struct {
 	agent      agent.Agent
 	shouldFail bool
 	started    bool
+	delay      time.Duration
+	sends      *int
+	exits      *int
+	output     string
+	prompts    *[]string
+	usage      float64
+	ctx        context.Context // The context the session was started with
+	partial    bool
 }
 
 func (s *mockSession) Start(ctx context.Context, agentsPath string) error {
 	s.started = true
+	s.ctx = ctx
 	return nil
 }
 
 func (s *mockSession) Send(ctx context.Context, prompt string) (session.Response, error) {
+	if s.sends != nil {
+		*s.sends++
+	}
+	if s.prompts != nil {
+		*s.prompts = append(*s.prompts, prompt)
+	}
 	if s.shouldFail {
 		return session.Response{Error: context.DeadlineExceeded}, context.DeadlineExceeded
 	}
+	if s.exits != nil && *s.exits > 0 {
+		*s.exits--
+		s.started = false
+		return session.Response{Error: session.ErrProcessExited}, session.ErrProcessExited
+	}
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return session.Response{Error: ctx.Err()}, ctx.Err()
+		}
+	}
+	output := s.output
+	if output == "" {
+		output = "Mock response"
+	}
 	return session.Response{
-		Output:       "Mock response",
+		Output:       output,
 		ContextUsage: 0.1,
+		Partial:      s.partial,
 	}, nil
 }
 
 func (s *mockSession) ContextUsage() float64 {
+	if s.usage > 0 {
+		return s.usage
+	}
 	return 0.1
 }
 
+// IsAlive reports whether the session is started and, like a process run
+// with exec.CommandContext, its start context hasn't been canceled.
 func (s *mockSession) IsAlive() bool {
-	return s.started
+	return s.started && (s.ctx == nil || s.ctx.Err() == nil)
 }
 
 func (s *mockSession) Agent() agent.Agent {
@@ -364,3 +1323,91 @@ func (s *mockSession) Close() error {
 	s.started = false
 	return nil
 }
+
+func TestRoundResult_SucceededCount(t *testing.T) {
+	result := RoundResult{
+		AgentResults: make([]AgentResult, 5),
+		FailedCount:  2,
+		SkippedCount: 1,
+	}
+	if got := result.SucceededCount(); got != 2 {
+		t.Errorf("SucceededCount() = %d, want 2", got)
+	}
+}
+
+// barrierSessionManager hands out sessions whose Send waits until every
+// expected agent is mid-turn at once, failing the turn after a second.
+type barrierSessionManager struct {
+	mu       sync.Mutex
+	expected int
+	inFlight int
+	all      chan struct{}
+}
+
+func (m *barrierSessionManager) CreateSession(a agent.Agent) (session.Session, error) {
+	return &barrierSession{mockSession: mockSession{agent: a}, mgr: m}, nil
+}
+
+func (m *barrierSessionManager) ShouldRespawn(s session.Session, threshold float64) bool {
+	return false
+}
+
+type barrierSession struct {
+	mockSession
+	mgr *barrierSessionManager
+}
+
+func (s *barrierSession) Send(ctx context.Context, prompt string) (session.Response, error) {
+	s.mgr.mu.Lock()
+	s.mgr.inFlight++
+	if s.mgr.inFlight == s.mgr.expected {
+		close(s.mgr.all)
+	}
+	s.mgr.mu.Unlock()
+
+	select {
+	case <-s.mgr.all:
+		return session.Response{Output: "bd update buckshot-1 --status in_progress"}, nil
+	case <-time.After(time.Second):
+		return session.Response{}, errors.New("agents did not run at once")
+	}
+}
+
+// TestRunRound_Parallel tests that with SetParallel every agent's turn runs
+// at once against the round's starting beads state, with results reported
+// in agent order
+func TestRunRound_Parallel(t *testing.T) {
+	mgr := &barrierSessionManager{expected: 3, all: make(chan struct{})}
+	builder := &mockContextBuilder{beadsStates: []string{"after round 1"}}
+	orch := NewRoundOrchestrator()
+	orch.SetSessionManager(mgr)
+	orch.SetContextBuilder(builder)
+	orch.SetParallel(true)
+
+	agents := []agent.Agent{
+		{Name: "claude", Authenticated: true},
+		{Name: "codex", Authenticated: true},
+		{Name: "amp", Authenticated: false},
+		{Name: "gemini", Authenticated: true},
+	}
+	result, err := orch.RunRound(context.Background(), agents, buckctx.PlanningContext{Prompt: "Test prompt", Round: 1})
+	if err != nil {
+		t.Fatalf("RunRound() error = %v", err)
+	}
+
+	for i, ar := range result.AgentResults {
+		if ar.Agent.Name != agents[i].Name {
+			t.Errorf("result %d is %s, want %s", i, ar.Agent.Name, agents[i].Name)
+		}
+		if ar.Error != nil {
+			t.Errorf("%s failed: %v", ar.Agent.Name, ar.Error)
+		}
+	}
+	if result.TotalChanges != 3 || result.FailedCount != 0 || result.SkippedCount != 1 {
+		t.Errorf("TotalChanges = %d, FailedCount = %d, SkippedCount = %d; want 3, 0, 1", result.TotalChanges, result.FailedCount, result.SkippedCount)
+	}
+	// Only the refresh for the next round; no agent waits on another's changes
+	if builder.refreshCalls != 1 {
+		t.Errorf("RefreshBeadsState() called %d times, want 1", builder.refreshCalls)
+	}
+}
@@ -0,0 +1,45 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	buckctx "github.com/michaellady/buckshot/internal/context"
+)
+
+// maxConclusionLength caps each agent's conclusion in a round summary, in
+// characters, so the summary stays short next to the beads state.
+const maxConclusionLength = 300
+
+// summarizeRound condenses result into the summary put before the next
+// round's prompt.
+func summarizeRound(result RoundResult) *buckctx.RoundSummary {
+	summary := &buckctx.RoundSummary{Round: result.Round}
+	for _, ar := range result.AgentResults {
+		as := buckctx.AgentSummary{Name: ar.Agent.Name}
+		switch {
+		case ar.Skipped:
+			as.Outcome = "skipped: " + ar.SkipReason
+		case ar.Error != nil:
+			as.Outcome = "failed: " + ar.Error.Error()
+		default:
+			for _, c := range ar.BeadChanges {
+				as.Changes = append(as.Changes, fmt.Sprintf("%s %s", c.Action, c.ID))
+			}
+			as.Conclusion = conclusion(ar.Response.Output)
+		}
+		summary.Agents = append(summary.Agents, as)
+	}
+	return summary
+}
+
+// conclusion returns the last paragraph of an agent's response on one line,
+// where agents usually sum up, cut to maxConclusionLength characters.
+func conclusion(output string) string {
+	paragraphs := strings.Split(strings.TrimSpace(output), "\n\n")
+	last := strings.Join(strings.Fields(paragraphs[len(paragraphs)-1]), " ")
+	if runes := []rune(last); len(runes) > maxConclusionLength {
+		last = strings.TrimRight(string(runes[:maxConclusionLength-1]), " ") + "…"
+	}
+	return last
+}
@@ -0,0 +1,59 @@
+package orchestrator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	buckctx "github.com/michaellady/buckshot/internal/context"
+	"github.com/michaellady/buckshot/internal/session"
+)
+
+func TestSummarizeRound(t *testing.T) {
+	result := RoundResult{
+		Round: 2,
+		AgentResults: []AgentResult{
+			{
+				Agent:       agent.Agent{Name: "claude"},
+				Response:    session.Response{Output: "Ran bd create.\n\nAdded a cache bead;\nthe API still needs tests."},
+				BeadChanges: []BeadChange{{ID: "buckshot-a1", Action: BeadCreated}, {ID: "buckshot-b2", Action: BeadUpdated}},
+			},
+			{Agent: agent.Agent{Name: "codex"}, Error: errors.New("timed out"), Response: session.Response{Output: "partial"}},
+			{Agent: agent.Agent{Name: "gemini"}, Skipped: true, SkipReason: SkipNotAuthenticated},
+		},
+	}
+
+	want := &buckctx.RoundSummary{
+		Round: 2,
+		Agents: []buckctx.AgentSummary{
+			{Name: "claude", Changes: []string{"created buckshot-a1", "updated buckshot-b2"}, Conclusion: "Added a cache bead; the API still needs tests."},
+			{Name: "codex", Outcome: "failed: timed out"},
+			{Name: "gemini", Outcome: "skipped: not authenticated"},
+		},
+	}
+	if got := summarizeRound(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeRound() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConclusion(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"last paragraph", "First I read the beads.\n\nThe plan is complete.\n", "The plan is complete."},
+		{"single line", "Looks good", "Looks good"},
+		{"empty", "", ""},
+		{"long", strings.Repeat("word ", 100), strings.Repeat("word ", 59) + "word…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conclusion(tt.output); got != tt.want {
+				t.Errorf("conclusion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
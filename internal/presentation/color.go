@@ -0,0 +1,33 @@
+package presentation
+
+// ANSI escape sequences used when color is enabled.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiDim    = "\x1b[2m"
+)
+
+// palette wraps text in ANSI colors when enabled. Pad text before painting it
+// so escape codes don't count toward column widths.
+type palette bool
+
+func (p palette) paint(code, s string) string {
+	if !p || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// success colors text green.
+func (p palette) success(s string) string { return p.paint(ansiGreen, s) }
+
+// failure colors text red.
+func (p palette) failure(s string) string { return p.paint(ansiRed, s) }
+
+// warning colors text yellow.
+func (p palette) warning(s string) string { return p.paint(ansiYellow, s) }
+
+// dim renders text faint.
+func (p palette) dim(s string) string { return p.paint(ansiDim, s) }
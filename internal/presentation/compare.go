@@ -0,0 +1,174 @@
+package presentation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DiffOp classifies a line in a comparison.
+type DiffOp int
+
+const (
+	// DiffShared marks a point both agents made.
+	DiffShared DiffOp = iota
+	// DiffLeft marks a point only the left agent made.
+	DiffLeft
+	// DiffRight marks a point only the right agent made.
+	DiffRight
+)
+
+// DiffLine is one sentence in a comparison.
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// Comparison is a sentence-level diff of two agents' responses.
+type Comparison struct {
+	Left  string     // Left agent name
+	Right string     // Right agent name
+	Lines []DiffLine // Diff in response order
+}
+
+// Count returns how many lines have the given op.
+func (c Comparison) Count(op DiffOp) int {
+	n := 0
+	for _, l := range c.Lines {
+		if l.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+// Compare diffs two agents' responses sentence by sentence.
+// Sentences are matched after normalizing case, whitespace, list markers,
+// and trailing punctuation, so formatting differences don't count as
+// disagreement.
+func Compare(left, right AgentResult) Comparison {
+	a := splitSentences(left.Response.Output)
+	b := splitSentences(right.Response.Output)
+
+	return Comparison{
+		Left:  left.Agent.Name,
+		Right: right.Agent.Name,
+		Lines: diffSentences(a, b),
+	}
+}
+
+// FormatComparison renders a comparison as a unified diff with a summary.
+// With color, lines only in the left response are red and lines only in the
+// right are green.
+func FormatComparison(c Comparison, color bool) string {
+	var sb strings.Builder
+	p := palette(color)
+
+	sb.WriteString(p.failure(fmt.Sprintf("--- %s", c.Left)) + "\n")
+	sb.WriteString(p.success(fmt.Sprintf("+++ %s", c.Right)) + "\n")
+
+	for _, l := range c.Lines {
+		switch l.Op {
+		case DiffLeft:
+			sb.WriteString(p.failure("- " + l.Text))
+		case DiffRight:
+			sb.WriteString(p.success("+ " + l.Text))
+		default:
+			sb.WriteString("  " + l.Text)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\nShared: %d, only %s: %d, only %s: %d\n",
+		c.Count(DiffShared), c.Left, c.Count(DiffLeft), c.Right, c.Count(DiffRight)))
+
+	return sb.String()
+}
+
+var (
+	sentenceEnd = regexp.MustCompile(`([.!?])\s+`)
+	listMarker  = regexp.MustCompile(`^(\s*([-*•]|\d+[.)])\s+)`)
+)
+
+// splitSentences breaks text into non-empty lines, then sentences.
+func splitSentences(text string) []string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// Keep a list marker like "1. " with its sentence
+		marker := listMarker.FindString(line)
+		body := sentenceEnd.ReplaceAllString(line[len(marker):], "$1\n")
+		for i, s := range strings.Split(body, "\n") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			if i == 0 {
+				s = marker + s
+			}
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// normalizeSentence reduces a sentence to a comparison key.
+func normalizeSentence(s string) string {
+	s = listMarker.ReplaceAllString(s, "")
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	return strings.TrimRight(s, ".!?:;,")
+}
+
+// diffSentences computes an LCS-based diff of two sentence lists.
+func diffSentences(a, b []string) []DiffLine {
+	ka := make([]string, len(a))
+	for i, s := range a {
+		ka[i] = normalizeSentence(s)
+	}
+	kb := make([]string, len(b))
+	for i, s := range b {
+		kb[i] = normalizeSentence(s)
+	}
+
+	// lcs[i][j] is the LCS length of ka[i:] and kb[j:]
+	lcs := make([][]int, len(ka)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(kb)+1)
+	}
+	for i := len(ka) - 1; i >= 0; i-- {
+		for j := len(kb) - 1; j >= 0; j-- {
+			if ka[i] == kb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case ka[i] == kb[j]:
+			lines = append(lines, DiffLine{Op: DiffShared, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: DiffLeft, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffRight, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: DiffLeft, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: DiffRight, Text: b[j]})
+	}
+
+	return lines
+}
@@ -0,0 +1,90 @@
+package presentation
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCompare_SharedAndDivergent verifies the diff structure for two canned responses.
+func TestCompare_SharedAndDivergent(t *testing.T) {
+	left := makeResult("claude", "Use JWT for sessions. Add rate limiting.\n- Store refresh tokens in Redis.", nil, 0)
+	right := makeResult("codex", "use JWT for sessions!\n\n1. Store refresh tokens in Postgres.\n2. Add rate limiting", nil, 0)
+
+	c := Compare(left, right)
+
+	want := []DiffLine{
+		{DiffShared, "Use JWT for sessions."},
+		{DiffRight, "1. Store refresh tokens in Postgres."},
+		{DiffShared, "Add rate limiting."},
+		{DiffLeft, "- Store refresh tokens in Redis."},
+	}
+	if len(c.Lines) != len(want) {
+		t.Fatalf("Compare() lines = %+v, want %+v", c.Lines, want)
+	}
+	for i := range want {
+		if c.Lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, c.Lines[i], want[i])
+		}
+	}
+
+	if c.Count(DiffShared) != 2 || c.Count(DiffLeft) != 1 || c.Count(DiffRight) != 1 {
+		t.Errorf("counts = %d shared / %d left / %d right", c.Count(DiffShared), c.Count(DiffLeft), c.Count(DiffRight))
+	}
+}
+
+// TestCompare_Identical verifies identical responses are fully shared.
+func TestCompare_Identical(t *testing.T) {
+	r := "Split the work into three beads. Start with the schema."
+	c := Compare(makeResult("a", r, nil, 0), makeResult("b", r, nil, 0))
+
+	if c.Count(DiffShared) != 2 || len(c.Lines) != 2 {
+		t.Errorf("identical responses should be all shared, got %+v", c.Lines)
+	}
+}
+
+// TestFormatComparison verifies the unified rendering and summary.
+func TestFormatComparison(t *testing.T) {
+	c := Comparison{
+		Left:  "claude",
+		Right: "codex",
+		Lines: []DiffLine{
+			{DiffShared, "Agree on this."},
+			{DiffLeft, "Claude only."},
+			{DiffRight, "Codex only."},
+		},
+	}
+
+	out := FormatComparison(c, false)
+
+	for _, want := range []string{
+		"--- claude\n+++ codex\n",
+		"  Agree on this.\n",
+		"- Claude only.\n",
+		"+ Codex only.\n",
+		"Shared: 1, only claude: 1, only codex: 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatComparison() missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestFormatComparison_Color verifies diff lines are colored only when asked.
+func TestFormatComparison_Color(t *testing.T) {
+	c := Comparison{
+		Left:  "claude",
+		Right: "codex",
+		Lines: []DiffLine{{DiffShared, "Same."}, {DiffLeft, "Left."}, {DiffRight, "Right."}},
+	}
+
+	if out := FormatComparison(c, false); strings.Contains(out, "\x1b[") {
+		t.Errorf("uncolored output should have no escape codes, got %q", out)
+	}
+
+	out := FormatComparison(c, true)
+	for _, want := range []string{ansiRed + "- Left." + ansiReset, ansiGreen + "+ Right." + ansiReset, "\n  Same.\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("colored output missing %q, got %q", want, out)
+		}
+	}
+}
@@ -3,11 +3,15 @@ package presentation
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/michaellady/buckshot/internal/dispatch"
+	"github.com/michaellady/buckshot/internal/logging"
 )
 
 // OutputFormat specifies the output format for results.
@@ -20,14 +24,29 @@ const (
 	FormatJSON
 	// FormatMarkdown outputs markdown for saving.
 	FormatMarkdown
+	// FormatMarkdownTable outputs a compact markdown table with each full
+	// response folded into a <details> block, for pasting into pull requests.
+	FormatMarkdownTable
 )
 
+// tableSummaryWidth caps the response excerpt shown in a markdown table row.
+const tableSummaryWidth = 80
+
 // AgentResult extends dispatch.Result with presentation metadata.
 type AgentResult struct {
 	dispatch.Result
 	Duration time.Duration // How long the agent took to respond
 }
 
+// emptyResponseNote stands in for the response of an agent that succeeded
+// without saying anything, which usually means its output wasn't parsed.
+const emptyResponseNote = "(empty response; check the agent's output format and parser)"
+
+// Empty reports whether the agent succeeded but returned no output.
+func (r AgentResult) Empty() bool {
+	return r.Error == nil && strings.TrimSpace(r.Response.Output) == ""
+}
+
 // Formatter handles formatting of dispatch results.
 type Formatter interface {
 	// Format formats results in the specified output format.
@@ -35,17 +54,32 @@ type Formatter interface {
 
 	// SetMaxResponseLength sets the maximum response length before truncation.
 	SetMaxResponseLength(length int)
+
+	// SetTruncateStrategy sets which part of a long response is kept.
+	// Default is TruncateHead.
+	SetTruncateStrategy(strategy TruncateStrategy)
+
+	// SetColor enables ANSI colors in terminal output. Off by default.
+	SetColor(enabled bool)
+
+	// SetLogger sets where formatting problems are reported.
+	// Default is logging.Default().
+	SetLogger(logger *logging.Logger)
 }
 
 // formatter is the default implementation.
 type formatter struct {
 	maxResponseLength int
+	truncateStrategy  TruncateStrategy
+	color             palette
+	logger            *logging.Logger
 }
 
 // New creates a new Formatter.
 func New() Formatter {
 	return &formatter{
 		maxResponseLength: 1000, // Default max length
+		logger:            logging.Default(),
 	}
 }
 
@@ -60,11 +94,17 @@ func (f *formatter) Format(results []AgentResult, format OutputFormat) string {
 		}
 	}
 
+	// Binary tool output echoed back can leave invalid UTF-8 that would
+	// garble the boxes
+	results = validUTF8(results)
+
 	switch format {
 	case FormatJSON:
 		return f.formatJSON(results)
 	case FormatMarkdown:
 		return f.formatMarkdown(results)
+	case FormatMarkdownTable:
+		return f.formatMarkdownTable(results)
 	default:
 		return f.formatTerminal(results)
 	}
@@ -75,11 +115,27 @@ func (f *formatter) SetMaxResponseLength(length int) {
 	f.maxResponseLength = length
 }
 
+// SetTruncateStrategy sets which part of a long response is kept.
+func (f *formatter) SetTruncateStrategy(strategy TruncateStrategy) {
+	f.truncateStrategy = strategy
+}
+
+// SetColor enables ANSI colors in terminal output.
+func (f *formatter) SetColor(enabled bool) {
+	f.color = palette(enabled)
+}
+
+// SetLogger sets where formatting problems are reported.
+func (f *formatter) SetLogger(logger *logging.Logger) {
+	f.logger = logger
+}
+
 // formatTerminal formats results for terminal display with box-drawing characters.
 func (f *formatter) formatTerminal(results []AgentResult) string {
 	var sb strings.Builder
 
 	successCount := 0
+	emptyCount := 0
 	failCount := 0
 
 	for i, r := range results {
@@ -91,12 +147,18 @@ func (f *formatter) formatTerminal(results []AgentResult) string {
 		sb.WriteString("┌──────────────────────────────────────────────────────────────────────────────┐\n")
 
 		// Agent name and duration
-		duration := formatDuration(r.Duration)
+		duration := f.color.dim(fmt.Sprintf("%33s", formatDuration(r.Duration)))
 		if r.Error != nil {
-			sb.WriteString(fmt.Sprintf("│ %-40s %33s │\n", r.Agent.Name+" [ERROR]", duration))
+			name := f.color.failure(fmt.Sprintf("%-40s", r.Agent.Name+" [ERROR]"))
+			sb.WriteString(fmt.Sprintf("│ %s %s │\n", name, duration))
 			failCount++
+		} else if r.Empty() {
+			name := f.color.warning(fmt.Sprintf("%-40s", r.Agent.Name+" [EMPTY]"))
+			sb.WriteString(fmt.Sprintf("│ %s %s │\n", name, duration))
+			emptyCount++
 		} else {
-			sb.WriteString(fmt.Sprintf("│ %-40s %33s │\n", r.Agent.Name, duration))
+			name := f.color.success(fmt.Sprintf("%-40s", r.Agent.Name))
+			sb.WriteString(fmt.Sprintf("│ %s %s │\n", name, duration))
 			successCount++
 		}
 
@@ -105,12 +167,11 @@ func (f *formatter) formatTerminal(results []AgentResult) string {
 
 		// Content (response or error)
 		if r.Error != nil {
-			sb.WriteString(fmt.Sprintf("│ Error: %-68s │\n", r.Error.Error()))
+			sb.WriteString(fmt.Sprintf("│ %s │\n", f.color.failure(fmt.Sprintf("Error: %-68s", r.Error.Error()))))
+		} else if r.Empty() {
+			sb.WriteString(fmt.Sprintf("│ %s │\n", f.color.warning(fmt.Sprintf("%-76s", emptyResponseNote))))
 		} else {
-			response := r.Response.Output
-			if f.maxResponseLength > 0 && len(response) > f.maxResponseLength {
-				response = response[:f.maxResponseLength] + "... [truncated]"
-			}
+			response := truncate(r.Response.Output, f.maxResponseLength, f.truncateStrategy)
 
 			// Wrap response in box
 			lines := wrapText(response, 76)
@@ -123,8 +184,12 @@ func (f *formatter) formatTerminal(results []AgentResult) string {
 		sb.WriteString("└──────────────────────────────────────────────────────────────────────────────┘\n")
 	}
 
-	// Summary
-	sb.WriteString(fmt.Sprintf("\nSummary: %d agents, %d succeeded, %d failed\n", len(results), successCount, failCount))
+	// Summary; empty responses are only counted when there are some
+	if emptyCount > 0 {
+		sb.WriteString(fmt.Sprintf("\nSummary: %d agents, %d succeeded, %d empty, %d failed\n", len(results), successCount, emptyCount, failCount))
+	} else {
+		sb.WriteString(fmt.Sprintf("\nSummary: %d agents, %d succeeded, %d failed\n", len(results), successCount, failCount))
+	}
 
 	return sb.String()
 }
@@ -135,6 +200,7 @@ func (f *formatter) formatJSON(results []AgentResult) string {
 		Agent    string  `json:"agent"`
 		Response string  `json:"response"`
 		Error    string  `json:"error,omitempty"`
+		Empty    bool    `json:"empty,omitempty"`
 		Duration string  `json:"duration"`
 		DurationMs int64 `json:"duration_ms"`
 	}
@@ -150,16 +216,49 @@ func (f *formatter) formatJSON(results []AgentResult) string {
 		if r.Error != nil {
 			jr.Error = r.Error.Error()
 		}
+		jr.Empty = r.Empty()
 		jsonResults[i] = jr
 	}
 
-	data, err := json.MarshalIndent(jsonResults, "", "  ")
+	data, err := marshalJSON(jsonResults, "", "  ")
 	if err != nil {
-		return "[]"
+		f.logger.Warnf("failed to encode results as JSON: %v", err)
+		// Encode results one by one so a bad one costs only its own entry
+		items := make([]string, len(jsonResults))
+		for i, jr := range jsonResults {
+			item, err := marshalJSON(jr, "  ", "  ")
+			if err != nil {
+				item, _ = json.Marshal(jsonResult{
+					Agent:      jr.Agent,
+					Error:      "could not encode result: " + err.Error(),
+					Duration:   jr.Duration,
+					DurationMs: jr.DurationMs,
+				})
+			}
+			items[i] = string(item)
+		}
+		return "[\n  " + strings.Join(items, ",\n  ") + "\n]"
 	}
 	return string(data)
 }
 
+// marshalJSON encodes JSON output. It is a variable so tests can make it fail.
+var marshalJSON = json.MarshalIndent
+
+// validUTF8 returns results with invalid UTF-8 in responses and errors
+// replaced by U+FFFD.
+func validUTF8(results []AgentResult) []AgentResult {
+	clean := make([]AgentResult, len(results))
+	for i, r := range results {
+		r.Response.Output = strings.ToValidUTF8(r.Response.Output, string(utf8.RuneError))
+		if r.Error != nil && !utf8.ValidString(r.Error.Error()) {
+			r.Error = errors.New(strings.ToValidUTF8(r.Error.Error(), string(utf8.RuneError)))
+		}
+		clean[i] = r
+	}
+	return clean
+}
+
 // formatMarkdown formats results as markdown.
 func (f *formatter) formatMarkdown(results []AgentResult) string {
 	var sb strings.Builder
@@ -172,6 +271,8 @@ func (f *formatter) formatMarkdown(results []AgentResult) string {
 
 		if r.Error != nil {
 			sb.WriteString(fmt.Sprintf("**Error:** %s\n\n", r.Error.Error()))
+		} else if r.Empty() {
+			sb.WriteString(fmt.Sprintf("**Empty response:** %s\n\n", emptyResponseNote))
 		} else {
 			sb.WriteString(r.Response.Output)
 			sb.WriteString("\n\n")
@@ -183,6 +284,59 @@ func (f *formatter) formatMarkdown(results []AgentResult) string {
 	return sb.String()
 }
 
+// formatMarkdownTable formats results as one table row per agent followed by
+// a collapsible block per full response.
+func (f *formatter) formatMarkdownTable(results []AgentResult) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Agent Responses\n\n")
+	sb.WriteString("| Agent | Duration | Status | Response |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	for _, r := range results {
+		status, summary := "ok", firstLine(r.Response.Output)
+		if r.Error != nil {
+			status, summary = "error", firstLine(r.Error.Error())
+		} else if r.Empty() {
+			status, summary = "empty", emptyResponseNote
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			tableCell(r.Agent.Name), formatDuration(r.Duration), status, tableCell(summary)))
+	}
+
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("\n<details>\n<summary>%s</summary>\n\n", html.EscapeString(r.Agent.Name)))
+		if r.Error != nil {
+			sb.WriteString(fmt.Sprintf("**Error:** %s\n", r.Error.Error()))
+		} else {
+			sb.WriteString(r.Response.Output)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n</details>\n")
+	}
+
+	return sb.String()
+}
+
+// firstLine returns the first non-blank line of text, shortened to fit a table cell.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > tableSummaryWidth {
+			line = string(runes[:tableSummaryWidth-1]) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// tableCell escapes text so it stays inside one markdown table cell.
+func tableCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
 // formatDuration formats a duration for display.
 func formatDuration(d time.Duration) string {
 	if d < time.Second {
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/michaellady/buckshot/internal/agent"
 	"github.com/michaellady/buckshot/internal/dispatch"
@@ -223,6 +224,68 @@ func TestFormatJSONIncludesAllFields(t *testing.T) {
 	}
 }
 
+// TestFormatInvalidUTF8 verifies invalid bytes in responses and errors are
+// replaced rather than passed through.
+func TestFormatInvalidUTF8(t *testing.T) {
+	results := []AgentResult{
+		makeResult("claude", "ok \xff\xfe done", nil, time.Second),
+		makeResult("codex", "", errors.New("exit status 1: \xc3("), time.Second),
+	}
+
+	f := New()
+	for _, format := range []OutputFormat{FormatTerminal, FormatJSON} {
+		output := f.Format(results, format)
+		if !utf8.ValidString(output) {
+			t.Errorf("format %d output should be valid UTF-8:\n%q", format, output)
+		}
+		if !strings.Contains(output, "ok \uFFFD done") {
+			t.Errorf("format %d output should mark the invalid bytes:\n%s", format, output)
+		}
+	}
+
+	var parsed []map[string]interface{}
+	if err := json.Unmarshal([]byte(f.Format(results, FormatJSON)), &parsed); err != nil {
+		t.Fatalf("JSON output should be valid JSON: %v", err)
+	}
+	if len(parsed) != 2 || parsed[1]["error"] != "exit status 1: \uFFFD(" {
+		t.Errorf("JSON results = %v, want both agents with the error cleaned", parsed)
+	}
+}
+
+// TestFormatJSONMarshalError verifies a failed encode still reports every
+// agent instead of an empty array.
+func TestFormatJSONMarshalError(t *testing.T) {
+	orig := marshalJSON
+	defer func() { marshalJSON = orig }()
+	marshalJSON = func(v any, prefix, indent string) ([]byte, error) {
+		data, err := json.Marshal(v)
+		if err == nil && strings.Contains(string(data), "codex") {
+			return nil, errors.New("boom")
+		}
+		return orig(v, prefix, indent)
+	}
+
+	results := []AgentResult{
+		makeResult("claude", "Claude's analysis.", nil, time.Second),
+		makeResult("codex", "Codex's input.", nil, time.Second),
+	}
+	output := New().Format(results, FormatJSON)
+
+	var parsed []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("output should be valid JSON: %v\n%s", err, output)
+	}
+	if len(parsed) != 2 {
+		t.Fatalf("output should keep both agents, got:\n%s", output)
+	}
+	if parsed[0]["response"] != "Claude's analysis." {
+		t.Errorf("claude should be encoded in full, got %v", parsed[0])
+	}
+	if parsed[1]["agent"] != "codex" || !strings.Contains(parsed[1]["error"].(string), "boom") {
+		t.Errorf("codex should be reported with the encode error, got %v", parsed[1])
+	}
+}
+
 // TestFormatEmptyResults verifies handling of empty results.
 func TestFormatEmptyResults(t *testing.T) {
 	f := New()
@@ -260,6 +323,32 @@ func TestFormatMarkdown(t *testing.T) {
 	}
 }
 
+// TestFormatMarkdownTable verifies the compact table with collapsible responses.
+func TestFormatMarkdownTable(t *testing.T) {
+	results := []AgentResult{
+		makeResult("claude", "\nUse a | pipe queue.\nMore detail here.", nil, time.Second),
+		makeResult("codex", "", errors.New("timed out"), 2*time.Second),
+	}
+
+	output := New().Format(results, FormatMarkdownTable)
+
+	for _, want := range []string{
+		"| Agent | Duration | Status | Response |",
+		"| claude | 1.0s | ok | Use a \\| pipe queue. |",
+		"| codex | 2.0s | error | timed out |",
+		"<details>\n<summary>claude</summary>",
+		"More detail here.",
+		"</details>",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("markdown table output missing %q:\n%s", want, output)
+		}
+	}
+	if got := strings.Count(output, "<details>"); got != 2 {
+		t.Errorf("got %d <details> blocks, want 2", got)
+	}
+}
+
 // TestFormatTerminalSummary verifies summary line is included.
 func TestFormatTerminalSummary(t *testing.T) {
 	testErr := errors.New("failed")
@@ -282,6 +371,39 @@ func TestFormatTerminalSummary(t *testing.T) {
 	}
 }
 
+// TestFormatEmptyResponse verifies a success with no output is counted and
+// shown as empty, apart from successes and failures.
+func TestFormatEmptyResponse(t *testing.T) {
+	results := []AgentResult{
+		makeResult("amp", "Use a queue.", nil, time.Second),
+		makeResult("claude", "Use a cache.", nil, time.Second),
+		makeResult("codex", "  \n", nil, time.Second),
+		makeResult("gemini", "", errors.New("timeout"), time.Second),
+	}
+	f := New()
+
+	terminal := f.Format(results, FormatTerminal)
+	for _, want := range []string{"codex [EMPTY]", emptyResponseNote, "Summary: 4 agents, 2 succeeded, 1 empty, 1 failed"} {
+		if !strings.Contains(terminal, want) {
+			t.Errorf("terminal output should contain %q, got:\n%s", want, terminal)
+		}
+	}
+
+	if table := f.Format(results, FormatMarkdownTable); !strings.Contains(table, "| codex | 1.0s | empty |") {
+		t.Errorf("markdown table should mark codex empty, got:\n%s", table)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal([]byte(f.Format(results, FormatJSON)), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, r := range decoded {
+		if empty, _ := r["empty"].(bool); empty != (r["agent"] == "codex") {
+			t.Errorf("%v empty = %v, want only codex empty", r["agent"], empty)
+		}
+	}
+}
+
 // TestFormatSingleAgent verifies single agent formatting.
 func TestFormatSingleAgent(t *testing.T) {
 	results := []AgentResult{
@@ -298,3 +420,31 @@ func TestFormatSingleAgent(t *testing.T) {
 		t.Error("Output should contain response")
 	}
 }
+
+// TestFormatTerminal_Color verifies ANSI colors are off by default, and when
+// enabled keep the box layout intact.
+func TestFormatTerminal_Color(t *testing.T) {
+	results := []AgentResult{
+		makeResult("claude", "Looks good.", nil, 2*time.Second),
+		makeResult("codex", "", errors.New("boom"), 500*time.Millisecond),
+	}
+
+	f := New()
+	plain := f.Format(results, FormatTerminal)
+	if strings.Contains(plain, "\x1b[") {
+		t.Fatalf("color should be off by default, got %q", plain)
+	}
+
+	f.SetColor(true)
+	colored := f.Format(results, FormatTerminal)
+	for _, want := range []string{ansiGreen + "claude", ansiRed + "codex [ERROR]", ansiRed + "Error: boom", ansiDim} {
+		if !strings.Contains(colored, want) {
+			t.Errorf("colored output missing %q, got %q", want, colored)
+		}
+	}
+
+	stripped := strings.NewReplacer(ansiReset, "", ansiRed, "", ansiGreen, "", ansiDim, "").Replace(colored)
+	if stripped != plain {
+		t.Errorf("color should not change the layout:\n%s\nvs\n%s", stripped, plain)
+	}
+}
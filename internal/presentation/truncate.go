@@ -0,0 +1,49 @@
+package presentation
+
+import "fmt"
+
+// TruncateStrategy picks which part of a long response is kept.
+type TruncateStrategy string
+
+const (
+	// TruncateHead keeps the start of the response. The default.
+	TruncateHead TruncateStrategy = "head"
+	// TruncateTail keeps the end, where agents usually put their conclusion.
+	TruncateTail TruncateStrategy = "tail"
+	// TruncateHeadTail keeps both ends and marks the omitted middle.
+	TruncateHeadTail TruncateStrategy = "head-tail"
+)
+
+// middleOmitted separates the ends kept by TruncateHeadTail.
+const middleOmitted = "\n… [middle omitted] …\n"
+
+// ParseTruncateStrategy validates a truncation strategy name. Empty means
+// TruncateHead.
+func ParseTruncateStrategy(name string) (TruncateStrategy, error) {
+	switch s := TruncateStrategy(name); s {
+	case "":
+		return TruncateHead, nil
+	case TruncateHead, TruncateTail, TruncateHeadTail:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown truncate strategy %q (want %s, %s, or %s)", name, TruncateHead, TruncateTail, TruncateHeadTail)
+}
+
+// truncate shortens text to max characters using strategy, marking what was
+// cut. Zero max, or text that already fits, is returned unchanged.
+func truncate(text string, max int, strategy TruncateStrategy) string {
+	runes := []rune(text)
+	if max <= 0 || len(runes) <= max {
+		return text
+	}
+
+	switch strategy {
+	case TruncateTail:
+		return "[truncated] ..." + string(runes[len(runes)-max:])
+	case TruncateHeadTail:
+		head := max / 2
+		return string(runes[:head]) + middleOmitted + string(runes[len(runes)-(max-head):])
+	default:
+		return string(runes[:max]) + "... [truncated]"
+	}
+}
@@ -0,0 +1,72 @@
+package presentation
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTruncate verifies the shape each strategy gives a long response
+func TestTruncate(t *testing.T) {
+	text := "Intro: let me look. " + strings.Repeat("x", 100) + " Recommendation: use an LRU."
+	tests := []struct {
+		strategy TruncateStrategy
+		want     string
+	}{
+		{TruncateHead, "Intro: let me look. xxxxxxxxxx... [truncated]"},
+		{"", "Intro: let me look. xxxxxxxxxx... [truncated]"},
+		{TruncateTail, "[truncated] ...xx Recommendation: use an LRU."},
+		{TruncateHeadTail, "Intro: let me l" + middleOmitted + "on: use an LRU."},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			if got := truncate(text, 30, tt.strategy); got != tt.want {
+				t.Errorf("truncate(%s) = %q, want %q", tt.strategy, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTruncate_Fits verifies short text and a zero limit are left alone, and
+// that characters are never split
+func TestTruncate_Fits(t *testing.T) {
+	for _, strategy := range []TruncateStrategy{TruncateHead, TruncateTail, TruncateHeadTail} {
+		if got := truncate("short", 30, strategy); got != "short" {
+			t.Errorf("truncate(short, %s) = %q, want it unchanged", strategy, got)
+		}
+		if got := truncate("long text", 0, strategy); got != "long text" {
+			t.Errorf("truncate with no limit, %s = %q, want it unchanged", strategy, got)
+		}
+	}
+	if got := truncate("→→→→→→", 2, TruncateTail); got != "[truncated] ...→→" {
+		t.Errorf("truncate(arrows, tail) = %q, want whole characters", got)
+	}
+}
+
+// TestFormatTerminalTruncateStrategy verifies the formatter applies the
+// strategy and marks the omitted middle
+func TestFormatTerminalTruncateStrategy(t *testing.T) {
+	response := "First I read the code. " + strings.Repeat("Detail. ", 200) + "Conclusion: ship it."
+	f := New()
+	f.SetMaxResponseLength(100)
+	f.SetTruncateStrategy(TruncateHeadTail)
+	output := f.Format([]AgentResult{makeResult("claude", response, nil, time.Second)}, FormatTerminal)
+
+	for _, want := range []string{"First I read the code.", "… [middle omitted] …", "Conclusion: ship it."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+// TestParseTruncateStrategy verifies names are validated
+func TestParseTruncateStrategy(t *testing.T) {
+	for name, want := range map[string]TruncateStrategy{"": TruncateHead, "head": TruncateHead, "tail": TruncateTail, "head-tail": TruncateHeadTail} {
+		if got, err := ParseTruncateStrategy(name); err != nil || got != want {
+			t.Errorf("ParseTruncateStrategy(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseTruncateStrategy("middle"); err == nil || !strings.Contains(err.Error(), "unknown truncate strategy") {
+		t.Errorf("ParseTruncateStrategy(middle) error = %v, want unknown truncate strategy", err)
+	}
+}
@@ -6,33 +6,51 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
-	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	buckctx "github.com/michaellady/buckshot/internal/context"
 )
 
 // DefaultSession implements the Session interface using an underlying agent CLI process.
 type DefaultSession struct {
-	agent          agent.Agent
-	cmd            *exec.Cmd
-	stdin          io.WriteCloser
-	stdout         io.ReadCloser
-	stderr         io.ReadCloser
-	contextUsage   float64
-	alive          bool
-	mu             sync.Mutex
-	agentsPath     string
-	started        bool
-	outputBuffer   strings.Builder
-	responseSignal chan struct{} // Signals when context usage is updated (response complete)
+	agent        agent.Agent
+	opts         Options
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	stdout       io.ReadCloser
+	stderr       io.ReadCloser
+	contextUsage float64
+	alive        bool
+	mu           sync.Mutex
+	sendMu       sync.Mutex // Serializes Send so turns never share the output buffer
+	agentsPath   string
+	started      bool
+	outputBuffer strings.Builder
+	sessionID    string        // Agent-side session ID, captured from output
+	turnDone     chan struct{} // Closed by the reader at the end of the current turn; nil between turns
+	startup      chan struct{} // The startup turn's turnDone until a Send has waited it out
+	exited       chan struct{} // Closed once the agent process has exited and been reaped
+	exitErr      error         // Why the agent exited, set before exited is closed
+	exitCode     int           // The agent's exit code, or -1 if it was killed; valid once exitErr is set
+	stderrTail   []string      // Last lines the agent wrote to stderr
+	promptFile   string        // Temp file holding the system prompt, removed on Close
+	stream       func(string)  // Receives parsed output as it arrives, if set
+	lastStreamed string        // Last text streamed, to drop repeated result events
+	turnBytes    int           // Bytes of text the agent has written this turn
+	cutOff       bool          // Whether the agent was stopped for passing MaxOutputTokens
 }
 
+// bytesPerToken approximates how many bytes of text make up a token, to
+// hold output to Options.MaxOutputTokens.
+const bytesPerToken = 4
+
 // Start initializes the session with the path to AGENTS.md.
 func (s *DefaultSession) Start(ctx context.Context, agentsPath string) error {
 	s.mu.Lock()
@@ -42,61 +60,97 @@ func (s *DefaultSession) Start(ctx context.Context, agentsPath string) error {
 		return errors.New("session already started")
 	}
 
-	// Validate AGENTS.md exists
-	if _, err := os.Stat(agentsPath); err != nil {
-		return fmt.Errorf("AGENTS.md not found at %s: %w", agentsPath, err)
+	// Validate AGENTS.md exists (an empty path means use default instructions)
+	if agentsPath != "" {
+		if _, err := os.Stat(agentsPath); err != nil {
+			return fmt.Errorf("AGENTS.md not found at %s: %w", agentsPath, err)
+		}
 	}
 
 	s.agentsPath = agentsPath
 
 	// Build command based on agent pattern
 	pattern := s.agent.Pattern
-	args := buildStartCommand(pattern, agentsPath)
+	opts, promptFile, err := systemPromptFile(pattern, s.opts)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStartFailed, err)
+	}
+	if promptFile != "" {
+		s.promptFile = promptFile
+		// A session that fails to start is never closed, so clean up here
+		defer func() {
+			if !s.started {
+				_ = os.Remove(promptFile)
+				s.promptFile = ""
+			}
+		}()
+	}
+	args := buildStartCommand(pattern, agentsPath, s.opts.ResumeIDs[s.agent.Name], opts)
 
 	s.cmd = exec.CommandContext(ctx, s.agent.Path, args...)
+	s.cmd.Dir = processDir(pattern, s.opts)
+	s.cmd.Env = s.opts.environ()
+	s.opts.trace("[%s] $ %s", s.agent.Name, commandLine(s.agent.Path, args))
 
 	// Set up pipes for stdin/stdout/stderr
-	var err error
 	s.stdin, err = s.cmd.StdinPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
+		return fmt.Errorf("%w: stdin pipe: %w", ErrStartFailed, err)
 	}
 
 	s.stdout, err = s.cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
+		return fmt.Errorf("%w: stdout pipe: %w", ErrStartFailed, err)
 	}
 
 	s.stderr, err = s.cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
+		return fmt.Errorf("%w: stderr pipe: %w", ErrStartFailed, err)
 	}
 
 	// Start the command
 	if err := s.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start agent: %w", err)
+		return fmt.Errorf("%w: %w", ErrStartFailed, err)
 	}
 
 	s.alive = true
 	s.started = true
-	s.responseSignal = make(chan struct{}, 1) // Buffered to avoid blocking
-
-	// Start goroutines to read output
-	go s.readOutput(s.stdout)
-	go s.readOutput(s.stderr)
+	s.exited = make(chan struct{})
+	// The agent's reply to the AGENTS.md instruction is a turn of its own,
+	// which the first Send waits out
+	s.startup = make(chan struct{})
+	s.turnDone = s.startup
+
+	// Start goroutines to read output; once both pipes close, reap the process
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		s.readOutput(s.stdout, false)
+	}()
+	go func() {
+		defer readers.Done()
+		s.readOutput(s.stderr, true)
+	}()
+	go func(cmd *exec.Cmd, exited chan struct{}) {
+		readers.Wait()
+		s.recordExit(cmd.Wait())
+		close(exited)
+	}(s.cmd, s.exited)
 
 	return nil
 }
 
 // buildStartCommand builds the command arguments for starting an agent session.
-func buildStartCommand(pattern agent.CLIPattern, agentsPath string) []string {
-	var args []string
-
-	// Add non-interactive args
-	args = append(args, pattern.NonInteractiveArgs...)
+func buildStartCommand(pattern agent.CLIPattern, agentsPath, resumeID string, opts Options) []string {
+	// Add non-interactive args (or the resume equivalent)
+	args := leadingArgs(pattern, resumeID)
 
 	// Add the initial prompt to read AGENTS.md
-	initialPrompt := fmt.Sprintf("please read and apply %s", agentsPath)
+	initialPrompt := buckctx.DefaultInstructions
+	if agentsPath != "" {
+		initialPrompt = fmt.Sprintf("please read and apply %s", agentsPath)
+	}
 	args = append(args, initialPrompt)
 
 	// Add JSON output args if available
@@ -104,109 +158,439 @@ func buildStartCommand(pattern agent.CLIPattern, agentsPath string) []string {
 		args = append(args, pattern.JSONOutputArgs...)
 	}
 
-	// Add skip approvals args if available
-	if len(pattern.SkipApprovalsArgs) > 0 {
-		args = append(args, pattern.SkipApprovalsArgs...)
+	// Later prompts arrive on stdin, framed
+	args = append(args, pattern.PromptFramingArgs...)
+
+	// Add approval args for the chosen mode (skip approvals by default)
+	args = append(args, pattern.ApprovalArgs(opts.ApprovalMode)...)
+	args = append(args, pattern.PermissionArgs(opts.ToolPermissions)...)
+
+	args = appendWorkDir(args, pattern, opts.WorkDir)
+	args = appendSystemPrompt(args, pattern, opts.SystemPrompt)
+	return append(args, pattern.ExtraArgs...)
+}
+
+// leadingArgs returns the arguments that precede the prompt.
+// With a resumeID, flag-based agents get ResumeSessionArg appended, while
+// subcommand-based agents (codex exec resume, amp threads continue) swap
+// any subcommand in NonInteractiveArgs for ResumeSubcommand and the ID.
+func leadingArgs(pattern agent.CLIPattern, resumeID string) []string {
+	var args []string
+
+	switch {
+	case resumeID != "" && len(pattern.ResumeSubcommand) > 0:
+		args = append(args, pattern.ResumeSubcommand...)
+		args = append(args, resumeID)
+		for _, arg := range pattern.NonInteractiveArgs {
+			if strings.HasPrefix(arg, "-") {
+				args = append(args, arg)
+			}
+		}
+	case resumeID != "" && pattern.ResumeSessionArg != "":
+		args = append(args, pattern.NonInteractiveArgs...)
+		args = append(args, pattern.ResumeSessionArg, resumeID)
+	default:
+		args = append(args, pattern.NonInteractiveArgs...)
 	}
 
 	return args
 }
 
-// readOutput reads from a pipe and stores output.
-func (s *DefaultSession) readOutput(pipe io.ReadCloser) {
+// appendWorkDir adds the workspace flag for agents that accept one.
+func appendWorkDir(args []string, pattern agent.CLIPattern, workDir string) []string {
+	if workDir == "" || pattern.WorkspaceDirArg == "" {
+		return args
+	}
+	return append(args, pattern.WorkspaceDirArg, workDir)
+}
+
+// processDir returns the working directory for the agent process.
+// Agents without a workspace flag are run from the work directory instead.
+func processDir(pattern agent.CLIPattern, opts Options) string {
+	if pattern.WorkspaceDirArg != "" {
+		return ""
+	}
+	return opts.WorkDir
+}
+
+// appendSystemPrompt adds the system prompt flag if both the agent and caller provide one.
+func appendSystemPrompt(args []string, pattern agent.CLIPattern, systemPrompt string) []string {
+	if systemPrompt == "" || pattern.SystemPromptArg == "" {
+		return args
+	}
+	return append(args, pattern.SystemPromptArg, systemPrompt)
+}
+
+// systemPromptFile writes the system prompt to a temp file for agents whose
+// SystemPromptArg takes a path, returning opts with the prompt swapped for
+// that path. The path is empty if no file was needed; otherwise the caller
+// removes it once the agent is done.
+func systemPromptFile(pattern agent.CLIPattern, opts Options) (Options, string, error) {
+	if !pattern.SystemPromptIsFile || pattern.SystemPromptArg == "" || opts.SystemPrompt == "" {
+		return opts, "", nil
+	}
+	f, err := os.CreateTemp("", "buckshot-system-prompt-*.md")
+	if err != nil {
+		return opts, "", fmt.Errorf("failed to write system prompt file: %w", err)
+	}
+	path := f.Name()
+	_, err = io.WriteString(f, opts.SystemPrompt)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return opts, "", fmt.Errorf("failed to write system prompt file: %w", err)
+	}
+	opts.SystemPrompt = path
+	return opts, path, nil
+}
+
+// maxStderrLines is how much of the agent's stderr is kept to explain an exit.
+const maxStderrLines = 5
+
+// readOutput reads from a pipe and stores output. Lines from stderr are also
+// kept aside so an unexpected exit can be explained. For agents whose output
+// is parsed, JSON objects split across lines are rejoined first.
+func (s *DefaultSession) readOutput(pipe io.ReadCloser, stderr bool) {
 	scanner := bufio.NewScanner(pipe)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineBytes)
+	stream := "stdout"
+	if stderr {
+		stream = "stderr"
+	}
+
+	var reassembler *jsonReassembler
+	if s.agent.Parser != nil && !agent.IsRaw(s.agent.Parser) {
+		reassembler = &jsonReassembler{}
+	}
 	for scanner.Scan() {
 		line := scanner.Text()
-		s.mu.Lock()
-		s.outputBuffer.WriteString(line)
-		s.outputBuffer.WriteString("\n")
-
-		// Parse context usage from output
-		if usage := parseContextUsage(line); usage >= 0 {
-			s.contextUsage = usage
-			// Signal that we received a context update (indicates response complete)
-			select {
-			case s.responseSignal <- struct{}{}:
-			default:
-				// Channel full, signal already pending
-			}
+		s.opts.trace("[%s] %s: %s", s.agent.Name, stream, line)
+		if reassembler == nil {
+			s.handleLine(line, stderr)
+			continue
+		}
+		for _, line := range reassembler.add(line) {
+			s.handleLine(line, stderr)
 		}
-		s.mu.Unlock()
+	}
+	if reassembler != nil {
+		for _, line := range reassembler.flush() {
+			s.handleLine(line, stderr)
+		}
+	}
+
+	// A line past maxLineBytes stops the scanner; keep draining the pipe so
+	// the agent doesn't block writing to it
+	if err := scanner.Err(); err != nil {
+		s.opts.trace("[%s] %s: %v", s.agent.Name, stream, err)
+		_, _ = io.Copy(io.Discard, pipe)
 	}
 }
 
-// parseContextUsage extracts context usage from agent output.
-// Looks for patterns like "Context: 15% used" or "15% used (29368/200000 tokens)"
-var contextUsageRegex = regexp.MustCompile(`(?i)(\d+)%\s+used`)
+// handleLine stores one line of output, noting the session ID, context usage
+// and end of turn it may carry, and streams its text.
+func (s *DefaultSession) handleLine(line string, stderr bool) {
+	s.mu.Lock()
+	if s.cutOff && !stderr {
+		// The turn already ended at the output limit
+		s.mu.Unlock()
+		return
+	}
+	s.outputBuffer.WriteString(line)
+	s.outputBuffer.WriteString("\n")
+	if stderr && strings.TrimSpace(line) != "" {
+		s.stderrTail = append(s.stderrTail, line)
+		if len(s.stderrTail) > maxStderrLines {
+			s.stderrTail = s.stderrTail[1:]
+		}
+	}
+
+	// Capture the session ID once; it is reported early (e.g., on init)
+	if s.sessionID == "" {
+		s.sessionID = agent.ParseSessionID(s.agent.Parser, line)
+	}
+
+	// A context usage status line or the agent's own end-of-turn event
+	// indicates the response is complete. Usage carried on JSON events
+	// arrives mid-turn, so only the turn event ends those. The line is
+	// already in the buffer, so Send sees the whole turn once it wakes.
+	complete := agent.IsTurnComplete(s.agent.Parser, line)
+	if usage := agent.ParseContextUsage(s.agent.Parser, line); usage >= 0 {
+		s.contextUsage = usage
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			complete = true
+		}
+	}
+	if !stderr && s.turnDone != nil && s.overOutputLimit(line) {
+		s.cutOff = true
+		s.alive = false
+		complete = true
+		if s.cmd != nil && s.cmd.Process != nil {
+			_ = s.cmd.Process.Kill()
+		}
+	}
+	if complete && s.turnDone != nil {
+		close(s.turnDone)
+		s.turnDone = nil
+	}
 
-func parseContextUsage(line string) float64 {
-	matches := contextUsageRegex.FindStringSubmatch(line)
-	if len(matches) >= 2 {
-		if pct, err := strconv.Atoi(matches[1]); err == nil {
-			return float64(pct) / 100.0
+	stream, text := s.stream, ""
+	if stream != nil {
+		text = streamText(s.agent.Parser, line)
+		if !s.opts.KeepANSI {
+			text = agent.SanitizeOutput(text)
 		}
+		if text == s.lastStreamed {
+			text = ""
+		} else if text != "" {
+			s.lastStreamed = text
+		}
+	}
+	s.mu.Unlock()
+
+	if text != "" {
+		stream(text)
+	}
+}
+
+// overOutputLimit adds line's text to the turn's count and reports whether
+// the turn has passed Options.MaxOutputTokens. Callers hold s.mu.
+func (s *DefaultSession) overOutputLimit(line string) bool {
+	if s.opts.MaxOutputTokens <= 0 {
+		return false
+	}
+	s.turnBytes += len(streamText(s.agent.Parser, line))
+	return s.turnBytes > s.opts.MaxOutputTokens*bytesPerToken
+}
+
+// recordExit marks the session dead and records why the agent exited, from
+// the exit status and the last lines it wrote to stderr.
+func (s *DefaultSession) recordExit(waitErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.alive = false
+	reason := "exited"
+	s.exitCode = 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(waitErr, &exitErr) && exitErr.ExitCode() >= 0:
+		s.exitCode = exitErr.ExitCode()
+		reason = fmt.Sprintf("exited with code %d", s.exitCode)
+	case errors.As(waitErr, &exitErr):
+		s.exitCode = -1
+		reason = "was killed"
+	case waitErr != nil:
+		s.exitCode = -1
+		reason = fmt.Sprintf("exited: %v", waitErr)
+	}
+	if len(s.stderrTail) > 0 {
+		reason += ": " + strings.Join(s.stderrTail, "; ")
+	}
+	s.exitErr = fmt.Errorf("%w: %s %s", ErrProcessExited, s.agent.Name, reason)
+}
+
+// LastError returns why the agent process exited, or nil while it is still
+// running (or was never started).
+func (s *DefaultSession) LastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exitErr
+}
+
+// ExitCode returns the agent's exit code. ok is false while the agent is
+// still running (or was never started) and when it was killed by a signal.
+func (s *DefaultSession) ExitCode() (code int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exitErr == nil || s.exitCode < 0 {
+		return 0, false
+	}
+	return s.exitCode, true
+}
+
+// Stderr returns the last lines the agent wrote to stderr, oldest first.
+func (s *DefaultSession) Stderr() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.stderrTail)
+}
+
+// SetStream registers fn to receive parsed output line by line as the
+// agent produces it. Lines that carry no text (e.g. JSON bookkeeping
+// events) are not streamed.
+func (s *DefaultSession) SetStream(fn func(text string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stream = fn
+}
+
+// streamText extracts the readable text from a single line of output.
+func streamText(parser agent.OutputParser, line string) string {
+	if parser == nil || agent.IsRaw(parser) || !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return line
+	}
+	// Parsers hand back input they extracted nothing from
+	if text := parser.Parse(line); text != line {
+		return text
 	}
-	return -1.0
+	return ""
 }
 
 // SendTimeout is the default timeout for waiting for agent responses.
-const SendTimeout = 120 * time.Second
+// It is a variable so tests can shorten it.
+var SendTimeout = 120 * time.Second
 
 // Send sends a prompt to the agent and returns the response.
+// Concurrent calls are serialized; each returns only its own turn's output.
 func (s *DefaultSession) Send(ctx context.Context, prompt string) (Response, error) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if err := s.awaitStartup(ctx); err != nil {
+		return Response{Error: err}, err
+	}
+
 	s.mu.Lock()
 	if !s.started {
 		s.mu.Unlock()
 		return Response{}, errors.New("session not started")
 	}
-	if !s.alive {
+	if !s.alive || s.hasExited() {
+		s.alive = false
+		err := s.exitErr
 		s.mu.Unlock()
-		return Response{}, errors.New("session not alive")
+		if err == nil {
+			err = fmt.Errorf("%w: %s is no longer running", ErrProcessExited, s.agent.Name)
+		}
+		return Response{Error: err}, err
 	}
 
-	// Clear output buffer and drain any pending signals before sending
+	// Start a new turn: output from here on belongs to this prompt, and the
+	// reader closes done when it sees the turn end
 	s.outputBuffer.Reset()
-	select {
-	case <-s.responseSignal:
-	default:
-	}
+	s.turnBytes = 0
+	done := make(chan struct{})
+	s.turnDone = done
 	s.mu.Unlock()
 
 	// Write prompt to stdin
-	_, err := fmt.Fprintln(s.stdin, prompt)
+	s.opts.trace("[%s] stdin:\n%s", s.agent.Name, prompt)
+	_, err := io.WriteString(s.stdin, agent.FramePrompt(s.agent.Pattern.PromptFraming, prompt))
 	if err != nil {
 		s.mu.Lock()
 		s.alive = false
+		s.turnDone = nil
 		s.mu.Unlock()
-		return Response{Error: fmt.Errorf("failed to send prompt: %w", err)}, err
+		err = fmt.Errorf("%w: failed to send prompt: %w", ErrProcessExited, err)
+		return Response{Error: err}, err
 	}
 
-	// Wait for response signal (context usage update) or timeout
+	// Wait for the end of the turn, exit or timeout
+	exited, timedOut := false, false
 	select {
-	case <-s.responseSignal:
+	case <-done:
 		// Response received
+	case <-s.exited:
+		// The agent ended its turn by exiting; any output it wrote is the response
+		exited = true
 	case <-time.After(SendTimeout):
-		// Timeout - return whatever we have
+		timedOut = true
 	case <-ctx.Done():
+		s.endTurn(done)
 		return Response{Error: ctx.Err()}, ctx.Err()
 	}
 
 	// Get output
 	s.mu.Lock()
+	if s.turnDone == done {
+		s.turnDone = nil
+	}
 	output := s.outputBuffer.String()
 	usage := s.contextUsage
+	sessionID := s.sessionID
+	exitErr := s.exitErr
+	crashed := exited && s.exitCode > 0
+	cutOff := s.cutOff
 	s.mu.Unlock()
 
+	if cutOff {
+		s.opts.logger().Warnf("%s passed its limit of %d output tokens; its response was cut off and it will be restarted", s.agent.Name, s.opts.MaxOutputTokens)
+	}
+	if strings.TrimSpace(output) == "" {
+		if exited {
+			// The agent ended its turn by exiting without a word
+			return Response{Error: exitErr}, exitErr
+		}
+		if timedOut {
+			err := fmt.Errorf("%w: %s sent nothing within %s", ErrNoOutput, s.agent.Name, SendTimeout)
+			return Response{Error: err}, err
+		}
+	}
+	if timedOut {
+		// Return whatever we have
+		s.opts.logger().Warnf("%s did not signal completion within %s; returning partial output", s.agent.Name, SendTimeout)
+	}
+
 	// Apply parser if available
+	var tokens agent.TokenUsage
+	var agentErr error
 	if s.agent.Parser != nil {
+		tokens = agent.ParseUsage(s.agent.Parser, output)
+		agentErr = agent.ParseError(s.agent.Parser, output)
 		output = s.agent.Parser.Parse(output)
 	}
+	if !s.opts.KeepANSI {
+		output = agent.SanitizeOutput(output)
+	}
+
+	// An agent that crashed mid-turn left at best a partial answer
+	if crashed {
+		if agentErr != nil {
+			agentErr = fmt.Errorf("%w (%w)", agentErr, exitErr)
+		} else {
+			agentErr = exitErr
+		}
+	}
 
 	return Response{
 		Output:       output,
 		ContextUsage: usage,
-		Error:        nil,
-	}, nil
+		TokenUsage:   tokens,
+		SessionID:    sessionID,
+		Partial:      timedOut,
+		Error:        agentErr,
+	}, agentErr
+}
+
+// awaitStartup waits for the agent to finish replying to its startup
+// instruction, so the end of that turn can't end the next one. An agent
+// that stays silent past SendTimeout is sent its prompt anyway. Callers
+// hold sendMu.
+func (s *DefaultSession) awaitStartup(ctx context.Context) error {
+	s.mu.Lock()
+	startup, exited := s.startup, s.exited
+	s.mu.Unlock()
+	if startup == nil {
+		return nil
+	}
+
+	select {
+	case <-startup:
+	case <-exited:
+	case <-time.After(SendTimeout):
+		s.opts.logger().Warnf("%s did not finish reading its instructions within %s", s.agent.Name, SendTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	s.startup = nil
+	s.mu.Unlock()
+	s.endTurn(startup)
+	return nil
 }
 
 // ContextUsage returns the current context usage (0.0 to 1.0).
@@ -229,11 +613,8 @@ func (s *DefaultSession) IsAlive() bool {
 		return false
 	}
 
-	// Check if process is still running
+	// alive is cleared as soon as the process exits and is reaped
 	if s.cmd != nil && s.cmd.Process != nil {
-		// Try to check process state (this is platform-specific)
-		// On Unix, we can send signal 0 to check if process exists
-		// For now, we'll rely on our alive flag
 		return true
 	}
 
@@ -245,7 +626,27 @@ func (s *DefaultSession) Agent() agent.Agent {
 	return s.agent
 }
 
+// endTurn abandons a turn that is still waiting for its end.
+func (s *DefaultSession) endTurn(done chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.turnDone == done {
+		s.turnDone = nil
+	}
+}
+
+// hasExited reports whether the agent's output stream has ended.
+func (s *DefaultSession) hasExited() bool {
+	select {
+	case <-s.exited:
+		return true
+	default:
+		return false
+	}
+}
+
 // Close terminates the session.
+// It returns ErrProcessExited if the agent had already exited with a failure.
 func (s *DefaultSession) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -269,39 +670,80 @@ func (s *DefaultSession) Close() error {
 		_ = s.stderr.Close()
 	}
 
-	// Kill the process if still running
+	// Kill the process if still running and wait for it to be reaped. A
+	// process we killed reports no exit code; a positive code means it
+	// failed before we got here.
+	var err error
 	if s.cmd != nil && s.cmd.Process != nil {
 		_ = s.cmd.Process.Kill()
-		_ = s.cmd.Wait() // Clean up zombie process
+		exited := s.exited
+		s.mu.Unlock()
+		<-exited
+		s.mu.Lock()
+		if state := s.cmd.ProcessState; state != nil && state.ExitCode() > 0 {
+			err = s.exitErr
+		}
+	}
+	if s.promptFile != "" {
+		_ = os.Remove(s.promptFile)
+		s.promptFile = ""
 	}
 
 	s.started = false
-	return nil
+	return err
 }
 
 // DefaultManager is the default implementation of Manager.
-type DefaultManager struct{}
+type DefaultManager struct {
+	opts    Options
+	mu      sync.Mutex
+	resumed map[string]bool // Agents whose first session has been created
+}
 
 // NewManager creates a new session manager.
 func NewManager() Manager {
 	return &DefaultManager{}
 }
 
+// NewManagerWithOptions creates a session manager whose sessions launch with opts.
+func NewManagerWithOptions(opts Options) Manager {
+	opts.ResumeIDs = maps.Clone(opts.ResumeIDs)
+	return &DefaultManager{opts: opts}
+}
+
 // CreateSession creates a new session for the given agent.
 func (m *DefaultManager) CreateSession(agent agent.Agent) (Session, error) {
 	if !agent.Authenticated {
-		return nil, errors.New("agent not authenticated")
+		return nil, fmt.Errorf("%w: %s", ErrNotAuthenticated, agent.Name)
 	}
 
 	return &DefaultSession{
-		agent:          agent,
-		contextUsage:   0.0,
-		alive:          false,
-		started:        false,
-		responseSignal: nil, // Will be initialized in Start()
+		agent:        agent,
+		opts:         m.sessionOptions(agent.Name),
+		contextUsage: 0.0,
+		alive:        false,
+		started:      false,
 	}, nil
 }
 
+// sessionOptions returns the options for a new session of the named agent,
+// resuming its prior session only the first time: a later session replaces
+// one that exited or filled up, and must start fresh.
+func (m *DefaultManager) sessionOptions(name string) Options {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	opts := m.opts
+	opts.ResumeIDs = nil
+	if id, ok := m.opts.ResumeIDs[name]; ok && !m.resumed[name] {
+		opts.ResumeIDs = map[string]string{name: id}
+	}
+	if m.resumed == nil {
+		m.resumed = make(map[string]bool)
+	}
+	m.resumed[name] = true
+	return opts
+}
+
 // ShouldRespawn returns true if session context > threshold.
 func (m *DefaultManager) ShouldRespawn(session Session, threshold float64) bool {
 	return session.ContextUsage() > threshold
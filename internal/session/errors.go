@@ -0,0 +1,20 @@
+package session
+
+import "errors"
+
+// Errors returned by sessions, wrapped with detail where available.
+// Callers match them with errors.Is to decide whether to skip an agent,
+// retry it with a fresh session, or give up on it.
+var (
+	// ErrStartFailed means the agent process could not be launched.
+	ErrStartFailed = errors.New("agent failed to start")
+
+	// ErrNotAuthenticated means the agent has no usable credentials.
+	ErrNotAuthenticated = errors.New("agent not authenticated")
+
+	// ErrProcessExited means the agent process ended while it was still needed.
+	ErrProcessExited = errors.New("agent process exited")
+
+	// ErrNoOutput means the agent never answered a prompt.
+	ErrNoOutput = errors.New("agent produced no output")
+)
@@ -0,0 +1,307 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newScriptAgent returns a test agent that runs the given shell script.
+func newScriptAgent(t *testing.T, script string) (Session, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mock-agent")
+	if err := os.WriteFile(path, []byte("#!/bin/bash\necho 'Context: 1% used'\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write mock agent: %v", err)
+	}
+	ag := newTestAgent()
+	ag.Path = path
+	ag.Pattern = mockPattern()
+	return NewManager().CreateSession(ag)
+}
+
+// TestSessionSystemPromptFile tests that a session's system prompt file
+// lasts until the session is closed
+func TestSessionSystemPromptFile(t *testing.T) {
+	seen := filepath.Join(t.TempDir(), "seen")
+	path := filepath.Join(t.TempDir(), "mock-agent")
+	script := "#!/bin/sh\nfor arg; do last=$arg; done\necho \"$last\" > " + seen + "\necho 'Context: 1% used'\nwhile read -r line; do :; done\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ag := newTestAgent()
+	ag.Path = path
+	ag.Pattern = mockPattern()
+	ag.Pattern.SystemPromptArg = "--rules"
+	ag.Pattern.SystemPromptIsFile = true
+
+	sess, err := NewManagerWithOptions(Options{SystemPrompt: "Be terse."}).CreateSession(ag)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	var rules string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if data, err := os.ReadFile(seen); err == nil && strings.HasSuffix(string(data), "\n") {
+			rules = strings.TrimSpace(string(data))
+			break
+		}
+	}
+	if data, err := os.ReadFile(rules); err != nil || string(data) != "Be terse." {
+		t.Fatalf("system prompt file %q = %q, %v; want the prompt while the session runs", rules, data, err)
+	}
+
+	_ = sess.Close()
+	if _, err := os.Stat(rules); !os.IsNotExist(err) {
+		t.Errorf("system prompt file should be removed on Close, stat error = %v", err)
+	}
+}
+
+func TestErrNotAuthenticated(t *testing.T) {
+	_, err := NewManager().CreateSession(newUnauthenticatedTestAgent())
+	if !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("CreateSession() error = %v, want ErrNotAuthenticated", err)
+	}
+}
+
+func TestErrStartFailed(t *testing.T) {
+	ag := newTestAgent()
+	ag.Path = filepath.Join(t.TempDir(), "no-such-agent")
+	sess, err := NewManager().CreateSession(ag)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	err = sess.Start(context.Background(), "")
+	if !errors.Is(err, ErrStartFailed) {
+		t.Errorf("Start() error = %v, want ErrStartFailed", err)
+	}
+}
+
+func TestErrProcessExited_Send(t *testing.T) {
+	sess, err := newScriptAgent(t, "exit 3\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	_, err = sess.Send(context.Background(), "hello")
+	if !errors.Is(err, ErrProcessExited) {
+		t.Errorf("Send() error = %v, want ErrProcessExited", err)
+	}
+	if sess.IsAlive() {
+		t.Error("session should not be alive after the agent exited")
+	}
+
+	if err := sess.Close(); !errors.Is(err, ErrProcessExited) {
+		t.Errorf("Close() error = %v, want ErrProcessExited", err)
+	}
+}
+
+func TestErrProcessExited_OutputBeforeExit(t *testing.T) {
+	sess, err := newScriptAgent(t, "read -r line\necho \"answer to $line\"\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	resp, err := sess.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v, want the output written before exit", err)
+	}
+	if resp.Output == "" {
+		t.Error("Send() should return the output written before exit")
+	}
+
+	if _, err := sess.Send(context.Background(), "again"); !errors.Is(err, ErrProcessExited) {
+		t.Errorf("second Send() error = %v, want ErrProcessExited", err)
+	}
+}
+
+func TestErrNoOutput(t *testing.T) {
+	orig := SendTimeout
+	SendTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { SendTimeout = orig })
+
+	sess, err := newScriptAgent(t, "while read -r line; do :; done\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	_, err = sess.Send(context.Background(), "hello")
+	if !errors.Is(err, ErrNoOutput) {
+		t.Errorf("Send() error = %v, want ErrNoOutput", err)
+	}
+}
+
+func TestSend_PartialOutput(t *testing.T) {
+	orig := SendTimeout
+	SendTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { SendTimeout = orig })
+
+	sess, err := newScriptAgent(t, "while read -r line; do echo 'Still thinking'; done\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	resp, err := sess.Send(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v, want the partial output", err)
+	}
+	if !resp.Partial || !strings.Contains(resp.Output, "Still thinking") {
+		t.Errorf("Send() = %+v, want partial output", resp)
+	}
+}
+
+func TestClose_KilledSessionIsNotAnError(t *testing.T) {
+	sess, err := newScriptAgent(t, "while read -r line; do :; done\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := sess.Close(); err != nil {
+		t.Errorf("Close() error = %v, want nil for a session we terminated", err)
+	}
+}
+
+func TestRunOneShot_TypedErrors(t *testing.T) {
+	ag := newTestAgent()
+	ag.Path = filepath.Join(t.TempDir(), "no-such-agent")
+	if _, err := RunOneShot(context.Background(), ag, "hello"); !errors.Is(err, ErrStartFailed) {
+		t.Errorf("RunOneShot() missing binary error = %v, want ErrStartFailed", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "failing-agent")
+	if err := os.WriteFile(path, []byte("#!/bin/bash\nexit 2\n"), 0755); err != nil {
+		t.Fatalf("failed to write mock agent: %v", err)
+	}
+	ag.Path = path
+	if _, err := RunOneShot(context.Background(), ag, "hello"); !errors.Is(err, ErrProcessExited) {
+		t.Errorf("RunOneShot() non-zero exit error = %v, want ErrProcessExited", err)
+	}
+}
+
+func TestLastError_ExitRightAfterStart(t *testing.T) {
+	sess, err := newScriptAgent(t, "echo 'error: unknown flag --bogus' >&2\nexit 2\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sess.IsAlive() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sess.IsAlive() {
+		t.Fatal("IsAlive() should turn false once the agent exits")
+	}
+
+	lastErr := sess.(*DefaultSession).LastError()
+	if !errors.Is(lastErr, ErrProcessExited) {
+		t.Fatalf("LastError() = %v, want ErrProcessExited", lastErr)
+	}
+	for _, want := range []string{"exited with code 2", "unknown flag --bogus"} {
+		if !strings.Contains(lastErr.Error(), want) {
+			t.Errorf("LastError() = %q, want it to mention %q", lastErr, want)
+		}
+	}
+
+	start := time.Now()
+	_, err = sess.Send(context.Background(), "hello")
+	if !errors.Is(err, ErrProcessExited) || !strings.Contains(err.Error(), "unknown flag --bogus") {
+		t.Errorf("Send() error = %v, want the exit reason", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send() took %s, want it to fail fast", elapsed)
+	}
+}
+
+func TestLastError_NilWhileRunning(t *testing.T) {
+	sess, err := newScriptAgent(t, "cat >/dev/null\n")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if err := sess.(*DefaultSession).LastError(); err != nil {
+		t.Errorf("LastError() = %v while the agent is running, want nil", err)
+	}
+	if err := sess.Close(); err != nil {
+		t.Errorf("Close() error = %v, want nil for an agent we stopped", err)
+	}
+}
+
+// TestSend_CrashAfterOneMessage tests that an agent exiting non-zero midway
+// through its second turn fails that turn with its exit code and stderr,
+// keeping the partial output
+func TestSend_CrashAfterOneMessage(t *testing.T) {
+	sess, err := newScriptAgent(t, `read -r line
+echo 'First answer'
+echo 'Context: 1% used'
+read -r line
+echo 'Partial second answer'
+echo 'fatal: out of memory' >&2
+exit 3
+`)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+	ds := sess.(*DefaultSession)
+
+	if _, err := sess.Send(context.Background(), "first"); err != nil {
+		t.Fatalf("first Send() error = %v", err)
+	}
+	if _, ok := ds.ExitCode(); ok {
+		t.Error("ExitCode() should report nothing while the agent runs")
+	}
+
+	resp, err := sess.Send(context.Background(), "second")
+	if !errors.Is(err, ErrProcessExited) {
+		t.Fatalf("second Send() error = %v, want ErrProcessExited", err)
+	}
+	for _, want := range []string{"exited with code 3", "fatal: out of memory"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Send() error = %q, want it to mention %q", err, want)
+		}
+	}
+	if !strings.Contains(resp.Output, "Partial second answer") {
+		t.Errorf("Send() output = %q, want the partial answer kept", resp.Output)
+	}
+	if code, ok := ds.ExitCode(); !ok || code != 3 {
+		t.Errorf("ExitCode() = %d, %v; want 3, true", code, ok)
+	}
+	if stderr := ds.Stderr(); len(stderr) != 1 || stderr[0] != "fatal: out of memory" {
+		t.Errorf("Stderr() = %q, want the agent's last stderr line", stderr)
+	}
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// maxLineBytes bounds a single line of agent output. Tool results can put
+// far more than bufio.Scanner's default 64KB on one line.
+const maxLineBytes = 16 << 20
+
+// maxPartialLines is how many lines a JSON object may be split across
+// before the held lines are given up on and passed through as read.
+const maxPartialLines = 64
+
+// jsonReassembler rejoins JSON objects that arrive split across lines. A
+// line that starts an object but doesn't parse is held, and the lines after
+// it are appended until the whole parses.
+type jsonReassembler struct {
+	pending []string
+}
+
+// add takes the next line read and returns the lines ready to handle: none
+// while an object is still incomplete, the rejoined object once it parses,
+// or the held lines as read if they never do.
+func (r *jsonReassembler) add(line string) []string {
+	if len(r.pending) == 0 {
+		if !startsJSON(line) || json.Valid([]byte(line)) {
+			return []string{line}
+		}
+		r.pending = []string{line}
+		return nil
+	}
+
+	// A complete object of its own means the held lines were never JSON
+	if startsJSON(line) && json.Valid([]byte(line)) {
+		return append(r.flush(), line)
+	}
+	r.pending = append(r.pending, line)
+	if joined := strings.Join(r.pending, ""); json.Valid([]byte(joined)) {
+		r.pending = nil
+		return []string{joined}
+	}
+	if len(r.pending) >= maxPartialLines {
+		return r.flush()
+	}
+	return nil
+}
+
+// flush returns the held lines as read.
+func (r *jsonReassembler) flush() []string {
+	lines := r.pending
+	r.pending = nil
+	return lines
+}
+
+// startsJSON reports whether line opens a JSON object.
+func startsJSON(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "{")
+}
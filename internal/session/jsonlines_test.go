@@ -0,0 +1,112 @@
+package session
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+func TestJSONReassembler(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"whole lines", []string{`{"a":1}`, "plain text"}, []string{`{"a":1}`, "plain text"}},
+		{"split object", []string{`{"type":"assis`, `tant","n":1`, `2}`}, []string{`{"type":"assistant","n":12}`}},
+		{"never completes", []string{`{"a":`, `{"b":2}`}, []string{`{"a":`, `{"b":2}`}},
+		{"left open at the end", []string{"text", `{"a":`}, []string{"text", `{"a":`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r jsonReassembler
+			var got []string
+			for _, line := range tt.lines {
+				got = append(got, r.add(line)...)
+			}
+			got = append(got, r.flush()...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONReassembler_GivesUp(t *testing.T) {
+	var r jsonReassembler
+	var got []string
+	got = append(got, r.add("{ not json")...)
+	for i := 1; i < maxPartialLines; i++ {
+		got = append(got, r.add("more")...)
+	}
+	if len(got) != maxPartialLines {
+		t.Errorf("released %d lines after %d, want them all", len(got), maxPartialLines)
+	}
+}
+
+// readTestOutput runs readOutput over output for an agent using parser and
+// returns what a turn's Send would parse out of it.
+func readTestOutput(t *testing.T, parser agent.OutputParser, output string) (string, *DefaultSession) {
+	t.Helper()
+	s := &DefaultSession{agent: agent.Agent{Name: "claude", Parser: parser}}
+	s.readOutput(io.NopCloser(strings.NewReader(output)), false)
+	return parser.Parse(s.outputBuffer.String()), s
+}
+
+func TestReadOutput_SplitJSON(t *testing.T) {
+	output := `{"type":"system","subtype":"init","session_id":"abc-123"}` + "\n" +
+		`{"type":"assistant","message":{"content":[{"type":"text","te` + "\n" +
+		`xt":"Split across reads"}]}}` + "\n"
+
+	got, s := readTestOutput(t, &agent.ClaudeParser{}, output)
+	if got != "Split across reads" {
+		t.Errorf("parsed %q, want the rejoined message", got)
+	}
+	if s.sessionID != "abc-123" {
+		t.Errorf("session ID = %q, want abc-123", s.sessionID)
+	}
+}
+
+func TestReadOutput_LongLine(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	output := `{"type":"assistant","message":{"content":[{"type":"text","text":"` + long + `"}]}}` + "\n" +
+		"Context: 12% used\n"
+
+	got, s := readTestOutput(t, &agent.ClaudeParser{}, output)
+	if got != long {
+		t.Errorf("parsed %d bytes, want the whole %d-byte message", len(got), len(long))
+	}
+	if s.contextUsage != 0.12 {
+		t.Errorf("context usage = %v, want the line after the long one read too", s.contextUsage)
+	}
+}
+
+func TestReadOutput_RawNotRejoined(t *testing.T) {
+	output := "{ not json\nContext: 12% used\n"
+
+	got, s := readTestOutput(t, agent.NewRawParser(&agent.ClaudeParser{}), output)
+	if got != output || s.contextUsage != 0.12 {
+		t.Errorf("raw output = %q (usage %v), want it as read", got, s.contextUsage)
+	}
+}
+
+func TestReadOutput_StructuredContextUsage(t *testing.T) {
+	output := `{"type":"assistant","message":{"content":[{"type":"text","text":"Reading"}],"usage":{"input_tokens":50000}}}` + "\n"
+
+	s := &DefaultSession{agent: agent.Agent{Name: "claude", Parser: &agent.ClaudeParser{}}}
+	done := make(chan struct{})
+	s.turnDone = done
+	s.readOutput(io.NopCloser(strings.NewReader(output)), false)
+
+	if s.contextUsage != 0.25 {
+		t.Errorf("context usage = %v, want 0.25 from the assistant usage", s.contextUsage)
+	}
+	select {
+	case <-done:
+		t.Error("usage on an assistant event should not end the turn")
+	default:
+	}
+}
@@ -3,17 +3,22 @@ package session
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/michaellady/buckshot/internal/agent"
 )
 
 // OneShotResult represents the result of a one-shot agent execution.
 type OneShotResult struct {
-	Output   string // Combined stdout/stderr output
-	ExitCode int    // Process exit code
-	Error    error  // Any error during execution
+	Output     string           // Combined stdout/stderr output
+	ExitCode   int              // Process exit code
+	TokenUsage agent.TokenUsage // Tokens consumed, if reported by the agent
+	SessionID  string           // Agent-side session ID, if reported (for resuming)
+	Error      error            // Any error during execution
 }
 
 // RunOneShot executes an agent in one-shot mode and waits for completion.
@@ -26,11 +31,41 @@ type OneShotResult struct {
 // - Captures all output
 // - Returns when process completes
 func RunOneShot(ctx context.Context, ag agent.Agent, prompt string) (OneShotResult, error) {
+	return RunOneShotWithOptions(ctx, ag, prompt, Options{})
+}
+
+// RunOneShotWithOptions is like RunOneShot but launches the agent with opts.
+func RunOneShotWithOptions(ctx context.Context, ag agent.Agent, prompt string, opts Options) (OneShotResult, error) {
+	opts, promptFile, err := systemPromptFile(ag.Pattern, opts)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrStartFailed, err)
+		return OneShotResult{ExitCode: -1, Error: err}, err
+	}
+	if promptFile != "" {
+		defer func() { _ = os.Remove(promptFile) }()
+	}
+
 	// Build command arguments
-	args := buildOneShotArgs(ag.Pattern, prompt)
+	args := buildOneShotArgs(ag.Pattern, prompt, opts.ResumeIDs[ag.Name], opts)
+
+	// Agents that can write their final message to a file are read from it
+	lastMessage := ""
+	if ag.Pattern.OutputLastMessageArg != "" {
+		path, err := lastMessageFile()
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrStartFailed, err)
+			return OneShotResult{ExitCode: -1, Error: err}, err
+		}
+		defer func() { _ = os.Remove(path) }()
+		lastMessage = path
+		args = append(args, ag.Pattern.OutputLastMessageArg, path)
+	}
 
 	// Create command with context for cancellation
 	cmd := exec.CommandContext(ctx, ag.Path, args...)
+	cmd.Dir = processDir(ag.Pattern, opts)
+	cmd.Env = opts.environ()
+	opts.trace("[%s] $ %s", ag.Name, commandLine(ag.Path, args))
 
 	// Capture stdout and stderr together
 	var outputBuf bytes.Buffer
@@ -38,15 +73,29 @@ func RunOneShot(ctx context.Context, ag agent.Agent, prompt string) (OneShotResu
 	cmd.Stderr = &outputBuf
 
 	// Run command and wait for completion
-	err := cmd.Run()
+	err = cmd.Run()
 
 	// Get output
 	output := outputBuf.String()
+	opts.trace("[%s] output:\n%s", ag.Name, output)
 
 	// Apply parser if available
+	var tokens agent.TokenUsage
+	var sessionID string
+	var agentErr error
 	if ag.Parser != nil {
+		tokens = agent.ParseUsage(ag.Parser, output)
+		sessionID = agent.ParseSessionID(ag.Parser, output)
+		agentErr = agent.ParseError(ag.Parser, output)
 		output = ag.Parser.Parse(output)
 	}
+	if message, ok := readLastMessage(lastMessage); ok {
+		opts.trace("[%s] last message from %s", ag.Name, lastMessage)
+		output = message
+	}
+	if !opts.KeepANSI {
+		output = agent.SanitizeOutput(output)
+	}
 
 	// Get exit code
 	exitCode := 0
@@ -55,36 +104,79 @@ func RunOneShot(ctx context.Context, ag agent.Agent, prompt string) (OneShotResu
 			exitCode = exitErr.ExitCode()
 		} else {
 			// Other error (e.g., context cancelled, command not found)
+			if ctx.Err() == nil {
+				err = fmt.Errorf("%w: %w", ErrStartFailed, err)
+			}
 			return OneShotResult{
-				Output:   output,
-				ExitCode: -1,
-				Error:    err,
+				Output:     output,
+				ExitCode:   -1,
+				TokenUsage: tokens,
+				SessionID:  sessionID,
+				Error:      err,
 			}, err
 		}
 	}
 
 	// Return result
 	result := OneShotResult{
-		Output:   output,
-		ExitCode: exitCode,
-		Error:    nil,
+		Output:     output,
+		ExitCode:   exitCode,
+		TokenUsage: tokens,
+		SessionID:  sessionID,
+		Error:      nil,
 	}
 
 	// If exit code is non-zero, set error
 	if exitCode != 0 {
-		result.Error = fmt.Errorf("agent exited with code %d", exitCode)
+		result.Error = fmt.Errorf("%w with code %d", ErrProcessExited, exitCode)
+		if agentErr != nil {
+			result.Error = fmt.Errorf("%w (%w with code %d)", agentErr, ErrProcessExited, exitCode)
+		}
 		return result, result.Error
 	}
 
+	// The agent may report a failed turn and still exit cleanly
+	if agentErr != nil {
+		result.Error = agentErr
+		return result, agentErr
+	}
+
 	return result, nil
 }
 
-// buildOneShotArgs builds command arguments for one-shot execution.
-func buildOneShotArgs(pattern agent.CLIPattern, prompt string) []string {
-	var args []string
+// lastMessageFile creates an empty temp file for the agent to write its
+// final message to, and returns its path.
+func lastMessageFile() (string, error) {
+	f, err := os.CreateTemp("", "buckshot-last-message-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	path := f.Name()
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	return path, nil
+}
 
-	// Add non-interactive mode args
-	args = append(args, pattern.NonInteractiveArgs...)
+// readLastMessage returns the final message the agent wrote to path. It
+// reports false if there is no path or the agent left the file empty, so
+// the message scraped from stdout is kept.
+func readLastMessage(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return "", false
+	}
+	return strings.TrimRight(string(data), "\n"), true
+}
+
+// buildOneShotArgs builds command arguments for one-shot execution.
+func buildOneShotArgs(pattern agent.CLIPattern, prompt, resumeID string, opts Options) []string {
+	// Add non-interactive mode args (or the resume equivalent)
+	args := leadingArgs(pattern, resumeID)
 
 	// Add the prompt
 	args = append(args, prompt)
@@ -94,10 +186,66 @@ func buildOneShotArgs(pattern agent.CLIPattern, prompt string) []string {
 		args = append(args, pattern.JSONOutputArgs...)
 	}
 
-	// Add skip approvals args if available
-	if len(pattern.SkipApprovalsArgs) > 0 {
-		args = append(args, pattern.SkipApprovalsArgs...)
+	// Add approval args for the chosen mode (skip approvals by default)
+	args = append(args, pattern.ApprovalArgs(opts.ApprovalMode)...)
+	args = append(args, pattern.PermissionArgs(opts.ToolPermissions)...)
+
+	args = appendWorkDir(args, pattern, opts.WorkDir)
+	args = appendSystemPrompt(args, pattern, opts.SystemPrompt)
+	return append(args, pattern.ExtraArgs...)
+}
+
+// oneShotSession adapts one-shot execution to the Session interface so
+// one-shot runs can be fanned out by a dispatcher. Each Send launches the
+// agent afresh and waits for it to exit.
+type oneShotSession struct {
+	agent   agent.Agent
+	opts    Options
+	started bool
+}
+
+// NewOneShotSession returns a Session whose Send runs the agent in one-shot mode.
+func NewOneShotSession(ag agent.Agent, opts Options) Session {
+	return &oneShotSession{agent: ag, opts: opts}
+}
+
+// Start marks the session ready; nothing runs until Send.
+func (s *oneShotSession) Start(ctx context.Context, agentsPath string) error {
+	s.started = true
+	return nil
+}
+
+// Send runs the agent with prompt and returns its output.
+func (s *oneShotSession) Send(ctx context.Context, prompt string) (Response, error) {
+	if !s.started {
+		return Response{}, errors.New("session not started")
 	}
+	result, err := RunOneShotWithOptions(ctx, s.agent, prompt, s.opts)
+	return Response{
+		Output:     result.Output,
+		TokenUsage: result.TokenUsage,
+		SessionID:  result.SessionID,
+		Error:      err,
+	}, err
+}
+
+// ContextUsage returns 0; each one-shot run starts with an empty context.
+func (s *oneShotSession) ContextUsage() float64 {
+	return 0
+}
+
+// IsAlive returns whether the session has been started and not closed.
+func (s *oneShotSession) IsAlive() bool {
+	return s.started
+}
+
+// Agent returns the underlying agent for this session.
+func (s *oneShotSession) Agent() agent.Agent {
+	return s.agent
+}
 
-	return args
+// Close ends the session.
+func (s *oneShotSession) Close() error {
+	s.started = false
+	return nil
 }
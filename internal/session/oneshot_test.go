@@ -2,6 +2,8 @@ package session
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -186,6 +188,35 @@ func TestRunOneShot_AppliesParser(t *testing.T) {
 	}
 }
 
+// TestRunOneShot_AgentReportedError tests that an error the agent reports in its
+// output fails the run even when the process exits cleanly.
+func TestRunOneShot_AgentReportedError(t *testing.T) {
+	ag := agent.Agent{
+		Name:          "test-codex",
+		Path:          "/bin/sh",
+		Authenticated: true,
+		Pattern: agent.CLIPattern{
+			NonInteractiveArgs: []string{"-c"},
+		},
+		Parser: &agent.CodexParser{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	script := `printf '%s\n' '{"type":"turn.started"}' '{"type":"turn.failed","error":{"message":"quota exceeded"}}'`
+	result, err := RunOneShot(ctx, ag, script)
+	if result.ExitCode != 0 {
+		t.Fatalf("ExitCode = %d, want 0", result.ExitCode)
+	}
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("RunOneShot() error = %v, want the reported turn failure", err)
+	}
+	if result.Error != err {
+		t.Errorf("result.Error = %v, want it to match the returned error", result.Error)
+	}
+}
+
 // testParser is a simple parser for testing.
 type testParser struct {
 	prefix string
@@ -194,3 +225,237 @@ type testParser struct {
 func (p *testParser) Parse(output string) string {
 	return p.prefix + output
 }
+
+// TestRunOneShotWithOptions_SetsProcessDir tests agents without a workspace flag run in WorkDir.
+func TestRunOneShotWithOptions_SetsProcessDir(t *testing.T) {
+	workDir := t.TempDir()
+	ag := agent.Agent{
+		Name:          "test-agent",
+		Path:          "/bin/sh",
+		Authenticated: true,
+		Pattern: agent.CLIPattern{
+			NonInteractiveArgs: []string{"-c"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := RunOneShotWithOptions(ctx, ag, "pwd", Options{WorkDir: workDir})
+	if err != nil {
+		t.Fatalf("RunOneShotWithOptions failed: %v", err)
+	}
+
+	want, _ := filepath.EvalSymlinks(workDir)
+	got, _ := filepath.EvalSymlinks(strings.TrimSpace(result.Output))
+	if got != want {
+		t.Errorf("agent ran in %q, want %q", got, want)
+	}
+}
+
+// TestRunOneShot_RegisteredAgent tests that a registered custom agent is detected and dispatched.
+func TestRunOneShot_RegisteredAgent(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = \"--version\" ]; then echo 'v1'; exit 0; fi\necho \"custom got: $*\"\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "custom-cli"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create mock binary: %v", err)
+	}
+
+	reg := agent.NewRegistry()
+	reg.Register("custom", agent.CLIPattern{
+		Binary:             "custom-cli",
+		VersionArgs:        []string{"--version"},
+		NonInteractiveArgs: []string{"ask"},
+	}, nil)
+
+	detector := agent.NewDetectorWithPath(tmpDir)
+	detector.SetRegistry(reg)
+	agents, err := detector.DetectAll(context.Background(), false)
+	if err != nil || len(agents) != 1 {
+		t.Fatalf("DetectAll() = %v, %v; want the custom agent", agents, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := RunOneShot(ctx, agents[0], "hello")
+	if err != nil {
+		t.Fatalf("RunOneShot failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "custom got: ask hello") {
+		t.Errorf("Output = %q, want the custom agent's response", result.Output)
+	}
+}
+
+// TestOneShotSession_SendRunsAgent tests that each Send is a fresh one-shot run.
+func TestOneShotSession_SendRunsAgent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "echo-agent")
+	if err := os.WriteFile(path, []byte("#!/bin/bash\necho \"ran with: ${@: -1}\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "echo", Path: path, Authenticated: true}
+	sess := NewOneShotSession(ag, Options{})
+
+	if _, err := sess.Send(context.Background(), "early"); err == nil {
+		t.Error("Send() before Start() should fail")
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	for _, prompt := range []string{"first", "second"} {
+		resp, err := sess.Send(context.Background(), prompt)
+		if err != nil {
+			t.Fatalf("Send(%q) error = %v", prompt, err)
+		}
+		if !strings.Contains(resp.Output, "ran with: "+prompt) {
+			t.Errorf("Send(%q) output = %q, want a run with that prompt", prompt, resp.Output)
+		}
+	}
+}
+
+// TestRunOneShotWithOptions_SanitizesOutput tests that escape codes and
+// carriage returns are stripped unless KeepANSI is set.
+func TestRunOneShotWithOptions_SanitizesOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "color-agent")
+	script := "#!/bin/sh\nprintf '\\033[1;32mPlan\\033[0m ready\\r\\nStep 1\\rStep 2\\n'\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "color", Path: path, Authenticated: true}
+
+	result, err := RunOneShotWithOptions(context.Background(), ag, "go", Options{})
+	if err != nil {
+		t.Fatalf("RunOneShotWithOptions() error = %v", err)
+	}
+	if want := "Plan ready\nStep 1\nStep 2\n"; result.Output != want {
+		t.Errorf("Output = %q, want %q", result.Output, want)
+	}
+
+	result, err = RunOneShotWithOptions(context.Background(), ag, "go", Options{KeepANSI: true})
+	if err != nil {
+		t.Fatalf("RunOneShotWithOptions() error = %v", err)
+	}
+	if !strings.Contains(result.Output, "\x1b[1;32m") || !strings.Contains(result.Output, "\r") {
+		t.Errorf("KeepANSI Output = %q, want escapes and carriage returns kept", result.Output)
+	}
+}
+
+// lastMessageAgent returns an agent that prints noise on stdout, records the
+// path it was given after --output-last-message in seen, and writes message
+// there unless it is empty.
+func lastMessageAgent(t *testing.T, message string) (agent.Agent, string) {
+	t.Helper()
+	dir := t.TempDir()
+	seen := filepath.Join(dir, "seen")
+	script := "#!/bin/sh\nout=''\nwhile [ $# -gt 0 ]; do\n  if [ \"$1\" = '--output-last-message' ]; then out=\"$2\"; shift; fi\n  shift\ndone\n" +
+		"echo \"$out\" > '" + seen + "'\necho 'thinking...'\necho 'scraped answer'\n"
+	if message != "" {
+		script += "printf '" + message + "\\n' > \"$out\"\n"
+	}
+	path := filepath.Join(dir, "codex")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "codex", Path: path, Authenticated: true, Pattern: agent.CLIPattern{
+		NonInteractiveArgs:   []string{"exec"},
+		OutputLastMessageArg: "--output-last-message",
+	}}
+	return ag, seen
+}
+
+// TestRunOneShot_ReadsLastMessageFile tests that an agent's final message is
+// read from the file it was told to write, which is removed afterwards
+func TestRunOneShot_ReadsLastMessageFile(t *testing.T) {
+	ag, seen := lastMessageAgent(t, "Final plan: ship it")
+
+	result, err := RunOneShot(context.Background(), ag, "Plan it")
+	if err != nil {
+		t.Fatalf("RunOneShot() error = %v", err)
+	}
+	if result.Output != "Final plan: ship it" {
+		t.Errorf("Output = %q, want the message from the file", result.Output)
+	}
+
+	data, err := os.ReadFile(seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := strings.TrimSpace(string(data))
+	if file == "" {
+		t.Fatal("agent was not given an output file")
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("output file %s should be removed after the run, stat error = %v", file, err)
+	}
+}
+
+// TestRunOneShot_LastMessageFileFallback tests that stdout is used when the
+// agent leaves the file empty
+func TestRunOneShot_LastMessageFileFallback(t *testing.T) {
+	ag, _ := lastMessageAgent(t, "")
+
+	result, err := RunOneShot(context.Background(), ag, "Plan it")
+	if err != nil {
+		t.Fatalf("RunOneShot() error = %v", err)
+	}
+	if result.Output != "thinking...\nscraped answer\n" {
+		t.Errorf("Output = %q, want stdout when the file is empty", result.Output)
+	}
+}
+
+// TestRunOneShotWithOptions_SystemPromptFile tests that agents whose system
+// prompt flag takes a path get a file holding the prompt, removed afterwards
+func TestRunOneShotWithOptions_SystemPromptFile(t *testing.T) {
+	ag := agent.Agent{
+		Name:          "test-agent",
+		Path:          "/bin/sh",
+		Authenticated: true,
+		Pattern: agent.CLIPattern{
+			NonInteractiveArgs: []string{"-c"},
+			SystemPromptArg:    "--rules",
+			SystemPromptIsFile: true,
+		},
+	}
+
+	// sh -c puts --rules in $0 and the path in $1
+	opts := Options{SystemPrompt: "Be terse."}
+	result, err := RunOneShotWithOptions(context.Background(), ag, `echo "$1"; cat "$1"`, opts)
+	if err != nil {
+		t.Fatalf("RunOneShotWithOptions() error = %v", err)
+	}
+	path, rules, _ := strings.Cut(result.Output, "\n")
+	if rules != "Be terse." {
+		t.Errorf("agent read %q from %s, want the system prompt", rules, path)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("system prompt file %s should be removed after the run, stat error = %v", path, err)
+	}
+}
+
+// TestRunOneShotWithOptions_Env tests that one-shot agents get the same
+// environment as session agents
+func TestRunOneShotWithOptions_Env(t *testing.T) {
+	t.Setenv("BUCKSHOT_INHERITED", "leaked")
+	ag := agent.Agent{
+		Name:          "test-agent",
+		Path:          "/bin/sh",
+		Authenticated: true,
+		Pattern: agent.CLIPattern{
+			NonInteractiveArgs: []string{"-c"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := Options{Env: []string{"BUCKSHOT_CUSTOM=set"}, CleanEnv: true}
+	result, err := RunOneShotWithOptions(ctx, ag, `echo "[$BUCKSHOT_CUSTOM|$BUCKSHOT_INHERITED|$HOME]"`, opts)
+	if err != nil {
+		t.Fatalf("RunOneShotWithOptions failed: %v", err)
+	}
+	if want := "[set||" + os.Getenv("HOME") + "]"; strings.TrimSpace(result.Output) != want {
+		t.Errorf("agent saw %q, want %q", strings.TrimSpace(result.Output), want)
+	}
+}
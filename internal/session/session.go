@@ -3,15 +3,128 @@ package session
 
 import (
 	"context"
+	"os"
+	"strings"
 
 	"github.com/michaellady/buckshot/internal/agent"
+	buckctx "github.com/michaellady/buckshot/internal/context"
+	"github.com/michaellady/buckshot/internal/logging"
 )
 
 // Response represents an agent's response to a prompt.
 type Response struct {
-	Output       string  // The agent's output
-	ContextUsage float64 // Context usage as 0.0-1.0
-	Error        error   // Any error that occurred
+	Output       string           // The agent's output
+	ContextUsage float64          // Context usage as 0.0-1.0
+	TokenUsage   agent.TokenUsage // Tokens consumed by this response, if reported
+	SessionID    string           // Agent-side session ID, if reported (for resuming)
+	Partial      bool             // Whether SendTimeout passed before the turn ended; the agent may still be answering
+	Error        error            // Any error that occurred
+}
+
+// Options customizes how agent processes are launched.
+type Options struct {
+	SystemPrompt string // Passed via CLIPattern.SystemPromptArg when the agent supports it
+	WorkDir      string // Directory the agent works in (WorkspaceDirArg, or the process cwd)
+
+	// ApprovalMode picks the agent's permission args; empty skips approvals
+	ApprovalMode agent.ApprovalMode
+
+	// ToolPermissions are "tool:allow" or "tool:deny" entries passed via
+	// CLIPattern.PerToolPermissionArg; agents without one ignore them
+	ToolPermissions []string
+
+	// KeepANSI leaves escape sequences and carriage returns in responses
+	// instead of stripping them with agent.SanitizeOutput
+	KeepANSI bool
+
+	// ResumeIDs maps agent names to prior session IDs to reattach to. A
+	// Manager resumes each only in the first session it creates for the
+	// agent; sessions replacing that one start fresh.
+	ResumeIDs map[string]string
+
+	// MaxOutputTokens cuts a turn off once the agent's text passes about
+	// this many tokens, stopping the agent so the next turn starts a fresh
+	// session. Zero means no limit.
+	MaxOutputTokens int
+
+	// Env adds KEY=VALUE entries to the agent's environment, overriding
+	// any inherited value of the same key
+	Env []string
+
+	// CleanEnv starts the agent with only PATH, HOME and Env instead of
+	// everything in buckshot's own environment
+	CleanEnv bool
+
+	// Trace, when set, receives each agent's command line, the prompts sent
+	// to it and its raw unparsed output (plan -vv)
+	Trace func(format string, args ...interface{})
+
+	// Logger receives warnings about the agent's turns, such as a response
+	// cut off or returned partially; nil means logging.Default()
+	Logger *logging.Logger
+}
+
+// logger returns o.Logger, or the process-wide logger if it is unset.
+func (o Options) logger() *logging.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return logging.Default()
+}
+
+// trace reports raw agent I/O to o.Trace, if set.
+func (o Options) trace(format string, args ...interface{}) {
+	if o.Trace != nil {
+		o.Trace(format, args...)
+	}
+}
+
+// cleanEnvKeys are the variables a CleanEnv agent still inherits.
+var cleanEnvKeys = []string{"PATH", "HOME"}
+
+// environ returns the environment to start an agent with, or nil to inherit
+// buckshot's unchanged.
+func (o Options) environ() []string {
+	if len(o.Env) == 0 && !o.CleanEnv {
+		return nil
+	}
+	var env []string
+	if o.CleanEnv {
+		for _, key := range cleanEnvKeys {
+			if value, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+value)
+			}
+		}
+	} else {
+		env = os.Environ()
+	}
+	// exec keeps the last value of a repeated key, so Env wins
+	return append(env, o.Env...)
+}
+
+// commandLine renders a command as it could be pasted into a shell.
+func commandLine(path string, args []string) string {
+	parts := []string{shellQuote(path)}
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote single-quotes s unless it is made only of characters that are
+// safe unquoted.
+func shellQuote(s string) string {
+	safe := s != ""
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // Session represents a persistent connection to an AI agent.
@@ -35,6 +148,13 @@ type Session interface {
 	Close() error
 }
 
+// Streamer is implemented by sessions that can report output as it arrives,
+// rather than only once Send returns.
+type Streamer interface {
+	// SetStream registers fn to receive parsed output line by line.
+	SetStream(fn func(text string))
+}
+
 // Manager handles creation and lifecycle of agent sessions.
 type Manager interface {
 	// CreateSession creates a new session for the given agent.
@@ -43,3 +163,16 @@ type Manager interface {
 	// ShouldRespawn returns true if session context > threshold.
 	ShouldRespawn(session Session, threshold float64) bool
 }
+
+// PredictRespawn reports whether sending prompt would take sess past
+// threshold (0.0-1.0) of its agent's context window, so that a fresh session
+// should take it instead. Sessions that have used nothing yet, and agents
+// whose window isn't known, never need one.
+func PredictRespawn(sess Session, prompt string, threshold float64) bool {
+	window := agent.ContextWindow(sess.Agent().Name)
+	usage := sess.ContextUsage()
+	if window <= 0 || usage <= 0 {
+		return false
+	}
+	return usage+float64(buckctx.EstimateTokens(prompt))/float64(window) > threshold
+}
@@ -2,7 +2,16 @@ package session
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/michaellady/buckshot/internal/agent"
+	buckctx "github.com/michaellady/buckshot/internal/context"
 )
 
 // TestSessionInterface ensures Session interface is properly defined
@@ -56,6 +65,24 @@ func TestSessionStartWithInvalidPath(t *testing.T) {
 	}
 }
 
+// TestSessionStartWithoutAgentsFile tests Start falls back to default instructions
+func TestSessionStartWithoutAgentsFile(t *testing.T) {
+	sess, err := NewManager().CreateSession(newTestAgentWithMock(t))
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Errorf("Start() with no AGENTS.md should not error, got %v", err)
+	}
+
+	args := buildStartCommand(agent.KnownAgents()["claude"], "", "", Options{})
+	if indexOf(args, buckctx.DefaultInstructions) < 0 {
+		t.Errorf("args = %v, want the default instructions as the initial prompt", args)
+	}
+}
+
 // TestSessionSend tests sending a prompt and receiving a response
 // This is an integration test that requires real agent interaction
 func TestSessionSend(t *testing.T) {
@@ -245,6 +272,30 @@ func TestManagerCreateSessionWithUnauthenticatedAgent(t *testing.T) {
 	}
 }
 
+// TestManagerResumesOnlyFirstSession tests that an agent's prior session is
+// resumed by its first session only, so a respawn starts with a fresh
+// context, and that later changes to the caller's map don't leak in
+func TestManagerResumesOnlyFirstSession(t *testing.T) {
+	ids := map[string]string{"claude": "sess-1"}
+	mgr := NewManagerWithOptions(Options{ResumeIDs: ids})
+	ids["claude"] = "sess-2"
+
+	resumeID := func() string {
+		t.Helper()
+		sess, err := mgr.CreateSession(agent.Agent{Name: "claude", Authenticated: true})
+		if err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		return sess.(*DefaultSession).opts.ResumeIDs["claude"]
+	}
+	if got := resumeID(); got != "sess-1" {
+		t.Errorf("first session resumes %q, want %q", got, "sess-1")
+	}
+	if got := resumeID(); got != "" {
+		t.Errorf("respawned session resumes %q, want a fresh start", got)
+	}
+}
+
 // TestManagerShouldRespawn tests context threshold checking
 func TestManagerShouldRespawn(t *testing.T) {
 	mgr := NewManager()
@@ -263,6 +314,43 @@ func TestManagerShouldRespawn(t *testing.T) {
 	t.Skip("Integration test: requires real agent with proper response timing")
 }
 
+// usageSession is a session reporting a fixed context usage.
+type usageSession struct {
+	*DefaultSession
+	usage float64
+}
+
+func (s usageSession) ContextUsage() float64 {
+	return s.usage
+}
+
+// TestPredictRespawn tests that a prompt respawns a session only when it
+// would push the agent's context past the threshold
+func TestPredictRespawn(t *testing.T) {
+	// claude's window is 200000 tokens, about 800000 bytes
+	tests := []struct {
+		name   string
+		agent  string
+		usage  float64
+		prompt int // Bytes
+		want   bool
+	}{
+		{"small prompt", "claude", 0.4, 4000, false},
+		{"prompt overflows", "claude", 0.4, 480000, true},
+		{"prompt just fits", "claude", 0.4, 400000, false},
+		{"fresh session", "claude", 0, 800000, false},
+		{"unknown window", "auggie", 0.4, 800000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := usageSession{DefaultSession: &DefaultSession{agent: agent.Agent{Name: tt.agent}}, usage: tt.usage}
+			if got := PredictRespawn(sess, strings.Repeat("x", tt.prompt), 0.9); got != tt.want {
+				t.Errorf("PredictRespawn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestSessionPersistence tests that sessions persist if context < 50%
 func TestSessionPersistence(t *testing.T) {
 	t.Skip("Integration test: requires real agent with proper response timing")
@@ -316,3 +404,466 @@ func TestSessionMultipleSends(t *testing.T) {
 		t.Error("IsAlive() = false after multiple prompts, want true")
 	}
 }
+
+// TestBuildArgs_SystemPrompt tests the system prompt is passed only to agents that support it
+func TestBuildArgs_SystemPrompt(t *testing.T) {
+	patterns := agent.KnownAgents()
+	opts := Options{SystemPrompt: "Be terse."}
+
+	tests := []struct {
+		name    string
+		wantArg string
+	}{
+		{"claude", "--append-system-prompt"},
+		{"auggie", "--rules"},
+		{"codex", ""},
+		{"gemini", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := patterns[tt.name]
+			builds := map[string][]string{
+				"start":   buildStartCommand(pattern, "/path/AGENTS.md", "", opts),
+				"oneshot": buildOneShotArgs(pattern, "prompt", "", opts),
+			}
+			for kind, args := range builds {
+				idx := indexOf(args, "Be terse.")
+				if tt.wantArg == "" {
+					if idx >= 0 {
+						t.Errorf("%s args = %v, system prompt should be absent", kind, args)
+					}
+					continue
+				}
+				if idx < 1 || args[idx-1] != tt.wantArg {
+					t.Errorf("%s args = %v, want %q followed by the system prompt", kind, args, tt.wantArg)
+				}
+			}
+		})
+	}
+}
+
+// TestBuildArgs_NoSystemPrompt tests that no flag is added without a system prompt
+func TestBuildArgs_NoSystemPrompt(t *testing.T) {
+	args := buildStartCommand(agent.KnownAgents()["claude"], "/path/AGENTS.md", "", Options{})
+	if indexOf(args, "--append-system-prompt") >= 0 {
+		t.Errorf("args = %v, should not include --append-system-prompt", args)
+	}
+}
+
+// TestBuildArgs_ExtraArgs tests that a pattern's extra args come last in both builds
+func TestBuildArgs_ExtraArgs(t *testing.T) {
+	pattern := agent.KnownAgents()["claude"]
+	pattern.ExtraArgs = []string{"--model=opus", "--verbose"}
+	opts := Options{SystemPrompt: "Be terse.", WorkDir: "/work/repo"}
+
+	builds := map[string][]string{
+		"start":   buildStartCommand(pattern, "/path/AGENTS.md", "", opts),
+		"oneshot": buildOneShotArgs(pattern, "prompt", "", opts),
+	}
+	for kind, args := range builds {
+		n := len(args)
+		if n < 2 || args[n-2] != "--model=opus" || args[n-1] != "--verbose" {
+			t.Errorf("%s args = %v, want extra args at the end", kind, args)
+		}
+	}
+}
+
+// TestBuildArgs_ApprovalMode tests the approval args built per agent and mode
+func TestBuildArgs_ApprovalMode(t *testing.T) {
+	patterns := agent.KnownAgents()
+	tests := []struct {
+		agent   string
+		mode    agent.ApprovalMode
+		want    string // Expected approval args, space-joined
+		notWant string // Skip-approvals flag that must be absent, if any
+	}{
+		{"gemini", "", "--yolo", ""},
+		{"gemini", agent.ApprovalAutoEdit, "--approval-mode auto_edit", "--yolo"},
+		{"gemini", agent.ApprovalDefault, "--approval-mode default", "--yolo"},
+		{"claude", agent.ApprovalYolo, "--dangerously-skip-permissions", ""},
+		{"claude", agent.ApprovalAutoEdit, "", "--dangerously-skip-permissions"},
+		{"codex", agent.ApprovalYolo, "--dangerously-bypass-approvals-and-sandbox", ""},
+		{"codex", agent.ApprovalDefault, "", "--dangerously-bypass-approvals-and-sandbox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agent+"/"+string(tt.mode), func(t *testing.T) {
+			opts := Options{ApprovalMode: tt.mode}
+			for kind, args := range map[string][]string{
+				"start":   buildStartCommand(patterns[tt.agent], "", "", opts),
+				"oneshot": buildOneShotArgs(patterns[tt.agent], "prompt", "", opts),
+			} {
+				joined := strings.Join(args, " ")
+				if tt.want != "" && !strings.Contains(joined, tt.want) {
+					t.Errorf("%s args = %v, want %q", kind, args, tt.want)
+				}
+				if tt.notWant != "" && indexOf(args, tt.notWant) >= 0 {
+					t.Errorf("%s args = %v, should not contain %s", kind, args, tt.notWant)
+				}
+			}
+		})
+	}
+}
+
+// TestBuildArgs_ToolPermissions tests that auggie gets one --permission per
+// tool and agents with blanket skip flags get none
+func TestBuildArgs_ToolPermissions(t *testing.T) {
+	patterns := agent.KnownAgents()
+	opts := Options{ToolPermissions: []string{"launch-process:allow", "web-fetch:deny"}}
+
+	want := "--permission launch-process:allow --permission web-fetch:deny"
+	for kind, args := range map[string][]string{
+		"start":   buildStartCommand(patterns["auggie"], "", "", opts),
+		"oneshot": buildOneShotArgs(patterns["auggie"], "prompt", "", opts),
+	} {
+		if joined := strings.Join(args, " "); !strings.Contains(joined, want) {
+			t.Errorf("auggie %s args = %v, want %q", kind, args, want)
+		}
+	}
+
+	if args := buildOneShotArgs(patterns["claude"], "prompt", "", opts); indexOf(args, "--permission") >= 0 || indexOf(args, "web-fetch:deny") >= 0 {
+		t.Errorf("claude args = %v, should not contain per-tool permissions", args)
+	}
+}
+
+func indexOf(args []string, s string) int {
+	for i, a := range args {
+		if a == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// TestBuildArgs_WorkDir tests the workspace flag is injected only for agents that support it
+func TestBuildArgs_WorkDir(t *testing.T) {
+	patterns := agent.KnownAgents()
+	opts := Options{WorkDir: "/work/repo"}
+
+	tests := []struct {
+		name    string
+		wantArg string
+	}{
+		{"codex", "--cd"},
+		{"cursor-agent", "--workspace"},
+		{"auggie", "--workspace-root"},
+		{"claude", ""},
+		{"gemini", ""},
+		{"amp", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := patterns[tt.name]
+			builds := map[string][]string{
+				"start":   buildStartCommand(pattern, "/path/AGENTS.md", "", opts),
+				"oneshot": buildOneShotArgs(pattern, "prompt", "", opts),
+			}
+			for kind, args := range builds {
+				idx := indexOf(args, "/work/repo")
+				if tt.wantArg == "" {
+					if idx >= 0 {
+						t.Errorf("%s args = %v, work dir should not be passed as an arg", kind, args)
+					}
+					continue
+				}
+				if idx < 1 || args[idx-1] != tt.wantArg {
+					t.Errorf("%s args = %v, want %q followed by the work dir", kind, args, tt.wantArg)
+				}
+			}
+
+			wantDir := ""
+			if tt.wantArg == "" {
+				wantDir = "/work/repo"
+			}
+			if got := processDir(pattern, opts); got != wantDir {
+				t.Errorf("processDir() = %q, want %q", got, wantDir)
+			}
+		})
+	}
+}
+
+// TestBuildArgs_Resume tests that session and one-shot commands resume
+// flag-based agents (claude --resume <id>) and subcommand-based ones (codex
+// exec resume <id>)
+func TestBuildArgs_Resume(t *testing.T) {
+	patterns := agent.KnownAgents()
+
+	tests := []struct {
+		name        string
+		wantLeading []string
+	}{
+		{"claude", []string{"-p", "--resume", "sess-1"}},
+		{"gemini", []string{"--resume", "sess-1"}},
+		{"codex", []string{"exec", "resume", "sess-1"}},
+		{"amp", []string{"threads", "continue", "sess-1", "--execute"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builders := []struct {
+				name   string
+				args   []string
+				prompt string
+			}{
+				{"session", buildStartCommand(patterns[tt.name], "/repo/AGENTS.md", "sess-1", Options{}), "please read and apply /repo/AGENTS.md"},
+				{"oneshot", buildOneShotArgs(patterns[tt.name], "prompt", "sess-1", Options{}), "prompt"},
+			}
+			for _, b := range builders {
+				n := len(tt.wantLeading)
+				if len(b.args) <= n || !slices.Equal(b.args[:n], tt.wantLeading) {
+					t.Fatalf("%s args = %v, want prefix %v", b.name, b.args, tt.wantLeading)
+				}
+				if b.args[n] != b.prompt {
+					t.Errorf("%s args = %v, want %q right after the resume args", b.name, b.args, b.prompt)
+				}
+			}
+		})
+	}
+}
+
+// TestBuildArgs_NoResume tests that agents start fresh without a resume ID
+func TestBuildArgs_NoResume(t *testing.T) {
+	args := buildStartCommand(agent.KnownAgents()["codex"], "/path/AGENTS.md", "", Options{})
+	if args[0] != "exec" || indexOf(args, "resume") >= 0 {
+		t.Errorf("args = %v, want a plain exec invocation", args)
+	}
+}
+
+// TestCommandLine tests that traced command lines quote args for the shell
+func TestCommandLine(t *testing.T) {
+	got := commandLine("/usr/bin/claude", []string{"-p", "What's next?", "--model=opus", ""})
+	want := `/usr/bin/claude -p 'What'\''s next?' --model=opus ''`
+	if got != want {
+		t.Errorf("commandLine = %s, want %s", got, want)
+	}
+}
+
+// TestSessionSend_MultiLinePromptIsOneTurn tests that a framed multi-line
+// prompt reaches a line-reading agent as a single message
+func TestSessionSend_MultiLinePromptIsOneTurn(t *testing.T) {
+	prompt := "Prompt: Design API\n\nInstructions:\n- Use `bd create` to create new beads"
+
+	tests := []struct {
+		name    string
+		framing agent.PromptFraming
+		script  string
+		want    string
+	}{
+		{
+			name:    "json",
+			framing: agent.FramingJSON,
+			script: `while IFS= read -r line; do
+  printf 'turn: %s\n' "$line"
+  echo "Context: 10% used (20000/200000 tokens)"
+done`,
+			want: `turn: "Prompt: Design API\n\nInstructions:\n- Use ` + "`bd create`" + ` to create new beads"`,
+		},
+		{
+			name:    "sentinel",
+			framing: agent.FramingSentinel,
+			script: `n=0
+while IFS= read -r line; do
+  if [ "$line" = "` + agent.PromptSentinel + `" ]; then
+    echo "turn: $n lines"
+    echo "Context: 10% used (20000/200000 tokens)"
+    n=0
+  else
+    n=$((n+1))
+  fi
+done`,
+			want: "turn: 4 lines",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "agent")
+			if err := os.WriteFile(path, []byte("#!/bin/sh\necho 'Context: 1% used'\n"+tt.script+"\n"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			ag := agent.Agent{Name: "framed", Path: path, Authenticated: true, Pattern: agent.CLIPattern{PromptFraming: tt.framing}}
+			sess, err := NewManager().CreateSession(ag)
+			if err != nil {
+				t.Fatalf("CreateSession() error = %v", err)
+			}
+			defer func() { _ = sess.Close() }()
+			if err := sess.Start(context.Background(), ""); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			// A second prompt must not pick up leftovers of the first
+			for i := 0; i < 2; i++ {
+				resp, err := sess.Send(context.Background(), prompt)
+				if err != nil {
+					t.Fatalf("Send() %d error = %v", i, err)
+				}
+				if strings.Count(resp.Output, "turn:") != 1 || !strings.Contains(resp.Output, tt.want) {
+					t.Errorf("Send() %d output = %q, want one turn with %q", i, resp.Output, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestSessionSend_SlowStartupTurn tests that the end of the agent's reply to
+// its startup instruction, arriving late, doesn't end the first prompt's turn
+func TestSessionSend_SlowStartupTurn(t *testing.T) {
+	script := `sleep 0.3
+echo "Read AGENTS.md"
+echo "Context: 1% used (2000/200000 tokens)"
+while IFS= read -r line; do
+  echo "Reply to: $line"
+  echo "Context: 2% used (4000/200000 tokens)"
+done`
+	path := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "slow", Path: path, Authenticated: true, Pattern: mockPattern()}
+	sess, err := NewManager().CreateSession(ag)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for _, prompt := range []string{"first", "second"} {
+		resp, err := sess.Send(context.Background(), prompt)
+		if err != nil {
+			t.Fatalf("Send(%q) error = %v", prompt, err)
+		}
+		if !strings.Contains(resp.Output, "Reply to: "+prompt) || strings.Contains(resp.Output, "AGENTS.md") {
+			t.Errorf("Send(%q) output = %q, want only its own reply", prompt, resp.Output)
+		}
+	}
+}
+
+// TestSessionSend_BuiltinFraming tests that claude's built-in pattern starts
+// the agent reading stream-json input and sends a multi-line prompt as one
+// user message
+func TestSessionSend_BuiltinFraming(t *testing.T) {
+	prompt := "Prompt: Design API\n\nInstructions:\n- Use `bd create` to create new beads"
+	script := `case " $* " in
+  *" --input-format stream-json "*) ;;
+  *) echo "missing --input-format stream-json"; exit 1 ;;
+esac
+echo "Context: 1% used (2000/200000 tokens)"
+while IFS= read -r line; do
+  printf 'turn: %s\n' "$line"
+  echo "Context: 10% used (20000/200000 tokens)"
+done`
+	path := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "claude", Path: path, Authenticated: true, Pattern: agent.KnownAgents()["claude"]}
+	sess, err := NewManager().CreateSession(ag)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	resp, err := sess.Send(context.Background(), prompt)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if strings.Count(resp.Output, "turn:") != 1 {
+		t.Fatalf("Send() output = %q, want one turn", resp.Output)
+	}
+	line := strings.TrimSpace(strings.SplitN(strings.SplitN(resp.Output, "turn: ", 2)[1], "\n", 2)[0])
+	var msg struct {
+		Type    string `json:"type"`
+		Message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		t.Fatalf("agent read %q, want a stream-json message: %v", line, err)
+	}
+	if msg.Type != "user" || msg.Message.Role != "user" || msg.Message.Content != prompt {
+		t.Errorf("agent read %+v, want a user message with the prompt", msg)
+	}
+}
+
+// TestSessionSend_MaxOutputTokens tests that an agent writing past the
+// output limit is cut off, ending the turn with what it wrote so far
+func TestSessionSend_MaxOutputTokens(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "runaway")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'Context: 1% used'\nread -r line\nexec yes 'runaway output'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "claude", Path: script, Authenticated: true}
+
+	sess, err := NewManagerWithOptions(Options{MaxOutputTokens: 100}).CreateSession(ag)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	resp, err := sess.Send(context.Background(), "Plan")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if n := len(resp.Output); n < 400 || n > 500 {
+		t.Errorf("output is %d bytes, want it cut off just past 400 (100 tokens)", n)
+	}
+	if sess.IsAlive() {
+		t.Error("IsAlive() = true after the cut-off, want the agent stopped")
+	}
+	if _, err := sess.Send(context.Background(), "Again"); !errors.Is(err, ErrProcessExited) {
+		t.Errorf("Send() after the cut-off error = %v, want ErrProcessExited", err)
+	}
+}
+
+// TestSessionStart_Env tests that --env values reach the agent process and
+// that a clean environment leaves out everything else but PATH and HOME
+func TestSessionStart_Env(t *testing.T) {
+	t.Setenv("BUCKSHOT_INHERITED", "leaked")
+	script := filepath.Join(t.TempDir(), "agent")
+	body := "#!/bin/sh\necho 'Context: 1% used'\nwhile read -r line; do echo \"[$BUCKSHOT_CUSTOM|$BUCKSHOT_INHERITED|$HOME]\"; echo 'Context: 1% used'; done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ag := agent.Agent{Name: "claude", Path: script, Authenticated: true}
+	home := os.Getenv("HOME")
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"inherited", Options{}, "[|leaked|" + home + "]"},
+		{"added", Options{Env: []string{"BUCKSHOT_CUSTOM=set"}}, "[set|leaked|" + home + "]"},
+		{"overridden", Options{Env: []string{"BUCKSHOT_INHERITED=replaced"}}, "[|replaced|" + home + "]"},
+		{"clean", Options{Env: []string{"BUCKSHOT_CUSTOM=set"}, CleanEnv: true}, "[set||" + home + "]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, err := NewManagerWithOptions(tt.opts).CreateSession(ag)
+			if err != nil {
+				t.Fatalf("CreateSession() error = %v", err)
+			}
+			defer func() { _ = sess.Close() }()
+			if err := sess.Start(context.Background(), ""); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			resp, err := sess.Send(context.Background(), "env")
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if !strings.Contains(resp.Output, tt.want) {
+				t.Errorf("agent saw %q, want %q", resp.Output, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultStatePath is where agent session IDs are recorded between runs.
+const DefaultStatePath = ".buckshot/sessions.json"
+
+// State records the most recent session ID for each agent so a later run
+// can resume it.
+type State struct {
+	Sessions map[string]string `json:"sessions"` // Agent name -> session ID
+}
+
+// Record stores the session ID for an agent. Empty IDs are ignored.
+func (s *State) Record(agentName, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	if s.Sessions == nil {
+		s.Sessions = make(map[string]string)
+	}
+	s.Sessions[agentName] = sessionID
+}
+
+// LoadState reads the state file at path. A missing file yields an empty state.
+func LoadState(path string) (State, error) {
+	state := State{Sessions: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read session state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse session state: %w", err)
+	}
+	if state.Sessions == nil {
+		state.Sessions = make(map[string]string)
+	}
+	return state, nil
+}
+
+// SaveState writes state to path, creating its directory if needed.
+func SaveState(path string, state State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create session state dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session state: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+	return nil
+}
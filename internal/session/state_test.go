@@ -0,0 +1,54 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestState_SaveAndLoad tests round-tripping the session state file
+func TestState_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".buckshot", "sessions.json")
+
+	var state State
+	state.Record("claude", "claude-session")
+	state.Record("codex", "codex-thread")
+	state.Record("gemini", "") // Ignored
+
+	if err := SaveState(path, state); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if len(loaded.Sessions) != 2 {
+		t.Errorf("loaded %d sessions, want 2: %v", len(loaded.Sessions), loaded.Sessions)
+	}
+	if loaded.Sessions["claude"] != "claude-session" || loaded.Sessions["codex"] != "codex-thread" {
+		t.Errorf("loaded sessions = %v", loaded.Sessions)
+	}
+}
+
+// TestLoadState_Missing tests that a missing state file yields an empty state
+func TestLoadState_Missing(t *testing.T) {
+	state, err := LoadState(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if state.Sessions == nil || len(state.Sessions) != 0 {
+		t.Errorf("Sessions = %v, want empty map", state.Sessions)
+	}
+}
+
+// TestLoadState_Invalid tests that a corrupt state file is reported
+func TestLoadState_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(path); err == nil {
+		t.Error("LoadState() should fail on invalid JSON")
+	}
+}
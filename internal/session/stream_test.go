@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/michaellady/buckshot/internal/agent"
+)
+
+func TestStreamText(t *testing.T) {
+	jsonParser := &agent.StreamJSONParser{}
+	assistant := `{"type":"assistant","message":{"content":[{"type":"text","text":"Hello"}]}}`
+
+	tests := []struct {
+		name   string
+		parser agent.OutputParser
+		line   string
+		want   string
+	}{
+		{"no parser", nil, "plain text", "plain text"},
+		{"plain line", jsonParser, "plain text", "plain text"},
+		{"text event", jsonParser, assistant, "Hello"},
+		{"bookkeeping event", jsonParser, `{"type":"system","subtype":"init"}`, ""},
+		{"raw parser", agent.NewRawParser(jsonParser), assistant, assistant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamText(tt.parser, tt.line); got != tt.want {
+				t.Errorf("streamText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionSetStream(t *testing.T) {
+	sess, err := NewManager().CreateSession(newTestAgentWithMock(t))
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var streamed []string
+	sess.(Streamer).SetStream(func(text string) {
+		mu.Lock()
+		defer mu.Unlock()
+		streamed = append(streamed, text)
+	})
+
+	if err := sess.Start(context.Background(), newTestAgentsFile(t)); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	if _, err := sess.Send(context.Background(), "hello"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	// The reply streams in as it arrives, before or after Send returns
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := strings.Join(streamed, "\n")
+		mu.Unlock()
+		if strings.Contains(got, "Mock response to: hello") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("streamed %q, want the response as it arrived", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSessionSend_ConcurrentCompleteOutput sends from several goroutines to an
+// agent that streams a long answer per prompt. Each Send must return exactly
+// its own answer, whole. Run with -race to check the buffer handoff.
+func TestSessionSend_ConcurrentCompleteOutput(t *testing.T) {
+	const lines = 300
+	sess, err := newScriptAgent(t, `while IFS= read -r prompt; do
+    for i in $(seq 1 `+strconv.Itoa(lines)+`); do echo "$prompt line $i"; done
+    echo "Context: 10% used"
+done
+`)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := sess.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = sess.Close() }()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 3; n++ {
+				prompt := fmt.Sprintf("p%d-%d", g, n)
+				resp, err := sess.Send(context.Background(), prompt)
+				if err != nil {
+					t.Errorf("Send(%s) error = %v", prompt, err)
+					return
+				}
+				if got := strings.Count(resp.Output, prompt+" line "); got != lines {
+					t.Errorf("Send(%s) returned %d of %d lines", prompt, got, lines)
+				}
+				if !strings.Contains(resp.Output, fmt.Sprintf("%s line %d\n", prompt, lines)) {
+					t.Errorf("Send(%s) is missing its last line", prompt)
+				}
+				if other := strings.Count(resp.Output, " line "); other != lines {
+					t.Errorf("Send(%s) has %d lines, want only its own %d", prompt, other, lines)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
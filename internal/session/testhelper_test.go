@@ -62,10 +62,19 @@ func newTestAgentWithMock(t *testing.T) agent.Agent {
 		Path:          mockPath,
 		Authenticated: true,
 		Version:       "1.0.0",
-		Pattern:       agent.KnownAgents()["claude"],
+		Pattern:       mockPattern(),
 	}
 }
 
+// mockPattern is claude's pattern for mock agents, which read each prompt
+// as a plain line rather than a stream-json message.
+func mockPattern() agent.CLIPattern {
+	pattern := agent.KnownAgents()["claude"]
+	pattern.PromptFraming = agent.FramingLines
+	pattern.PromptFramingArgs = nil
+	return pattern
+}
+
 // newUnauthenticatedTestAgent creates an unauthenticated agent for testing.
 func newUnauthenticatedTestAgent() agent.Agent {
 	a := newTestAgent()
@@ -233,6 +233,7 @@ exec "` + binaryPath + `" \
     -context-growth=` + formatFloat(config.ContextGrowth) + ` \
     -delay=` + formatInt(config.ResponseDelay) + ` \
     -error-msg="` + config.ErrorMessage + `" \
+    -framing=json \
     "$@"
 `
 
@@ -252,6 +253,7 @@ func createMockPattern(name string) agent.CLIPattern {
 		NonInteractiveArgs: []string{"-p"},
 		JSONOutputArgs:     []string{},
 		SkipApprovalsArgs:  []string{},
+		PromptFraming:      agent.FramingJSON,
 	}
 }
 
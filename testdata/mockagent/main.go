@@ -28,6 +28,7 @@ type Config struct {
 	ResponseDelay  int     // Delay in milliseconds before responding
 	ErrorMessage   string  // Custom error message for error mode
 	Version        string  // Version string to report
+	Framing        string  // How stdin prompts are framed: lines, json, or sentinel
 }
 
 var config Config
@@ -50,6 +51,7 @@ func main() {
 	flag.IntVar(&config.ResponseDelay, "delay", 0, "Response delay in milliseconds")
 	flag.StringVar(&config.ErrorMessage, "error-msg", "Mock error occurred", "Error message for error mode")
 	flag.StringVar(&config.Version, "mock-version", "1.0.0-mock", "Version string for mock responses")
+	flag.StringVar(&config.Framing, "framing", "lines", "How stdin prompts are framed: lines, json, or sentinel")
 	flag.StringVar(&prompt, "p", "", "Prompt to process (non-interactive mode)")
 	flag.Parse()
 
@@ -68,14 +70,16 @@ func main() {
 		}
 	}
 
-	// If we have a prompt, run in one-shot mode
+	// A prompt given with -p is answered first; like real agents, the mock
+	// then keeps reading prompts from stdin, which is empty in one-shot mode
 	if prompt != "" {
 		handlePrompt(prompt)
+		runConversationMode(false)
 		return
 	}
 
 	// Otherwise run in conversation mode (reading from stdin)
-	runConversationMode()
+	runConversationMode(true)
 }
 
 func handlePrompt(prompt string) {
@@ -102,17 +106,21 @@ func handlePrompt(prompt string) {
 	}
 }
 
-func runConversationMode() {
+// runConversationMode answers prompts read from stdin. greet prints the
+// initial context first, ending the startup turn of an agent given no -p.
+func runConversationMode(greet bool) {
 	scanner := bufio.NewScanner(os.Stdin)
 	contextUsage := config.InitialContext
 	messageCount := 0
 
-	// Print initial context
-	printContextUsage(contextUsage)
+	if greet {
+		printContextUsage(contextUsage)
+	}
 
+	var pending []string
 	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
+		line, ok := readPrompt(scanner.Text(), &pending)
+		if !ok || strings.TrimSpace(line) == "" {
 			continue
 		}
 
@@ -154,6 +162,29 @@ func runConversationMode() {
 	}
 }
 
+// readPrompt reassembles a prompt from one stdin line according to
+// config.Framing. It returns false while a sentinel-framed prompt is still
+// arriving.
+func readPrompt(line string, pending *[]string) (string, bool) {
+	switch config.Framing {
+	case "json":
+		var prompt string
+		if err := json.Unmarshal([]byte(line), &prompt); err != nil {
+			return line, true
+		}
+		return prompt, true
+	case "sentinel":
+		if line != "<<<END_OF_PROMPT>>>" {
+			*pending = append(*pending, line)
+			return "", false
+		}
+		prompt := strings.Join(*pending, "\n")
+		*pending = nil
+		return prompt, true
+	}
+	return line, true
+}
+
 func generateResponse(prompt string) string {
 	// Generate a response that looks like what a real agent might produce
 	lowerPrompt := strings.ToLower(prompt)